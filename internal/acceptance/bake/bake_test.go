@@ -152,7 +152,9 @@ var _ = Describe("bake command", func() {
 
 		info, err := file.Stat()
 		Expect(err).NotTo(HaveOccurred())
-		Expect(info.ModTime()).To(Equal(time.Unix(0, 0).In(time.UTC)))
+		commitTime, err := builder.GitCommitTime(".")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.ModTime()).To(Equal(commitTime))
 
 		metadataContents, err := io.ReadAll(file)
 		Expect(err).NotTo(HaveOccurred())
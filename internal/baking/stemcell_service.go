@@ -96,7 +96,8 @@ func (ss StemcellService) FromKilnfile(kilnfilePath string) (map[string]any, err
 	}
 
 	stemcellCriteria := struct {
-		Metadata stemcellMetadata `yaml:"stemcell_criteria"`
+		Metadata  stemcellMetadata   `yaml:"stemcell_criteria"`
+		Stemcells []stemcellMetadata `yaml:"stemcells"`
 	}{}
 
 	lockFileContent, err := io.ReadAll(kilnfileLock)
@@ -109,11 +110,16 @@ func (ss StemcellService) FromKilnfile(kilnfilePath string) (map[string]any, err
 		return nil, err
 	}
 
-	stemcell := stemcellCriteria.Metadata
-
-	stemcellManifest := map[string]any{
-		stemcell.OperatingSystem: stemcellCriteria.Metadata,
+	stemcellManifest := make(map[string]any)
+	if stemcellCriteria.Metadata.OperatingSystem != "" {
+		stemcellManifest[stemcellCriteria.Metadata.OperatingSystem] = stemcellCriteria.Metadata
+	}
+	for _, stemcell := range stemcellCriteria.Stemcells {
+		if _, ok := stemcellManifest[stemcell.OperatingSystem]; ok {
+			return nil, fmt.Errorf("more than one stemcell was found for OS '%s' in %s", stemcell.OperatingSystem, kilnfileLockBasename)
+		}
+		stemcellManifest[stemcell.OperatingSystem] = stemcell
 	}
 
-	return stemcellManifest, err
+	return stemcellManifest, nil
 }
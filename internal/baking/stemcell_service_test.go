@@ -7,6 +7,7 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
 
 	. "github.com/pivotal-cf/kiln/internal/baking"
 	"github.com/pivotal-cf/kiln/internal/baking/fakes"
@@ -156,4 +157,66 @@ var _ = Describe("StemcellService", func() {
 			})
 		})
 	})
+
+	Describe("FromKilnfile", func() {
+		var (
+			kilnfilePath string
+			service      StemcellService
+		)
+
+		BeforeEach(func() {
+			service = NewStemcellService(&fakes.Logger{}, &fakes.PartReader{})
+			kilnfilePath = filepath.Join(GinkgoT().TempDir(), "Kilnfile")
+		})
+
+		It("reads the stemcell criteria and stemcells from the Kilnfile.lock", func() {
+			Expect(os.WriteFile(kilnfilePath+".lock", []byte(`stemcell_criteria:
+  os: ubuntu-jammy
+  version: "1.100"
+stemcells:
+  - os: windows2019
+    version: "2019.50"
+`), 0o644)).To(Succeed())
+
+			stemcells, err := service.FromKilnfile(kilnfilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stemcells).To(HaveLen(2))
+
+			windows, err := yaml.Marshal(stemcells["windows2019"])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(windows).To(MatchYAML(`{os: windows2019, version: "2019.50"}`))
+			jammy, err := yaml.Marshal(stemcells["ubuntu-jammy"])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jammy).To(MatchYAML(`{os: ubuntu-jammy, version: "1.100"}`))
+		})
+
+		When("the Kilnfile.lock only has stemcells", func() {
+			It("does not add empty stemcell criteria", func() {
+				Expect(os.WriteFile(kilnfilePath+".lock", []byte(`stemcells:
+  - os: ubuntu-jammy
+    version: "1.100"
+  - os: windows2019
+    version: "2019.50"
+`), 0o644)).To(Succeed())
+
+				stemcells, err := service.FromKilnfile(kilnfilePath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stemcells).To(HaveLen(2))
+				Expect(stemcells).To(HaveKey("ubuntu-jammy"))
+				Expect(stemcells).To(HaveKey("windows2019"))
+				Expect(stemcells).NotTo(HaveKey(""))
+			})
+		})
+
+		When("an OS is listed twice", func() {
+			It("returns an error", func() {
+				Expect(os.WriteFile(kilnfilePath+".lock", []byte(`stemcell_criteria: {os: windows2019, version: "2019.50"}
+stemcells: [{os: windows2019, version: "2019.51"}]
+`), 0o644)).To(Succeed())
+
+				_, err := service.FromKilnfile(kilnfilePath)
+				Expect(err).To(MatchError(ContainSubstring("more than one stemcell was found for OS 'windows2019'")))
+			})
+		})
+	})
 })
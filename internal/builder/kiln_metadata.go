@@ -9,9 +9,9 @@ import (
 )
 
 type KilnMetadata struct {
-	MetadataGitSHA string `yaml:"metadata_git_sha"`
+	MetadataGitSHA string `yaml:"metadata_git_sha" json:"metadata_git_sha"`
 
-	TileName string `yaml:"tile_name,omitempty"`
+	TileName string `yaml:"tile_name,omitempty" json:"tile_name,omitempty"`
 }
 
 func newKilnMetadata(input InterpolateInput) KilnMetadata {
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const DirtyWorktreeSHAValue = "DEVELOPMENT"
@@ -48,6 +50,24 @@ func gitHeadRevision(repositoryDirectory string) (string, error) {
 	return strings.TrimSpace(out.String()), nil
 }
 
+// GitCommitTime returns the committer time of HEAD. Bake uses it as the
+// modification time of the tile entries so baking the same commit twice
+// produces the same tile.
+func GitCommitTime(repositoryDirectory string) (time.Time, error) {
+	var out bytes.Buffer
+	gitShow := exec.Command("git", "show", "--no-patch", "--format=%ct", "HEAD")
+	gitShow.Dir = repositoryDirectory
+	gitShow.Stdout = &out
+	if err := gitShow.Run(); err != nil {
+		return time.Time{}, fmt.Errorf("failed to get HEAD commit time: %w", err)
+	}
+	seconds, err := strconv.ParseInt(strings.TrimSpace(out.String()), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse HEAD commit time: %w", err)
+	}
+	return time.Unix(seconds, 0).In(time.UTC), nil
+}
+
 func ensureGitExecutableIsFound() error {
 	if _, err := exec.LookPath("git"); err != nil {
 		return fmt.Errorf("could not calculate %q: %w", MetadataGitSHAVariable, err)
@@ -7,6 +7,7 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -54,7 +55,9 @@ type WriteInput struct {
 	MigrationDirectories []string
 	ReleaseDirectories   []string
 	EmbedPaths           []string
-	ModTime              time.Time
+
+	// ModTime is set on every entry in the tile
+	ModTime time.Time
 }
 
 type tileMetadata struct {
@@ -143,7 +146,8 @@ func (w TileWriter) addStubReleases(generatedMetadataContents []byte, outputFile
 }
 
 func (w TileWriter) addReleaseTarballs(releasesDir string, outputFile string) error {
-	return w.filesystem.Walk(releasesDir, func(filePath string, info os.FileInfo, err error) error {
+	var entries []tileEntry
+	err := w.filesystem.Walk(releasesDir, func(filePath string, info os.FileInfo, err error) error {
 		isTarball, _ := regexp.MatchString("tgz$|tar.gz$", filePath)
 		if !isTarball {
 			return nil
@@ -157,14 +161,14 @@ func (w TileWriter) addReleaseTarballs(releasesDir string, outputFile string) er
 			return nil
 		}
 
-		file, err := w.filesystem.Open(filePath)
-		if err != nil {
-			return err
-		}
-		defer closeAndIgnoreError(file)
-
-		return w.addToZipper(path.Join("releases", filepath.Base(filePath)), file, outputFile)
+		entries = append(entries, tileEntry{path: path.Join("releases", filepath.Base(filePath)), source: filePath})
+		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	return w.addEntries(entries, outputFile)
 }
 
 func (w TileWriter) addEmbeddedPaths(embedPaths []string, outputFile string) error {
@@ -179,7 +183,8 @@ func (w TileWriter) addEmbeddedPaths(embedPaths []string, outputFile string) err
 }
 
 func (w TileWriter) addEmbeddedPath(pathToEmbed, outputFile string) error {
-	return w.filesystem.Walk(pathToEmbed, func(filePath string, info os.FileInfo, err error) error {
+	var entries []tileEntry
+	err := w.filesystem.Walk(pathToEmbed, func(filePath string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -188,24 +193,24 @@ func (w TileWriter) addEmbeddedPath(pathToEmbed, outputFile string) error {
 			return nil
 		}
 
-		file, err := w.filesystem.Open(filePath)
-		if err != nil {
-			return err
-		}
-		defer closeAndIgnoreError(file)
-
 		relativePath, err := filepath.Rel(pathToEmbed, filePath)
 		if err != nil {
 			return err // not tested
 		}
 
 		entryPath := path.Join("embed", filepath.Join(filepath.Base(pathToEmbed), relativePath))
-		return w.addToZipperWithMode(entryPath, file, info.Mode(), outputFile)
+		entries = append(entries, tileEntry{path: entryPath, source: filePath, mode: normalizedFileMode(info.Mode())})
+		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	return w.addEntries(entries, outputFile)
 }
 
 func (w TileWriter) addMigrations(migrationsDir []string, outputFile string) error {
-	var found bool
+	var entries []tileEntry
 
 	for _, migrationDir := range migrationsDir {
 		err := w.filesystem.Walk(migrationDir, func(filePath string, info os.FileInfo, err error) error {
@@ -225,28 +230,66 @@ func (w TileWriter) addMigrations(migrationsDir []string, outputFile string) err
 				return nil
 			}
 
-			found = true
-
-			file, err := w.filesystem.Open(filePath)
-			if err != nil {
-				return err
-			}
-			defer closeAndIgnoreError(file)
-
-			return w.addToZipper(path.Join("migrations", "v1", filepath.Base(filePath)), file, outputFile)
+			entries = append(entries, tileEntry{path: path.Join("migrations", "v1", filepath.Base(filePath)), source: filePath})
+			return nil
 		})
 		if err != nil {
 			return err
 		}
 	}
 
-	if !found {
+	if len(entries) == 0 {
 		return w.addEmptyMigrationsDirectory(outputFile)
 	}
 
+	return w.addEntries(entries, outputFile)
+}
+
+// tileEntry is a file from the tile source to be added to the tile.
+type tileEntry struct {
+	path   string
+	source string
+
+	// mode is only set for embedded files; other entries get the zipper's
+	// default mode.
+	mode os.FileMode
+}
+
+// addEntries adds the entries sorted by their path in the tile so the entry
+// order does not depend on the order the filesystem lists files in.
+func (w TileWriter) addEntries(entries []tileEntry, outputFile string) error {
+	slices.SortStableFunc(entries, func(a, b tileEntry) int { return strings.Compare(a.path, b.path) })
+	for _, entry := range entries {
+		if err := w.addEntry(entry, outputFile); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+func (w TileWriter) addEntry(entry tileEntry, outputFile string) error {
+	file, err := w.filesystem.Open(entry.source)
+	if err != nil {
+		return err
+	}
+	defer closeAndIgnoreError(file)
+
+	if entry.mode != 0 {
+		return w.addToZipperWithMode(entry.path, file, entry.mode, outputFile)
+	}
+	return w.addToZipper(entry.path, file, outputFile)
+}
+
+// normalizedFileMode drops the permissions that depend on the umask or the
+// filesystem of the machine baking the tile. Executable files get 0755 and
+// all other files 0644.
+func normalizedFileMode(mode os.FileMode) os.FileMode {
+	if mode&0o111 != 0 {
+		return 0o755
+	}
+	return 0o644
+}
+
 func (w TileWriter) addToZipper(path string, contents io.Reader, outputFile string) error {
 	w.logger.Printf("Adding %s to %s...", path, outputFile)
 
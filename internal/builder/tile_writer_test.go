@@ -333,7 +333,7 @@ releases:
 
 				embedFileInfo := &fakes.FileInfo{}
 				embedFileInfo.IsDirReturns(false)
-				embedFileInfo.ModeReturns(12345678)
+				embedFileInfo.ModeReturns(0o600)
 
 				filesystem.WalkStub = func(root string, walkFn filepath.WalkFunc) error {
 					switch root {
@@ -381,7 +381,7 @@ releases:
 				path, file, mode := zipper.AddWithModeArgsForCall(0)
 				Expect(path).To(Equal(filepath.Join("embed", "my-file.txt")))
 				Eventually(gbytes.BufferReader(file)).Should(gbytes.Say("contents-of-embedded-file"))
-				Expect(mode).To(Equal(os.FileMode(12345678)))
+				Expect(mode).To(Equal(os.FileMode(0o644)))
 			})
 		})
 
@@ -395,7 +395,7 @@ releases:
 
 				embedFileInfo := &fakes.FileInfo{}
 				embedFileInfo.IsDirReturns(false)
-				embedFileInfo.ModeReturns(87654)
+				embedFileInfo.ModeReturns(0o775)
 
 				filesystem.WalkStub = func(root string, walkFn filepath.WalkFunc) error {
 					switch root {
@@ -448,12 +448,54 @@ releases:
 				path, file, mode := zipper.AddWithModeArgsForCall(0)
 				Expect(path).To(Equal(filepath.Join("embed", "to-embed", "my-file-1.txt")))
 				Eventually(gbytes.BufferReader(file)).Should(gbytes.Say("contents-of-embedded-file-1"))
-				Expect(mode).To(Equal(os.FileMode(87654)))
+				Expect(mode).To(Equal(os.FileMode(0o755)))
 
 				path, file, mode = zipper.AddWithModeArgsForCall(1)
 				Expect(path).To(Equal(filepath.Join("embed", "to-embed", "my-file-2.txt")))
 				Eventually(gbytes.BufferReader(file)).Should(gbytes.Say("contents-of-embedded-file-2"))
-				Expect(mode).To(Equal(os.FileMode(87654)))
+				Expect(mode).To(Equal(os.FileMode(0o755)))
+			})
+		})
+
+		Context("when the filesystem does not list files in order", func() {
+			BeforeEach(func() {
+				fileInfo := &fakes.FileInfo{}
+				fileInfo.IsDirReturns(false)
+
+				filesystem.WalkStub = func(root string, walkFn filepath.WalkFunc) error {
+					for _, name := range []string{"b.tgz", "c.js", "a.tgz", "a.js"} {
+						_ = walkFn(filepath.Join(root, name), fileInfo, nil)
+					}
+					return nil
+				}
+				filesystem.OpenStub = func(path string) (io.ReadCloser, error) {
+					return NewBuffer(bytes.NewBufferString(path)), nil
+				}
+			})
+
+			It("adds the entries in each section sorted by path", func() {
+				err := tileWriter.Write([]byte("generated-metadata-contents"), builder.WriteInput{
+					ReleaseDirectories:   []string{"/some/path/releases", "/some/other/path/releases"},
+					MigrationDirectories: []string{"/some/path/migrations"},
+					EmbedPaths:           []string{"/some/path/to-embed"},
+					OutputFile:           outputFile,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintfCall.Receives.LogLines).To(Equal([]string{
+					fmt.Sprintf("Building %s...", outputFile),
+					fmt.Sprintf("Adding metadata/metadata.yml to %s...", outputFile),
+					fmt.Sprintf("Adding migrations/v1/a.js to %s...", outputFile),
+					fmt.Sprintf("Adding migrations/v1/c.js to %s...", outputFile),
+					fmt.Sprintf("Adding releases/a.tgz to %s...", outputFile),
+					fmt.Sprintf("Adding releases/b.tgz to %s...", outputFile),
+					fmt.Sprintf("Adding releases/a.tgz to %s...", outputFile),
+					fmt.Sprintf("Adding releases/b.tgz to %s...", outputFile),
+					fmt.Sprintf("Adding embed/to-embed/a.js to %s...", outputFile),
+					fmt.Sprintf("Adding embed/to-embed/a.tgz to %s...", outputFile),
+					fmt.Sprintf("Adding embed/to-embed/b.tgz to %s...", outputFile),
+					fmt.Sprintf("Adding embed/to-embed/c.js to %s...", outputFile),
+				}))
 			})
 		})
 
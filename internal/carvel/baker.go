@@ -1,6 +1,7 @@
 package carvel
 
 import (
+	"context"
 	"crypto/sha1"
 	"crypto/sha256"
 	"encoding/hex"
@@ -1057,7 +1058,7 @@ func (b *baker) fetchAdditionalReleases(kilnfile cargo.Kilnfile, kilnfileLock ca
 		}
 
 		b.progress(fmt.Sprintf("  Fetching %s %s from %s", lockEntry.Name, lockEntry.Version, lockEntry.RemoteSource))
-		local, err := sources.DownloadRelease(opts.ReleasesDirectory, lockEntry)
+		local, err := sources.DownloadRelease(context.Background(), opts.ReleasesDirectory, lockEntry)
 		if err != nil {
 			return fmt.Errorf("failed to download additional release %q: %w", ar.Name, err)
 		}
@@ -1083,7 +1084,7 @@ func additionalReleaseLocalFilename(sources component.ReleaseSourceList, name st
 		return defaultName
 	}
 	switch source.Configuration().Type {
-	case cargo.BOSHReleaseTarballSourceTypeS3, cargo.BOSHReleaseTarballSourceTypeArtifactory:
+	case cargo.BOSHReleaseTarballSourceTypeS3, cargo.BOSHReleaseTarballSourceTypeArtifactory, cargo.BOSHReleaseTarballSourceTypeDirectory:
 		return filepath.Base(lockEntry.RemotePath)
 	default:
 		return defaultName
@@ -1,18 +1,20 @@
 package commands
 
 import (
+	"crypto"
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net/url"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
 	"slices"
 	"strings"
-	"time"
 
 	"github.com/go-git/go-billy/v5"
 	"github.com/pivotal-cf/jhanda"
@@ -23,6 +25,7 @@ import (
 	"github.com/pivotal-cf/kiln/internal/helper"
 	"github.com/pivotal-cf/kiln/pkg/bake"
 	"github.com/pivotal-cf/kiln/pkg/cargo"
+	"github.com/pivotal-cf/kiln/pkg/provenance"
 )
 
 //counterfeiter:generate -o ./fakes/interpolator.go --fake-name Interpolator . interpolator
@@ -149,7 +152,7 @@ func (bake Bake) WithKilnfileFunc(fn func(string) (cargo.Kilnfile, error)) Bake
 	return bake
 }
 
-type writeBakeRecordSignature func(string, string, string, []byte) error
+type writeBakeRecordSignature func(string, string, string, string, string, []byte) error
 
 type Bake struct {
 	interpolator      interpolator
@@ -210,9 +213,16 @@ type BakeOptions struct {
 	TileName string `short:"t" long:"tile-name" description:"select the bake_configuration matching the tile-name from the Kilnfile"`
 
 	IsFinal bool `long:"final" description:"this flag causes build metadata to be written to bake_records"`
+
+	ProvenanceKey string `long:"provenance-key" description:"path to an ed25519 or ECDSA private key (PEM) to sign an in-toto provenance statement written next to the bake record (requires --final)"`
 }
 
+// NewBakeWithInterfaces is for setting up tests. When writeBakeRecordFn is nil
+// the bake record is written like NewBake does.
 func NewBakeWithInterfaces(interpolator interpolator, tileWriter tileWriter, outLogger *log.Logger, errLogger *log.Logger, templateVariablesService templateVariablesService, boshVariablesService metadataTemplatesParser, releasesService fromDirectories, stemcellService stemcellService, formsService metadataTemplatesParser, instanceGroupsService metadataTemplatesParser, jobsService metadataTemplatesParser, propertiesService metadataTemplatesParser, runtimeConfigsService metadataTemplatesParser, iconService iconService, metadataService metadataService, checksummer checksummer, fetcher jhanda.Command, fs FileSystem, homeDir flags.HomeDirFunc, writeBakeRecordFn writeBakeRecordSignature) Bake {
+	if writeBakeRecordFn == nil {
+		writeBakeRecordFn = writeBakeRecord
+	}
 	return Bake{
 		interpolator:      interpolator,
 		tileWriter:        tileWriter,
@@ -242,7 +252,16 @@ func NewBakeWithInterfaces(interpolator interpolator, tileWriter tileWriter, out
 
 var _ writeBakeRecordSignature = writeBakeRecord
 
-func writeBakeRecord(kilnVersion, tileFilepath, metadataFilepath string, productTemplate []byte) error {
+func writeBakeRecord(kilnVersion, tileFilepath, metadataFilepath, kilnfilePath, provenanceKeyPath string, productTemplate []byte) error {
+	var provenanceKey crypto.Signer
+	if provenanceKeyPath != "" {
+		var err error
+		provenanceKey, err = provenance.ReadSigningKey(provenanceKeyPath)
+		if err != nil {
+			return fmt.Errorf("failed to read provenance key: %w", err)
+		}
+	}
+
 	tileSum, err := tileChecksum(tileFilepath)
 	if err != nil {
 		return fmt.Errorf("failed to calculate checksum: %w", err)
@@ -254,6 +273,17 @@ func writeBakeRecord(kilnVersion, tileFilepath, metadataFilepath string, product
 
 	b.KilnVersion = kilnVersion
 
+	var kilnfileLockPath string
+	if kilnfilePath != "" {
+		if _, err := os.Stat(kilnfilePath + ".lock"); err == nil {
+			kilnfileLockPath = kilnfilePath + ".lock"
+			b, err = b.SetKilnfileLock(kilnfileLockPath)
+			if err != nil {
+				return fmt.Errorf("failed to record Kilnfile.lock: %w", err)
+			}
+		}
+	}
+
 	abs, err := filepath.Abs(metadataFilepath)
 	if err != nil {
 		return fmt.Errorf("failed to find tile root for bake records: %w", err)
@@ -265,12 +295,61 @@ func writeBakeRecord(kilnVersion, tileFilepath, metadataFilepath string, product
 		return err
 	}
 
+	var envelope provenance.Envelope
+	if provenanceKey != nil {
+		envelope, err = signTileProvenance(b, tileDir, tileFilepath, kilnfileLockPath, provenanceKey)
+		if err != nil {
+			return fmt.Errorf("failed to sign provenance: %w", err)
+		}
+	}
+
 	if err := b.WriteFile(tileDir); err != nil {
 		return fmt.Errorf("failed to write bake record: %w", err)
 	}
+
+	if provenanceKey != nil {
+		if err := provenance.WriteFile(provenance.Filepath(b.Filepath(tileDir)), envelope); err != nil {
+			return fmt.Errorf("failed to write provenance: %w", err)
+		}
+	}
 	return nil
 }
 
+// signTileProvenance builds and signs the provenance of the tile. It is called
+// before the bake record is written so a signing failure does not leave a
+// record without provenance.
+func signTileProvenance(record bake.Record, tileSourceDirectory, tilePath, kilnfileLockPath string, key crypto.Signer) (provenance.Envelope, error) {
+	statement, err := provenance.NewStatement(tilePath, provenance.Build{
+		SourceRevision:   record.SourceRevision,
+		SourceURI:        gitRemoteURL(tileSourceDirectory),
+		KilnfileLockPath: kilnfileLockPath,
+		KilnVersion:      record.KilnVersion,
+		Version:          record.Version,
+		TileName:         record.TileName,
+	})
+	if err != nil {
+		return provenance.Envelope{}, err
+	}
+	return provenance.Sign(statement, key)
+}
+
+// gitRemoteURL returns the URL of the origin remote without credentials or ""
+// when there is no origin.
+func gitRemoteURL(dir string) string {
+	cmd := exec.Command("git", "remote", "get-url", "origin")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	remote := strings.TrimSpace(string(out))
+	if u, err := url.Parse(remote); err == nil && u.User != nil {
+		u.User = nil
+		return u.String()
+	}
+	return remote
+}
+
 func tileChecksum(tileFilepath string) (string, error) {
 	f, err := os.Open(tileFilepath)
 	if err != nil {
@@ -518,6 +597,10 @@ func (b Bake) Execute(args []string) error {
 		return errors.New("--output-file cannot be provided when using --metadata-only")
 	}
 
+	if b.Options.ProvenanceKey != "" && !b.Options.IsFinal {
+		return errors.New("--provenance-key requires --final")
+	}
+
 	boshVariables, err := b.boshVariables.ParseMetadataTemplates(b.Options.BOSHVariableDirectories, templateVariables)
 	if err != nil {
 		return fmt.Errorf("failed to parse bosh variables: %w", err)
@@ -564,7 +647,10 @@ func (b Bake) Execute(args []string) error {
 		return fmt.Errorf("failed to read metadata: %w", err)
 	}
 
-	modTime := time.Unix(0, 0).In(time.UTC)
+	modTime, err := builder.GitCommitTime(filepath.Dir(b.Options.Kilnfile))
+	if err != nil {
+		return err
+	}
 
 	input := builder.InterpolateInput{
 		Version:            b.Options.Version,
@@ -612,7 +698,7 @@ func (b Bake) Execute(args []string) error {
 	}
 
 	if b.Options.IsFinal {
-		if err := b.writeBakeRecord(b.KilnVersion, b.Options.OutputFile, b.Options.Metadata, interpolatedMetadata); err != nil {
+		if err := b.writeBakeRecord(b.KilnVersion, b.Options.OutputFile, b.Options.Metadata, b.Options.Kilnfile, b.Options.ProvenanceKey, interpolatedMetadata); err != nil {
 			return err
 		}
 	}
@@ -667,41 +753,59 @@ func errorBakeConfigurationNotFound(options *BakeOptions, kf cargo.Kilnfile) err
 	return fmt.Errorf("the provided tile_name %q does not match any configuration. The available names are: %v", options.TileName, names)
 }
 
+// fromConfiguration sets the bake flags from the configuration. The paths in
+// the configuration are relative to the directory of the Kilnfile, not the
+// working directory.
 func fromConfiguration(b *BakeOptions, configuration cargo.BakeConfiguration) {
+	kilnfileDirectory := filepath.Dir(b.Kilnfile)
+	fromKilnfile := func(p string) string {
+		if filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(kilnfileDirectory, p)
+	}
+	allFromKilnfile := func(paths []string) []string {
+		result := make([]string, 0, len(paths))
+		for _, p := range paths {
+			result = append(result, fromKilnfile(p))
+		}
+		return result
+	}
+
 	if len(configuration.Metadata) > 0 {
-		b.Metadata = configuration.Metadata
+		b.Metadata = fromKilnfile(configuration.Metadata)
 	}
 	if len(configuration.FormDirectories) > 0 {
-		b.FormDirectories = configuration.FormDirectories
+		b.FormDirectories = allFromKilnfile(configuration.FormDirectories)
 	}
 	if len(configuration.IconPath) > 0 {
-		b.IconPath = configuration.IconPath
+		b.IconPath = fromKilnfile(configuration.IconPath)
 	}
 	if len(configuration.InstanceGroupDirectories) > 0 {
-		b.InstanceGroupDirectories = configuration.InstanceGroupDirectories
+		b.InstanceGroupDirectories = allFromKilnfile(configuration.InstanceGroupDirectories)
 	}
 	if len(configuration.JobDirectories) > 0 {
-		b.JobDirectories = configuration.JobDirectories
+		b.JobDirectories = allFromKilnfile(configuration.JobDirectories)
 	}
 	if len(configuration.MigrationDirectories) > 0 {
-		b.MigrationDirectories = configuration.MigrationDirectories
+		b.MigrationDirectories = allFromKilnfile(configuration.MigrationDirectories)
 	}
 	if len(configuration.PropertyDirectories) > 0 {
-		b.PropertyDirectories = configuration.PropertyDirectories
+		b.PropertyDirectories = allFromKilnfile(configuration.PropertyDirectories)
 	}
 	if len(configuration.RuntimeConfigDirectories) > 0 {
-		b.RuntimeConfigDirectories = configuration.RuntimeConfigDirectories
+		b.RuntimeConfigDirectories = allFromKilnfile(configuration.RuntimeConfigDirectories)
 	}
 	if len(configuration.BOSHVariableDirectories) > 0 {
-		b.BOSHVariableDirectories = configuration.BOSHVariableDirectories
+		b.BOSHVariableDirectories = allFromKilnfile(configuration.BOSHVariableDirectories)
 	}
 	if len(configuration.EmbedPaths) > 0 {
-		b.EmbedPaths = configuration.EmbedPaths
+		b.EmbedPaths = allFromKilnfile(configuration.EmbedPaths)
 	}
 	if len(configuration.VariableFiles) > 0 {
 		// simplify when go1.22 comes out https://pkg.go.dev/slices@master#Concat
 		variableFiles := make([]string, 0, len(configuration.VariableFiles)+len(b.VariableFiles))
-		variableFiles = append(variableFiles, configuration.VariableFiles...)
+		variableFiles = append(variableFiles, allFromKilnfile(configuration.VariableFiles)...)
 		variableFiles = append(variableFiles, b.VariableFiles...)
 
 		slices.Sort(variableFiles)
@@ -0,0 +1,243 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pivotal-cf/jhanda"
+
+	"github.com/pivotal-cf/kiln/pkg/bake"
+)
+
+// BakeRecordsDirectoryOption selects the tile source directory a bake-records
+// subcommand reads the bake records from.
+type BakeRecordsDirectoryOption struct {
+	TilePath string `long:"tile-path" default:"." description:"path to the tile source directory containing the bake_records directory"`
+}
+
+func (o BakeRecordsDirectoryOption) records() ([]bake.Record, error) {
+	records, err := bake.ReadRecords(os.DirFS(o.TilePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bake records: %w", err)
+	}
+	return records, nil
+}
+
+// NewBakeRecords returns the "kiln bake-records" command group for querying
+// the bake records written by "kiln bake --final".
+func NewBakeRecords(outLogger *log.Logger) CommandGroup {
+	return newCommandGroup("bake-records",
+		"inspects the bake records of final tiles",
+		"Commands for inspecting the bake records \"kiln bake --final\" writes to the bake_records directory.",
+		jhanda.CommandSet{
+			"list":         &BakeRecordsList{outLogger: outLogger},
+			"show":         &BakeRecordsShow{outLogger: outLogger},
+			"find-release": &BakeRecordsFindRelease{outLogger: outLogger},
+		},
+	)
+}
+
+type BakeRecordsList struct {
+	outLogger *log.Logger
+
+	Options struct {
+		BakeRecordsDirectoryOption
+		JSON bool `long:"json" description:"print the bake records as JSON"`
+	}
+}
+
+func (cmd *BakeRecordsList) Execute(args []string) error {
+	if _, err := jhanda.Parse(&cmd.Options, args); err != nil {
+		return err
+	}
+	records, err := cmd.Options.records()
+	if err != nil {
+		return err
+	}
+
+	if cmd.Options.JSON {
+		return printBakeRecordsJSON(cmd.outLogger, records)
+	}
+
+	var out strings.Builder
+	w := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "TILE\tSOURCE REVISION\tKILN VERSION\tSTEMCELLS\tRELEASES")
+	for _, record := range records {
+		releases := "-"
+		if record.HasBillOfMaterials() {
+			releases = fmt.Sprint(len(record.Releases))
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", record.Name(), record.SourceRevision, valueOrDash(record.KilnVersion), bakeRecordStemcells(record), releases)
+	}
+	_ = w.Flush()
+	cmd.outLogger.Print(out.String())
+	return nil
+}
+
+func (cmd *BakeRecordsList) Usage() jhanda.Usage {
+	return jhanda.Usage{
+		Description:      "Lists the bake records sorted by version.",
+		ShortDescription: "lists bake records",
+		Flags:            cmd.Options,
+	}
+}
+
+type BakeRecordsShow struct {
+	outLogger *log.Logger
+
+	Options struct {
+		BakeRecordsDirectoryOption
+	}
+}
+
+func (cmd *BakeRecordsShow) Execute(args []string) error {
+	names, err := jhanda.Parse(&cmd.Options, args)
+	if err != nil {
+		return err
+	}
+	if len(names) != 1 {
+		return errors.New("expected one argument: the tile version (or <tile-name>/<version>)")
+	}
+	records, err := cmd.Options.records()
+	if err != nil {
+		return err
+	}
+	record, err := findBakeRecord(records, names[0])
+	if err != nil {
+		return err
+	}
+	buf, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	cmd.outLogger.Println(string(buf))
+	return nil
+}
+
+func (cmd *BakeRecordsShow) Usage() jhanda.Usage {
+	return jhanda.Usage{
+		Description:      "Prints a bake record. The argument is the tile version or, when the tile source bakes several tiles, <tile-name>/<version>.",
+		ShortDescription: "prints a bake record",
+		Flags:            cmd.Options,
+	}
+}
+
+// findBakeRecord finds the record with the name or, when no record has the
+// name, the only record with the version.
+func findBakeRecord(records []bake.Record, name string) (bake.Record, error) {
+	var withVersion []bake.Record
+	for _, record := range records {
+		if record.Name() == name {
+			return record, nil
+		}
+		if record.Version == name {
+			withVersion = append(withVersion, record)
+		}
+	}
+	switch len(withVersion) {
+	case 0:
+		return bake.Record{}, fmt.Errorf("bake record %q not found", name)
+	case 1:
+		return withVersion[0], nil
+	default:
+		return bake.Record{}, fmt.Errorf("more than one bake record has version %q: use <tile-name>/<version>", name)
+	}
+}
+
+type BakeRecordsFindRelease struct {
+	outLogger *log.Logger
+
+	Options struct {
+		BakeRecordsDirectoryOption
+		JSON bool `long:"json" description:"print the matching bake records as JSON"`
+	}
+}
+
+func (cmd *BakeRecordsFindRelease) Execute(args []string) error {
+	release, err := jhanda.Parse(&cmd.Options, args)
+	if err != nil {
+		return err
+	}
+	if len(release) < 1 || len(release) > 2 {
+		return errors.New("expected arguments: <release-name> [<release-version>]")
+	}
+	records, err := cmd.Options.records()
+	if err != nil {
+		return err
+	}
+
+	var (
+		matching        []bake.Record
+		out             strings.Builder
+		withoutReleases int
+	)
+	w := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "TILE\tSOURCE REVISION\tRELEASE\tVERSION\tSHA1\tREMOTE SOURCE")
+	for _, record := range records {
+		if !record.HasBillOfMaterials() {
+			withoutReleases++
+			continue
+		}
+		for _, r := range record.Releases {
+			if r.Name != release[0] || (len(release) == 2 && r.Version != release[1]) {
+				continue
+			}
+			matching = append(matching, record)
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", record.Name(), record.SourceRevision, r.Name, r.Version, valueOrDash(r.SHA1), valueOrDash(r.RemoteSource))
+			break
+		}
+	}
+	_ = w.Flush()
+
+	if cmd.Options.JSON {
+		return printBakeRecordsJSON(cmd.outLogger, matching)
+	}
+	cmd.outLogger.Print(out.String())
+	if withoutReleases > 0 {
+		cmd.outLogger.Printf("warning: %d bake records do not list their releases (they were written by an older version of kiln)", withoutReleases)
+	}
+	return nil
+}
+
+func (cmd *BakeRecordsFindRelease) Usage() jhanda.Usage {
+	return jhanda.Usage{
+		Description:      "Lists the bake records of the tiles that shipped a release. Pass a release version to only list the tiles that shipped that version.",
+		ShortDescription: "lists the tiles that shipped a release",
+		Flags:            cmd.Options,
+	}
+}
+
+func printBakeRecordsJSON(outLogger *log.Logger, records []bake.Record) error {
+	if records == nil {
+		records = []bake.Record{}
+	}
+	buf, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	outLogger.Println(string(buf))
+	return nil
+}
+
+func bakeRecordStemcells(record bake.Record) string {
+	if len(record.Stemcells) == 0 {
+		return "-"
+	}
+	stemcells := make([]string, 0, len(record.Stemcells))
+	for _, stemcell := range record.Stemcells {
+		stemcells = append(stemcells, stemcell.OS+"/"+stemcell.Version)
+	}
+	return strings.Join(stemcells, ",")
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,98 @@
+package commands_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/kiln/internal/commands"
+	"github.com/pivotal-cf/kiln/pkg/bake"
+)
+
+var _ = Describe("bake-records", func() {
+	var (
+		tileDir string
+		output  bytes.Buffer
+		command commands.CommandGroup
+	)
+
+	BeforeEach(func() {
+		tileDir = GinkgoT().TempDir()
+		output.Reset()
+		command = commands.NewBakeRecords(log.New(&output, "", 0))
+
+		Expect(os.Mkdir(filepath.Join(tileDir, bake.RecordsDirectory), 0o755)).To(Succeed())
+		for _, record := range []bake.Record{
+			{Version: "1.0.0", SourceRevision: "rev-1", KilnVersion: "0.90.0"},
+			{
+				Version: "1.1.0", SourceRevision: "rev-2", KilnVersion: "0.100.0",
+				Stemcells: []bake.Stemcell{{OS: "ubuntu-jammy", Version: "1.329"}},
+				Releases:  []bake.Release{{Name: "bpm", Version: "1.2.12", SHA1: "bpm-sha1", RemoteSource: "bosh.io"}, {Name: "uaa", Version: "76.0.0"}},
+			},
+			{
+				Version: "1.2.0", SourceRevision: "rev-3", KilnVersion: "0.100.0",
+				Stemcells: []bake.Stemcell{{OS: "ubuntu-jammy", Version: "1.340"}},
+				Releases:  []bake.Release{{Name: "bpm", Version: "1.2.13", SHA1: "bpm-sha1-new", RemoteSource: "bosh.io"}},
+			},
+		} {
+			buf, err := json.Marshal(record)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.WriteFile(filepath.Join(tileDir, bake.RecordsDirectory, record.Version+".json"), buf, 0o644)).To(Succeed())
+		}
+	})
+
+	Describe("list", func() {
+		It("prints the bake records", func() {
+			Expect(command.Execute([]string{"list", "--tile-path", tileDir})).To(Succeed())
+			Expect(output.String()).To(Equal(`TILE   SOURCE REVISION  KILN VERSION  STEMCELLS           RELEASES
+1.0.0  rev-1            0.90.0        -                   -
+1.1.0  rev-2            0.100.0       ubuntu-jammy/1.329  2
+1.2.0  rev-3            0.100.0       ubuntu-jammy/1.340  1
+`))
+		})
+	})
+
+	Describe("show", func() {
+		It("prints the bake record", func() {
+			Expect(command.Execute([]string{"show", "--tile-path", tileDir, "1.1.0"})).To(Succeed())
+
+			var record bake.Record
+			Expect(json.Unmarshal(output.Bytes(), &record)).To(Succeed())
+			Expect(record.SourceRevision).To(Equal("rev-2"))
+			Expect(record.Releases).To(HaveLen(2))
+		})
+
+		When("the record does not exist", func() {
+			It("returns an error", func() {
+				Expect(command.Execute([]string{"show", "--tile-path", tileDir, "9.9.9"})).To(MatchError(`bake record "9.9.9" not found`))
+			})
+		})
+	})
+
+	Describe("find-release", func() {
+		It("lists the tiles that shipped the release", func() {
+			Expect(command.Execute([]string{"find-release", "--tile-path", tileDir, "bpm"})).To(Succeed())
+			Expect(output.String()).To(Equal(`TILE   SOURCE REVISION  RELEASE  VERSION  SHA1          REMOTE SOURCE
+1.1.0  rev-2            bpm      1.2.12   bpm-sha1      bosh.io
+1.2.0  rev-3            bpm      1.2.13   bpm-sha1-new  bosh.io
+warning: 1 bake records do not list their releases (they were written by an older version of kiln)
+`))
+		})
+
+		When("a release version is given", func() {
+			It("only lists the tiles that shipped that version", func() {
+				Expect(command.Execute([]string{"find-release", "--tile-path", tileDir, "--json", "bpm", "1.2.13"})).To(Succeed())
+
+				var records []bake.Record
+				Expect(json.Unmarshal(output.Bytes(), &records)).To(Succeed())
+				Expect(records).To(HaveLen(1))
+				Expect(records[0].Version).To(Equal("1.2.0"))
+			})
+		})
+	})
+})
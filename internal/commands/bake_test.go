@@ -1,9 +1,14 @@
 package commands_test
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
 	"log"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"reflect"
 	"strings"
@@ -990,6 +995,59 @@ var _ = Describe("Bake", func() {
 				})
 			})
 
+			Context("when the provenance-key flag is passed without the final flag", func() {
+				It("returns an error", func() {
+					err := bake.Execute([]string{
+						"--metadata", "some-metadata",
+						"--output-file", "some-output-dir/some-product-file-1.2.3-build.4",
+						"--releases-directory", someReleasesDirectory,
+						"--stemcell-tarball", "some-stemcell-tarball",
+						"--version", "1.2.3",
+						"--provenance-key", "some-key.pem",
+					})
+
+					Expect(err).To(MatchError("--provenance-key requires --final"))
+				})
+			})
+
+			Context("when the provenance can not be signed", func() {
+				It("does not write the bake record", func() {
+					tileDirectory := filepath.Join(tmpDir, "tile")
+					Expect(os.MkdirAll(tileDirectory, 0o755)).To(Succeed())
+					Expect(exec.Command("git", "init", tileDirectory).Run()).To(Succeed())
+
+					_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+					Expect(err).NotTo(HaveOccurred())
+					der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+					Expect(err).NotTo(HaveOccurred())
+					keyPath := filepath.Join(tmpDir, "provenance.pem")
+					Expect(os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)).To(Succeed())
+
+					// the tile is not a zip file so the provenance statement can not be built
+					outputFile := filepath.Join(tmpDir, "tile-1.2.3.pivotal")
+					Expect(os.WriteFile(outputFile, []byte("not a zip file"), 0o644)).To(Succeed())
+					fakeInterpolator.InterpolateReturns([]byte("product_version: 1.2.3\nkiln_metadata:\n  metadata_git_sha: some-revision\n"), nil)
+
+					bake = commands.NewBakeWithInterfaces(fakeInterpolator, fakeTileWriter, fakeLogger, fakeLogger, fakeTemplateVariablesService, fakeBOSHVariablesService, fakeReleasesService, fakeStemcellService, fakeFormsService, fakeInstanceGroupsService, fakeJobsService, fakePropertiesService, fakeRuntimeConfigsService, fakeIconService, fakeMetadataService, fakeChecksummer, fakeFetcher, fakeFilesystem, fakeHomeDirFunc, nil)
+					bake = bake.WithKilnfileFunc(func(s string) (cargo.Kilnfile, error) { return cargo.Kilnfile{}, nil })
+
+					err = bake.Execute([]string{
+						"--final",
+						"--metadata", filepath.Join(tileDirectory, "base.yml"),
+						"--output-file", outputFile,
+						"--releases-directory", someReleasesDirectory,
+						"--stemcell-tarball", "some-stemcell-tarball",
+						"--version", "1.2.3",
+						"--provenance-key", keyPath,
+					})
+
+					Expect(err).To(MatchError(ContainSubstring("failed to sign provenance")))
+					entries, err := os.ReadDir(tileDirectory)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(entries).To(HaveLen(1), "only .git should be in the tile directory")
+				})
+			})
+
 			Context("when an invalid flag is passed", func() {
 				It("returns an error", func() {
 					err := bake.Execute([]string{
@@ -1115,7 +1173,22 @@ var _ = Describe("BakeArgumentsFromKilnfileConfiguration", func() {
 				It("handles getting the first configuration", func() {
 					err := commands.BakeArgumentsFromKilnfileConfiguration(opts, loadKilnfile)
 					Expect(err).NotTo(HaveOccurred())
-					Expect(opts.Metadata).To(Equal("peach.yml"))
+					Expect(opts.Metadata).To(Equal(filepath.Join("tile", "peach.yml")))
+				})
+			})
+			When("the configuration has an absolute path", func() {
+				It("does not make it relative to the Kilnfile", func() {
+					metadataPath := filepath.Join(GinkgoT().TempDir(), "peach.yml")
+					loadKilnfile = func(s string) (cargo.Kilnfile, error) {
+						return cargo.Kilnfile{
+							BakeConfigurations: []cargo.BakeConfiguration{
+								{TileName: "peach", Metadata: metadataPath},
+							},
+						}, nil
+					}
+					err := commands.BakeArgumentsFromKilnfileConfiguration(opts, loadKilnfile)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(opts.Metadata).To(Equal(metadataPath))
 				})
 			})
 			When("a tile_name is a variable and does not match the bake configuration", func() {
@@ -1149,35 +1222,37 @@ var _ = Describe("BakeArgumentsFromKilnfileConfiguration", func() {
 				opts.TileName = "pair"
 				err := commands.BakeArgumentsFromKilnfileConfiguration(opts, loadKilnfile)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(opts.Metadata).To(Equal("pair.yml"))
+				Expect(opts.Metadata).To(Equal(filepath.Join("tile", "pair.yml")))
 			})
 			It("handles getting the second configuration by name", func() {
 				opts.TileName = "peach"
 				err := commands.BakeArgumentsFromKilnfileConfiguration(opts, loadKilnfile)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(opts.Metadata).To(Equal("peach.yml"))
+				Expect(opts.Metadata).To(Equal(filepath.Join("tile", "peach.yml")))
 			})
 			//It("handles getting the first configuration when no tile_name is passed", func() {
 			//	variables := map[string]any{}
 			//	err := commands.BakeArgumentsFromKilnfileConfiguration(opts, variables, loadKilnfile)
 			//	Expect(err).NotTo(HaveOccurred())
-			//	Expect(opts.Metadata).To(Equal("peach.yml"))
+			//	Expect(opts.Metadata).To(Equal(filepath.Join("tile", "peach.yml")))
 			//})
 		})
 	})
 })
 
 type fakeWriteBakeRecordFunc struct {
-	kilnVersion, tilePath, recordPath string
-	productTemplate                   []byte
+	kilnVersion, tilePath, recordPath, kilnfilePath, provenanceKeyPath string
+	productTemplate                                                    []byte
 
 	err error
 }
 
-func (f *fakeWriteBakeRecordFunc) call(kilnVersion, tilePath, recordPath string, productTemplate []byte) error {
+func (f *fakeWriteBakeRecordFunc) call(kilnVersion, tilePath, recordPath, kilnfilePath, provenanceKeyPath string, productTemplate []byte) error {
 	f.kilnVersion = kilnVersion
 	f.tilePath = tilePath
 	f.recordPath = recordPath
+	f.kilnfilePath = kilnfilePath
+	f.provenanceKeyPath = provenanceKeyPath
 	f.productTemplate = productTemplate
 	return f.err
 }
@@ -0,0 +1,224 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/pivotal-cf/jhanda"
+
+	"github.com/pivotal-cf/kiln/internal/component"
+)
+
+// CacheDirectoryOption selects the shared release tarball cache a cache subcommand operates on.
+type CacheDirectoryOption struct {
+	CacheDirectory string `long:"cache-directory" description:"path to the shared release tarball cache (defaults to ~/.kiln/cache/releases)"`
+}
+
+func (o CacheDirectoryOption) cache() (component.TarballCache, error) {
+	if o.CacheDirectory != "" {
+		return component.TarballCache{Directory: o.CacheDirectory}, nil
+	}
+	dir, err := component.DefaultTarballCacheDirectory()
+	if err != nil {
+		return component.TarballCache{}, err
+	}
+	return component.TarballCache{Directory: dir}, nil
+}
+
+// NewCache returns the "kiln cache" command group for managing the shared
+// release tarball cache populated by "kiln fetch".
+func NewCache(outLogger *log.Logger) CommandGroup {
+	return newCommandGroup("cache",
+		"manages the shared release tarball cache",
+		"Commands for managing the release tarball cache shared by \"kiln fetch\" across tile repositories.",
+		jhanda.CommandSet{
+			"list":   &CacheList{outLogger: outLogger},
+			"verify": &CacheVerify{outLogger: outLogger},
+			"prune":  &CachePrune{outLogger: outLogger, now: time.Now},
+		},
+	)
+}
+
+type CacheList struct {
+	outLogger *log.Logger
+
+	Options struct {
+		CacheDirectoryOption
+		JSON bool `long:"json" description:"print the cached tarballs as JSON"`
+	}
+}
+
+func (cmd *CacheList) Execute(args []string) error {
+	if _, err := jhanda.Parse(&cmd.Options, args); err != nil {
+		return err
+	}
+	cache, err := cmd.Options.cache()
+	if err != nil {
+		return err
+	}
+	list, err := cache.List()
+	if err != nil {
+		return err
+	}
+
+	if cmd.Options.JSON {
+		if list == nil {
+			list = []component.CachedTarball{}
+		}
+		buf, err := json.MarshalIndent(list, "", "  ")
+		if err != nil {
+			return err
+		}
+		cmd.outLogger.Println(string(buf))
+		return nil
+	}
+
+	var (
+		out   strings.Builder
+		total int64
+	)
+	w := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SHA1\tSIZE\tLAST USED\tFILE")
+	for _, entry := range list {
+		total += entry.Size
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", entry.SHA1, units.HumanSize(float64(entry.Size)), entry.LastUsed.Format(time.RFC3339), filepath.Base(entry.Path))
+	}
+	_ = w.Flush()
+	cmd.outLogger.Print(out.String())
+	cmd.outLogger.Printf("%d tarballs (%s) in %s", len(list), units.HumanSize(float64(total)), cache.Directory)
+	return nil
+}
+
+func (cmd *CacheList) Usage() jhanda.Usage {
+	return jhanda.Usage{
+		Description:      "Lists the release tarballs in the shared release tarball cache.",
+		ShortDescription: "lists cached release tarballs",
+		Flags:            cmd.Options,
+	}
+}
+
+type CacheVerify struct {
+	outLogger *log.Logger
+
+	Options struct {
+		CacheDirectoryOption
+		Remove bool `long:"remove" description:"remove cached tarballs whose content does not match their SHA1"`
+	}
+}
+
+func (cmd *CacheVerify) Execute(args []string) error {
+	if _, err := jhanda.Parse(&cmd.Options, args); err != nil {
+		return err
+	}
+	cache, err := cmd.Options.cache()
+	if err != nil {
+		return err
+	}
+	invalid, err := cache.Verify()
+	if err != nil {
+		return err
+	}
+	if len(invalid) == 0 {
+		cmd.outLogger.Println("all cached release tarballs match their SHA1")
+		return nil
+	}
+	for _, entry := range invalid {
+		cmd.outLogger.Printf("%s does not match its SHA1 %s", entry.Path, entry.SHA1)
+		if cmd.Options.Remove {
+			if err := cache.Remove(entry.SHA1); err != nil {
+				return err
+			}
+		}
+	}
+	if cmd.Options.Remove {
+		cmd.outLogger.Printf("removed %d corrupt cached release tarballs", len(invalid))
+		return nil
+	}
+	return fmt.Errorf("%d cached release tarballs are corrupt; run again with --remove to delete them", len(invalid))
+}
+
+func (cmd *CacheVerify) Usage() jhanda.Usage {
+	return jhanda.Usage{
+		Description:      "Recalculates the SHA1 of each cached release tarball and reports those that do not match.",
+		ShortDescription: "checks cached release tarballs for corruption",
+		Flags:            cmd.Options,
+	}
+}
+
+type CachePrune struct {
+	outLogger *log.Logger
+	now       func() time.Time
+
+	Options struct {
+		CacheDirectoryOption
+		MaxSize string `long:"max-size" description:"remove the least recently used tarballs until the cache is no larger than this (for example 20GB)"`
+		MaxAge  string `long:"max-age"  description:"remove tarballs not used within this duration (for example 720h or 30d)"`
+	}
+}
+
+func (cmd *CachePrune) Execute(args []string) error {
+	if _, err := jhanda.Parse(&cmd.Options, args); err != nil {
+		return err
+	}
+	if cmd.Options.MaxSize == "" && cmd.Options.MaxAge == "" {
+		return fmt.Errorf("at least one of --max-size or --max-age is required")
+	}
+
+	var (
+		maxSize int64
+		maxAge  time.Duration
+		err     error
+	)
+	if cmd.Options.MaxSize != "" {
+		maxSize, err = units.FromHumanSize(cmd.Options.MaxSize)
+		if err != nil {
+			return fmt.Errorf("failed to parse --max-size: %w", err)
+		}
+	}
+	if cmd.Options.MaxAge != "" {
+		maxAge, err = parseAge(cmd.Options.MaxAge)
+		if err != nil {
+			return fmt.Errorf("failed to parse --max-age: %w", err)
+		}
+	}
+
+	cache, err := cmd.Options.cache()
+	if err != nil {
+		return err
+	}
+	removed, err := cache.Prune(maxSize, maxAge, cmd.now())
+	var freed int64
+	for _, entry := range removed {
+		freed += entry.Size
+		cmd.outLogger.Printf("removed %s", entry.Path)
+	}
+	cmd.outLogger.Printf("removed %d cached release tarballs (%s)", len(removed), units.HumanSize(float64(freed)))
+	return err
+}
+
+func (cmd *CachePrune) Usage() jhanda.Usage {
+	return jhanda.Usage{
+		Description:      "Removes release tarballs from the shared release tarball cache by age and then, least recently used first, by total size.",
+		ShortDescription: "removes old cached release tarballs",
+		Flags:            cmd.Options,
+	}
+}
+
+// parseAge is time.ParseDuration with support for a whole number of days ("30d").
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
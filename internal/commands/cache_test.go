@@ -0,0 +1,116 @@
+package commands_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/kiln/internal/commands"
+	"github.com/pivotal-cf/kiln/internal/component"
+	test_helpers "github.com/pivotal-cf/kiln/internal/test-helpers"
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+var _ = Describe("Cache", func() {
+	var (
+		output   bytes.Buffer
+		cache    component.TarballCache
+		cacheCmd commands.CommandGroup
+
+		bpmSHA1, uaaSHA1 string
+	)
+
+	BeforeEach(func() {
+		output.Reset()
+		cache = component.TarballCache{Directory: GinkgoT().TempDir()}
+		cacheCmd = commands.NewCache(log.New(&output, "", 0))
+
+		downloads := GinkgoT().TempDir()
+		for _, release := range []struct {
+			name, version string
+			sum           *string
+		}{
+			{"bpm", "1.2.0", &bpmSHA1},
+			{"uaa", "7.0.0", &uaaSHA1},
+		} {
+			p := filepath.Join(downloads, release.name+".tgz")
+			sum, err := test_helpers.WriteReleaseTarball(p, release.name, release.version, osfs.New(""))
+			Expect(err).NotTo(HaveOccurred())
+			*release.sum = sum
+			Expect(cache.Put(context.Background(), component.Local{Lock: cargo.BOSHReleaseTarballLock{SHA1: sum}, LocalPath: p})).To(Succeed())
+		}
+	})
+
+	Describe("list", func() {
+		It("prints the cached tarballs", func() {
+			Expect(cacheCmd.Execute([]string{"list", "--cache-directory", cache.Directory})).To(Succeed())
+			Expect(output.String()).To(ContainSubstring(bpmSHA1))
+			Expect(output.String()).To(ContainSubstring(uaaSHA1))
+			Expect(output.String()).To(ContainSubstring("2 tarballs"))
+		})
+
+		It("prints JSON", func() {
+			Expect(cacheCmd.Execute([]string{"list", "--cache-directory", cache.Directory, "--json"})).To(Succeed())
+			var list []component.CachedTarball
+			Expect(json.Unmarshal(output.Bytes(), &list)).To(Succeed())
+			Expect(list).To(HaveLen(2))
+		})
+	})
+
+	Describe("verify", func() {
+		When("a cached tarball is corrupt", func() {
+			BeforeEach(func() {
+				p, _ := cache.Lookup(uaaSHA1)
+				Expect(os.Remove(p)).To(Succeed())
+				Expect(os.WriteFile(p, []byte("banana"), 0o644)).To(Succeed())
+			})
+
+			It("returns an error", func() {
+				err := cacheCmd.Execute([]string{"verify", "--cache-directory", cache.Directory})
+				Expect(err).To(MatchError(ContainSubstring("1 cached release tarballs are corrupt")))
+				Expect(output.String()).To(ContainSubstring(uaaSHA1))
+			})
+
+			It("removes the corrupt tarball when asked", func() {
+				Expect(cacheCmd.Execute([]string{"verify", "--cache-directory", cache.Directory, "--remove"})).To(Succeed())
+				Expect(cache.List()).To(HaveLen(1))
+			})
+		})
+	})
+
+	Describe("prune", func() {
+		It("removes tarballs older than the maximum age", func() {
+			p, _ := cache.Lookup(bpmSHA1)
+			old := time.Now().Add(-45 * 24 * time.Hour)
+			Expect(os.Chtimes(p, old, old)).To(Succeed())
+
+			Expect(cacheCmd.Execute([]string{"prune", "--cache-directory", cache.Directory, "--max-age", "30d"})).To(Succeed())
+
+			list, err := cache.List()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(list).To(HaveLen(1))
+			Expect(list[0].SHA1).To(Equal(uaaSHA1))
+		})
+
+		It("removes tarballs until the cache fits the maximum size", func() {
+			Expect(cacheCmd.Execute([]string{"prune", "--cache-directory", cache.Directory, "--max-size", "1B"})).To(Succeed())
+			Expect(cache.List()).To(BeEmpty())
+		})
+
+		It("requires a limit", func() {
+			Expect(cacheCmd.Execute([]string{"prune", "--cache-directory", cache.Directory})).To(MatchError(ContainSubstring("--max-size or --max-age")))
+		})
+	})
+
+	It("rejects unknown subcommands", func() {
+		Expect(cacheCmd.Execute([]string{"banana"})).To(MatchError(ContainSubstring("unknown subcommand")))
+	})
+})
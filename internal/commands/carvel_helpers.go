@@ -1,35 +1,25 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 
-	"github.com/go-git/go-billy/v5/osfs"
-	"github.com/pivotal-cf/kiln/internal/baking"
 	"github.com/pivotal-cf/kiln/internal/commands/flags"
 	"github.com/pivotal-cf/kiln/internal/component"
 	"github.com/pivotal-cf/kiln/pkg/cargo"
-	"gopkg.in/yaml.v3"
 )
 
+// loadKilnfileOnly loads the Kilnfile, with the Kilnfiles it extends, and
+// resolves its credentials when there is no Kilnfile.lock.
 func loadKilnfileOnly(options flags.Standard) (cargo.Kilnfile, error) {
-	fs := osfs.New("")
-	variablesService := baking.NewTemplateVariablesService(fs)
-
-	templateVariables, err := variablesService.FromPathsAndPairs(options.VariableFiles, options.Variables)
-	if err != nil {
-		return cargo.Kilnfile{}, fmt.Errorf("failed to parse template variables: %w", err)
-	}
-
-	kilnfileFP, err := fs.Open(options.Kilnfile)
+	kilnfile, err := options.LoadKilnfile(nil, nil)
 	if err != nil {
-		return cargo.Kilnfile{}, fmt.Errorf("failed to open Kilnfile: %w", err)
+		return cargo.Kilnfile{}, err
 	}
-	defer func() { _ = kilnfileFP.Close() }()
-
-	return cargo.InterpolateAndParseKilnfile(kilnfileFP, templateVariables)
+	return cargo.CredentialResolver{}.ResolveCredentials(kilnfile)
 }
 
 func findArtifactorySource(kilnfile cargo.Kilnfile) (cargo.ReleaseSourceConfig, error) {
@@ -54,7 +44,7 @@ func downloadCarvelRelease(logger *log.Logger, kilnfile cargo.Kilnfile, lock car
 	sources := component.NewReleaseSourceRepo(kilnfile)
 
 	logger.Printf("Downloading %s %s from %s", releaseLock.Name, releaseLock.Version, releaseLock.RemoteSource)
-	local, err := sources.DownloadRelease(destDir, releaseLock)
+	local, err := sources.DownloadRelease(context.Background(), destDir, releaseLock)
 	if err != nil {
 		return "", fmt.Errorf("failed to download release: %w", err)
 	}
@@ -68,14 +58,9 @@ func downloadCarvelRelease(logger *log.Logger, kilnfile cargo.Kilnfile, lock car
 }
 
 func writeStandardKilnfileLock(lockfilePath string, releaseName, releaseVersion, remotePath, remoteSourceID, sha1 string) error {
-	var lock cargo.KilnfileLock
-
 	data, err := os.ReadFile(lockfilePath)
 	switch {
 	case err == nil:
-		if err := yaml.Unmarshal(data, &lock); err != nil {
-			return fmt.Errorf("failed to parse existing Kilnfile.lock: %w", err)
-		}
 	case os.IsNotExist(err):
 		// No existing lockfile to preserve — fine, we're creating one.
 	default:
@@ -85,6 +70,15 @@ func writeStandardKilnfileLock(lockfilePath string, releaseName, releaseVersion,
 		return fmt.Errorf("failed to read existing Kilnfile.lock: %w", err)
 	}
 
+	doc, err := cargo.ParseKilnfileLockDocument(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse existing Kilnfile.lock: %w", err)
+	}
+	lock, err := doc.KilnfileLock()
+	if err != nil {
+		return fmt.Errorf("failed to parse existing Kilnfile.lock: %w", err)
+	}
+
 	newEntry := cargo.BOSHReleaseTarballLock{
 		Name:         releaseName,
 		Version:      releaseVersion,
@@ -92,18 +86,20 @@ func writeStandardKilnfileLock(lockfilePath string, releaseName, releaseVersion,
 		RemoteSource: remoteSourceID,
 		SHA1:         sha1,
 	}
-	if err := (&lock).UpdateBOSHReleaseTarballLockWithName(releaseName, newEntry); err != nil {
+	if err := doc.SetRelease(newEntry); err != nil {
 		return fmt.Errorf("failed to update Kilnfile.lock: %w", err)
 	}
 
 	if lock.Stemcell.OS == "" {
-		lock.Stemcell = cargo.Stemcell{
+		if err := doc.SetStemcell(cargo.Stemcell{
 			OS:      "ubuntu-jammy",
 			Version: "1.446",
+		}); err != nil {
+			return fmt.Errorf("failed to update Kilnfile.lock: %w", err)
 		}
 	}
 
-	data, err = yaml.Marshal(&lock)
+	data, err = doc.Bytes()
 	if err != nil {
 		return fmt.Errorf("failed to marshal Kilnfile.lock: %w", err)
 	}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/pivotal-cf/kiln/internal/commands/flags"
 	"github.com/pivotal-cf/kiln/pkg/cargo"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
@@ -72,3 +73,25 @@ func TestWriteStandardKilnfileLock_FailsRatherThanOverwriteUnreadableFile(t *tes
 	require.NoError(t, err)
 	require.Equal(t, data, onDisk)
 }
+
+func TestLoadKilnfileOnly_ResolvesExtends(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(tmpDir, "Kilnfile.base"), []byte(`release_sources:
+  - type: artifactory
+    artifactory_host: https://artifactory.example.com
+    username: some-user
+    password: $( variable "artifactory_password" )
+`), 0644)
+	require.NoError(t, err)
+	kilnfilePath := filepath.Join(tmpDir, "Kilnfile")
+	err = os.WriteFile(kilnfilePath, []byte("extends: [Kilnfile.base]\nslug: my-tile\n"), 0644)
+	require.NoError(t, err)
+
+	kilnfile, err := loadKilnfileOnly(flags.Standard{Kilnfile: kilnfilePath, Variables: []string{"artifactory_password=some-password"}})
+	require.NoError(t, err)
+
+	src, err := findArtifactorySource(kilnfile)
+	require.NoError(t, err)
+	require.Equal(t, "https://artifactory.example.com", src.ArtifactoryHost)
+	require.Equal(t, "some-password", src.Password)
+}
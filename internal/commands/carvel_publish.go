@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"crypto"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
@@ -14,6 +15,7 @@ import (
 	"github.com/pivotal-cf/kiln/internal/carvel"
 	"github.com/pivotal-cf/kiln/internal/commands/flags"
 	"github.com/pivotal-cf/kiln/pkg/bake"
+	"github.com/pivotal-cf/kiln/pkg/provenance"
 )
 
 type CarvelPublish struct {
@@ -32,6 +34,7 @@ type CarvelPublishOptions struct {
 	Verbose           bool   `short:"v" long:"verbose"           description:"enable verbose output"`
 	SkipFetch         bool   `short:"sfr" long:"skip-fetch"        description:"skip fetching additional releases (assumes they are already in the releases directory)"`
 	ReleasesDirectory string `short:"rd"  long:"releases-directory" description:"path to the releases directory" default:"releases"`
+	ProvenanceKey     string `          long:"provenance-key"    description:"path to an ed25519 or ECDSA private key (PEM) to sign an in-toto provenance statement written next to the bake record (requires --final)"`
 }
 
 func NewCarvelPublish(outLogger, errLogger *log.Logger) CarvelPublish {
@@ -59,6 +62,17 @@ func (c CarvelPublish) Execute(args []string) error {
 
 	kilnfilePath := resolveKilnfilePath(c.Options.Kilnfile, sourcePath)
 
+	var provenanceKey crypto.Signer
+	if c.Options.ProvenanceKey != "" {
+		if !c.Options.IsFinal {
+			return fmt.Errorf("--provenance-key requires --final")
+		}
+		provenanceKey, err = provenance.ReadSigningKey(c.Options.ProvenanceKey)
+		if err != nil {
+			return fmt.Errorf("failed to read provenance key: %w", err)
+		}
+	}
+
 	if _, statErr := os.Stat(kilnfilePath); statErr != nil {
 		return fmt.Errorf("could not find Kilnfile at %s: run 'kiln carvel upload' first to create the BOSH release, Kilnfile, and Kilnfile.lock", kilnfilePath)
 	}
@@ -153,12 +167,28 @@ func (c CarvelPublish) Execute(args []string) error {
 			return fmt.Errorf("failed to set tile directory on bake record: %w", err)
 		}
 
+		var envelope provenance.Envelope
+		if provenanceKey != nil {
+			envelope, err = signTileProvenance(record, resolvedSourcePath, targetPath, lockfilePath, provenanceKey)
+			if err != nil {
+				return fmt.Errorf("failed to sign provenance: %w", err)
+			}
+		}
+
 		err = record.WriteFile(resolvedSourcePath)
 		if err != nil {
 			return fmt.Errorf("failed to write bake record: %w", err)
 		}
 
 		c.outLogger.Printf("Wrote bake record for version %s", ver)
+
+		if provenanceKey != nil {
+			provenancePath := provenance.Filepath(record.Filepath(resolvedSourcePath))
+			if err := provenance.WriteFile(provenancePath, envelope); err != nil {
+				return fmt.Errorf("failed to write provenance: %w", err)
+			}
+			c.outLogger.Printf("Wrote provenance to %s", provenancePath)
+		}
 	}
 
 	return nil
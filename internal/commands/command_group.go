@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pivotal-cf/jhanda"
+)
+
+// CommandGroup is a command that dispatches to a set of subcommands
+// (for example "kiln cache list").
+type CommandGroup struct {
+	name             string
+	description      string
+	shortDescription string
+	commands         jhanda.CommandSet
+	output           io.Writer
+}
+
+func newCommandGroup(name, shortDescription, description string, subcommands jhanda.CommandSet) CommandGroup {
+	return CommandGroup{
+		name:             name,
+		description:      description,
+		shortDescription: shortDescription,
+		commands:         subcommands,
+		output:           os.Stdout,
+	}
+}
+
+func (g CommandGroup) Execute(args []string) error {
+	if len(args) == 0 {
+		return g.printHelp()
+	}
+
+	subcommand, subargs := args[0], args[1:]
+
+	if subcommand == "help" || subcommand == "-h" || subcommand == "--help" {
+		if len(subargs) > 0 {
+			return g.printSubcommandHelp(subargs[0])
+		}
+		return g.printHelp()
+	}
+
+	for _, arg := range subargs {
+		if arg == "-h" || arg == "--help" {
+			return g.printSubcommandHelp(subcommand)
+		}
+	}
+
+	cmd, ok := g.commands[subcommand]
+	if !ok {
+		return fmt.Errorf("unknown subcommand: %s", subcommand)
+	}
+	return cmd.Execute(subargs)
+}
+
+func (g CommandGroup) Usage() jhanda.Usage {
+	var description strings.Builder
+	description.WriteString(g.description)
+	description.WriteString("\n\nSubcommands:\n")
+	g.writeSubcommands(&description)
+	fmt.Fprintf(&description, "\nUse 'kiln %s help <subcommand>' for more information about a subcommand.", g.name)
+
+	return jhanda.Usage{
+		Description:      description.String(),
+		ShortDescription: g.shortDescription,
+	}
+}
+
+func (g CommandGroup) writeSubcommands(w io.Writer) {
+	var (
+		names  []string
+		length int
+	)
+	for name := range g.commands {
+		names = append(names, name)
+		length = max(length, len(name))
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-*s  %s\n", length, name, g.commands[name].Usage().ShortDescription)
+	}
+}
+
+func (g CommandGroup) printHelp() error {
+	fmt.Fprintf(g.output, "kiln %s - %s\n\n", g.name, g.shortDescription)
+	fmt.Fprintf(g.output, "Usage: kiln %s <subcommand> [<args>]\n\n", g.name)
+	fmt.Fprintln(g.output, "Subcommands:")
+	g.writeSubcommands(g.output)
+	fmt.Fprintf(g.output, "\nUse 'kiln %s help <subcommand>' for more information about a subcommand.\n", g.name)
+	return nil
+}
+
+func (g CommandGroup) printSubcommandHelp(subcommand string) error {
+	cmd, ok := g.commands[subcommand]
+	if !ok {
+		return fmt.Errorf("unknown subcommand: %s", subcommand)
+	}
+
+	usage := cmd.Usage()
+	fmt.Fprintf(g.output, "kiln %s %s - %s\n\n", g.name, subcommand, usage.ShortDescription)
+	fmt.Fprintf(g.output, "%s\n\n", usage.Description)
+	fmt.Fprintf(g.output, "Usage: kiln %s %s [<args>]\n", g.name, subcommand)
+
+	if usage.Flags != nil {
+		flagUsage, err := jhanda.PrintUsage(usage.Flags)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(g.output)
+		fmt.Fprintln(g.output, "Arguments:")
+		for _, flag := range strings.Split(flagUsage, "\n") {
+			if flag != "" {
+				fmt.Fprintf(g.output, "  %s\n", flag)
+			}
+		}
+	}
+
+	return nil
+}
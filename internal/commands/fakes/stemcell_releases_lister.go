@@ -0,0 +1,112 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+
+	"github.com/pivotal-cf/kiln/internal/pivnet"
+)
+
+type StemcellReleasesLister struct {
+	ReleasesStub        func(string) ([]pivnet.Release, error)
+	releasesMutex       sync.RWMutex
+	releasesArgsForCall []struct {
+		arg1 string
+	}
+	releasesReturns struct {
+		result1 []pivnet.Release
+		result2 error
+	}
+	releasesReturnsOnCall map[int]struct {
+		result1 []pivnet.Release
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *StemcellReleasesLister) Releases(arg1 string) ([]pivnet.Release, error) {
+	fake.releasesMutex.Lock()
+	ret, specificReturn := fake.releasesReturnsOnCall[len(fake.releasesArgsForCall)]
+	fake.releasesArgsForCall = append(fake.releasesArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ReleasesStub
+	fakeReturns := fake.releasesReturns
+	fake.recordInvocation("Releases", []interface{}{arg1})
+	fake.releasesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *StemcellReleasesLister) ReleasesCallCount() int {
+	fake.releasesMutex.RLock()
+	defer fake.releasesMutex.RUnlock()
+	return len(fake.releasesArgsForCall)
+}
+
+func (fake *StemcellReleasesLister) ReleasesCalls(stub func(string) ([]pivnet.Release, error)) {
+	fake.releasesMutex.Lock()
+	defer fake.releasesMutex.Unlock()
+	fake.ReleasesStub = stub
+}
+
+func (fake *StemcellReleasesLister) ReleasesArgsForCall(i int) string {
+	fake.releasesMutex.RLock()
+	defer fake.releasesMutex.RUnlock()
+	argsForCall := fake.releasesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *StemcellReleasesLister) ReleasesReturns(result1 []pivnet.Release, result2 error) {
+	fake.releasesMutex.Lock()
+	defer fake.releasesMutex.Unlock()
+	fake.ReleasesStub = nil
+	fake.releasesReturns = struct {
+		result1 []pivnet.Release
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *StemcellReleasesLister) ReleasesReturnsOnCall(i int, result1 []pivnet.Release, result2 error) {
+	fake.releasesMutex.Lock()
+	defer fake.releasesMutex.Unlock()
+	fake.ReleasesStub = nil
+	if fake.releasesReturnsOnCall == nil {
+		fake.releasesReturnsOnCall = make(map[int]struct {
+			result1 []pivnet.Release
+			result2 error
+		})
+	}
+	fake.releasesReturnsOnCall[i] = struct {
+		result1 []pivnet.Release
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *StemcellReleasesLister) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *StemcellReleasesLister) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
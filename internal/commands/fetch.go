@@ -1,9 +1,14 @@
 package commands
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/pivotal-cf/jhanda"
 
@@ -16,10 +21,20 @@ type FetchReleaseDir struct {
 	ReleasesDir string `short:"rd" long:"releases-directory" default:"releases" description:"path to a directory to download releases into"`
 }
 
+type FetchReleaseCache struct {
+	ReleaseCacheDirectory string `long:"release-cache-directory" description:"path to the shared release tarball cache (defaults to ~/.kiln/cache/releases)"`
+	NoReleaseCache        bool   `long:"no-release-cache"        description:"do not read or populate the shared release tarball cache"`
+}
+
 type FetchOptions struct {
 	flags.Standard
 	flags.FetchBakeOptions
 	FetchReleaseDir
+	FetchReleaseCache
+	flags.Timeouts
+
+	ParallelDownloads int  `long:"parallel-downloads" default:"4" description:"number of releases to download at the same time"`
+	DownloadFailover  bool `long:"download-failover" description:"when a download fails, get a release with the same SHA1 from another release source"`
 }
 
 type Fetch struct {
@@ -53,6 +68,9 @@ func (f Fetch) Execute(args []string) error {
 		return err
 	}
 
+	ctx, cancel := f.Options.Context()
+	defer cancel()
+
 	_, missingReleases, extraReleases := partition(kilnfileLock.Releases, availableLocalReleaseSet)
 
 	err = f.localReleaseDirectory.DeleteExtraReleases(extraReleases, f.Options.NoConfirm)
@@ -63,7 +81,7 @@ func (f Fetch) Execute(args []string) error {
 	if len(missingReleases) > 0 {
 		f.logger.Printf("Found %d missing releases to download", len(missingReleases))
 
-		_, err := f.downloadMissingReleases(kilnfile, missingReleases)
+		_, err := f.downloadMissingReleases(ctx, kilnfile, missingReleases)
 		if err != nil {
 			return err
 		}
@@ -111,37 +129,134 @@ func (f *Fetch) setup(args []string) (cargo.Kilnfile, cargo.KilnfileLock, []comp
 	return kilnfile, kilnfileLock, availableLocalReleaseSet, nil
 }
 
-func (f Fetch) downloadMissingReleases(kilnfile cargo.Kilnfile, releaseLocks []cargo.BOSHReleaseTarballLock) ([]component.Local, error) {
+func (f Fetch) downloadMissingReleases(ctx context.Context, kilnfile cargo.Kilnfile, releaseLocks []cargo.BOSHReleaseTarballLock) ([]component.Local, error) {
 	releaseSource := f.multiReleaseSourceProvider(kilnfile, f.Options.AllowOnlyPublishableReleases)
 
-	var downloaded []component.Local
+	useCache := false
+	if !f.Options.NoReleaseCache {
+		cacheDirectory := f.Options.ReleaseCacheDirectory
+		if cacheDirectory == "" {
+			var err error
+			cacheDirectory, err = component.DefaultTarballCacheDirectory()
+			if err != nil {
+				f.logger.Printf("warning: release cache disabled: %s", err)
+			}
+		}
+		if cacheDirectory != "" {
+			releaseSource = component.NewCachedMultiReleaseSource(releaseSource, component.TarballCache{Directory: cacheDirectory}, f.logger)
+			useCache = true
+		}
+	}
 
-	for _, rl := range releaseLocks {
-		remoteRelease := cargo.BOSHReleaseTarballLock{
-			Name:         rl.Name,
-			Version:      rl.Version,
-			RemotePath:   rl.RemotePath,
-			RemoteSource: rl.RemoteSource,
+	if f.Options.DownloadFailover {
+		releaseSource = component.NewFailoverMultiReleaseSource(releaseSource, kilnfile, f.logger)
+	}
+
+	workerCount := min(max(f.Options.ParallelDownloads, 1), len(releaseLocks))
+
+	type downloadResult struct {
+		local component.Local
+		err   error
+	}
+
+	var (
+		results   = make([]downloadResult, len(releaseLocks))
+		completed atomic.Int64
+		start     = time.Now()
+	)
+
+	indexes := make(chan int)
+	go func() {
+		defer close(indexes)
+		for i := range releaseLocks {
+			indexes <- i
+		}
+	}()
+
+	wg := sync.WaitGroup{}
+	wg.Add(workerCount)
+	for w := 0; w < workerCount; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				rl := releaseLocks[i]
+				local, err := f.downloadRelease(ctx, releaseSource, rl, useCache)
+				results[i] = downloadResult{local: local, err: err}
+
+				n := completed.Add(1)
+				if err != nil {
+					f.logger.Printf("[%d/%d] failed to download %s %s", n, len(releaseLocks), rl.Name, rl.Version)
+				} else {
+					f.logger.Printf("[%d/%d] downloaded %s %s", n, len(releaseLocks), rl.Name, rl.Version)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var (
+		downloaded []component.Local
+		errs       []error
+	)
+	for _, result := range results {
+		if result.err != nil {
+			errs = append(errs, result.err)
+			continue
 		}
+		downloaded = append(downloaded, result.local)
+	}
+
+	f.logger.Printf("Downloaded %d of %d releases in %s (%d failed)", len(downloaded), len(releaseLocks), time.Since(start).Round(time.Second), len(errs))
+
+	return downloaded, errors.Join(errs...)
+}
+
+func (f Fetch) downloadRelease(ctx context.Context, releaseSource component.MultiReleaseSource, rl cargo.BOSHReleaseTarballLock, useCache bool) (component.Local, error) {
+	if err := ctx.Err(); err != nil {
+		return component.Local{}, fmt.Errorf("download canceled for %s %s: %w", rl.Name, rl.Version, err)
+	}
+	ctx, cancel := f.Options.RequestContext(ctx)
+	defer cancel()
+
+	remoteRelease := cargo.BOSHReleaseTarballLock{
+		Name:         rl.Name,
+		Version:      rl.Version,
+		RemotePath:   rl.RemotePath,
+		RemoteSource: rl.RemoteSource,
+	}
+	if useCache || f.Options.DownloadFailover {
+		remoteRelease.SHA1 = rl.SHA1
+		remoteRelease.SHA256 = rl.SHA256
+	}
+	if f.Options.DownloadFailover {
+		remoteRelease.StemcellOS = rl.StemcellOS
+		remoteRelease.StemcellVersion = rl.StemcellVersion
+	}
+
+	local, err := releaseSource.DownloadRelease(ctx, f.Options.ReleasesDir, remoteRelease)
+	if err != nil {
+		return component.Local{}, fmt.Errorf("download failed for %s %s: %w", rl.Name, rl.Version, err)
+	}
 
-		local, err := releaseSource.DownloadRelease(f.Options.ReleasesDir, remoteRelease)
+	if local.Lock.SHA1 != rl.SHA1 {
+		err = os.Remove(local.LocalPath)
 		if err != nil {
-			return nil, fmt.Errorf("download failed: %w", err)
+			return component.Local{}, fmt.Errorf("error deleting bad release file %q: %w", local.LocalPath, err) // untested
 		}
 
-		if local.Lock.SHA1 != rl.SHA1 {
-			err = os.Remove(local.LocalPath)
-			if err != nil {
-				return nil, fmt.Errorf("error deleting bad release file %q: %w", local.LocalPath, err) // untested
-			}
+		return component.Local{}, fmt.Errorf("downloaded release %q had an incorrect SHA1 - expected %q, got %q", local.LocalPath, rl.SHA1, local.Lock.SHA1)
+	}
 
-			return nil, fmt.Errorf("downloaded release %q had an incorrect SHA1 - expected %q, got %q", local.LocalPath, rl.SHA1, local.Lock.SHA1)
+	if rl.SHA256 != "" && local.Lock.SHA256 != rl.SHA256 {
+		err = os.Remove(local.LocalPath)
+		if err != nil {
+			return component.Local{}, fmt.Errorf("error deleting bad release file %q: %w", local.LocalPath, err) // untested
 		}
 
-		downloaded = append(downloaded, local)
+		return component.Local{}, fmt.Errorf("downloaded release %q had an incorrect SHA256 - expected %q, got %q", local.LocalPath, rl.SHA256, local.Lock.SHA256)
 	}
 
-	return downloaded, nil
+	return local, nil
 }
 
 func (f Fetch) Usage() jhanda.Usage {
@@ -159,7 +274,7 @@ func partition(releaseLocks []cargo.BOSHReleaseTarballLock, localReleases []comp
 nextRelease:
 	for _, rel := range localReleases {
 		for j, lock := range missing {
-			if rel.Lock.Name == lock.Name && rel.Lock.Version == lock.Version && rel.Lock.SHA1 == lock.SHA1 {
+			if rel.Lock.Name == lock.Name && rel.Lock.Version == lock.Version && rel.Lock.SHA1 == lock.SHA1 && (lock.SHA256 == "" || rel.Lock.SHA256 == lock.SHA256) {
 				intersection = append(intersection, rel)
 				missing = append(missing[:j], missing[j+1:]...)
 				continue nextRelease
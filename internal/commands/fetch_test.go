@@ -1,12 +1,16 @@
 package commands_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/go-git/go-billy/v5/osfs"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/pivotal-cf/jhanda"
@@ -16,6 +20,7 @@ import (
 	commandsFakes "github.com/pivotal-cf/kiln/internal/commands/fakes"
 	"github.com/pivotal-cf/kiln/internal/component"
 	componentFakes "github.com/pivotal-cf/kiln/internal/component/fakes"
+	test_helpers "github.com/pivotal-cf/kiln/internal/test-helpers"
 	"github.com/pivotal-cf/kiln/pkg/cargo"
 )
 
@@ -54,6 +59,7 @@ var _ = Describe("Fetch", func() {
 			var err error
 			tmpDir, err = os.MkdirTemp("", "fetch-test")
 			Expect(err).NotTo(HaveOccurred())
+			GinkgoT().Setenv("HOME", tmpDir)
 
 			someReleasesDirectory, err = os.MkdirTemp(tmpDir, "")
 			Expect(err).NotTo(HaveOccurred())
@@ -101,14 +107,14 @@ stemcell_criteria:
 			fakeReleaseSources.FindByIDStub = func(s string) (component.ReleaseSource, error) {
 				return releaseSourceList.FindByID(s)
 			}
-			fakeReleaseSources.DownloadReleaseStub = func(s string, lock cargo.BOSHReleaseTarballLock) (component.Local, error) {
-				return releaseSourceList.DownloadRelease(s, lock)
+			fakeReleaseSources.DownloadReleaseStub = func(ctx context.Context, s string, lock cargo.BOSHReleaseTarballLock) (component.Local, error) {
+				return releaseSourceList.DownloadRelease(ctx, s, lock)
 			}
-			fakeReleaseSources.FindReleaseVersionStub = func(requirement cargo.BOSHReleaseTarballSpecification, withSHA bool) (cargo.BOSHReleaseTarballLock, error) {
-				return releaseSourceList.FindReleaseVersion(requirement, false)
+			fakeReleaseSources.FindReleaseVersionStub = func(ctx context.Context, requirement cargo.BOSHReleaseTarballSpecification, withSHA bool) (cargo.BOSHReleaseTarballLock, error) {
+				return releaseSourceList.FindReleaseVersion(ctx, requirement, false)
 			}
-			fakeReleaseSources.GetMatchedReleaseStub = func(requirement cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error) {
-				return releaseSourceList.GetMatchedRelease(requirement)
+			fakeReleaseSources.GetMatchedReleaseStub = func(ctx context.Context, requirement cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error) {
+				return releaseSourceList.GetMatchedRelease(ctx, requirement)
 			}
 			multiReleaseSourceProvider = func(kilnfile cargo.Kilnfile, allowOnlyPublishable bool) component.MultiReleaseSource {
 				return fakeReleaseSources
@@ -192,6 +198,30 @@ stemcell_criteria:
 					Expect(extras).To(HaveLen(0))
 				})
 			})
+			When("the Kilnfile.lock has a SHA256 that does not match the release on disk", func() {
+				BeforeEach(func() {
+					lockContents = strings.Replace(lockContents, "  sha1: correct-sha\n", "  sha1: correct-sha\n  sha256: correct-sha256\n", 1)
+					releaseOnDisk = component.Local{
+						Lock:      releaseID.Lock().WithSHA1("correct-sha").WithSHA256("wrong-sha256"),
+						LocalPath: fmt.Sprintf("releases/%s-%s.tgz", releaseID.Name, releaseID.Version),
+					}
+					fakeLocalReleaseDirectory.GetLocalReleasesReturns([]component.Local{releaseOnDisk}, nil)
+					fakeS3CompiledReleaseSource.DownloadReleaseReturns(
+						component.Local{
+							Lock:      releaseID.Lock().WithSHA1("correct-sha").WithSHA256("correct-sha256"),
+							LocalPath: fmt.Sprintf("releases/%s-%s.tgz", releaseID.Name, releaseID.Version),
+						}, nil)
+				})
+
+				It("downloads the release again", func() {
+					Expect(fetchExecuteErr).NotTo(HaveOccurred())
+
+					Expect(fakeS3CompiledReleaseSource.DownloadReleaseCallCount()).To(Equal(1))
+
+					extras, _ := fakeLocalReleaseDirectory.DeleteExtraReleasesArgsForCall(0)
+					Expect(extras).To(ConsistOf(releaseOnDisk))
+				})
+			})
 		})
 
 		Context("starting with no releases but all can be downloaded from their source (happy path)", func() {
@@ -244,7 +274,7 @@ stemcell_criteria:
 			It("fetches compiled release from s3 compiled release source", func() {
 				Expect(fakeS3CompiledReleaseSource.DownloadReleaseCallCount()).To(Equal(1))
 
-				releasesDir, object := fakeS3CompiledReleaseSource.DownloadReleaseArgsForCall(0)
+				_, releasesDir, object := fakeS3CompiledReleaseSource.DownloadReleaseArgsForCall(0)
 				Expect(releasesDir).To(Equal(someReleasesDirectory))
 				Expect(object).To(Equal(
 					s3CompiledReleaseID.Lock().WithRemote(s3CompiledReleaseSourceID, "some-s3-key"),
@@ -253,7 +283,7 @@ stemcell_criteria:
 
 			It("fetches built release from s3 built release source", func() {
 				Expect(fakeS3BuiltReleaseSource.DownloadReleaseCallCount()).To(Equal(1))
-				releasesDir, object := fakeS3BuiltReleaseSource.DownloadReleaseArgsForCall(0)
+				_, releasesDir, object := fakeS3BuiltReleaseSource.DownloadReleaseArgsForCall(0)
 				Expect(releasesDir).To(Equal(someReleasesDirectory))
 				Expect(object).To(Equal(
 					s3BuiltReleaseID.Lock().WithRemote(s3BuiltReleaseSourceID, "some-other-s3-key"),
@@ -262,7 +292,7 @@ stemcell_criteria:
 
 			It("fetches bosh.io release from bosh.io release source", func() {
 				Expect(fakeBoshIOReleaseSource.DownloadReleaseCallCount()).To(Equal(1))
-				releasesDir, object := fakeBoshIOReleaseSource.DownloadReleaseArgsForCall(0)
+				_, releasesDir, object := fakeBoshIOReleaseSource.DownloadReleaseArgsForCall(0)
 				Expect(releasesDir).To(Equal(someReleasesDirectory))
 				Expect(object).To(Equal(
 					boshIOReleaseID.Lock().WithRemote(boshIOReleaseSourceID, "some-bosh-io-url"),
@@ -270,6 +300,57 @@ stemcell_criteria:
 			})
 		})
 
+		Context("when the release is in the release cache", func() {
+			var (
+				cacheDirectory string
+				releaseSHA1    string
+			)
+			BeforeEach(func() {
+				cacheDirectory = filepath.Join(tmpDir, "cache")
+				tarballPath := filepath.Join(tmpDir, "cached-release-1.2.3.tgz")
+				var err error
+				releaseSHA1, err = test_helpers.WriteReleaseTarball(tarballPath, "cached-release", "1.2.3", osfs.New(""))
+				Expect(err).NotTo(HaveOccurred())
+				cache := component.TarballCache{Directory: cacheDirectory}
+				Expect(cache.Put(context.Background(), component.Local{Lock: cargo.BOSHReleaseTarballLock{SHA1: releaseSHA1}, LocalPath: tarballPath})).To(Succeed())
+
+				lockContents = `---
+releases:
+- name: cached-release
+  version: "1.2.3"
+  remote_source: ` + s3CompiledReleaseSourceID + `
+  remote_path: some-s3-key
+  sha1: ` + releaseSHA1 + `
+stemcell_criteria:
+  os: some-os
+  version: "4.5.6"
+`
+				fakeLocalReleaseDirectory.GetLocalReleasesReturns(nil, nil)
+				fetchExecuteArgs = append(fetchExecuteArgs, "--release-cache-directory", cacheDirectory)
+			})
+
+			It("links the release from the cache without downloading it", func() {
+				Expect(fetchExecuteErr).NotTo(HaveOccurred())
+				Expect(fakeS3CompiledReleaseSource.DownloadReleaseCallCount()).To(Equal(0))
+				Expect(filepath.Join(someReleasesDirectory, "cached-release-1.2.3.tgz")).To(BeAnExistingFile())
+			})
+
+			When("the release cache is disabled", func() {
+				BeforeEach(func() {
+					fetchExecuteArgs = append(fetchExecuteArgs, "--no-release-cache")
+					fakeS3CompiledReleaseSource.DownloadReleaseReturns(component.Local{
+						Lock:      cargo.BOSHReleaseTarballLock{Name: "cached-release", Version: "1.2.3", SHA1: releaseSHA1},
+						LocalPath: filepath.Join(someReleasesDirectory, "cached-release-1.2.3.tgz"),
+					}, nil)
+				})
+
+				It("downloads the release", func() {
+					Expect(fetchExecuteErr).NotTo(HaveOccurred())
+					Expect(fakeS3CompiledReleaseSource.DownloadReleaseCallCount()).To(Equal(1))
+				})
+			})
+		})
+
 		Context("when all releases are already present in releases directory", func() {
 			BeforeEach(func() {
 				lockContents = `---
@@ -383,15 +464,15 @@ stemcell_criteria:
 				Expect(fetchExecuteErr).NotTo(HaveOccurred())
 
 				Expect(fakeS3CompiledReleaseSource.DownloadReleaseCallCount()).To(Equal(1))
-				_, object := fakeS3CompiledReleaseSource.DownloadReleaseArgsForCall(0)
+				_, _, object := fakeS3CompiledReleaseSource.DownloadReleaseArgsForCall(0)
 				Expect(object).To(Equal(missingReleaseS3Compiled))
 
 				Expect(fakeBoshIOReleaseSource.DownloadReleaseCallCount()).To(Equal(1))
-				_, object = fakeBoshIOReleaseSource.DownloadReleaseArgsForCall(0)
+				_, _, object = fakeBoshIOReleaseSource.DownloadReleaseArgsForCall(0)
 				Expect(object).To(Equal(missingReleaseBoshIO))
 
 				Expect(fakeS3BuiltReleaseSource.DownloadReleaseCallCount()).To(Equal(1))
-				_, object = fakeS3BuiltReleaseSource.DownloadReleaseArgsForCall(0)
+				_, _, object = fakeS3BuiltReleaseSource.DownloadReleaseArgsForCall(0)
 				Expect(object).To(Equal(missingReleaseS3Built))
 			})
 
@@ -411,6 +492,98 @@ stemcell_criteria:
 					Expect(fetchExecuteErr).To(MatchError(ContainSubstring("download failed")))
 					Expect(errors.Is(fetchExecuteErr, wrappedErr)).To(BeTrue())
 				})
+
+				It("still downloads the other releases", func() {
+					Expect(fakeBoshIOReleaseSource.DownloadReleaseCallCount()).To(Equal(1))
+					Expect(fakeS3BuiltReleaseSource.DownloadReleaseCallCount()).To(Equal(1))
+				})
+
+				When("more than one download fails", func() {
+					BeforeEach(func() {
+						fakeBoshIOReleaseSource.DownloadReleaseReturns(component.Local{}, errors.New("lemon"))
+					})
+
+					It("returns all of the errors", func() {
+						Expect(fetchExecuteErr).To(MatchError(ContainSubstring("some-missing-release-on-s3-compiled 4.5.6")))
+						Expect(fetchExecuteErr).To(MatchError(ContainSubstring("kaboom")))
+						Expect(fetchExecuteErr).To(MatchError(ContainSubstring("some-missing-release-on-boshio 5.6.7")))
+						Expect(fetchExecuteErr).To(MatchError(ContainSubstring("lemon")))
+					})
+				})
+			})
+
+			Context("when download failover is enabled", func() {
+				BeforeEach(func() {
+					fetchExecuteArgs = append(fetchExecuteArgs, "--download-failover")
+					Expect(os.WriteFile(someKilnfilePath, []byte(`---
+release_sources:
+- id: `+s3CompiledReleaseSourceID+`
+  type: s3
+- id: `+s3BuiltReleaseSourceID+`
+  type: s3
+`), 0o644)).To(Succeed())
+
+					fakeS3CompiledReleaseSource.DownloadReleaseReturns(component.Local{}, errors.New("kaboom"))
+					fakeS3BuiltReleaseSource.GetMatchedReleaseReturns(missingReleaseS3CompiledID.Lock().WithRemote(s3BuiltReleaseSourceID, "mirrored-path"), nil)
+					fakeS3BuiltReleaseSource.DownloadReleaseStub = func(_ context.Context, _ string, lock cargo.BOSHReleaseTarballLock) (component.Local, error) {
+						return component.Local{Lock: lock.WithSHA1("correct-sha"), LocalPath: "local-path-" + lock.Name}, nil
+					}
+				})
+
+				It("downloads the release from another source with the same SHA1", func() {
+					Expect(fetchExecuteErr).NotTo(HaveOccurred())
+					Expect(fakeS3BuiltReleaseSource.GetMatchedReleaseCallCount()).To(Equal(1))
+					_, spec := fakeS3BuiltReleaseSource.GetMatchedReleaseArgsForCall(0)
+					Expect(spec.Name).To(Equal(missingReleaseS3CompiledID.Name))
+					Expect(spec.Version).To(Equal(missingReleaseS3CompiledID.Version))
+					Expect(fakeS3BuiltReleaseSource.DownloadReleaseCallCount()).To(Equal(2))
+				})
+			})
+
+			Context("when parallel downloads is set to one", func() {
+				BeforeEach(func() {
+					fetchExecuteArgs = append(fetchExecuteArgs, "--parallel-downloads", "1")
+				})
+
+				It("downloads the releases one at a time in lock order", func() {
+					Expect(fetchExecuteErr).NotTo(HaveOccurred())
+					Expect(fakeReleaseSources.DownloadReleaseCallCount()).To(Equal(3))
+					_, _, first := fakeReleaseSources.DownloadReleaseArgsForCall(0)
+					Expect(first.Name).To(Equal(missingReleaseS3Compiled.Name))
+					_, _, last := fakeReleaseSources.DownloadReleaseArgsForCall(2)
+					Expect(last.Name).To(Equal(missingReleaseS3Built.Name))
+				})
+			})
+
+			Context("when a request timeout is set", func() {
+				BeforeEach(func() {
+					fetchExecuteArgs = append(fetchExecuteArgs, "--request-timeout", "1m")
+				})
+
+				It("passes a context with a deadline to each download", func() {
+					Expect(fetchExecuteErr).NotTo(HaveOccurred())
+					ctx, _, _ := fakeS3CompiledReleaseSource.DownloadReleaseArgsForCall(0)
+					deadline, ok := ctx.Deadline()
+					Expect(ok).To(BeTrue())
+					Expect(deadline).To(BeTemporally("~", time.Now().Add(time.Minute), 10*time.Second))
+				})
+			})
+
+			Context("when the overall timeout elapses", func() {
+				BeforeEach(func() {
+					fetchExecuteArgs = append(fetchExecuteArgs, "--timeout", "50ms", "--parallel-downloads", "1")
+					fakeS3CompiledReleaseSource.DownloadReleaseCalls(func(ctx context.Context, _ string, _ cargo.BOSHReleaseTarballLock) (component.Local, error) {
+						<-ctx.Done()
+						return component.Local{}, ctx.Err()
+					})
+				})
+
+				It("does not start the remaining downloads", func() {
+					Expect(errors.Is(fetchExecuteErr, context.DeadlineExceeded)).To(BeTrue())
+					Expect(fetchExecuteErr).To(MatchError(ContainSubstring("download canceled")))
+					Expect(fakeBoshIOReleaseSource.DownloadReleaseCallCount()).To(Equal(0))
+					Expect(fakeS3BuiltReleaseSource.DownloadReleaseCallCount()).To(Equal(0))
+				})
 			})
 
 			Context("when the downloaded release has the wrong sha1", func() {
@@ -419,7 +592,7 @@ stemcell_criteria:
 				BeforeEach(func() {
 					badReleasePath = filepath.Join(someReleasesDirectory, "local-path-3")
 
-					fakeS3BuiltReleaseSource.DownloadReleaseCalls(func(string, cargo.BOSHReleaseTarballLock) (component.Local, error) {
+					fakeS3BuiltReleaseSource.DownloadReleaseCalls(func(context.Context, string, cargo.BOSHReleaseTarballLock) (component.Local, error) {
 						f, err := os.Create(badReleasePath)
 						Expect(err).NotTo(HaveOccurred())
 						defer closeAndIgnoreError(f)
@@ -442,6 +615,31 @@ stemcell_criteria:
 					Expect(os.IsNotExist(err)).To(BeTrue(), "Expected file %q not to exist, but got a different error: %v", badReleasePath, err)
 				})
 			})
+			Context("when the downloaded release has the wrong sha256", func() {
+				var badReleasePath string
+
+				BeforeEach(func() {
+					lockContents = strings.Replace(lockContents, "  remote_path: "+missingReleaseS3BuiltPath+"\n  sha1: correct-sha\n", "  remote_path: "+missingReleaseS3BuiltPath+"\n  sha1: correct-sha\n  sha256: correct-sha256\n", 1)
+					badReleasePath = filepath.Join(someReleasesDirectory, "local-path-3")
+
+					fakeS3BuiltReleaseSource.DownloadReleaseCalls(func(context.Context, string, cargo.BOSHReleaseTarballLock) (component.Local, error) {
+						f, err := os.Create(badReleasePath)
+						Expect(err).NotTo(HaveOccurred())
+						defer closeAndIgnoreError(f)
+
+						return component.Local{
+							Lock: missingReleaseS3BuiltID.Lock().WithSHA1("correct-sha").WithSHA256("wrong-sha256"), LocalPath: badReleasePath,
+						}, nil
+					})
+				})
+
+				It("errors and deletes the release file from disk", func() {
+					Expect(fetchExecuteErr).To(MatchError(ContainSubstring("incorrect SHA256")))
+					Expect(fetchExecuteErr).To(MatchError(ContainSubstring(`"correct-sha256"`)))
+					Expect(fetchExecuteErr).To(MatchError(ContainSubstring(`"wrong-sha256"`)))
+					Expect(badReleasePath).NotTo(BeAnExistingFile())
+				})
+			})
 		})
 
 		Context("when there are extra releases locally that are not in the Kilnfile.lock", func() {
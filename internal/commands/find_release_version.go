@@ -9,6 +9,7 @@ import (
 	"github.com/pivotal-cf/jhanda"
 
 	"github.com/pivotal-cf/kiln/internal/commands/flags"
+	"github.com/pivotal-cf/kiln/internal/component"
 	"github.com/pivotal-cf/kiln/pkg/cargo"
 )
 
@@ -18,6 +19,7 @@ type FindReleaseVersion struct {
 
 	Options struct {
 		flags.Standard
+		flags.Timeouts
 		Release    string `short:"r" long:"release" description:"release name"`
 		NoDownload bool   `long:"no-download" description:"do not download any files"`
 	}
@@ -28,6 +30,18 @@ type releaseVersionOutput struct {
 	RemotePath string `json:"remote_path"`
 	Source     string `json:"source"`
 	SHA        string `json:"sha"`
+
+	// Reason and Candidates are set when the release source can explain its choice.
+	Reason     string                   `json:"reason,omitempty"`
+	Candidates []releaseCandidateOutput `json:"candidates,omitempty"`
+}
+
+type releaseCandidateOutput struct {
+	Source          string `json:"source"`
+	Version         string `json:"version"`
+	StemcellOS      string `json:"stemcell_os,omitempty"`
+	StemcellVersion string `json:"stemcell_version,omitempty"`
+	Publishable     bool   `json:"publishable"`
 }
 
 func NewFindReleaseVersion(outLogger *log.Logger, multiReleaseSourceProvider MultiReleaseSourceProvider) *FindReleaseVersion {
@@ -49,24 +63,57 @@ func (cmd *FindReleaseVersion) Execute(args []string) error {
 		return err
 	}
 
-	spec.StemcellOS = kilnfileLock.Stemcell.OS
-	spec.StemcellVersion = kilnfileLock.Stemcell.Version
-
-	releaseRemote, err := releaseSource.FindReleaseVersion(spec, cmd.Options.NoDownload)
+	stemcell, err := kilnfileLock.StemcellForRelease(spec)
 	if err != nil {
 		return err
 	}
+	spec.StemcellOS = stemcell.OS
+	spec.StemcellVersion = stemcell.Version
+
+	ctx, cancel := cmd.Options.Context()
+	defer cancel()
+	ctx, cancelRequest := cmd.Options.RequestContext(ctx)
+	defer cancelRequest()
+
+	var output releaseVersionOutput
+	if explainer, ok := releaseSource.(component.ReleaseVersionExplainer); ok {
+		selection, err := explainer.ExplainReleaseVersion(ctx, spec, cmd.Options.NoDownload)
+		if err != nil {
+			return err
+		}
+		output = newReleaseVersionOutput(selection.Chosen.Lock)
+		output.Reason = selection.Reason
+		for _, candidate := range selection.Candidates {
+			output.Candidates = append(output.Candidates, releaseCandidateOutput{
+				Source:          candidate.SourceID,
+				Version:         candidate.Lock.Version,
+				StemcellOS:      candidate.Lock.StemcellOS,
+				StemcellVersion: candidate.Lock.StemcellVersion,
+				Publishable:     candidate.Publishable,
+			})
+		}
+	} else {
+		releaseRemote, err := releaseSource.FindReleaseVersion(ctx, spec, cmd.Options.NoDownload)
+		if err != nil {
+			return err
+		}
+		output = newReleaseVersionOutput(releaseRemote)
+	}
 
-	releaseVersionJson, _ := json.Marshal(releaseVersionOutput{
-		Version:    releaseRemote.Version,
-		RemotePath: releaseRemote.RemotePath,
-		Source:     releaseRemote.RemoteSource,
-		SHA:        releaseRemote.SHA1,
-	})
+	releaseVersionJson, _ := json.Marshal(output)
 	cmd.outLogger.Println(string(releaseVersionJson))
 	return err
 }
 
+func newReleaseVersionOutput(lock cargo.BOSHReleaseTarballLock) releaseVersionOutput {
+	return releaseVersionOutput{
+		Version:    lock.Version,
+		RemotePath: lock.RemotePath,
+		Source:     lock.RemoteSource,
+		SHA:        lock.SHA1,
+	}
+}
+
 func (cmd *FindReleaseVersion) setup(args []string) (cargo.Kilnfile, cargo.KilnfileLock, error) {
 	argsAfterFlags, err := flags.LoadWithDefaultFilePaths(&cmd.Options, args, nil)
 	if err != nil {
@@ -92,7 +139,7 @@ func (cmd *FindReleaseVersion) setup(args []string) (cargo.Kilnfile, cargo.Kilnf
 
 func (cmd *FindReleaseVersion) Usage() jhanda.Usage {
 	return jhanda.Usage{
-		Description:      "Prints a json string of a remote release satisfying the Kilnfile version and stemcell constraints.",
+		Description:      "Prints a json string of a remote release satisfying the Kilnfile version and stemcell constraints. When several release sources have the release, the output includes every candidate and the reason one was chosen (see source_preference in the Kilnfile).",
 		ShortDescription: "prints a json string of a remote release satisfying the Kilnfile version and stemcell constraints",
 		Flags:            cmd.Options,
 	}
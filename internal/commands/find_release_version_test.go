@@ -20,6 +20,7 @@ var _ = Describe("Find the release version", func() {
 		findReleaseVersion *commands.FindReleaseVersion
 		logger             *log.Logger
 		fakeReleasesSource *fakes.MultiReleaseSource
+		releaseSource      component.MultiReleaseSource
 
 		writer strings.Builder
 
@@ -33,6 +34,7 @@ var _ = Describe("Find the release version", func() {
 		BeforeEach(func() {
 			logger = log.New(&writer, "", 0)
 			fakeReleasesSource = new(fakes.MultiReleaseSource)
+			releaseSource = fakeReleasesSource
 
 			tmpDir, err := os.MkdirTemp("", "fetch-test")
 			Expect(err).NotTo(HaveOccurred())
@@ -67,7 +69,7 @@ releases:
 
 		JustBeforeEach(func() {
 			multiReleaseSourceProvider := func(kilnfile cargo.Kilnfile, allowOnlyPublishable bool) component.MultiReleaseSource {
-				return fakeReleasesSource
+				return releaseSource
 			}
 			findReleaseVersion = commands.NewFindReleaseVersion(logger, multiReleaseSourceProvider)
 
@@ -104,7 +106,7 @@ releases:
 				When("uaac has releases on bosh.io", func() {
 					It("returns the latest release version", func() {
 						Expect(executeErr).NotTo(HaveOccurred())
-						args, _ := fakeReleasesSource.FindReleaseVersionArgsForCall(0)
+						_, args, _ := fakeReleasesSource.FindReleaseVersionArgsForCall(0)
 						Expect(args.StemcellVersion).To(Equal("4.5.6"))
 						Expect(args.StemcellOS).To(Equal("some-os"))
 						Expect(args.Version).To(Equal(""))
@@ -137,7 +139,7 @@ releases:
 				When("uaa has releases on bosh.io", func() {
 					It("returns the latest release version", func() {
 						Expect(executeErr).NotTo(HaveOccurred())
-						args, noDownload := fakeReleasesSource.FindReleaseVersionArgsForCall(0)
+						_, args, noDownload := fakeReleasesSource.FindReleaseVersionArgsForCall(0)
 						Expect(noDownload).To(BeFalse())
 						Expect(args.Version).To(Equal("~74.16.0"))
 						Expect(args.StemcellVersion).To(Equal("4.5.6"))
@@ -161,11 +163,49 @@ releases:
 
 			It("calls source with correct args", func() {
 				Expect(executeErr).NotTo(HaveOccurred())
-				_, noDownload := fakeReleasesSource.FindReleaseVersionArgsForCall(0)
+				_, _, noDownload := fakeReleasesSource.FindReleaseVersionArgsForCall(0)
 				Expect(noDownload).To(BeTrue())
 			})
 		})
 
+		When("the release source explains its choice", func() {
+			var bucket, boshIO *fakes.ReleaseSource
+
+			BeforeEach(func() {
+				releaseName = "has-no-constraint"
+				kilnContents := `
+---
+source_preference:
+  prefer_compiled: true
+releases:
+- name: has-no-constraint
+`
+				Expect(os.WriteFile(someKilnfilePath, []byte(kilnContents), 0o644)).To(Succeed())
+				fetchExecuteArgs = []string{
+					"--kilnfile", someKilnfilePath,
+					"--release", releaseName,
+				}
+
+				boshIO = new(fakes.ReleaseSource)
+				boshIO.ConfigurationReturns(cargo.ReleaseSourceConfig{ID: "bosh.io"})
+				boshIO.FindReleaseVersionReturns(cargo.BOSHReleaseTarballLock{Name: releaseName, Version: "74.12.5", RemoteSource: "bosh.io"}, nil)
+				bucket = new(fakes.ReleaseSource)
+				bucket.ConfigurationReturns(cargo.ReleaseSourceConfig{ID: "compiled-releases", Publishable: true})
+				bucket.FindReleaseVersionReturns(cargo.BOSHReleaseTarballLock{Name: releaseName, Version: "74.12.5", StemcellOS: "some-os", StemcellVersion: "4.5.6", RemoteSource: "compiled-releases"}, nil)
+				releaseSource = component.NewMultiReleaseSource(boshIO, bucket)
+			})
+
+			It("passes the source preference and prints the reason", func() {
+				Expect(executeErr).NotTo(HaveOccurred())
+				_, args, _ := bucket.FindReleaseVersionArgsForCall(0)
+				Expect(args.SourcePreference).To(Equal(&cargo.SourcePreference{PreferCompiled: true}))
+
+				Expect(writer.String()).To(ContainSubstring(`"source":"compiled-releases"`))
+				Expect(writer.String()).To(ContainSubstring(`"reason":"chose has-no-constraint 74.12.5 from \"compiled-releases\" because it is compiled for stemcell some-os 4.5.6 and the release from \"bosh.io\" is not (prefer_compiled)"`))
+				Expect(writer.String()).To(ContainSubstring(`"candidates":[{"source":"compiled-releases","version":"74.12.5","stemcell_os":"some-os","stemcell_version":"4.5.6","publishable":true},{"source":"bosh.io","version":"74.12.5","publishable":false}]`))
+			})
+		})
+
 		When("--no-download is not specified", func() {
 			BeforeEach(func() {
 				releaseName = "has-no-constraint"
@@ -177,7 +217,7 @@ releases:
 
 			It("calls source with correct args", func() {
 				Expect(executeErr).NotTo(HaveOccurred())
-				_, noDownload := fakeReleasesSource.FindReleaseVersionArgsForCall(0)
+				_, _, noDownload := fakeReleasesSource.FindReleaseVersionArgsForCall(0)
 				Expect(noDownload).To(BeFalse())
 			})
 		})
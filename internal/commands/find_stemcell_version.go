@@ -27,12 +27,15 @@ type FindStemcellVersion struct {
 
 	Options struct {
 		flags.Standard
+
+		OS string `long:"os" description:"operating system of the stemcell to find (by default every stemcell in the Kilnfile is printed, one per line)"`
 	}
 
 	FS billy.Filesystem
 }
 
 type stemcellVersionOutput struct {
+	OS         string `json:"os,omitempty"`
 	Version    string `json:"version"`
 	Source     string `json:"source"`
 	RemotePath string `json:"remote_path"`
@@ -52,12 +55,31 @@ func (cmd FindStemcellVersion) Execute(args []string) error {
 		return err
 	}
 
-	productSlug, err := kilnfile.Stemcell.ProductSlug()
+	stemcells := kilnfile.AllStemcells()
+	if cmd.Options.OS != "" || len(stemcells) == 0 {
+		stemcell, err := kilnfile.StemcellForOS(cmd.Options.OS)
+		if err != nil {
+			return err
+		}
+		stemcells = []cargo.Stemcell{stemcell}
+	}
+
+	for _, stemcell := range stemcells {
+		if err := cmd.printStemcellVersion(stemcell); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (cmd FindStemcellVersion) printStemcellVersion(stemcell cargo.Stemcell) error {
+	productSlug, err := stemcell.ProductSlug()
 	if err != nil {
 		return err
 	}
 
-	if kilnfile.Stemcell.Version == "" {
+	if stemcell.Version == "" {
 		return errors.New(ErrStemcellMajorVersionMustBeValid)
 	}
 
@@ -67,7 +89,7 @@ func (cmd FindStemcellVersion) Execute(args []string) error {
 		return err
 	}
 
-	c, err := semver.NewConstraint(kilnfile.Stemcell.Version)
+	c, err := semver.NewConstraint(stemcell.Version)
 	if err != nil {
 		return err
 	}
@@ -78,6 +100,7 @@ func (cmd FindStemcellVersion) Execute(args []string) error {
 	}
 
 	stemcellVersionJson, err := json.Marshal(stemcellVersionOutput{
+		OS:         stemcell.OS,
 		Version:    v,
 		Source:     "Tanzunet",
 		RemotePath: TanzuNetRemotePath,
@@ -112,7 +135,7 @@ func (cmd *FindStemcellVersion) setup(args []string) (cargo.Kilnfile, error) {
 
 func (cmd FindStemcellVersion) Usage() jhanda.Usage {
 	return jhanda.Usage{
-		Description:      "Prints the latest stemcell version from Pivnet using the stemcell type listed in the Kilnfile",
+		Description:      "Prints the latest stemcell version from Pivnet using the stemcell type listed in the Kilnfile. When the Kilnfile lists more than one stemcell, it prints a JSON line for each one unless --os is set.",
 		ShortDescription: "prints the latest stemcell version from Pivnet using the stemcell type listed in the Kilnfile",
 		Flags:            cmd.Options,
 	}
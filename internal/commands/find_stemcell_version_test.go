@@ -23,6 +23,7 @@ var _ = Describe("Find the stemcell version", func() {
 		writer strings.Builder
 
 		fetchExecuteArgs     []string
+		extraArgs            []string
 		executeErr           error
 		someKilnfilePath     string
 		someKilnfileLockPath string
@@ -37,6 +38,7 @@ var _ = Describe("Find the stemcell version", func() {
 	Describe("Execute", func() {
 		BeforeEach(func() {
 			logger = log.New(&writer, "", 0)
+			extraArgs = nil
 
 			pivnetService = new(pivnet.Service)
 			simpleRequest, _ = http.NewRequest(http.MethodGet, "/", nil)
@@ -91,9 +93,9 @@ stemcell_criteria:
 
 			findStemcellVersion = commands.NewFindStemcellVersion(logger, pivnetService)
 
-			fetchExecuteArgs = []string{
+			fetchExecuteArgs = append([]string{
 				"--kilnfile", someKilnfilePath,
-			}
+			}, extraArgs...)
 			executeErr = findStemcellVersion.Execute(fetchExecuteArgs)
 		})
 
@@ -165,6 +167,35 @@ stemcell_criteria:
 			})
 		})
 
+		When("the Kilnfile lists more than one stemcell", func() {
+			BeforeEach(func() {
+				kilnfileContents += `stemcells:
+  - os: windows2019
+    version: "~2019"
+`
+				extraArgs = []string{"--os", "windows2019"}
+				serverMock.Results.Res.Body = fakes.NewReadCloser(`{"releases":[{"version": "2019.52"},{"version": "2019.51"}]}`)
+				serverMock.Results.Res.StatusCode = http.StatusOK
+				serverMock.Results.Err = nil
+			})
+
+			It("returns the latest version of the stemcell with the OS", func() {
+				Expect(executeErr).NotTo(HaveOccurred())
+				Expect(serverMock.Params.Req.URL.String()).To(ContainSubstring("stemcells-windows-server"))
+				Expect((&writer).String()).To(ContainSubstring(`{"os":"windows2019","version":"2019.52"`))
+			})
+
+			When("the OS is not in the Kilnfile", func() {
+				BeforeEach(func() {
+					extraArgs = []string{"--os", "ubuntu-noble"}
+				})
+
+				It("returns an error", func() {
+					Expect(executeErr).To(MatchError(ContainSubstring(`stemcell with os "ubuntu-noble" not found in Kilnfile`)))
+				})
+			})
+		})
+
 		When("stemcell OS and major version is specified", func() {
 			When("a new stemcell exists", func() {
 				BeforeEach(func() {
@@ -1,13 +1,18 @@
 package flags
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"reflect"
 	"slices"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-billy/v5/osfs"
@@ -48,7 +53,38 @@ type FetchBakeOptions struct {
 	AllowOnlyPublishableReleases bool `long:"allow-only-publishable-releases" default:"false" description:"include releases that would not be shipped with the tile (development builds)"`
 }
 
-// LoadKilnfiles parses and interpolates the Kilnfile and parsed the Kilnfile.lock.
+// Timeouts bounds how long a command waits on release sources.
+type Timeouts struct {
+	Timeout        time.Duration `long:"timeout"         description:"maximum duration for the command to finish communicating with release sources (0 means no limit)"`
+	RequestTimeout time.Duration `long:"request-timeout" description:"maximum duration for each release source lookup or download (0 means no limit)"`
+}
+
+// Context returns a context that is canceled on interrupt (Ctrl-C), on SIGTERM,
+// and when Timeout has elapsed.
+func (t Timeouts) Context() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	if t.Timeout <= 0 {
+		return ctx, stop
+	}
+	ctx, cancel := context.WithTimeout(ctx, t.Timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// RequestContext returns a child of ctx for a single release source call
+// bounded by RequestTimeout.
+func (t Timeouts) RequestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if t.RequestTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, t.RequestTimeout)
+}
+
+// LoadKilnfiles parses and interpolates the Kilnfile, merges the Kilnfiles it
+// extends, resolves release source credential references, and parses the
+// Kilnfile.lock.
 // The function parameters are for overriding default services. These parameters are
 // helpful for testing, in most cases nil can be passed for both.
 func (options *Standard) LoadKilnfiles(fsOverride billy.Basic, variablesServiceOverride VariablesService) (_ cargo.Kilnfile, _ cargo.KilnfileLock, err error) {
@@ -56,23 +92,15 @@ func (options *Standard) LoadKilnfiles(fsOverride billy.Basic, variablesServiceO
 	if fs == nil {
 		fs = osfs.New("")
 	}
-	variablesService := variablesServiceOverride
-	if variablesService == nil {
-		variablesService = baking.NewTemplateVariablesService(fs)
-	}
 
-	templateVariables, err := variablesService.FromPathsAndPairs(options.VariableFiles, options.Variables)
+	kilnfile, err := options.LoadKilnfile(fs, variablesServiceOverride)
 	if err != nil {
-		return cargo.Kilnfile{}, cargo.KilnfileLock{}, fmt.Errorf("failed to parse template variables: %w", err)
-	}
-
-	kilnfileFP, err := fs.Open(options.Kilnfile)
-	if err != nil {
-		return cargo.Kilnfile{}, cargo.KilnfileLock{}, fmt.Errorf("failed to open Kilnfile: %w", err)
+		return cargo.Kilnfile{}, cargo.KilnfileLock{}, err
 	}
-	defer closeAndIgnoreError(kilnfileFP)
 
-	kilnfile, err := cargo.InterpolateAndParseKilnfile(kilnfileFP, templateVariables)
+	kilnfile, err = cargo.CredentialResolver{
+		ReadFile: func(name string) ([]byte, error) { return readFile(fs, name) },
+	}.ResolveCredentials(kilnfile)
 	if err != nil {
 		return cargo.Kilnfile{}, cargo.KilnfileLock{}, err
 	}
@@ -96,13 +124,74 @@ func (options *Standard) LoadKilnfiles(fsOverride billy.Basic, variablesServiceO
 	return kilnfile, lock, nil
 }
 
+// LoadKilnfile parses and interpolates the Kilnfile and merges the Kilnfiles
+// it extends (see cargo.ResolveKilnfileExtends). Unlike LoadKilnfiles, it does
+// not resolve release source credentials.
+func (options *Standard) LoadKilnfile(fsOverride billy.Basic, variablesServiceOverride VariablesService) (cargo.Kilnfile, error) {
+	fs := fsOverride
+	if fs == nil {
+		fs = osfs.New("")
+	}
+	variablesService := variablesServiceOverride
+	if variablesService == nil {
+		variablesService = baking.NewTemplateVariablesService(fs)
+	}
+
+	templateVariables, err := variablesService.FromPathsAndPairs(options.VariableFiles, options.Variables)
+	if err != nil {
+		return cargo.Kilnfile{}, fmt.Errorf("failed to parse template variables: %w", err)
+	}
+
+	kilnfileFP, err := fs.Open(options.Kilnfile)
+	if err != nil {
+		return cargo.Kilnfile{}, fmt.Errorf("failed to open Kilnfile: %w", err)
+	}
+	defer closeAndIgnoreError(kilnfileFP)
+
+	kilnfile, err := cargo.InterpolateAndParseKilnfile(kilnfileFP, templateVariables)
+	if err != nil {
+		return cargo.Kilnfile{}, err
+	}
+
+	return cargo.ResolveKilnfileExtends(options.Kilnfile, kilnfile, func(path string) (cargo.Kilnfile, error) {
+		f, err := fs.Open(path)
+		if err != nil {
+			return cargo.Kilnfile{}, err
+		}
+		defer closeAndIgnoreError(f)
+		return cargo.InterpolateAndParseKilnfile(f, templateVariables)
+	})
+}
+
+// SaveKilnfileLock updates the Kilnfile.lock to match kilnfileLock. Only the
+// changed entries are rewritten, see cargo.KilnfileLockDocument.
 func (options Standard) SaveKilnfileLock(fsOverride billy.Basic, kilnfileLock cargo.KilnfileLock) error {
+	return options.EditKilnfileLock(fsOverride, func(doc *cargo.KilnfileLockDocument) error {
+		return doc.SetKilnfileLock(kilnfileLock)
+	})
+}
+
+// EditKilnfileLock parses the Kilnfile.lock, calls edit, and writes the result
+// keeping the comments, key order, and formatting of unchanged entries. A
+// missing Kilnfile.lock is edited as an empty one.
+func (options Standard) EditKilnfileLock(fsOverride billy.Basic, edit func(doc *cargo.KilnfileLockDocument) error) error {
 	fs := fsOverride
 	if fs == nil {
 		fs = osfs.New("")
 	}
 
-	updatedLockFileYAML, err := yaml.Marshal(kilnfileLock)
+	existing, err := readFile(fs, options.KilnfileLockPath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error reading the Kilnfile.lock: %w", err)
+	}
+	doc, err := cargo.ParseKilnfileLockDocument(existing)
+	if err != nil {
+		return err
+	}
+	if err := edit(doc); err != nil {
+		return err
+	}
+	updatedLockFileYAML, err := doc.Bytes()
 	if err != nil {
 		return fmt.Errorf("error marshaling the Kilnfile.lock: %w", err) // untestable
 	}
@@ -111,6 +200,7 @@ func (options Standard) SaveKilnfileLock(fsOverride billy.Basic, kilnfileLock ca
 	if err != nil {
 		return fmt.Errorf("error reopening the Kilnfile.lock for writing: %w", err)
 	}
+	defer closeAndIgnoreError(lockFile)
 
 	_, err = lockFile.Write(updatedLockFileYAML)
 	if err != nil {
@@ -312,4 +402,13 @@ func encodeFlags(v reflect.Value) []string {
 	return result
 }
 
+func readFile(fs billy.Basic, name string) ([]byte, error) {
+	f, err := fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer closeAndIgnoreError(f)
+	return io.ReadAll(f)
+}
+
 func closeAndIgnoreError(c io.Closer) { _ = c.Close() }
@@ -1,15 +1,19 @@
 package flags_test
 
 import (
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/pivotal-cf/kiln/internal/commands"
 	"github.com/pivotal-cf/kiln/internal/commands/flags"
+	"github.com/pivotal-cf/kiln/pkg/cargo"
 )
 
 func TestLoadFlagsWithDefaults(t *testing.T) {
@@ -392,3 +396,138 @@ func TestArgs(t *testing.T) {
 		}, "it encodes an options struct into a string slice with jhanda formatting")
 	})
 }
+
+func TestStandard_LoadKilnfiles(t *testing.T) {
+	t.Run("when a release source references credentials", func(t *testing.T) {
+		t.Setenv("KILN_TEST_SECRET_ACCESS_KEY", "some-secret-key")
+
+		fs := memfs.New()
+		writeFile(t, fs, "Kilnfile", `---
+release_sources:
+  - type: s3
+    bucket: some-bucket
+    access_key_id: some-access-key-id
+    credentials:
+      secret_access_key: {env: KILN_TEST_SECRET_ACCESS_KEY}
+  - type: artifactory
+    credentials:
+      password: {file: /secrets/artifactory-password}
+`)
+		writeFile(t, fs, "Kilnfile.lock", "{}\n")
+		writeFile(t, fs, "/secrets/artifactory-password", "some-password\n")
+
+		options := flags.Standard{Kilnfile: "Kilnfile"}
+		kilnfile, _, err := options.LoadKilnfiles(fs, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "some-secret-key", kilnfile.ReleaseSources[0].SecretAccessKey)
+		assert.Equal(t, "some-password", kilnfile.ReleaseSources[1].Password)
+	})
+
+	t.Run("when a credential can not be resolved", func(t *testing.T) {
+		fs := memfs.New()
+		writeFile(t, fs, "Kilnfile", `---
+release_sources:
+  - type: github
+    org: some-org
+    credentials:
+      github_token: {env: KILN_TEST_UNSET_GITHUB_TOKEN}
+`)
+		writeFile(t, fs, "Kilnfile.lock", "{}\n")
+
+		options := flags.Standard{Kilnfile: "Kilnfile"}
+		_, _, err := options.LoadKilnfiles(fs, nil)
+		assert.ErrorContains(t, err, `failed to resolve github_token for release source "some-org"`)
+	})
+
+	t.Run("when the Kilnfile extends another Kilnfile", func(t *testing.T) {
+		fs := memfs.New()
+		writeFile(t, fs, "shared/Kilnfile.base", `---
+release_sources:
+  - type: s3
+    bucket: $( variable "bucket" )
+releases:
+  - name: bpm
+  - name: uaa
+    version: ~75
+`)
+		writeFile(t, fs, "tile/Kilnfile", `---
+extends: [../shared/Kilnfile.base]
+releases:
+  - name: uaa
+    version: ~76
+`)
+		writeFile(t, fs, "tile/Kilnfile.lock", "{}\n")
+
+		options := flags.Standard{Kilnfile: "tile/Kilnfile", Variables: []string{"bucket=shared-bucket"}}
+		kilnfile, _, err := options.LoadKilnfiles(fs, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "shared-bucket", kilnfile.ReleaseSources[0].Bucket)
+		assert.Equal(t, []cargo.BOSHReleaseTarballSpecification{{Name: "bpm"}, {Name: "uaa", Version: "~76"}}, kilnfile.Releases)
+		assert.Empty(t, kilnfile.Extends)
+	})
+}
+
+func TestStandard_EditKilnfileLock(t *testing.T) {
+	t.Run("it keeps comments", func(t *testing.T) {
+		fs := memfs.New()
+		writeFile(t, fs, "Kilnfile.lock", `releases:
+  # pinned for CVE-1234
+  - name: bpm
+    sha1: old-sha
+    version: 1.2.0
+    remote_source: bosh.io
+    remote_path: bpm-1.2.0
+stemcell_criteria:
+  os: ubuntu-jammy
+  version: "1.100"
+`)
+
+		options := flags.Standard{Kilnfile: "Kilnfile"}
+		require.NoError(t, options.EditKilnfileLock(fs, func(doc *cargo.KilnfileLockDocument) error {
+			return doc.SetRelease(cargo.BOSHReleaseTarballLock{Name: "bpm", SHA1: "new-sha", Version: "1.2.1", RemoteSource: "bosh.io", RemotePath: "bpm-1.2.1"})
+		}))
+
+		assert.Equal(t, `releases:
+  # pinned for CVE-1234
+  - name: bpm
+    sha1: new-sha
+    version: 1.2.1
+    remote_source: bosh.io
+    remote_path: bpm-1.2.1
+stemcell_criteria:
+  os: ubuntu-jammy
+  version: "1.100"
+`, readFile(t, fs, "Kilnfile.lock"))
+	})
+
+	t.Run("when the Kilnfile.lock is empty", func(t *testing.T) {
+		fs := memfs.New()
+		writeFile(t, fs, "Kilnfile.lock", "{}\n")
+
+		options := flags.Standard{Kilnfile: "Kilnfile"}
+		require.NoError(t, options.SaveKilnfileLock(fs, cargo.KilnfileLock{
+			Stemcell: cargo.Stemcell{OS: "ubuntu-jammy", Version: "1.100"},
+		}))
+
+		assert.Equal(t, "stemcell_criteria:\n    os: ubuntu-jammy\n    version: \"1.100\"\n", readFile(t, fs, "Kilnfile.lock"))
+	})
+}
+
+func readFile(t *testing.T, fs billy.Basic, name string) string {
+	t.Helper()
+	f, err := fs.Open(name)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	buf, err := io.ReadAll(f)
+	require.NoError(t, err)
+	return string(buf)
+}
+
+func writeFile(t *testing.T, fs billy.Basic, name, content string) {
+	t.Helper()
+	f, err := fs.Create(name)
+	require.NoError(t, err)
+	_, err = f.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
@@ -0,0 +1,405 @@
+package commands
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/go-units"
+	"github.com/pivotal-cf/jhanda"
+	"gopkg.in/yaml.v3"
+
+	"github.com/pivotal-cf/kiln/internal/builder"
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+	"github.com/pivotal-cf/kiln/pkg/tile"
+)
+
+type Inspect struct {
+	outLogger *log.Logger
+
+	Options struct {
+		JSON                   bool     `long:"json"                     description:"print the summary as JSON"`
+		VerifyReleaseChecksums bool     `long:"verify-release-checksums" description:"check the SHA1 checksum of each release tarball against the tile metadata"`
+		Headers                []string `long:"header"                   description:"an HTTP header (\"Name: value\") to send when the tile is a URL"`
+	}
+}
+
+func NewInspect(outLogger *log.Logger) Inspect {
+	return Inspect{outLogger: outLogger}
+}
+
+func (cmd Inspect) Execute(args []string) error {
+	tilePaths, err := jhanda.Parse(&cmd.Options, args)
+	if err != nil {
+		return err
+	}
+	if len(tilePaths) != 1 {
+		return fmt.Errorf("expected one argument: <tile>: %d arguments passed", len(tilePaths))
+	}
+	tilePath := tilePaths[0]
+
+	zr, openEntry, closeTile, err := cmd.openTile(tilePath)
+	if err != nil {
+		return fmt.Errorf("failed to open tile: %w", err)
+	}
+	defer closeTile()
+
+	summary, err := inspectTile(zr, openEntry, cmd.Options.VerifyReleaseChecksums)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Options.JSON {
+		buf, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			return err
+		}
+		cmd.outLogger.Println(string(buf))
+	} else {
+		cmd.outLogger.Print(summary.table())
+	}
+
+	if mismatched := summary.releasesWithMismatchedChecksums(); len(mismatched) > 0 {
+		return fmt.Errorf("release tarball checksums do not match the tile metadata: %s", strings.Join(mismatched, ", "))
+	}
+	return nil
+}
+
+func (cmd Inspect) Usage() jhanda.Usage {
+	return jhanda.Usage{
+		Description:      "Summarizes a tile: the product name and version, stemcell criteria, releases, job types, errands, property blueprint counts, migrations, embedded files, and kiln_metadata.\n\nThe tile may be an http or https URL of a server that supports range requests (for example a presigned S3 URL). Only the zip central directory, the metadata, and the start of each release tarball are downloaded. With --verify-release-checksums each release tarball is downloaded with a single request.\n\nUsage: kiln inspect [options] <tile>",
+		ShortDescription: "summarizes a built tile",
+		Flags:            cmd.Options,
+	}
+}
+
+// zipEntryOpener opens the contents of an entry in the tile.
+type zipEntryOpener func(f *zip.File) (io.ReadCloser, error)
+
+// openTile opens a tile file or, when tilePath is a URL, reads the tile with
+// HTTP range requests. The returned zipEntryOpener should be used to read
+// release tarballs so a remote entry is streamed with one request.
+func (cmd Inspect) openTile(tilePath string) (*zip.Reader, zipEntryOpener, func(), error) {
+	if !strings.HasPrefix(tilePath, "http://") && !strings.HasPrefix(tilePath, "https://") {
+		zr, err := zip.OpenReader(tilePath)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return &zr.Reader, (*zip.File).Open, func() { closeAndIgnoreError(zr) }, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, tilePath, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, header := range cmd.Options.Headers {
+		name, value, found := strings.Cut(header, ":")
+		if !found {
+			return nil, nil, nil, fmt.Errorf("expected --header in the form \"Name: value\": got %q", header)
+		}
+		req.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	ra, err := tile.NewRangeReaderAt(http.DefaultClient, req)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	zr, err := zip.NewReader(ra, ra.Size())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return zr, ra.OpenFile, func() {}, nil
+}
+
+type tileSummary struct {
+	Name             string                 `json:"name"`
+	ProductVersion   string                 `json:"product_version"`
+	StemcellCriteria []tileStemcellCriteria `json:"stemcell_criteria"`
+	Releases         []tileRelease          `json:"releases"`
+	JobTypes         []tileJobType          `json:"job_types"`
+	Errands          []tileErrand           `json:"errands"`
+	Properties       tilePropertyBlueprints `json:"property_blueprints"`
+	Migrations       []string               `json:"migrations"`
+	EmbeddedFiles    []tileFile             `json:"embedded_files"`
+	KilnMetadata     builder.KilnMetadata   `json:"kiln_metadata"`
+}
+
+type tileStemcellCriteria struct {
+	OS      string `yaml:"os"      json:"os"`
+	Version string `yaml:"version" json:"version"`
+}
+
+type tileRelease struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	File    string `json:"file"`
+	SHA1    string `json:"sha1"`
+	Size    int64  `json:"size"`
+
+	// Compiled is nil when the release.MF could not be read from the tarball
+	Compiled *bool `json:"compiled"`
+
+	// CompiledStemcell is the <os>/<version> the packages were compiled on
+	CompiledStemcell string `json:"compiled_stemcell,omitempty"`
+
+	// SHA1Matches is only set when the checksums are verified
+	SHA1Matches *bool `json:"sha1_matches,omitempty"`
+}
+
+type tileJobType struct {
+	Name               string `json:"name"`
+	PropertyBlueprints int    `json:"property_blueprints"`
+}
+
+type tileErrand struct {
+	Name       string `json:"name"`
+	PostDeploy bool   `json:"post_deploy"`
+	PreDelete  bool   `json:"pre_delete"`
+}
+
+type tilePropertyBlueprints struct {
+	Product  int `json:"product"`
+	JobTypes int `json:"job_types"`
+}
+
+type tileFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	Mode string `json:"mode"`
+}
+
+// inspectTile summarizes the tile metadata and zip entries. When
+// verifyChecksums is set the release tarballs are read in full and hashed.
+func inspectTile(zr *zip.Reader, openEntry zipEntryOpener, verifyChecksums bool) (tileSummary, error) {
+	metadataBuffer, err := tile.ReadMetadataFromFS(zr)
+	if err != nil {
+		return tileSummary{}, err
+	}
+	type errandReference struct {
+		Name string `yaml:"name"`
+	}
+	var metadata struct {
+		Name                        string                 `yaml:"name"`
+		ProductVersion              string                 `yaml:"product_version"`
+		StemcellCriteria            tileStemcellCriteria   `yaml:"stemcell_criteria"`
+		AdditionalStemcellsCriteria []tileStemcellCriteria `yaml:"additional_stemcells_criteria"`
+		Releases                    []struct {
+			Name    string `yaml:"name"`
+			Version string `yaml:"version"`
+			File    string `yaml:"file"`
+			SHA1    string `yaml:"sha1"`
+		} `yaml:"releases"`
+		PropertyBlueprints []yaml.Node `yaml:"property_blueprints"`
+		JobTypes           []struct {
+			Name               string      `yaml:"name"`
+			Errand             bool        `yaml:"errand"`
+			PropertyBlueprints []yaml.Node `yaml:"property_blueprints"`
+		} `yaml:"job_types"`
+		PostDeployErrands []errandReference    `yaml:"post_deploy_errands"`
+		PreDeleteErrands  []errandReference    `yaml:"pre_delete_errands"`
+		KilnMetadata      builder.KilnMetadata `yaml:"kiln_metadata"`
+	}
+	if err := yaml.Unmarshal(metadataBuffer, &metadata); err != nil {
+		return tileSummary{}, fmt.Errorf("failed to parse tile metadata: %w", err)
+	}
+
+	summary := tileSummary{
+		Name:             metadata.Name,
+		ProductVersion:   metadata.ProductVersion,
+		StemcellCriteria: []tileStemcellCriteria{},
+		Releases:         []tileRelease{},
+		JobTypes:         []tileJobType{},
+		Errands:          []tileErrand{},
+		Properties:       tilePropertyBlueprints{Product: len(metadata.PropertyBlueprints)},
+		Migrations:       []string{},
+		EmbeddedFiles:    []tileFile{},
+		KilnMetadata:     metadata.KilnMetadata,
+	}
+
+	if metadata.StemcellCriteria != (tileStemcellCriteria{}) {
+		summary.StemcellCriteria = append(summary.StemcellCriteria, metadata.StemcellCriteria)
+	}
+	summary.StemcellCriteria = append(summary.StemcellCriteria, metadata.AdditionalStemcellsCriteria...)
+
+	entries := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		entries[f.Name] = f
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(f.Name, "migrations/"):
+			summary.Migrations = append(summary.Migrations, strings.TrimPrefix(f.Name, "migrations/"))
+		case strings.HasPrefix(f.Name, "embed/"):
+			summary.EmbeddedFiles = append(summary.EmbeddedFiles, tileFile{
+				Path: strings.TrimPrefix(f.Name, "embed/"),
+				Size: int64(f.UncompressedSize64),
+				Mode: f.Mode().Perm().String(),
+			})
+		}
+	}
+
+	for _, release := range metadata.Releases {
+		f, ok := entries[path.Join("releases", release.File)]
+		if !ok {
+			return tileSummary{}, fmt.Errorf("release %s/%s file %s not found in the tile", release.Name, release.Version, release.File)
+		}
+		r := tileRelease{
+			Name:    release.Name,
+			Version: release.Version,
+			File:    release.File,
+			SHA1:    release.SHA1,
+			Size:    int64(f.UncompressedSize64),
+		}
+		if manifest, err := readReleaseManifest(openEntry, f); err == nil {
+			compiled := len(manifest.CompiledPackages) > 0
+			r.Compiled = &compiled
+			if stemcellOS, stemcellVersion, ok := manifest.Stemcell(); ok {
+				r.CompiledStemcell = stemcellOS + "/" + stemcellVersion
+			}
+		}
+		if verifyChecksums {
+			sum, err := zipEntrySHA1(openEntry, f)
+			if err != nil {
+				return tileSummary{}, fmt.Errorf("failed to hash %s: %w", f.Name, err)
+			}
+			matches := sum == release.SHA1
+			r.SHA1Matches = &matches
+		}
+		summary.Releases = append(summary.Releases, r)
+	}
+
+	for _, jobType := range metadata.JobTypes {
+		summary.Properties.JobTypes += len(jobType.PropertyBlueprints)
+		if !jobType.Errand {
+			summary.JobTypes = append(summary.JobTypes, tileJobType{Name: jobType.Name, PropertyBlueprints: len(jobType.PropertyBlueprints)})
+			continue
+		}
+		isJobType := func(e errandReference) bool { return e.Name == jobType.Name }
+		summary.Errands = append(summary.Errands, tileErrand{
+			Name:       jobType.Name,
+			PostDeploy: slices.ContainsFunc(metadata.PostDeployErrands, isJobType),
+			PreDelete:  slices.ContainsFunc(metadata.PreDeleteErrands, isJobType),
+		})
+	}
+
+	return summary, nil
+}
+
+// readReleaseManifest reads the release.MF at the start of a release tarball
+// without reading the rest of it.
+func readReleaseManifest(openEntry zipEntryOpener, f *zip.File) (cargo.BOSHReleaseManifest, error) {
+	rc, err := openEntry(f)
+	if err != nil {
+		return cargo.BOSHReleaseManifest{}, err
+	}
+	defer closeAndIgnoreError(rc)
+	r := bufio.NewReader(rc)
+	magic, err := r.Peek(2)
+	if err != nil {
+		return cargo.BOSHReleaseManifest{}, err
+	}
+	isGzip := magic[0] == 0x1f && magic[1] == 0x8b
+	return cargo.ReadProductTemplatePartFromBOSHReleaseTarball(r, !isGzip)
+}
+
+func zipEntrySHA1(openEntry zipEntryOpener, f *zip.File) (string, error) {
+	rc, err := openEntry(f)
+	if err != nil {
+		return "", err
+	}
+	defer closeAndIgnoreError(rc)
+	sum := sha1.New()
+	if _, err := io.Copy(sum, rc); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+func (summary tileSummary) releasesWithMismatchedChecksums() []string {
+	var mismatched []string
+	for _, release := range summary.Releases {
+		if release.SHA1Matches != nil && !*release.SHA1Matches {
+			mismatched = append(mismatched, release.Name+"/"+release.Version)
+		}
+	}
+	return mismatched
+}
+
+func (summary tileSummary) table() string {
+	var out strings.Builder
+	w := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+
+	stemcells := make([]string, 0, len(summary.StemcellCriteria))
+	for _, criteria := range summary.StemcellCriteria {
+		stemcells = append(stemcells, criteria.OS+" "+criteria.Version)
+	}
+	_, _ = fmt.Fprintf(w, "Product:\t%s %s\n", summary.Name, summary.ProductVersion)
+	_, _ = fmt.Fprintf(w, "Stemcells:\t%s\n", strings.Join(stemcells, ", "))
+	_, _ = fmt.Fprintf(w, "Metadata git SHA:\t%s\n", valueOrDash(summary.KilnMetadata.MetadataGitSHA))
+	_, _ = fmt.Fprintf(w, "Tile name:\t%s\n", valueOrDash(summary.KilnMetadata.TileName))
+	_, _ = fmt.Fprintf(w, "Property blueprints:\t%d (product), %d (job types)\n", summary.Properties.Product, summary.Properties.JobTypes)
+	_ = w.Flush()
+
+	checksumsVerified := slices.ContainsFunc(summary.Releases, func(r tileRelease) bool { return r.SHA1Matches != nil })
+	_, _ = fmt.Fprintln(&out)
+	if checksumsVerified {
+		_, _ = fmt.Fprintln(w, "RELEASE\tVERSION\tSHA1\tSIZE\tCOMPILED\tSHA1 MATCHES")
+	} else {
+		_, _ = fmt.Fprintln(w, "RELEASE\tVERSION\tSHA1\tSIZE\tCOMPILED")
+	}
+	for _, release := range summary.Releases {
+		compiled := "unknown"
+		if release.Compiled != nil {
+			compiled = "no"
+			if *release.Compiled {
+				compiled = valueOrDash(release.CompiledStemcell)
+			}
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s", release.Name, release.Version, valueOrDash(release.SHA1), units.HumanSize(float64(release.Size)), compiled)
+		if release.SHA1Matches != nil {
+			_, _ = fmt.Fprintf(w, "\t%t", *release.SHA1Matches)
+		}
+		_, _ = fmt.Fprintln(w)
+	}
+	_ = w.Flush()
+
+	_, _ = fmt.Fprintln(&out)
+	_, _ = fmt.Fprintln(w, "JOB TYPE\tPROPERTY BLUEPRINTS")
+	for _, jobType := range summary.JobTypes {
+		_, _ = fmt.Fprintf(w, "%s\t%d\n", jobType.Name, jobType.PropertyBlueprints)
+	}
+	_ = w.Flush()
+
+	_, _ = fmt.Fprintln(&out)
+	_, _ = fmt.Fprintln(w, "ERRAND\tPOST DEPLOY\tPRE DELETE")
+	for _, errand := range summary.Errands {
+		_, _ = fmt.Fprintf(w, "%s\t%t\t%t\n", errand.Name, errand.PostDeploy, errand.PreDelete)
+	}
+	_ = w.Flush()
+
+	_, _ = fmt.Fprintln(&out)
+	_, _ = fmt.Fprintln(w, "MIGRATION")
+	for _, migration := range summary.Migrations {
+		_, _ = fmt.Fprintln(w, migration)
+	}
+	_ = w.Flush()
+
+	_, _ = fmt.Fprintln(&out)
+	_, _ = fmt.Fprintln(w, "EMBEDDED FILE\tSIZE\tMODE")
+	for _, file := range summary.EmbeddedFiles {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", file.Path, units.HumanSize(float64(file.Size)), file.Mode)
+	}
+	_ = w.Flush()
+
+	return out.String()
+}
@@ -0,0 +1,233 @@
+package commands_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/pivotal-cf/kiln/internal/commands"
+)
+
+var _ = Describe("inspect", func() {
+	var (
+		tilePath string
+		metadata string
+		output   *gbytes.Buffer
+		command  commands.Inspect
+
+		compiledRelease, release []byte
+	)
+
+	BeforeEach(func() {
+		compiledRelease = releaseTarball("release.MF", "name: bpm\nversion: 1.2.3\ncompiled_packages:\n- name: bpm\n  stemcell: ubuntu-jammy/1.234\n")
+		release = releaseTarball("release.MF", "name: hello\nversion: 0.1.0\npackages:\n- name: hello\n")
+		compiledReleaseSHA1 := sha1.Sum(compiledRelease)
+
+		metadata = fmt.Sprintf(`name: hello
+product_version: 1.0.0
+stemcell_criteria:
+  os: ubuntu-jammy
+  version: "1.234"
+additional_stemcells_criteria:
+- os: windows2019
+  version: "2019.70"
+releases:
+- name: bpm
+  version: 1.2.3
+  file: bpm-1.2.3-ubuntu-jammy-1.234.tgz
+  sha1: %s
+- name: hello
+  version: 0.1.0
+  file: hello-0.1.0.tgz
+  sha1: some-other-sha1
+property_blueprints:
+- name: some_property
+  type: string
+- name: some_selector
+  type: selector
+job_types:
+- name: hello-server
+  property_blueprints:
+  - name: port
+    type: port
+- name: smoke-tests
+  errand: true
+post_deploy_errands:
+- name: smoke-tests
+kiln_metadata:
+  metadata_git_sha: some-revision
+  tile_name: hello
+`, hex.EncodeToString(compiledReleaseSHA1[:]))
+
+		output = gbytes.NewBuffer()
+		command = commands.NewInspect(log.New(output, "", 0))
+	})
+
+	JustBeforeEach(func() {
+		tilePath = filepath.Join(GinkgoT().TempDir(), "hello-1.0.0.pivotal")
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for _, entry := range []struct {
+			name     string
+			contents []byte
+		}{
+			{name: "metadata/metadata.yml", contents: []byte(metadata)},
+			{name: "migrations/v1/"},
+			{name: "migrations/v1/201603041539_custom_buildpacks.js", contents: []byte("migration")},
+			{name: "releases/bpm-1.2.3-ubuntu-jammy-1.234.tgz", contents: compiledRelease},
+			{name: "releases/hello-0.1.0.tgz", contents: release},
+			{name: "embed/scripts/run.sh", contents: []byte("#!/bin/sh\n")},
+		} {
+			header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+			header.SetMode(0o644)
+			if filepath.Ext(entry.name) == ".sh" {
+				header.SetMode(0o755)
+			}
+			f, err := zw.CreateHeader(header)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = f.Write(entry.contents)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(zw.Close()).To(Succeed())
+		Expect(os.WriteFile(tilePath, buf.Bytes(), 0o644)).To(Succeed())
+	})
+
+	It("summarizes the tile", func() {
+		Expect(command.Execute([]string{tilePath})).To(Succeed())
+
+		Expect(output).To(gbytes.Say(`Product:\s+hello 1.0.0`))
+		Expect(output).To(gbytes.Say(`Stemcells:\s+ubuntu-jammy 1.234, windows2019 2019.70`))
+		Expect(output).To(gbytes.Say(`Metadata git SHA:\s+some-revision`))
+		Expect(output).To(gbytes.Say(`Property blueprints:\s+2 \(product\), 1 \(job types\)`))
+		Expect(output).To(gbytes.Say(`bpm\s+1.2.3\s+[0-9a-f]{40}\s+\S+\s+ubuntu-jammy/1.234`))
+		Expect(output).To(gbytes.Say(`hello\s+0.1.0\s+some-other-sha1\s+\S+\s+no`))
+		Expect(output).To(gbytes.Say(`hello-server\s+1`))
+		Expect(output).To(gbytes.Say(`smoke-tests\s+true\s+false`))
+		Expect(output).To(gbytes.Say(`v1/201603041539_custom_buildpacks.js`))
+		Expect(output).To(gbytes.Say(`scripts/run.sh\s+\S+\s+-rwxr-xr-x`))
+	})
+
+	When("the json flag is passed", func() {
+		It("prints the summary as JSON", func() {
+			Expect(command.Execute([]string{"--json", tilePath})).To(Succeed())
+
+			var summary struct {
+				Name     string `json:"name"`
+				Releases []struct {
+					Name             string `json:"name"`
+					Size             int64  `json:"size"`
+					Compiled         *bool  `json:"compiled"`
+					CompiledStemcell string `json:"compiled_stemcell"`
+					SHA1Matches      *bool  `json:"sha1_matches"`
+				} `json:"releases"`
+				Errands []struct {
+					Name       string `json:"name"`
+					PostDeploy bool   `json:"post_deploy"`
+				} `json:"errands"`
+				KilnMetadata struct {
+					MetadataGitSHA string `json:"metadata_git_sha"`
+				} `json:"kiln_metadata"`
+			}
+			Expect(json.Unmarshal(output.Contents(), &summary)).To(Succeed())
+
+			Expect(summary.Name).To(Equal("hello"))
+			Expect(summary.Releases).To(HaveLen(2))
+			Expect(summary.Releases[0].Size).To(BeNumerically(">", 0))
+			Expect(summary.Releases[0].Compiled).To(HaveValue(BeTrue()))
+			Expect(summary.Releases[0].CompiledStemcell).To(Equal("ubuntu-jammy/1.234"))
+			Expect(summary.Releases[0].SHA1Matches).To(BeNil())
+			Expect(summary.Releases[1].Compiled).To(HaveValue(BeFalse()))
+			Expect(summary.Errands).To(HaveLen(1))
+			Expect(summary.Errands[0].PostDeploy).To(BeTrue())
+			Expect(summary.KilnMetadata.MetadataGitSHA).To(Equal("some-revision"))
+		})
+	})
+
+	When("the tile only has additional stemcell criteria", func() {
+		BeforeEach(func() {
+			metadata = strings.Replace(metadata, "stemcell_criteria:\n  os: ubuntu-jammy\n  version: \"1.234\"\n", "", 1)
+		})
+
+		It("does not list an empty stemcell", func() {
+			Expect(command.Execute([]string{"--json", tilePath})).To(Succeed())
+
+			var summary struct {
+				StemcellCriteria []map[string]string `json:"stemcell_criteria"`
+			}
+			Expect(json.Unmarshal(output.Contents(), &summary)).To(Succeed())
+			Expect(summary.StemcellCriteria).To(Equal([]map[string]string{{"os": "windows2019", "version": "2019.70"}}))
+		})
+	})
+
+	When("the release checksums are verified", func() {
+		It("returns an error naming the releases that do not match", func() {
+			err := command.Execute([]string{"--verify-release-checksums", tilePath})
+			Expect(err).To(MatchError("release tarball checksums do not match the tile metadata: hello/0.1.0"))
+
+			Expect(output).To(gbytes.Say(`SHA1 MATCHES`))
+			Expect(output).To(gbytes.Say(`bpm\s+.*true`))
+			Expect(output).To(gbytes.Say(`hello\s+.*false`))
+		})
+	})
+
+	When("the tile is a URL", func() {
+		It("reads the tile with range requests", func() {
+			var authorization string
+			fileServer := http.FileServer(http.Dir(filepath.Dir(tilePath)))
+			server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+				authorization = req.Header.Get("Authorization")
+				Expect(req.Header.Get("Range")).NotTo(BeEmpty())
+				fileServer.ServeHTTP(res, req)
+			}))
+			defer server.Close()
+
+			Expect(command.Execute([]string{"--header", "Authorization: Token some-token", server.URL + "/" + filepath.Base(tilePath)})).To(Succeed())
+
+			Expect(authorization).To(Equal("Token some-token"))
+			Expect(output).To(gbytes.Say(`Product:\s+hello 1.0.0`))
+			Expect(output).To(gbytes.Say(`bpm\s+1.2.3\s+[0-9a-f]{40}\s+\S+\s+ubuntu-jammy/1.234`))
+		})
+
+		It("verifies the release checksums", func() {
+			server := httptest.NewServer(http.FileServer(http.Dir(filepath.Dir(tilePath))))
+			defer server.Close()
+
+			err := command.Execute([]string{"--verify-release-checksums", server.URL + "/" + filepath.Base(tilePath)})
+			Expect(err).To(MatchError("release tarball checksums do not match the tile metadata: hello/0.1.0"))
+			Expect(output).To(gbytes.Say(`bpm\s+.*true`))
+		})
+	})
+
+	When("no tile is passed", func() {
+		It("returns an error", func() {
+			Expect(command.Execute(nil)).To(MatchError(ContainSubstring("expected one argument")))
+		})
+	})
+})
+
+func releaseTarball(name, contents string) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	Expect(tw.WriteHeader(&tar.Header{Name: "./" + name, Mode: 0o644, Size: int64(len(contents))})).To(Succeed())
+	_, err := tw.Write([]byte(contents))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(tw.Close()).To(Succeed())
+	Expect(gw.Close()).To(Succeed())
+	return buf.Bytes()
+}
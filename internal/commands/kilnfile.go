@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/pivotal-cf/jhanda"
+	"gopkg.in/yaml.v3"
+
+	"github.com/pivotal-cf/kiln/internal/commands/flags"
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+// NewKilnfile returns the "kiln kilnfile" command group for working with the
+// Kilnfile itself.
+func NewKilnfile(fs billy.Filesystem, outLogger *log.Logger) CommandGroup {
+	return newCommandGroup("kilnfile",
+		"works with the Kilnfile",
+		"Commands for working with the Kilnfile.",
+		jhanda.CommandSet{
+			"render": &KilnfileRender{fs: fs, outLogger: outLogger},
+		},
+	)
+}
+
+type KilnfileRender struct {
+	fs        billy.Filesystem
+	outLogger *log.Logger
+
+	Options struct {
+		flags.Standard
+	}
+}
+
+func (cmd *KilnfileRender) Execute(args []string) error {
+	if _, err := flags.LoadWithDefaultFilePaths(&cmd.Options, args, cmd.fs.Stat); err != nil {
+		return err
+	}
+	kilnfile, err := cmd.Options.LoadKilnfile(cmd.fs, nil)
+	if err != nil {
+		return fmt.Errorf("error loading Kilnfile: %w", err)
+	}
+	kilnfile = redactReleaseSourceSecrets(kilnfile)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(kilnfile); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	cmd.outLogger.Print(buf.String())
+	return nil
+}
+
+func (cmd *KilnfileRender) Usage() jhanda.Usage {
+	return jhanda.Usage{
+		Description:      "Prints the effective Kilnfile: the Kilnfile interpolated with the variables and merged with the Kilnfiles it extends. Release source credentials are not resolved and secrets set with variables are replaced with " + redactedSecret + ".",
+		ShortDescription: "prints the Kilnfile merged with the Kilnfiles it extends",
+		Flags:            cmd.Options,
+	}
+}
+
+const redactedSecret = "REDACTED"
+
+// redactReleaseSourceSecrets replaces the release source secrets, which are
+// usually set with variables, so the rendered Kilnfile can be logged.
+func redactReleaseSourceSecrets(kilnfile cargo.Kilnfile) cargo.Kilnfile {
+	kilnfile.ReleaseSources = slices.Clone(kilnfile.ReleaseSources)
+	for i := range kilnfile.ReleaseSources {
+		source := &kilnfile.ReleaseSources[i]
+		for _, secret := range []*string{&source.AccessKeyId, &source.SecretAccessKey, &source.GithubToken, &source.Password} {
+			if *secret != "" {
+				*secret = redactedSecret
+			}
+		}
+	}
+	return kilnfile
+}
@@ -0,0 +1,120 @@
+package commands_test
+
+import (
+	"bytes"
+	"log"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/kiln/internal/commands"
+)
+
+var _ = Describe("kilnfile render", func() {
+	var (
+		fs      billy.Filesystem
+		output  bytes.Buffer
+		command commands.CommandGroup
+
+		writeFile func(name, content string)
+	)
+
+	BeforeEach(func() {
+		fs = memfs.New()
+		output.Reset()
+		command = commands.NewKilnfile(fs, log.New(&output, "", 0))
+
+		writeFile = func(name, content string) {
+			f, err := fs.Create(name)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = f.Write([]byte(content))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(f.Close()).To(Succeed())
+		}
+		writeFile("base/Kilnfile", `release_sources:
+  - type: bosh.io
+  - type: github
+    org: cloudfoundry
+    credentials:
+      github_token: {env: KILN_TEST_UNSET_GITHUB_TOKEN}
+releases:
+  - name: bpm
+    version: ~1
+  - name: uaa
+    version: ~75
+stemcell_criteria:
+  os: ubuntu-jammy
+  version: ~1
+`)
+		writeFile("tile/Kilnfile", `extends: [../base/Kilnfile]
+slug: $( variable "slug" )
+releases:
+  - name: uaa
+    version: ~76
+    github_repository: https://github.com/cloudfoundry/uaa-release
+`)
+	})
+
+	It("prints the Kilnfile merged with the Kilnfiles it extends", func() {
+		Expect(command.Execute([]string{"render", "--kilnfile", "tile/Kilnfile", "--variable", "slug=my-tile"})).To(Succeed())
+
+		Expect(output.String()).To(MatchYAML(`release_sources:
+  - type: bosh.io
+  - type: github
+    org: cloudfoundry
+    credentials:
+      github_token: {env: KILN_TEST_UNSET_GITHUB_TOKEN}
+slug: my-tile
+releases:
+  - name: bpm
+    version: ~1
+  - name: uaa
+    version: ~76
+    github_repository: https://github.com/cloudfoundry/uaa-release
+stemcell_criteria:
+  os: ubuntu-jammy
+  version: ~1
+bake_configurations: []
+`))
+	})
+
+	When("release source secrets are set with variables", func() {
+		It("does not print them", func() {
+			writeFile("tile/Kilnfile", `release_sources:
+  - type: s3
+    bucket: some-bucket
+    access_key_id: $( variable "aws_access_key_id" )
+    secret_access_key: $( variable "aws_secret_access_key" )
+  - type: artifactory
+    artifactory_host: https://artifactory.example.com
+    username: some-user
+    password: $( variable "artifactory_password" )
+  - type: github
+    org: cloudfoundry
+    github_token: $( variable "github_token" )
+`)
+			Expect(command.Execute([]string{"render", "--kilnfile", "tile/Kilnfile",
+				"--variable", "aws_access_key_id=some-access-key-id",
+				"--variable", "aws_secret_access_key=some-secret-access-key",
+				"--variable", "artifactory_password=some-password",
+				"--variable", "github_token=some-github-token",
+			})).To(Succeed())
+
+			for _, secret := range []string{"some-access-key-id", "some-secret-access-key", "some-password", "some-github-token"} {
+				Expect(output.String()).NotTo(ContainSubstring(secret))
+			}
+			Expect(output.String()).To(ContainSubstring("secret_access_key: REDACTED"))
+			Expect(output.String()).To(ContainSubstring("username: some-user"))
+		})
+	})
+
+	When("a base Kilnfile is missing", func() {
+		It("returns an error", func() {
+			writeFile("tile/Kilnfile", "extends: [Kilnfile.missing]\n")
+			err := command.Execute([]string{"render", "--kilnfile", "tile/Kilnfile"})
+			Expect(err).To(MatchError(ContainSubstring("failed to load Kilnfile.missing extended by tile/Kilnfile")))
+		})
+	})
+})
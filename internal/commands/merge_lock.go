@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/pivotal-cf/jhanda"
+	"github.com/pmezard/go-difflib/difflib"
+
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+const defaultConflictMarkerSize = 7
+
+type MergeLock struct {
+	fs        billy.Filesystem
+	outLogger *log.Logger
+
+	Options struct {
+		Strategy   string `long:"strategy"    description:"how to merge a release both sides changed: higher (default), ours, theirs, or conflict"`
+		MarkerSize int    `long:"marker-size" description:"length of the conflict markers (git passes it as %L; defaults to 7)"`
+	}
+}
+
+func NewMergeLock(fs billy.Filesystem, outLogger *log.Logger) *MergeLock {
+	return &MergeLock{
+		fs:        fs,
+		outLogger: outLogger,
+	}
+}
+
+func (cmd *MergeLock) Execute(args []string) error {
+	paths, err := jhanda.Parse(&cmd.Options, args)
+	if err != nil {
+		return err
+	}
+	if len(paths) != 3 {
+		return errors.New("expected three arguments: <base> <ours> <theirs> (git passes them as %O %A %B)")
+	}
+	if cmd.Options.MarkerSize <= 0 {
+		cmd.Options.MarkerSize = defaultConflictMarkerSize
+	}
+	basePath, oursPath, theirsPath := paths[0], paths[1], paths[2]
+
+	base, _, err := cmd.readKilnfileLock(basePath)
+	if err != nil {
+		return err
+	}
+	ours, oursBuf, err := cmd.readKilnfileLock(oursPath)
+	if err != nil {
+		return err
+	}
+	theirs, _, err := cmd.readKilnfileLock(theirsPath)
+	if err != nil {
+		return err
+	}
+
+	merge, err := cargo.MergeKilnfileLocks(base, ours, theirs, cargo.LockMergeStrategy(cmd.Options.Strategy))
+	if err != nil {
+		return err
+	}
+	for _, resolution := range merge.Resolutions {
+		cmd.outLogger.Println(resolution)
+	}
+
+	merged, err := editKilnfileLockBytes(oursBuf, merge.Lock)
+	if err != nil {
+		return err
+	}
+	if len(merge.Conflicts) > 0 {
+		theirMerged, err := editKilnfileLockBytes(oursBuf, merge.TheirLock)
+		if err != nil {
+			return err
+		}
+		merged = conflictMarkers(merged, theirMerged, cmd.Options.MarkerSize)
+	}
+
+	if err := writeFile(cmd.fs, oursPath, merged); err != nil {
+		return err
+	}
+
+	if len(merge.Conflicts) > 0 {
+		reasons := make([]string, 0, len(merge.Conflicts))
+		for _, conflict := range merge.Conflicts {
+			reasons = append(reasons, conflict.Reason)
+		}
+		return fmt.Errorf("failed to merge Kilnfile.lock: %s", strings.Join(reasons, "; "))
+	}
+	return nil
+}
+
+func (cmd *MergeLock) readKilnfileLock(name string) (cargo.KilnfileLock, []byte, error) {
+	f, err := cmd.fs.Open(name)
+	if err != nil {
+		return cargo.KilnfileLock{}, nil, err
+	}
+	defer closeAndIgnoreError(f)
+	buf, err := io.ReadAll(f)
+	if err != nil {
+		return cargo.KilnfileLock{}, nil, err
+	}
+	doc, err := cargo.ParseKilnfileLockDocument(buf)
+	if err != nil {
+		return cargo.KilnfileLock{}, nil, fmt.Errorf("%s: %w", name, err)
+	}
+	lock, err := doc.KilnfileLock()
+	if err != nil {
+		return cargo.KilnfileLock{}, nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return lock, buf, nil
+}
+
+func editKilnfileLockBytes(buf []byte, lock cargo.KilnfileLock) ([]byte, error) {
+	doc, err := cargo.ParseKilnfileLockDocument(buf)
+	if err != nil {
+		return nil, err
+	}
+	if err := doc.SetKilnfileLock(lock); err != nil {
+		return nil, err
+	}
+	return doc.Bytes()
+}
+
+func writeFile(fs billy.Basic, name string, buf []byte) error {
+	f, err := fs.Create(name)
+	if err != nil {
+		return err
+	}
+	defer closeAndIgnoreError(f)
+	_, err = f.Write(buf)
+	return err
+}
+
+// conflictMarkers combines two versions of a file, surrounding the lines that
+// differ with git style conflict markers.
+func conflictMarkers(ours, theirs []byte, size int) []byte {
+	a, b := splitLines(ours), splitLines(theirs)
+	var out strings.Builder
+	for _, op := range difflib.NewMatcher(a, b).GetOpCodes() {
+		if op.Tag == 'e' {
+			out.WriteString(strings.Join(a[op.I1:op.I2], ""))
+			continue
+		}
+		out.WriteString(strings.Repeat("<", size) + " ours\n")
+		out.WriteString(strings.Join(a[op.I1:op.I2], ""))
+		out.WriteString(strings.Repeat("=", size) + "\n")
+		out.WriteString(strings.Join(b[op.J1:op.J2], ""))
+		out.WriteString(strings.Repeat(">", size) + " theirs\n")
+	}
+	return []byte(out.String())
+}
+
+func splitLines(buf []byte) []string {
+	lines := strings.SplitAfter(string(buf), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func (cmd *MergeLock) Usage() jhanda.Usage {
+	return jhanda.Usage{
+		Description:      "Three-way merges Kilnfile.lock files by release name so it can be used as a git merge driver: kiln merge-lock %O %A %B --marker-size %L. Releases changed on one side are merged automatically; when both sides changed a release the strategy decides. It writes the result to <ours> and exits with an error, leaving conflict markers, when both sides changed the stemcell differently or a release could not be merged.",
+		ShortDescription: "merges Kilnfile.lock files (git merge driver)",
+		Flags:            cmd.Options,
+	}
+}
@@ -0,0 +1,150 @@
+package commands_test
+
+import (
+	"bytes"
+	"io"
+	"log"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/kiln/internal/commands"
+)
+
+var _ = Describe("merge-lock", func() {
+	const base = `releases:
+  - name: bpm
+    sha1: bpm-1.2.0
+    version: 1.2.0
+    remote_source: bosh.io
+    remote_path: bpm-1.2.0
+  # UAA is pinned for CVE-1234
+  - name: uaa
+    sha1: uaa-75.0.0
+    version: 75.0.0
+    remote_source: bosh.io
+    remote_path: uaa-75.0.0
+stemcell_criteria:
+  os: ubuntu-jammy
+  version: "1.100"
+`
+
+	var (
+		fs       billy.Filesystem
+		output   bytes.Buffer
+		command  *commands.MergeLock
+		readFile func(name string) string
+	)
+
+	BeforeEach(func() {
+		fs = memfs.New()
+		output.Reset()
+		command = commands.NewMergeLock(fs, log.New(&output, "", 0))
+
+		readFile = func(name string) string {
+			f, err := fs.Open(name)
+			Expect(err).NotTo(HaveOccurred())
+			defer func() { _ = f.Close() }()
+			buf, err := io.ReadAll(f)
+			Expect(err).NotTo(HaveOccurred())
+			return string(buf)
+		}
+		writeFile := func(name, content string) {
+			f, err := fs.Create(name)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = f.Write([]byte(content))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(f.Close()).To(Succeed())
+		}
+		writeFile("base", base)
+		writeFile("ours", `releases:
+  - name: bpm
+    sha1: bpm-1.3.0
+    version: 1.3.0
+    remote_source: bosh.io
+    remote_path: bpm-1.3.0
+  # UAA is pinned for CVE-1234
+  - name: uaa
+    sha1: uaa-75.0.0
+    version: 75.0.0
+    remote_source: bosh.io
+    remote_path: uaa-75.0.0
+stemcell_criteria:
+  os: ubuntu-jammy
+  version: "1.100"
+`)
+		writeFile("theirs", `releases:
+  - name: bpm
+    sha1: bpm-1.2.0
+    version: 1.2.0
+    remote_source: bosh.io
+    remote_path: bpm-1.2.0
+  - name: uaa
+    sha1: uaa-75.1.0
+    version: 75.1.0
+    remote_source: bosh.io
+    remote_path: uaa-75.1.0
+stemcell_criteria:
+  os: ubuntu-jammy
+  version: "1.105"
+`)
+	})
+
+	It("merges independent changes into ours", func() {
+		Expect(command.Execute([]string{"base", "ours", "theirs"})).To(Succeed())
+		Expect(readFile("ours")).To(Equal(`releases:
+  - name: bpm
+    sha1: bpm-1.3.0
+    version: 1.3.0
+    remote_source: bosh.io
+    remote_path: bpm-1.3.0
+  # UAA is pinned for CVE-1234
+  - name: uaa
+    sha1: uaa-75.1.0
+    version: 75.1.0
+    remote_source: bosh.io
+    remote_path: uaa-75.1.0
+stemcell_criteria:
+  os: ubuntu-jammy
+  version: "1.105"
+`))
+	})
+
+	When("both sides bump the same release", func() {
+		It("picks the higher version", func() {
+			Expect(command.Execute([]string{"base", "theirs", "ours"})).To(Succeed())
+			Expect(readFile("theirs")).To(ContainSubstring("version: 1.3.0\n"))
+			Expect(readFile("theirs")).To(ContainSubstring("version: 75.1.0\n"))
+		})
+	})
+
+	When("both sides change the stemcell differently", func() {
+		BeforeEach(func() {
+			f, err := fs.Create("ours")
+			Expect(err).NotTo(HaveOccurred())
+			_, _ = f.Write([]byte(base[:len(base)-len("\"1.100\"\n")] + "\"1.106\"\n"))
+			Expect(f.Close()).To(Succeed())
+		})
+
+		It("leaves conflict markers around the stemcell", func() {
+			err := command.Execute([]string{"--marker-size", "3", "base", "ours", "theirs"})
+			Expect(err).To(MatchError(ContainSubstring("both sides changed the stemcell (ours ubuntu-jammy 1.106, theirs ubuntu-jammy 1.105)")))
+
+			Expect(readFile("ours")).To(HaveSuffix(`stemcell_criteria:
+  os: ubuntu-jammy
+<<< ours
+  version: "1.106"
+===
+  version: "1.105"
+>>> theirs
+`))
+			Expect(readFile("ours")).To(ContainSubstring("version: 75.1.0\n"))
+		})
+	})
+
+	It("requires three files", func() {
+		Expect(command.Execute([]string{"base", "ours"})).To(MatchError(ContainSubstring("expected three arguments")))
+	})
+})
@@ -0,0 +1,164 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/pivotal-cf/jhanda"
+
+	"github.com/pivotal-cf/kiln/internal/commands/flags"
+	"github.com/pivotal-cf/kiln/internal/component"
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+type Mirror struct {
+	Options struct {
+		flags.Standard
+		flags.Timeouts
+
+		To         string `long:"to"          required:"true" description:"the ID of the release source to copy the releases into"`
+		UpdateLock bool   `long:"update-lock"                 description:"point remote_source and remote_path in the Kilnfile.lock at the copies"`
+	}
+	fs                         billy.Filesystem
+	logger                     *log.Logger
+	multiReleaseSourceProvider MultiReleaseSourceProvider
+	releaseUploaderFinder      ReleaseUploaderFinder
+}
+
+func NewMirror(fs billy.Filesystem, multiReleaseSourceProvider MultiReleaseSourceProvider, releaseUploaderFinder ReleaseUploaderFinder, logger *log.Logger) Mirror {
+	return Mirror{
+		fs:                         fs,
+		logger:                     logger,
+		multiReleaseSourceProvider: multiReleaseSourceProvider,
+		releaseUploaderFinder:      releaseUploaderFinder,
+	}
+}
+
+func (command Mirror) Execute(args []string) error {
+	_, err := flags.LoadWithDefaultFilePaths(&command.Options, args, command.fs.Stat)
+	if err != nil {
+		return err
+	}
+
+	kilnfile, kilnfileLock, err := command.Options.LoadKilnfiles(command.fs, nil)
+	if err != nil {
+		return fmt.Errorf("error loading Kilnfiles: %w", err)
+	}
+
+	uploader, err := command.releaseUploaderFinder(kilnfile, command.Options.To)
+	if err != nil {
+		return fmt.Errorf("couldn't load the release source: %w", err)
+	}
+	releaseSource := command.multiReleaseSourceProvider(kilnfile, false)
+
+	if command.Options.UpdateLock && !uploader.Configuration().Publishable {
+		command.logger.Printf("Warning: release source %q is not publishable; releases moved there will be excluded when fetching with --allow-only-publishable-releases", command.Options.To)
+	}
+
+	downloadDir, err := os.MkdirTemp("", "kiln-mirror-")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(downloadDir) }()
+
+	ctx, cancel := command.Options.Context()
+	defer cancel()
+
+	upload := releaseUpload{
+		uploader: uploader,
+		sourceID: command.Options.To,
+		timeouts: command.Options.Timeouts,
+		fs:       command.fs,
+		logger:   command.logger,
+	}
+
+	var errs []error
+	for i, lock := range kilnfileLock.Releases {
+		if lock.RemoteSource == command.Options.To {
+			command.logger.Printf("[%d/%d] %s %s is already in %s", i+1, len(kilnfileLock.Releases), lock.Name, lock.Version, command.Options.To)
+			continue
+		}
+
+		remotePath, err := command.mirrorRelease(ctx, releaseSource, upload, downloadDir, lock)
+		if err != nil {
+			command.logger.Printf("[%d/%d] failed to mirror %s %s", i+1, len(kilnfileLock.Releases), lock.Name, lock.Version)
+			errs = append(errs, fmt.Errorf("failed to mirror %s %s: %w", lock.Name, lock.Version, err))
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		command.logger.Printf("[%d/%d] mirrored %s %s to %s", i+1, len(kilnfileLock.Releases), lock.Name, lock.Version, remotePath)
+
+		if command.Options.UpdateLock {
+			kilnfileLock.Releases[i].RemoteSource = command.Options.To
+			kilnfileLock.Releases[i].RemotePath = remotePath
+		}
+	}
+
+	if command.Options.UpdateLock {
+		err := command.Options.EditKilnfileLock(command.fs, func(doc *cargo.KilnfileLockDocument) error {
+			for _, lock := range kilnfileLock.Releases {
+				if lock.RemoteSource != command.Options.To {
+					continue
+				}
+				if err := doc.SetRelease(lock); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (command Mirror) mirrorRelease(ctx context.Context, releaseSource component.MultiReleaseSource, upload releaseUpload, downloadDir string, lock cargo.BOSHReleaseTarballLock) (string, error) {
+	if lock.SHA1 == "" || lock.SHA1 == "not-calculated" {
+		return "", errors.New("the Kilnfile.lock does not have a SHA1 to verify the release with")
+	}
+
+	requestCtx, cancelRequest := command.Options.RequestContext(ctx)
+	local, err := releaseSource.DownloadRelease(requestCtx, downloadDir, lock)
+	cancelRequest()
+	if err != nil {
+		return "", fmt.Errorf("download failed: %w", err)
+	}
+	defer func() { _ = command.fs.Remove(local.LocalPath) }()
+
+	tarball, err := readReleaseTarball(command.fs, local.LocalPath)
+	if err != nil {
+		return "", err
+	}
+	if tarball.SHA1 != lock.SHA1 {
+		return "", fmt.Errorf("downloaded release had an incorrect SHA1 - expected %q, got %q", lock.SHA1, tarball.SHA1)
+	}
+	if lock.SHA256 != "" && tarball.SHA256 != lock.SHA256 {
+		return "", fmt.Errorf("downloaded release had an incorrect SHA256 - expected %q, got %q", lock.SHA256, tarball.SHA256)
+	}
+
+	spec := cargo.BOSHReleaseTarballSpecification{
+		Name:    lock.Name,
+		Version: lock.Version,
+	}
+	if stemcellOS, stemcellVersion, ok := tarball.Manifest.Stemcell(); ok {
+		spec.StemcellOS, spec.StemcellVersion = stemcellOS, stemcellVersion
+	}
+
+	return upload.upload(ctx, spec, local.LocalPath, lock.SHA1)
+}
+
+func (command Mirror) Usage() jhanda.Usage {
+	return jhanda.Usage{
+		Description:      "Copies every release in the Kilnfile.lock into another release source and optionally points the Kilnfile.lock at the copies",
+		ShortDescription: "copies the locked releases into another release source",
+		Flags:            command.Options,
+	}
+}
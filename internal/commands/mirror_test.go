@@ -0,0 +1,167 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"path/filepath"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/kiln/internal/commands"
+	commandsFakes "github.com/pivotal-cf/kiln/internal/commands/fakes"
+	"github.com/pivotal-cf/kiln/internal/component"
+	componentFakes "github.com/pivotal-cf/kiln/internal/component/fakes"
+	test_helpers "github.com/pivotal-cf/kiln/internal/test-helpers"
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+var _ = Describe("mirror", func() {
+	const (
+		kilnfilePath     = "Kilnfile"
+		kilnfileLockPath = kilnfilePath + ".lock"
+		targetID         = "backup-bucket"
+	)
+
+	var (
+		fs           billy.Filesystem
+		source       *componentFakes.MultiReleaseSource
+		uploader     *componentFakes.ReleaseUploader
+		finder       *commandsFakes.ReleaseUploaderFinder
+		mirror       commands.Mirror
+		kilnfileLock cargo.KilnfileLock
+		executeArgs  []string
+		executeErr   error
+		updatedLock  cargo.KilnfileLock
+
+		bpmSHA1, uaaSHA1 string
+	)
+
+	BeforeEach(func() {
+		fs = memfs.New()
+		var err error
+		bpmSHA1, err = test_helpers.WriteReleaseTarball("bpm.tgz", "bpm", "1.2.0", memfs.New())
+		Expect(err).NotTo(HaveOccurred())
+		uaaSHA1, err = test_helpers.WriteReleaseTarball("uaa.tgz", "uaa", "7.0.0", memfs.New())
+		Expect(err).NotTo(HaveOccurred())
+
+		kilnfileLock = cargo.KilnfileLock{
+			Releases: []cargo.BOSHReleaseTarballLock{
+				{Name: "bpm", Version: "1.2.0", SHA1: bpmSHA1, RemoteSource: "bosh.io", RemotePath: "https://bosh.io/bpm"},
+				{Name: "uaa", Version: "7.0.0", SHA1: uaaSHA1, RemoteSource: "dev-bucket", RemotePath: "uaa/uaa-7.0.0.tgz"},
+			},
+		}
+
+		source = new(componentFakes.MultiReleaseSource)
+		source.DownloadReleaseStub = func(_ context.Context, dir string, lock cargo.BOSHReleaseTarballLock) (component.Local, error) {
+			p := filepath.Join(dir, lock.Name+".tgz")
+			sum, err := test_helpers.WriteReleaseTarball(p, lock.Name, lock.Version, fs)
+			return component.Local{Lock: lock.WithSHA1(sum), LocalPath: p}, err
+		}
+
+		uploader = new(componentFakes.ReleaseUploader)
+		uploader.ConfigurationReturns(cargo.ReleaseSourceConfig{ID: targetID, Publishable: true})
+		uploader.RemotePathCalls(func(spec cargo.BOSHReleaseTarballSpecification) (string, error) {
+			return "mirror/" + spec.Name + "-" + spec.Version + ".tgz", nil
+		})
+		uploader.GetMatchedReleaseReturns(cargo.BOSHReleaseTarballLock{}, component.ErrNotFound)
+		uploader.UploadReleaseStub = func(_ context.Context, spec cargo.BOSHReleaseTarballSpecification, r io.Reader) (cargo.BOSHReleaseTarballLock, error) {
+			_, _ = io.Copy(io.Discard, r)
+			sum := bpmSHA1
+			if spec.Name == "uaa" {
+				sum = uaaSHA1
+			}
+			return cargo.BOSHReleaseTarballLock{Name: spec.Name, Version: spec.Version, SHA1: sum}, nil
+		}
+
+		finder = new(commandsFakes.ReleaseUploaderFinder)
+		finder.Returns(uploader, nil)
+
+		mirror = commands.NewMirror(fs, func(cargo.Kilnfile, bool) component.MultiReleaseSource { return source }, finder.Spy, log.New(GinkgoWriter, "", 0))
+		executeArgs = []string{"--kilnfile", kilnfilePath, "--to", targetID}
+	})
+
+	JustBeforeEach(func() {
+		Expect(fsWriteYAML(fs, kilnfilePath, cargo.Kilnfile{})).To(Succeed())
+		Expect(fsWriteYAML(fs, kilnfileLockPath, kilnfileLock)).To(Succeed())
+
+		executeErr = mirror.Execute(executeArgs)
+
+		updatedLock = cargo.KilnfileLock{}
+		Expect(fsReadYAML(fs, kilnfileLockPath, &updatedLock)).To(Succeed())
+	})
+
+	It("copies every release into the target without changing the Kilnfile.lock", func() {
+		Expect(executeErr).NotTo(HaveOccurred())
+		Expect(source.DownloadReleaseCallCount()).To(Equal(2))
+		Expect(uploader.UploadReleaseCallCount()).To(Equal(2))
+		_, spec, _ := uploader.UploadReleaseArgsForCall(1)
+		Expect(spec.Name).To(Equal("uaa"))
+		Expect(updatedLock).To(Equal(kilnfileLock))
+	})
+
+	When("--update-lock is set", func() {
+		BeforeEach(func() {
+			executeArgs = append(executeArgs, "--update-lock")
+		})
+
+		It("points the Kilnfile.lock at the copies", func() {
+			Expect(executeErr).NotTo(HaveOccurred())
+			Expect(updatedLock.Releases).To(Equal([]cargo.BOSHReleaseTarballLock{
+				{Name: "bpm", Version: "1.2.0", SHA1: bpmSHA1, RemoteSource: targetID, RemotePath: "mirror/bpm-1.2.0.tgz"},
+				{Name: "uaa", Version: "7.0.0", SHA1: uaaSHA1, RemoteSource: targetID, RemotePath: "mirror/uaa-7.0.0.tgz"},
+			}))
+		})
+
+		When("one release can not be mirrored", func() {
+			BeforeEach(func() {
+				kilnfileLock.Releases[0].SHA1 = "wrong"
+			})
+
+			It("mirrors the others and reports the failure", func() {
+				Expect(executeErr).To(MatchError(ContainSubstring("failed to mirror bpm 1.2.0")))
+				Expect(executeErr).To(MatchError(ContainSubstring("incorrect SHA1")))
+				Expect(updatedLock.Releases[0].RemoteSource).To(Equal("bosh.io"))
+				Expect(updatedLock.Releases[1].RemoteSource).To(Equal(targetID))
+			})
+		})
+	})
+
+	When("a release is already in the target", func() {
+		BeforeEach(func() {
+			kilnfileLock.Releases[1].RemoteSource = targetID
+		})
+
+		It("skips it", func() {
+			Expect(executeErr).NotTo(HaveOccurred())
+			Expect(source.DownloadReleaseCallCount()).To(Equal(1))
+		})
+	})
+
+	When("the target has a different file at the same path", func() {
+		BeforeEach(func() {
+			uploader.GetMatchedReleaseCalls(func(_ context.Context, spec cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error) {
+				return cargo.BOSHReleaseTarballLock{Name: spec.Name, SHA1: "different", RemotePath: "mirror/" + spec.Name + "-" + spec.Version + ".tgz"}, nil
+			})
+		})
+
+		It("does not overwrite it", func() {
+			Expect(executeErr).To(MatchError(ContainSubstring("refusing to overwrite")))
+			Expect(uploader.UploadReleaseCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the target can not be uploaded to", func() {
+		BeforeEach(func() {
+			finder.Returns(nil, errors.New("no upload-capable release sources"))
+		})
+
+		It("returns an error", func() {
+			Expect(executeErr).To(MatchError(ContainSubstring("no upload-capable release sources")))
+		})
+	})
+})
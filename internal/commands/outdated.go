@@ -0,0 +1,326 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-billy/v5"
+	"github.com/pivotal-cf/jhanda"
+
+	"github.com/pivotal-cf/kiln/internal/commands/flags"
+	"github.com/pivotal-cf/kiln/internal/component"
+	"github.com/pivotal-cf/kiln/internal/pivnet"
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+//counterfeiter:generate -o ./fakes/stemcell_releases_lister.go --fake-name StemcellReleasesLister . stemcellReleasesLister
+type stemcellReleasesLister interface {
+	Releases(productSlug string) ([]pivnet.Release, error)
+}
+
+const (
+	outdatedFormatTable    = "table"
+	outdatedFormatJSON     = "json"
+	outdatedFormatMarkdown = "markdown"
+
+	outdatedFailOnWanted = "wanted"
+	outdatedFailOnLatest = "latest"
+)
+
+type Outdated struct {
+	outLogger                  *log.Logger
+	fs                         billy.Filesystem
+	multiReleaseSourceProvider MultiReleaseSourceProvider
+	stemcellReleases           stemcellReleasesLister
+
+	Options struct {
+		flags.Standard
+		flags.Timeouts
+
+		Format       string `long:"format"        description:"output format: table (default), json, or markdown"`
+		FailOn       string `long:"fail-on"       description:"exit with an error when a release or stemcell has a newer version: wanted (allowed by the Kilnfile) or latest (any version)"`
+		SkipStemcell bool   `long:"skip-stemcell" description:"do not look up stemcell versions on TanzuNet"`
+
+		AllowOnlyPublishableReleases bool `long:"allow-only-publishable-releases" description:"only consider releases in publishable release sources"`
+	}
+}
+
+// OutdatedVersion is a version of a release or stemcell and where it was found.
+type OutdatedVersion struct {
+	Version string `json:"version"`
+	Source  string `json:"source,omitempty"`
+}
+
+// OutdatedItem compares the locked version of a release or the stemcell with
+// the newest version the Kilnfile allows (Wanted) and the newest version
+// available (Latest).
+type OutdatedItem struct {
+	Name    string           `json:"name"`
+	Current OutdatedVersion  `json:"current"`
+	Wanted  *OutdatedVersion `json:"wanted,omitempty"`
+	Latest  *OutdatedVersion `json:"latest,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+// OutdatedReport is the JSON output of kiln outdated.
+type OutdatedReport struct {
+	Releases  []OutdatedItem `json:"releases"`
+	Stemcells []OutdatedItem `json:"stemcells,omitempty"`
+}
+
+func NewOutdated(outLogger *log.Logger, fs billy.Filesystem, multiReleaseSourceProvider MultiReleaseSourceProvider, stemcellReleases stemcellReleasesLister) *Outdated {
+	return &Outdated{
+		outLogger:                  outLogger,
+		fs:                         fs,
+		multiReleaseSourceProvider: multiReleaseSourceProvider,
+		stemcellReleases:           stemcellReleases,
+	}
+}
+
+func (cmd *Outdated) Execute(args []string) error {
+	if _, err := flags.LoadWithDefaultFilePaths(&cmd.Options, args, cmd.fs.Stat); err != nil {
+		return err
+	}
+	switch cmd.Options.Format {
+	case "", outdatedFormatTable, outdatedFormatJSON, outdatedFormatMarkdown:
+	default:
+		return fmt.Errorf("unknown format %q (expected table, json, or markdown)", cmd.Options.Format)
+	}
+	switch cmd.Options.FailOn {
+	case "", outdatedFailOnWanted, outdatedFailOnLatest:
+	default:
+		return fmt.Errorf("unknown --fail-on value %q (expected wanted or latest)", cmd.Options.FailOn)
+	}
+
+	kilnfile, kilnfileLock, err := cmd.Options.LoadKilnfiles(cmd.fs, nil)
+	if err != nil {
+		return fmt.Errorf("error loading Kilnfiles: %w", err)
+	}
+	releaseSource := cmd.multiReleaseSourceProvider(kilnfile, cmd.Options.AllowOnlyPublishableReleases)
+
+	ctx, cancel := cmd.Options.Context()
+	defer cancel()
+
+	var report OutdatedReport
+	for _, lock := range kilnfileLock.Releases {
+		report.Releases = append(report.Releases, cmd.release(ctx, releaseSource, kilnfile, kilnfileLock, lock))
+	}
+	if !cmd.Options.SkipStemcell {
+		for _, stemcell := range kilnfileLock.AllStemcells() {
+			if stemcell.OS == "" {
+				continue
+			}
+			report.Stemcells = append(report.Stemcells, cmd.stemcell(kilnfile, stemcell))
+		}
+	}
+
+	if err := cmd.print(report); err != nil {
+		return err
+	}
+
+	var failed, outdated []string
+	for _, item := range report.items() {
+		if item.Error != "" {
+			failed = append(failed, item.Name)
+		}
+		if cmd.Options.FailOn != "" && item.isOutdated(cmd.Options.FailOn) {
+			outdated = append(outdated, item.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to find versions for %s", strings.Join(failed, ", "))
+	}
+	if len(outdated) > 0 {
+		return fmt.Errorf("%d out of date: %s", len(outdated), strings.Join(outdated, ", "))
+	}
+	return nil
+}
+
+func (cmd *Outdated) release(ctx context.Context, releaseSource component.MultiReleaseSource, kilnfile cargo.Kilnfile, kilnfileLock cargo.KilnfileLock, lock cargo.BOSHReleaseTarballLock) OutdatedItem {
+	item := OutdatedItem{
+		Name:    lock.Name,
+		Current: OutdatedVersion{Version: lock.Version, Source: lock.RemoteSource},
+	}
+
+	spec, err := kilnfile.BOSHReleaseTarballSpecification(lock.Name)
+	if err != nil {
+		spec = cargo.BOSHReleaseTarballSpecification{Name: lock.Name}
+	}
+	stemcell, err := kilnfileLock.StemcellForRelease(spec)
+	if err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	spec.StemcellOS = stemcell.OS
+	spec.StemcellVersion = stemcell.Version
+
+	wanted, err := cmd.findReleaseVersion(ctx, releaseSource, spec)
+	if err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	item.Wanted = wanted
+
+	// the latest version ignores the version constraint and release source pins
+	spec.Version = ""
+	spec.ReleaseSources = nil
+	latest, err := cmd.findReleaseVersion(ctx, releaseSource, spec)
+	if err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	item.Latest = latest
+
+	return item
+}
+
+func (cmd *Outdated) findReleaseVersion(ctx context.Context, releaseSource component.MultiReleaseSource, spec cargo.BOSHReleaseTarballSpecification) (*OutdatedVersion, error) {
+	ctx, cancel := cmd.Options.RequestContext(ctx)
+	defer cancel()
+	lock, err := releaseSource.FindReleaseVersion(ctx, spec, true)
+	if err != nil {
+		if component.IsErrNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &OutdatedVersion{Version: lock.Version, Source: lock.RemoteSource}, nil
+}
+
+func (cmd *Outdated) stemcell(kilnfile cargo.Kilnfile, locked cargo.Stemcell) OutdatedItem {
+	item := OutdatedItem{
+		Name:    "stemcell " + locked.OS,
+		Current: OutdatedVersion{Version: locked.Version},
+	}
+
+	criteria, err := kilnfile.StemcellForOS(locked.OS)
+	if err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	productSlug, err := criteria.ProductSlug()
+	if err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	releases, err := cmd.stemcellReleases.Releases(productSlug)
+	if err != nil {
+		item.Error = err.Error()
+		return item
+	}
+
+	for _, v := range []struct {
+		constraint string
+		result     **OutdatedVersion
+	}{
+		{constraint: criteria.Version, result: &item.Wanted},
+		{constraint: "*", result: &item.Latest},
+	} {
+		if v.constraint == "" {
+			v.constraint = "*"
+		}
+		c, err := semver.NewConstraint(v.constraint)
+		if err != nil {
+			item.Error = err.Error()
+			return item
+		}
+		version, err := findReleaseWithMatchingConstraint(releases, c)
+		if err != nil {
+			continue
+		}
+		*v.result = &OutdatedVersion{Version: version, Source: TanzuNetRemotePath}
+	}
+	return item
+}
+
+func (report OutdatedReport) items() []OutdatedItem {
+	return append(report.Releases[:len(report.Releases):len(report.Releases)], report.Stemcells...)
+}
+
+// isOutdated returns true when the wanted or latest (depending on failOn)
+// version is newer than the current one.
+func (item OutdatedItem) isOutdated(failOn string) bool {
+	newer := item.Wanted
+	if failOn == outdatedFailOnLatest {
+		newer = item.Latest
+	}
+	if newer == nil {
+		return false
+	}
+	current, err := semver.NewVersion(item.Current.Version)
+	if err != nil {
+		return newer.Version != item.Current.Version
+	}
+	v, err := semver.NewVersion(newer.Version)
+	if err != nil {
+		return false
+	}
+	return v.GreaterThan(current)
+}
+
+func (cmd *Outdated) print(report OutdatedReport) error {
+	switch cmd.Options.Format {
+	case outdatedFormatJSON:
+		if report.Releases == nil {
+			report.Releases = []OutdatedItem{}
+		}
+		buf, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		cmd.outLogger.Println(string(buf))
+	case outdatedFormatMarkdown:
+		var out strings.Builder
+		out.WriteString("| Name | Current | Wanted | Latest |\n")
+		out.WriteString("|------|---------|--------|--------|\n")
+		for _, item := range report.items() {
+			wanted, latest := formatOutdatedVersion(item.Wanted), formatOutdatedVersion(item.Latest)
+			if item.Error != "" {
+				wanted, latest = "error: "+item.Error, ""
+			}
+			_, _ = fmt.Fprintf(&out, "| %s | %s | %s | %s |\n", item.Name, formatOutdatedVersion(&item.Current), markdownEscape(wanted), markdownEscape(latest))
+		}
+		cmd.outLogger.Print(out.String())
+	default:
+		var out strings.Builder
+		w := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "NAME\tCURRENT\tWANTED\tLATEST")
+		for _, item := range report.items() {
+			wanted, latest := formatOutdatedVersion(item.Wanted), formatOutdatedVersion(item.Latest)
+			if item.Error != "" {
+				wanted, latest = "error: "+item.Error, ""
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", item.Name, formatOutdatedVersion(&item.Current), wanted, latest)
+		}
+		_ = w.Flush()
+		cmd.outLogger.Print(out.String())
+	}
+	return nil
+}
+
+func formatOutdatedVersion(v *OutdatedVersion) string {
+	if v == nil {
+		return "-"
+	}
+	if v.Source == "" {
+		return v.Version
+	}
+	return fmt.Sprintf("%s (%s)", v.Version, v.Source)
+}
+
+func markdownEscape(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+func (cmd *Outdated) Usage() jhanda.Usage {
+	return jhanda.Usage{
+		Description:      "Prints, for every release and stemcell in the Kilnfile.lock, the locked version, the newest version allowed by the Kilnfile (wanted), and the newest version available in any release source (latest). Stemcell versions come from TanzuNet.",
+		ShortDescription: "lists releases and stemcells with newer versions available",
+		Flags:            cmd.Options,
+	}
+}
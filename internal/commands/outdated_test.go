@@ -0,0 +1,163 @@
+package commands_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/kiln/internal/commands"
+	commandsFakes "github.com/pivotal-cf/kiln/internal/commands/fakes"
+	"github.com/pivotal-cf/kiln/internal/component"
+	"github.com/pivotal-cf/kiln/internal/component/fakes"
+	"github.com/pivotal-cf/kiln/internal/pivnet"
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+var _ = Describe("outdated", func() {
+	var (
+		fs               billy.Filesystem
+		output           bytes.Buffer
+		releaseSource    *fakes.MultiReleaseSource
+		stemcellReleases *commandsFakes.StemcellReleasesLister
+		outdated         *commands.Outdated
+	)
+
+	BeforeEach(func() {
+		fs = memfs.New()
+		output.Reset()
+
+		Expect(fsWriteYAML(fs, "Kilnfile", cargo.Kilnfile{
+			Stemcell: cargo.Stemcell{OS: "ubuntu-jammy", Version: "~1"},
+			Releases: []cargo.BOSHReleaseTarballSpecification{
+				{Name: "bpm", Version: "~1.2"},
+				{Name: "uaa"},
+			},
+		})).To(Succeed())
+		Expect(fsWriteYAML(fs, "Kilnfile.lock", cargo.KilnfileLock{
+			Stemcell: cargo.Stemcell{OS: "ubuntu-jammy", Version: "1.100"},
+			Releases: []cargo.BOSHReleaseTarballLock{
+				{Name: "bpm", Version: "1.2.0", RemoteSource: "bosh.io"},
+				{Name: "uaa", Version: "75.0.0", RemoteSource: "bosh.io"},
+			},
+		})).To(Succeed())
+
+		releaseSource = new(fakes.MultiReleaseSource)
+		releaseSource.FindReleaseVersionStub = func(_ context.Context, spec cargo.BOSHReleaseTarballSpecification, _ bool) (cargo.BOSHReleaseTarballLock, error) {
+			switch {
+			case spec.Name == "bpm" && spec.Version == "~1.2":
+				return cargo.BOSHReleaseTarballLock{Name: "bpm", Version: "1.2.5", RemoteSource: "bosh.io"}, nil
+			case spec.Name == "bpm":
+				return cargo.BOSHReleaseTarballLock{Name: "bpm", Version: "2.0.0", RemoteSource: "compiled-releases"}, nil
+			default:
+				return cargo.BOSHReleaseTarballLock{}, component.ErrNotFound
+			}
+		}
+
+		stemcellReleases = new(commandsFakes.StemcellReleasesLister)
+		stemcellReleases.ReleasesReturns([]pivnet.Release{
+			{Version: "2.3"}, {Version: "1.105"}, {Version: "1.100"},
+		}, nil)
+
+		outdated = commands.NewOutdated(log.New(&output, "", 0), fs, func(cargo.Kilnfile, bool) component.MultiReleaseSource {
+			return releaseSource
+		}, stemcellReleases)
+	})
+
+	It("prints a table of current, wanted, and latest versions", func() {
+		Expect(outdated.Execute(nil)).To(Succeed())
+
+		Expect(releaseSource.FindReleaseVersionCallCount()).To(Equal(4))
+		_, spec, noDownload := releaseSource.FindReleaseVersionArgsForCall(0)
+		Expect(spec.StemcellOS).To(Equal("ubuntu-jammy"))
+		Expect(spec.StemcellVersion).To(Equal("1.100"))
+		Expect(noDownload).To(BeTrue())
+		Expect(stemcellReleases.ReleasesArgsForCall(0)).To(Equal("stemcells-ubuntu-jammy"))
+
+		Expect(output.String()).To(MatchRegexp(`bpm\s+1\.2\.0 \(bosh\.io\)\s+1\.2\.5 \(bosh\.io\)\s+2\.0\.0 \(compiled-releases\)`))
+		Expect(output.String()).To(MatchRegexp(`uaa\s+75\.0\.0 \(bosh\.io\)\s+-\s+-`))
+		Expect(output.String()).To(MatchRegexp(`stemcell ubuntu-jammy\s+1\.100\s+1\.105 \(network\.pivotal\.io\)\s+2\.3 \(network\.pivotal\.io\)`))
+	})
+
+	It("prints JSON", func() {
+		Expect(outdated.Execute([]string{"--format", "json", "--skip-stemcell"})).To(Succeed())
+
+		var report commands.OutdatedReport
+		Expect(json.Unmarshal(output.Bytes(), &report)).To(Succeed())
+		Expect(report).To(Equal(commands.OutdatedReport{
+			Releases: []commands.OutdatedItem{
+				{
+					Name:    "bpm",
+					Current: commands.OutdatedVersion{Version: "1.2.0", Source: "bosh.io"},
+					Wanted:  &commands.OutdatedVersion{Version: "1.2.5", Source: "bosh.io"},
+					Latest:  &commands.OutdatedVersion{Version: "2.0.0", Source: "compiled-releases"},
+				},
+				{
+					Name:    "uaa",
+					Current: commands.OutdatedVersion{Version: "75.0.0", Source: "bosh.io"},
+				},
+			},
+		}))
+		Expect(stemcellReleases.ReleasesCallCount()).To(Equal(0))
+	})
+
+	It("prints markdown", func() {
+		Expect(outdated.Execute([]string{"--format", "markdown"})).To(Succeed())
+		Expect(output.String()).To(ContainSubstring("| Name | Current | Wanted | Latest |\n"))
+		Expect(output.String()).To(ContainSubstring("| bpm | 1.2.0 (bosh.io) | 1.2.5 (bosh.io) | 2.0.0 (compiled-releases) |\n"))
+	})
+
+	When("the Kilnfile lists more than one stemcell", func() {
+		BeforeEach(func() {
+			Expect(fsWriteYAML(fs, "Kilnfile", cargo.Kilnfile{
+				Stemcell:  cargo.Stemcell{OS: "ubuntu-jammy", Version: "~1"},
+				Stemcells: []cargo.Stemcell{{OS: "windows2019", Version: "~2019"}},
+				Releases: []cargo.BOSHReleaseTarballSpecification{
+					{Name: "bpm", Version: "~1.2"},
+					{Name: "uaa", StemcellOS: "windows2019"},
+				},
+			})).To(Succeed())
+			Expect(fsWriteYAML(fs, "Kilnfile.lock", cargo.KilnfileLock{
+				Stemcell:  cargo.Stemcell{OS: "ubuntu-jammy", Version: "1.100"},
+				Stemcells: []cargo.Stemcell{{OS: "windows2019", Version: "2019.50"}},
+				Releases: []cargo.BOSHReleaseTarballLock{
+					{Name: "bpm", Version: "1.2.0", RemoteSource: "bosh.io"},
+					{Name: "uaa", Version: "75.0.0", RemoteSource: "bosh.io"},
+				},
+			})).To(Succeed())
+		})
+
+		It("looks up releases with their stemcell and prints a row per stemcell", func() {
+			Expect(outdated.Execute(nil)).To(Succeed())
+
+			_, spec, _ := releaseSource.FindReleaseVersionArgsForCall(2)
+			Expect(spec.Name).To(Equal("uaa"))
+			Expect(spec.StemcellOS).To(Equal("windows2019"))
+			Expect(spec.StemcellVersion).To(Equal("2019.50"))
+
+			Expect(stemcellReleases.ReleasesCallCount()).To(Equal(2))
+			Expect(stemcellReleases.ReleasesArgsForCall(1)).To(Equal("stemcells-windows-server"))
+			Expect(output.String()).To(MatchRegexp(`stemcell ubuntu-jammy\s+1\.100\s+1\.105`))
+			Expect(output.String()).To(MatchRegexp(`stemcell windows2019\s+2019\.50\s+-`))
+		})
+	})
+
+	When("--fail-on is set", func() {
+		It("fails when a version allowed by the Kilnfile is newer", func() {
+			Expect(outdated.Execute([]string{"--fail-on", "wanted", "--skip-stemcell"})).To(MatchError("1 out of date: bpm"))
+		})
+
+		It("fails when any newer version is available", func() {
+			Expect(outdated.Execute([]string{"--fail-on", "latest"})).To(MatchError("2 out of date: bpm, stemcell ubuntu-jammy"))
+		})
+
+		It("rejects unknown values", func() {
+			Expect(outdated.Execute([]string{"--fail-on", "always"})).To(MatchError(ContainSubstring("unknown --fail-on value")))
+		})
+	})
+})
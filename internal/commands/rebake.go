@@ -33,14 +33,9 @@ func (cmd ReBake) Execute(args []string) error {
 	if len(records) != 1 {
 		return fmt.Errorf("please add exactly one required bake record argument: %d bake arguments passed", len(records))
 	}
-	recordBuffer, err := os.ReadFile(records[0])
+	record, err := readBakeRecordFile(records[0])
 	if err != nil {
-		return fmt.Errorf("failed to read bake record file: %w", err)
-	}
-
-	var record bake.Record
-	if err := json.Unmarshal(recordBuffer, &record); err != nil {
-		return fmt.Errorf("failed to parse bake record: %w", err)
+		return err
 	}
 
 	workingDirectorySHA, err := builder.GitMetadataSHA(".", false)
@@ -52,19 +47,7 @@ func (cmd ReBake) Execute(args []string) error {
 		return fmt.Errorf("expected the current worktree to be checked out at the source revision from the record %s but the current head is %s", exp, got)
 	}
 
-	tileDir := filepath.FromSlash(record.TileDirectory)
-
-	bakeFlags := []string{
-		"--version", record.Version,
-		"--kilnfile", filepath.Join(tileDir, "Kilnfile"),
-		"--output-file", cmd.Options.OutputFile,
-	}
-
-	if record.TileName != "" {
-		bakeFlags = append(bakeFlags, strings.Join([]string{"--variable", builder.TileNameVariable, record.TileName}, "="))
-	}
-
-	if err := cmd.bake.Execute(bakeFlags); err != nil {
+	if err := cmd.bake.Execute(reBakeArgs(record, filepath.FromSlash(record.TileDirectory), cmd.Options.OutputFile)); err != nil {
 		return err
 	}
 
@@ -80,6 +63,35 @@ func (cmd ReBake) Execute(args []string) error {
 	return nil
 }
 
+func readBakeRecordFile(recordPath string) (bake.Record, error) {
+	recordBuffer, err := os.ReadFile(recordPath)
+	if err != nil {
+		return bake.Record{}, fmt.Errorf("failed to read bake record file: %w", err)
+	}
+
+	var record bake.Record
+	if err := json.Unmarshal(recordBuffer, &record); err != nil {
+		return bake.Record{}, fmt.Errorf("failed to parse bake record: %w", err)
+	}
+	return record, nil
+}
+
+// reBakeArgs returns the bake arguments to build the tile described by record
+// from the tile source in tileDirectory.
+func reBakeArgs(record bake.Record, tileDirectory, outputFile string) []string {
+	bakeFlags := []string{
+		"--version", record.Version,
+		"--kilnfile", filepath.Join(tileDirectory, "Kilnfile"),
+		"--output-file", outputFile,
+	}
+
+	if record.TileName != "" {
+		bakeFlags = append(bakeFlags, strings.Join([]string{"--variable", builder.TileNameVariable, record.TileName}, "="))
+	}
+
+	return bakeFlags
+}
+
 func (cmd ReBake) Usage() jhanda.Usage {
 	return jhanda.Usage{
 		Description:      "re-bake (aka record bake) builds a tile from a bake record. You must check out the repository to the revision of the source_revision in the bake record before running this command.",
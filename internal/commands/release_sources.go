@@ -0,0 +1,140 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/pivotal-cf/jhanda"
+
+	"github.com/pivotal-cf/kiln/internal/commands/flags"
+	"github.com/pivotal-cf/kiln/internal/component"
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+// ReleaseSourceFactory constructs the release source for a Kilnfile release
+// source configuration. See component.ReleaseSourceFactory.
+type ReleaseSourceFactory func(cargo.ReleaseSourceConfig) component.ReleaseSource
+
+// NewReleaseSources returns the "kiln release-sources" command group for
+// inspecting the release sources configured in a Kilnfile.
+func NewReleaseSources(fs billy.Filesystem, newReleaseSource ReleaseSourceFactory, outLogger *log.Logger) CommandGroup {
+	return newCommandGroup("release-sources",
+		"inspects the release sources configured in the Kilnfile",
+		"Commands for inspecting the release sources configured in the Kilnfile.",
+		jhanda.CommandSet{
+			"check": &ReleaseSourcesCheck{fs: fs, newReleaseSource: newReleaseSource, outLogger: outLogger},
+		},
+	)
+}
+
+// ReleaseSourceCheckResult is the outcome of probing one release source.
+type ReleaseSourceCheckResult struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	Publishable bool   `json:"publishable"`
+	OK          bool   `json:"ok"`
+	Error       string `json:"error,omitempty"`
+}
+
+type ReleaseSourcesCheck struct {
+	fs               billy.Filesystem
+	newReleaseSource ReleaseSourceFactory
+	outLogger        *log.Logger
+
+	Options struct {
+		flags.Standard
+		flags.Timeouts
+
+		JSON bool `long:"json" description:"print the results as JSON"`
+	}
+}
+
+func (cmd *ReleaseSourcesCheck) Execute(args []string) error {
+	if _, err := flags.LoadWithDefaultFilePaths(&cmd.Options, args, cmd.fs.Stat); err != nil {
+		return err
+	}
+	kilnfile, _, err := cmd.Options.LoadKilnfiles(cmd.fs, nil)
+	if err != nil {
+		return fmt.Errorf("error loading Kilnfiles: %w", err)
+	}
+
+	ctx, cancel := cmd.Options.Context()
+	defer cancel()
+
+	results := make([]ReleaseSourceCheckResult, 0, len(kilnfile.ReleaseSources))
+	failed := 0
+	for _, config := range kilnfile.ReleaseSources {
+		result := ReleaseSourceCheckResult{
+			ID:          cargo.BOSHReleaseTarballSourceID(config),
+			Type:        config.Type,
+			Publishable: config.Publishable,
+		}
+		if err := cmd.check(ctx, config); err != nil {
+			result.Error = err.Error()
+			failed++
+		} else {
+			result.OK = true
+		}
+		results = append(results, result)
+	}
+
+	if cmd.Options.JSON {
+		buf, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		cmd.outLogger.Println(string(buf))
+	} else {
+		var out strings.Builder
+		w := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "ID\tTYPE\tPUBLISHABLE\tRESULT")
+		for _, result := range results {
+			status := "ok"
+			if !result.OK {
+				status = "failed: " + result.Error
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.ID, result.Type, strconv.FormatBool(result.Publishable), status)
+		}
+		_ = w.Flush()
+		cmd.outLogger.Print(out.String())
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d release sources failed the check", failed, len(results))
+	}
+	return nil
+}
+
+// check constructs and probes one release source. Release source constructors
+// panic on invalid configuration (for example a missing token), so a panic is
+// reported as a failure of that source rather than stopping the command.
+func (cmd *ReleaseSourcesCheck) check(ctx context.Context, config cargo.ReleaseSourceConfig) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid configuration: %v", r)
+		}
+	}()
+	source := cmd.newReleaseSource(config)
+	prober, ok := source.(component.ReleaseSourceProber)
+	if !ok {
+		return errors.New("release source type does not support checking")
+	}
+	ctx, cancel := cmd.Options.RequestContext(ctx)
+	defer cancel()
+	return prober.Probe(ctx)
+}
+
+func (cmd *ReleaseSourcesCheck) Usage() jhanda.Usage {
+	return jhanda.Usage{
+		Description:      "Constructs each release source in the Kilnfile and makes a cheap authenticated request to it (listing the S3 bucket, getting the Artifactory repository, looking up the GitHub organization, or requesting the bosh.io index) to check it is reachable and its credentials are accepted. It exits with an error if any release source fails.",
+		ShortDescription: "checks release source credentials and connectivity",
+		Flags:            cmd.Options,
+	}
+}
@@ -0,0 +1,90 @@
+package commands_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/kiln/internal/commands"
+	"github.com/pivotal-cf/kiln/internal/component"
+	componentFakes "github.com/pivotal-cf/kiln/internal/component/fakes"
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+var _ = Describe("release-sources", func() {
+	var (
+		fs         billy.Filesystem
+		output     bytes.Buffer
+		probers    map[string]*componentFakes.ReleaseSourceProber
+		releaseSrc commands.CommandGroup
+	)
+
+	BeforeEach(func() {
+		fs = memfs.New()
+		output.Reset()
+
+		Expect(fsWriteYAML(fs, "Kilnfile", cargo.Kilnfile{
+			ReleaseSources: []cargo.ReleaseSourceConfig{
+				{Type: component.ReleaseSourceTypeBOSHIO},
+				{Type: component.ReleaseSourceTypeS3, Bucket: "compiled-releases", Publishable: true},
+				{Type: component.ReleaseSourceTypeGithub, Org: "cloudfoundry"},
+			},
+		})).To(Succeed())
+		Expect(fsWriteYAML(fs, "Kilnfile.lock", cargo.KilnfileLock{})).To(Succeed())
+
+		probers = map[string]*componentFakes.ReleaseSourceProber{
+			"bosh.io":           new(componentFakes.ReleaseSourceProber),
+			"compiled-releases": new(componentFakes.ReleaseSourceProber),
+		}
+		probers["compiled-releases"].ProbeReturns(errors.New("AccessDenied"))
+
+		releaseSrc = commands.NewReleaseSources(fs, func(config cargo.ReleaseSourceConfig) component.ReleaseSource {
+			prober, ok := probers[cargo.BOSHReleaseTarballSourceID(config)]
+			if !ok {
+				panic("no token passed for github release source")
+			}
+			return prober
+		}, log.New(&output, "", 0))
+	})
+
+	Describe("check", func() {
+		It("prints the result for each release source", func() {
+			err := releaseSrc.Execute([]string{"check"})
+			Expect(err).To(MatchError("2 of 3 release sources failed the check"))
+
+			Expect(probers["bosh.io"].ProbeCallCount()).To(Equal(1))
+			Expect(output.String()).To(MatchRegexp(`bosh\.io\s+bosh\.io\s+false\s+ok`))
+			Expect(output.String()).To(MatchRegexp(`compiled-releases\s+s3\s+true\s+failed: AccessDenied`))
+			Expect(output.String()).To(MatchRegexp(`cloudfoundry\s+github\s+false\s+failed: invalid configuration: no token passed for github release source`))
+		})
+
+		It("prints JSON", func() {
+			_ = releaseSrc.Execute([]string{"check", "--json"})
+
+			var results []commands.ReleaseSourceCheckResult
+			Expect(json.Unmarshal(output.Bytes(), &results)).To(Succeed())
+			Expect(results).To(Equal([]commands.ReleaseSourceCheckResult{
+				{ID: "bosh.io", Type: "bosh.io", OK: true},
+				{ID: "compiled-releases", Type: "s3", Publishable: true, Error: "AccessDenied"},
+				{ID: "cloudfoundry", Type: "github", Error: "invalid configuration: no token passed for github release source"},
+			}))
+		})
+
+		When("every release source passes", func() {
+			BeforeEach(func() {
+				probers["compiled-releases"].ProbeReturns(nil)
+				probers["cloudfoundry"] = new(componentFakes.ReleaseSourceProber)
+			})
+
+			It("succeeds", func() {
+				Expect(releaseSrc.Execute([]string{"check"})).To(Succeed())
+			})
+		})
+	})
+})
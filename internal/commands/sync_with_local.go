@@ -63,12 +63,20 @@ func (command SyncWithLocal) Execute(args []string) error {
 
 	command.logger.Printf("Found %d releases on disk\n", len(releases))
 
+	var updated []cargo.BOSHReleaseTarballLock
 	for _, rel := range releases {
+		// a release missing from the Kilnfile gets the first stemcell
+		spec, _ := kilnfile.BOSHReleaseTarballSpecification(rel.Lock.Name)
+		stemcell, err := kilnfileLock.StemcellForRelease(spec)
+		if err != nil {
+			return fmt.Errorf("release %q: %w", rel.Lock.Name, err)
+		}
+
 		remotePath, err := remotePather.RemotePath(cargo.BOSHReleaseTarballSpecification{
 			Name:            rel.Lock.Name,
 			Version:         rel.Lock.Version,
-			StemcellOS:      kilnfileLock.Stemcell.OS,
-			StemcellVersion: kilnfileLock.Stemcell.Version,
+			StemcellOS:      stemcell.OS,
+			StemcellVersion: stemcell.Version,
 		})
 		if err != nil {
 			return fmt.Errorf("couldn't generate a remote path for release %q: %w", rel.Lock.Name, err)
@@ -92,13 +100,22 @@ func (command SyncWithLocal) Execute(args []string) error {
 
 		matchingRelease.Version = rel.Lock.Version
 		matchingRelease.SHA1 = rel.Lock.SHA1
+		matchingRelease.SHA256 = rel.Lock.SHA256
 		matchingRelease.RemoteSource = command.Options.ReleaseSourceID
 		matchingRelease.RemotePath = remotePath
+		updated = append(updated, *matchingRelease)
 
 		command.logger.Printf("Updated %s to %s\n", rel.Lock.Name, rel.Lock.Version)
 	}
 
-	err = command.Options.SaveKilnfileLock(command.fs, kilnfileLock)
+	err = command.Options.EditKilnfileLock(command.fs, func(doc *cargo.KilnfileLockDocument) error {
+		for _, lock := range updated {
+			if err := doc.SetRelease(lock); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
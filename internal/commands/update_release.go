@@ -15,6 +15,7 @@ import (
 type UpdateRelease struct {
 	Options struct {
 		flags.Standard
+		flags.Timeouts
 
 		Name                         string `short:"n"  long:"name"                            required:"true"    description:"name of release to update"`
 		Version                      string `short:"v"  long:"version"                         required:"true"    description:"desired version of release"`
@@ -63,64 +64,87 @@ func (u UpdateRelease) Execute(args []string) error {
 		releaseVersionConstraint = u.Options.Version
 	}
 
+	stemcell, err := kilnfileLock.StemcellForRelease(releaseSpec)
+	if err != nil {
+		return err
+	}
+
 	releaseSource := u.multiReleaseSourceProvider(kilnfile, u.Options.AllowOnlyPublishableReleases)
 
+	ctx, cancel := u.Options.Context()
+	defer cancel()
+
 	u.logger.Println("Searching for the release...")
 
 	var localRelease component.Local
 	var remoteRelease cargo.BOSHReleaseTarballLock
-	var newVersion, newSHA1, newSourceID, newRemotePath string
+	var newVersion, newSHA1, newSHA256, newSourceID, newRemotePath string
 	if u.Options.WithoutDownload {
-		remoteRelease, err = releaseSource.FindReleaseVersion(cargo.BOSHReleaseTarballSpecification{
+		requestCtx, cancelRequest := u.Options.RequestContext(ctx)
+		remoteRelease, err = releaseSource.FindReleaseVersion(requestCtx, cargo.BOSHReleaseTarballSpecification{
 			Name:             u.Options.Name,
 			Version:          releaseVersionConstraint,
-			StemcellVersion:  kilnfileLock.Stemcell.Version,
-			StemcellOS:       kilnfileLock.Stemcell.OS,
+			StemcellVersion:  stemcell.Version,
+			StemcellOS:       stemcell.OS,
 			GitHubRepository: releaseSpec.GitHubRepository,
+			SourcePreference: releaseSpec.SourcePreference,
+			ReleaseSources:   releaseSpec.ReleaseSources,
 		}, false)
+		cancelRequest()
 		if err != nil {
 			return fmt.Errorf("couldn't find %q %s in any release source: %w", u.Options.Name, u.Options.Version, err)
 		}
 
 		newVersion = remoteRelease.Version
 		newSHA1 = remoteRelease.SHA1
+		newSHA256 = remoteRelease.SHA256
 		newSourceID = remoteRelease.RemoteSource
 		newRemotePath = remoteRelease.RemotePath
 	} else {
-		remoteRelease, err = releaseSource.GetMatchedRelease(cargo.BOSHReleaseTarballSpecification{
+		requestCtx, cancelRequest := u.Options.RequestContext(ctx)
+		remoteRelease, err = releaseSource.GetMatchedRelease(requestCtx, cargo.BOSHReleaseTarballSpecification{
 			Name:             u.Options.Name,
 			Version:          u.Options.Version,
-			StemcellOS:       kilnfileLock.Stemcell.OS,
-			StemcellVersion:  kilnfileLock.Stemcell.Version,
+			StemcellOS:       stemcell.OS,
+			StemcellVersion:  stemcell.Version,
 			GitHubRepository: releaseSpec.GitHubRepository,
+			SourcePreference: releaseSpec.SourcePreference,
+			ReleaseSources:   releaseSpec.ReleaseSources,
 		})
+		cancelRequest()
 		if err != nil {
 			return fmt.Errorf("couldn't find %q %s in any release source: %w", u.Options.Name, u.Options.Version, err)
 		}
 
-		localRelease, err = releaseSource.DownloadRelease(u.Options.ReleasesDir, remoteRelease)
+		requestCtx, cancelRequest = u.Options.RequestContext(ctx)
+		localRelease, err = releaseSource.DownloadRelease(requestCtx, u.Options.ReleasesDir, remoteRelease)
+		cancelRequest()
 		if err != nil {
 			return fmt.Errorf("error downloading the release: %w", err)
 		}
 		newVersion = localRelease.Lock.Version
 		newSHA1 = localRelease.Lock.SHA1
+		newSHA256 = localRelease.Lock.SHA256
 		newSourceID = remoteRelease.RemoteSource
 		newRemotePath = remoteRelease.RemotePath
 	}
 
-	if releaseLock.Version == newVersion && releaseLock.SHA1 == newSHA1 && releaseLock.RemoteSource == newSourceID && releaseLock.RemotePath == newRemotePath {
+	if releaseLock.Version == newVersion && releaseLock.SHA1 == newSHA1 && releaseLock.SHA256 == newSHA256 && releaseLock.RemoteSource == newSourceID && releaseLock.RemotePath == newRemotePath {
 		u.logger.Println("Neither the version nor remote location of the release changed. No changes made.")
 		return nil
 	}
 
 	releaseLock.Version = newVersion
 	releaseLock.SHA1 = newSHA1
+	releaseLock.SHA256 = newSHA256
 	releaseLock.RemoteSource = newSourceID
 	releaseLock.RemotePath = newRemotePath
 
-	_ = (&kilnfileLock).UpdateBOSHReleaseTarballLockWithName(u.Options.Name, releaseLock)
+	releaseLock.Name = u.Options.Name
 
-	err = u.Options.SaveKilnfileLock(u.filesystem, kilnfileLock)
+	err = u.Options.EditKilnfileLock(u.filesystem, func(doc *cargo.KilnfileLockDocument) error {
+		return doc.SetRelease(releaseLock)
+	})
 	if err != nil {
 		return err
 	}
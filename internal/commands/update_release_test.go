@@ -34,6 +34,7 @@ var _ = Describe("UpdateRelease", func() {
 		notDownloadedReleaseSourceName = "compiled-releases"
 		oldReleaseSha1                 = "old-sha1"
 		newReleaseSha1                 = "new-sha1"
+		newReleaseSha256               = "new-sha256"
 		notDownloadedReleaseSha1       = "some-other-new-sha1"
 		githubRepo                     = "https://example.com/org/repo"
 
@@ -108,7 +109,7 @@ var _ = Describe("UpdateRelease", func() {
 
 			downloadedReleasePath = filepath.Join(releasesDir, fmt.Sprintf("%s-%s.tgz", releaseName, newReleaseVersion))
 			expectedDownloadedRelease = component.Local{
-				Lock:      cargo.BOSHReleaseTarballLock{Name: releaseName, Version: newReleaseVersion, SHA1: newReleaseSha1},
+				Lock:      cargo.BOSHReleaseTarballLock{Name: releaseName, Version: newReleaseVersion, SHA1: newReleaseSha1, SHA256: newReleaseSha256},
 				LocalPath: downloadedReleasePath,
 			}
 			expectedRemoteRelease = expectedDownloadedRelease.Lock.WithRemote(newReleaseSourceName, newRemotePath)
@@ -129,6 +130,31 @@ var _ = Describe("UpdateRelease", func() {
 			updateReleaseCommand = commands.NewUpdateRelease(logger, filesystem, multiReleaseSourceProvider.Spy)
 		})
 
+		When("the Kilnfile has a source_preference", func() {
+			BeforeEach(func() {
+				kilnfile := cargo.Kilnfile{
+					SourcePreference: &cargo.SourcePreference{PublishableOnly: true},
+					Releases: []cargo.BOSHReleaseTarballSpecification{
+						{Name: releaseName, GitHubRepository: githubRepo},
+					},
+				}
+				Expect(fsWriteYAML(filesystem, kilnfilePath, kilnfile)).NotTo(HaveOccurred())
+			})
+
+			It("passes it when finding the release to download", func() {
+				err := updateReleaseCommand.Execute([]string{
+					"--kilnfile", "Kilnfile",
+					"--name", releaseName,
+					"--version", newReleaseVersion,
+					"--releases-directory", releasesDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, receivedReleaseRequirement := releaseSource.GetMatchedReleaseArgsForCall(0)
+				Expect(receivedReleaseRequirement.SourcePreference).To(Equal(&cargo.SourcePreference{PublishableOnly: true}))
+			})
+		})
+
 		When("updating to a version that exists in the remote", func() {
 			It("downloads the release", func() {
 				err := updateReleaseCommand.Execute([]string{
@@ -141,7 +167,7 @@ var _ = Describe("UpdateRelease", func() {
 
 				Expect(releaseSource.GetMatchedReleaseCallCount()).To(Equal(1))
 
-				receivedReleaseRequirement := releaseSource.GetMatchedReleaseArgsForCall(0)
+				_, receivedReleaseRequirement := releaseSource.GetMatchedReleaseArgsForCall(0)
 				releaseRequirement := cargo.BOSHReleaseTarballSpecification{
 					Name:             releaseName,
 					Version:          newReleaseVersion,
@@ -153,7 +179,7 @@ var _ = Describe("UpdateRelease", func() {
 
 				Expect(releaseSource.DownloadReleaseCallCount()).To(Equal(1))
 
-				receivedReleasesDir, receivedRemoteRelease := releaseSource.DownloadReleaseArgsForCall(0)
+				_, receivedReleasesDir, receivedRemoteRelease := releaseSource.DownloadReleaseArgsForCall(0)
 				Expect(receivedReleasesDir).To(Equal(releasesDir))
 				Expect(receivedRemoteRelease).To(Equal(expectedRemoteRelease))
 			})
@@ -177,6 +203,7 @@ var _ = Describe("UpdateRelease", func() {
 						Version: newReleaseVersion,
 
 						SHA1:         newReleaseSha1,
+						SHA256:       newReleaseSha256,
 						RemoteSource: newReleaseSourceName,
 						RemotePath:   newRemotePath,
 					},
@@ -412,6 +439,40 @@ var _ = Describe("UpdateRelease", func() {
 			})
 		})
 
+		When("the release is pinned to release sources", func() {
+			BeforeEach(func() {
+				Expect(fsWriteYAML(filesystem, kilnfilePath, cargo.Kilnfile{
+					Releases: []cargo.BOSHReleaseTarballSpecification{
+						{Name: "minecraft"},
+						{Name: releaseName, GitHubRepository: githubRepo, ReleaseSources: []string{newReleaseSourceName}},
+					},
+				})).To(Succeed())
+			})
+
+			It("passes the pin to the release sources", func() {
+				Expect(updateReleaseCommand.Execute([]string{
+					"--kilnfile", "Kilnfile",
+					"--name", releaseName,
+					"--version", newReleaseVersion,
+					"--releases-directory", releasesDir,
+				})).To(Succeed())
+				_, receivedReleaseRequirement := releaseSource.GetMatchedReleaseArgsForCall(0)
+				Expect(receivedReleaseRequirement.ReleaseSources).To(Equal([]string{newReleaseSourceName}))
+			})
+
+			It("passes the pin to the release sources when not downloading", func() {
+				Expect(updateReleaseCommand.Execute([]string{
+					"--kilnfile", "Kilnfile",
+					"--name", releaseName,
+					"--version", newReleaseVersion,
+					"--releases-directory", releasesDir,
+					"--without-download",
+				})).To(Succeed())
+				_, receivedReleaseRequirement, _ := releaseSource.FindReleaseVersionArgsForCall(0)
+				Expect(receivedReleaseRequirement.ReleaseSources).To(Equal([]string{newReleaseSourceName}))
+			})
+		})
+
 		When("updating lock file without downloading", func() {
 			It("writes the new version to the Kilnfile.lock", func() {
 				err := updateReleaseCommand.Execute([]string{
@@ -423,7 +484,7 @@ var _ = Describe("UpdateRelease", func() {
 				})
 				Expect(err).NotTo(HaveOccurred())
 
-				receivedReleaseRequirement, _ := releaseSource.FindReleaseVersionArgsForCall(0)
+				_, receivedReleaseRequirement, _ := releaseSource.FindReleaseVersionArgsForCall(0)
 				releaseRequirement := cargo.BOSHReleaseTarballSpecification{
 					Name:             releaseName,
 					Version:          newReleaseVersion,
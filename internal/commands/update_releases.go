@@ -0,0 +1,302 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-billy/v5"
+	"github.com/pivotal-cf/jhanda"
+	"github.com/pmezard/go-difflib/difflib"
+
+	"github.com/pivotal-cf/kiln/internal/commands/flags"
+	"github.com/pivotal-cf/kiln/internal/component"
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+const (
+	updatePolicyPatch = "patch"
+	updatePolicyMinor = "minor"
+)
+
+type UpdateReleases struct {
+	Options struct {
+		flags.Standard
+		flags.Timeouts
+
+		Names                        []string `short:"n"  long:"name"                            description:"name or glob of releases to update (defaults to every release in the Kilnfile.lock)"`
+		Exclude                      []string `short:"x"  long:"exclude"                         description:"name or glob of releases not to update"`
+		Policy                       string   `           long:"policy"                          description:"further restrict the Kilnfile version constraints: patch (same major and minor version) or minor (same major version)"`
+		Download                     bool     `           long:"download"                        description:"download each new release and check its SHA1"`
+		ReleasesDir                  string   `short:"rd" long:"releases-directory"              default:"releases" description:"path to a directory to download releases into"`
+		AllowOnlyPublishableReleases bool     `           long:"allow-only-publishable-releases" description:"only search publishable release sources (exclude development builds that would not be shipped with the tile)"`
+		DryRun                       bool     `           long:"dry-run"                         description:"print the Kilnfile.lock changes without writing them"`
+	}
+	multiReleaseSourceProvider MultiReleaseSourceProvider
+	filesystem                 billy.Filesystem
+	logger                     *log.Logger
+}
+
+func NewUpdateReleases(logger *log.Logger, filesystem billy.Filesystem, multiReleaseSourceProvider MultiReleaseSourceProvider) UpdateReleases {
+	return UpdateReleases{
+		logger:                     logger,
+		multiReleaseSourceProvider: multiReleaseSourceProvider,
+		filesystem:                 filesystem,
+	}
+}
+
+func (u UpdateReleases) Execute(args []string) error {
+	_, err := flags.LoadWithDefaultFilePaths(&u.Options, args, u.filesystem.Stat)
+	if err != nil {
+		return err
+	}
+	switch u.Options.Policy {
+	case "", updatePolicyPatch, updatePolicyMinor:
+	default:
+		return fmt.Errorf("unknown policy %q (expected patch or minor)", u.Options.Policy)
+	}
+	for _, pattern := range append(u.Options.Names[:len(u.Options.Names):len(u.Options.Names)], u.Options.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid release name pattern %q: %w", pattern, err)
+		}
+	}
+
+	kilnfile, kilnfileLock, err := u.Options.LoadKilnfiles(u.filesystem, nil)
+	if err != nil {
+		return fmt.Errorf("error loading Kilnfiles: %w", err)
+	}
+	if u.Options.ReleasesDir == "" {
+		u.Options.ReleasesDir = "releases"
+	}
+
+	releaseSource := u.multiReleaseSourceProvider(kilnfile, u.Options.AllowOnlyPublishableReleases)
+
+	ctx, cancel := u.Options.Context()
+	defer cancel()
+
+	updatedLock := kilnfileLock
+	updatedLock.Releases = append([]cargo.BOSHReleaseTarballLock(nil), kilnfileLock.Releases...)
+
+	var errs []error
+	for i, releaseLock := range kilnfileLock.Releases {
+		if !u.selected(releaseLock.Name) {
+			continue
+		}
+		updated, err := u.updateRelease(ctx, releaseSource, kilnfile, kilnfileLock, releaseLock)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", releaseLock.Name, err))
+			continue
+		}
+		updatedLock.Releases[i] = updated
+	}
+
+	bumps := cargo.CalculateBumps(updatedLock.Releases, kilnfileLock.Releases)
+	setReleases := func(doc *cargo.KilnfileLockDocument) error {
+		for _, bump := range bumps {
+			if err := doc.SetRelease(bump.To); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if u.Options.DryRun {
+		diff, err := u.kilnfileLockDiff(setReleases)
+		if err != nil {
+			return err
+		}
+		if diff != "" {
+			u.logger.Print(diff)
+		}
+		u.logger.Print(bumpsSummary(bumps))
+		return errors.Join(errs...)
+	}
+
+	if len(bumps) > 0 {
+		if err := u.Options.EditKilnfileLock(u.filesystem, setReleases); err != nil {
+			return err
+		}
+	}
+	u.logger.Print(bumpsSummary(bumps))
+
+	return errors.Join(errs...)
+}
+
+// selected returns true when the release name matches one of the --name
+// patterns (or none were given) and none of the --exclude patterns.
+func (u UpdateReleases) selected(name string) bool {
+	for _, pattern := range u.Options.Exclude {
+		if ok, _ := path.Match(pattern, name); ok {
+			return false
+		}
+	}
+	if len(u.Options.Names) == 0 {
+		return true
+	}
+	for _, pattern := range u.Options.Names {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (u UpdateReleases) updateRelease(ctx context.Context, releaseSource component.MultiReleaseSource, kilnfile cargo.Kilnfile, kilnfileLock cargo.KilnfileLock, releaseLock cargo.BOSHReleaseTarballLock) (cargo.BOSHReleaseTarballLock, error) {
+	releaseSpec, err := kilnfile.BOSHReleaseTarballSpecification(releaseLock.Name)
+	if err != nil {
+		return releaseLock, err
+	}
+	stemcell, err := kilnfileLock.StemcellForRelease(releaseSpec)
+	if err != nil {
+		return releaseLock, err
+	}
+	releaseSpec.StemcellOS = stemcell.OS
+	releaseSpec.StemcellVersion = stemcell.Version
+
+	releaseSpec.Version, err = policyConstraint(releaseSpec.Version, releaseLock.Version, u.Options.Policy)
+	if err != nil {
+		return releaseLock, err
+	}
+
+	// With --download the new release is downloaded into the releases
+	// directory below and its SHA1 comes from that file, so the release sources
+	// need not download the tarball to calculate it while searching. Without
+	// --download the search must calculate the SHA1 for the Kilnfile.lock.
+	skipSearchDownload := u.Options.Download
+	requestCtx, cancelRequest := u.Options.RequestContext(ctx)
+	remoteRelease, err := releaseSource.FindReleaseVersion(requestCtx, releaseSpec, skipSearchDownload)
+	cancelRequest()
+	if err != nil {
+		if component.IsErrNotFound(err) {
+			u.logger.Printf("No version of %s matching %q found; leaving it at %s", releaseLock.Name, releaseSpec.Version, releaseLock.Version)
+			return releaseLock, nil
+		}
+		return releaseLock, err
+	}
+
+	newer, err := isNewerVersion(remoteRelease.Version, releaseLock.Version)
+	if err != nil {
+		return releaseLock, err
+	}
+	if !newer {
+		return releaseLock, nil
+	}
+
+	if u.Options.Download {
+		requestCtx, cancelRequest = u.Options.RequestContext(ctx)
+		local, err := releaseSource.DownloadRelease(requestCtx, u.Options.ReleasesDir, remoteRelease)
+		cancelRequest()
+		if err != nil {
+			return releaseLock, fmt.Errorf("error downloading the release: %w", err)
+		}
+		if remoteRelease.SHA1 != "" && remoteRelease.SHA1 != "not-calculated" && local.Lock.SHA1 != remoteRelease.SHA1 {
+			return releaseLock, fmt.Errorf("downloaded %s %s had an incorrect SHA1 - expected %q, got %q", remoteRelease.Name, remoteRelease.Version, remoteRelease.SHA1, local.Lock.SHA1)
+		}
+		remoteRelease.SHA1 = local.Lock.SHA1
+		remoteRelease.SHA256 = local.Lock.SHA256
+	}
+
+	u.logger.Printf("Updating %s from %s to %s (%s)", releaseLock.Name, releaseLock.Version, remoteRelease.Version, remoteRelease.RemoteSource)
+
+	releaseLock.Version = remoteRelease.Version
+	releaseLock.SHA1 = remoteRelease.SHA1
+	releaseLock.SHA256 = remoteRelease.SHA256
+	releaseLock.RemoteSource = remoteRelease.RemoteSource
+	releaseLock.RemotePath = remoteRelease.RemotePath
+	releaseLock.StemcellOS = remoteRelease.StemcellOS
+	releaseLock.StemcellVersion = remoteRelease.StemcellVersion
+	return releaseLock, nil
+}
+
+// policyConstraint adds the bound implied by the update policy to the Kilnfile
+// version constraint.
+func policyConstraint(constraint, lockedVersion, policy string) (string, error) {
+	if policy == "" {
+		return constraint, nil
+	}
+	current, err := semver.NewVersion(lockedVersion)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse locked version for the %s policy: %w", policy, err)
+	}
+	var bound string
+	switch policy {
+	case updatePolicyPatch:
+		bound = fmt.Sprintf(">=%s, <%d.%d.0", current, current.Major(), current.Minor()+1)
+	case updatePolicyMinor:
+		bound = fmt.Sprintf(">=%s, <%d.0.0", current, current.Major()+1)
+	}
+	if constraint == "" {
+		return bound, nil
+	}
+	return constraint + ", " + bound, nil
+}
+
+func isNewerVersion(version, lockedVersion string) (bool, error) {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse version from release source: %w", err)
+	}
+	current, err := semver.NewVersion(lockedVersion)
+	if err != nil {
+		return version != lockedVersion, nil
+	}
+	return v.GreaterThan(current), nil
+}
+
+// kilnfileLockDiff returns the unified diff between the Kilnfile.lock and the
+// result of editing it.
+func (u UpdateReleases) kilnfileLockDiff(edit func(doc *cargo.KilnfileLockDocument) error) (string, error) {
+	lockPath := u.Options.KilnfileLockPath()
+	f, err := u.filesystem.Open(lockPath)
+	if err != nil {
+		return "", err
+	}
+	defer closeAndIgnoreError(f)
+	before, err := io.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+	doc, err := cargo.ParseKilnfileLockDocument(before)
+	if err != nil {
+		return "", err
+	}
+	if err := edit(doc); err != nil {
+		return "", err
+	}
+	after, err := doc.Bytes()
+	if err != nil {
+		return "", err
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(before)),
+		B:        difflib.SplitLines(string(after)),
+		FromFile: lockPath,
+		ToFile:   lockPath,
+		Context:  3,
+	})
+}
+
+func bumpsSummary(bumps []cargo.Bump) string {
+	if len(bumps) == 0 {
+		return "No releases need updating.\n"
+	}
+	var s strings.Builder
+	_, _ = fmt.Fprintf(&s, "Release updates (%d):\n", len(bumps))
+	for _, bump := range bumps {
+		_, _ = fmt.Fprintf(&s, "  %s: %s -> %s\n", bump.Name, bump.From.Version, bump.To.Version)
+	}
+	return s.String()
+}
+
+func (u UpdateReleases) Usage() jhanda.Usage {
+	return jhanda.Usage{
+		Description:      "Finds the newest version of every release in the Kilnfile.lock (or those matching --name) allowed by its Kilnfile version constraint and the update policy, and updates Kilnfile.lock in one pass. With --dry-run it prints the Kilnfile.lock diff instead of writing it.",
+		ShortDescription: "bumps every release to the newest allowed version",
+		Flags:            u.Options,
+	}
+}
@@ -0,0 +1,169 @@
+package commands_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/kiln/internal/commands"
+	"github.com/pivotal-cf/kiln/internal/component"
+	"github.com/pivotal-cf/kiln/internal/component/fakes"
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+var _ = Describe("UpdateReleases", func() {
+	var (
+		fs            billy.Filesystem
+		output        bytes.Buffer
+		releaseSource *fakes.MultiReleaseSource
+		available     map[string][]string
+		initialLock   cargo.KilnfileLock
+		command       commands.UpdateReleases
+	)
+
+	BeforeEach(func() {
+		fs = memfs.New()
+		output.Reset()
+
+		Expect(fsWriteYAML(fs, "Kilnfile", cargo.Kilnfile{
+			Releases: []cargo.BOSHReleaseTarballSpecification{
+				{Name: "bpm", Version: "~1"},
+				{Name: "uaa"},
+				{Name: "diego"},
+			},
+		})).To(Succeed())
+		initialLock = cargo.KilnfileLock{
+			Stemcell: cargo.Stemcell{OS: "ubuntu-jammy", Version: "1.100"},
+			Releases: []cargo.BOSHReleaseTarballLock{
+				{Name: "bpm", Version: "1.2.0", SHA1: "bpm-sha", RemoteSource: "bosh.io", RemotePath: "bpm-1.2.0"},
+				{Name: "uaa", Version: "75.0.0", SHA1: "uaa-sha", RemoteSource: "bosh.io", RemotePath: "uaa-75.0.0"},
+				{Name: "diego", Version: "2.90.0", SHA1: "diego-sha", RemoteSource: "bosh.io", RemotePath: "diego-2.90.0"},
+			},
+		}
+		Expect(fsWriteYAML(fs, "Kilnfile.lock", initialLock)).To(Succeed())
+
+		available = map[string][]string{
+			"bpm":   {"1.2.0", "1.2.5", "1.3.1", "2.0.0"},
+			"uaa":   {"75.0.0", "75.1.0", "76.0.0"},
+			"diego": {"2.90.0"},
+		}
+
+		releaseSource = new(fakes.MultiReleaseSource)
+		releaseSource.FindReleaseVersionStub = func(_ context.Context, spec cargo.BOSHReleaseTarballSpecification, _ bool) (cargo.BOSHReleaseTarballLock, error) {
+			c, err := spec.VersionConstraints()
+			if err != nil {
+				return cargo.BOSHReleaseTarballLock{}, err
+			}
+			var found string
+			for _, v := range available[spec.Name] {
+				if c.Check(semver.MustParse(v)) {
+					found = v
+				}
+			}
+			if found == "" {
+				return cargo.BOSHReleaseTarballLock{}, component.ErrNotFound
+			}
+			return cargo.BOSHReleaseTarballLock{
+				Name: spec.Name, Version: found, SHA1: spec.Name + "-" + found + "-sha",
+				RemoteSource: "compiled-releases", RemotePath: spec.Name + "-" + found,
+			}, nil
+		}
+
+		command = commands.NewUpdateReleases(log.New(&output, "", 0), fs, func(cargo.Kilnfile, bool) component.MultiReleaseSource {
+			return releaseSource
+		})
+	})
+
+	It("updates every release to the newest version allowed by the Kilnfile", func() {
+		Expect(command.Execute(nil)).To(Succeed())
+
+		var lock cargo.KilnfileLock
+		Expect(fsReadYAML(fs, "Kilnfile.lock", &lock)).To(Succeed())
+		Expect(lock.Releases).To(Equal([]cargo.BOSHReleaseTarballLock{
+			{Name: "bpm", Version: "1.3.1", SHA1: "bpm-1.3.1-sha", RemoteSource: "compiled-releases", RemotePath: "bpm-1.3.1"},
+			{Name: "uaa", Version: "76.0.0", SHA1: "uaa-76.0.0-sha", RemoteSource: "compiled-releases", RemotePath: "uaa-76.0.0"},
+			initialLock.Releases[2],
+		}))
+		Expect(output.String()).To(ContainSubstring("Release updates (2):\n  bpm: 1.2.0 -> 1.3.1\n  uaa: 75.0.0 -> 76.0.0\n"))
+
+		_, spec, noDownload := releaseSource.FindReleaseVersionArgsForCall(0)
+		Expect(spec.StemcellOS).To(Equal("ubuntu-jammy"))
+		Expect(spec.StemcellVersion).To(Equal("1.100"))
+		Expect(noDownload).To(BeFalse(), "the release sources calculate the SHA1 while searching")
+		Expect(releaseSource.DownloadReleaseCallCount()).To(Equal(0))
+	})
+
+	When("a policy is set", func() {
+		It("only allows patch bumps", func() {
+			Expect(command.Execute([]string{"--policy", "patch"})).To(Succeed())
+			Expect(output.String()).To(ContainSubstring("bpm: 1.2.0 -> 1.2.5\n"))
+			Expect(output.String()).NotTo(ContainSubstring("uaa:"))
+		})
+
+		It("only allows minor bumps", func() {
+			Expect(command.Execute([]string{"--policy", "minor"})).To(Succeed())
+			Expect(output.String()).To(ContainSubstring("bpm: 1.2.0 -> 1.3.1\n"))
+			Expect(output.String()).To(ContainSubstring("uaa: 75.0.0 -> 75.1.0\n"))
+		})
+
+		It("rejects unknown policies", func() {
+			Expect(command.Execute([]string{"--policy", "yolo"})).To(MatchError(ContainSubstring("unknown policy")))
+		})
+	})
+
+	It("selects releases with --name and --exclude globs", func() {
+		Expect(command.Execute([]string{"--name", "*a*", "--exclude", "diego"})).To(Succeed())
+		Expect(output.String()).To(ContainSubstring("Release updates (1):\n  uaa: 75.0.0 -> 76.0.0\n"))
+		Expect(releaseSource.FindReleaseVersionCallCount()).To(Equal(1))
+	})
+
+	When("--dry-run is set", func() {
+		It("prints the lock diff without writing it", func() {
+			Expect(command.Execute([]string{"--dry-run", "--name", "bpm"})).To(Succeed())
+
+			var lock cargo.KilnfileLock
+			Expect(fsReadYAML(fs, "Kilnfile.lock", &lock)).To(Succeed())
+			Expect(lock).To(Equal(initialLock))
+
+			Expect(output.String()).To(ContainSubstring("--- Kilnfile.lock\n+++ Kilnfile.lock\n"))
+			Expect(output.String()).To(ContainSubstring("-      version: 1.2.0\n"))
+			Expect(output.String()).To(ContainSubstring("+      version: 1.3.1\n"))
+			Expect(output.String()).To(ContainSubstring("bpm: 1.2.0 -> 1.3.1\n"))
+		})
+	})
+
+	When("--download is set", func() {
+		BeforeEach(func() {
+			releaseSource.DownloadReleaseStub = func(_ context.Context, _ string, lock cargo.BOSHReleaseTarballLock) (component.Local, error) {
+				lock.SHA256 = "some-sha256"
+				if lock.Name == "uaa" {
+					lock.SHA1 = "corrupted"
+				}
+				return component.Local{Lock: lock}, nil
+			}
+		})
+
+		It("verifies the downloaded releases and keeps the ones that match", func() {
+			err := command.Execute([]string{"--download"})
+			Expect(err).To(MatchError(ContainSubstring(`uaa: downloaded uaa 76.0.0 had an incorrect SHA1 - expected "uaa-76.0.0-sha", got "corrupted"`)))
+
+			Expect(releaseSource.DownloadReleaseCallCount()).To(Equal(2))
+			_, dir, _ := releaseSource.DownloadReleaseArgsForCall(0)
+			Expect(dir).To(Equal("releases"))
+			_, _, noDownload := releaseSource.FindReleaseVersionArgsForCall(0)
+			Expect(noDownload).To(BeTrue(), "the search skips downloading because the new release is downloaded afterwards")
+
+			var lock cargo.KilnfileLock
+			Expect(fsReadYAML(fs, "Kilnfile.lock", &lock)).To(Succeed())
+			Expect(lock.Releases[0].Version).To(Equal("1.3.1"))
+			Expect(lock.Releases[0].SHA256).To(Equal("some-sha256"))
+			Expect(lock.Releases[1]).To(Equal(initialLock.Releases[1]))
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -20,6 +21,7 @@ type UpdateStemcell struct {
 		flags.Standard
 
 		Version         string `short:"v"   long:"version"               required:"true"    description:"desired version of stemcell"`
+		OS              string `            long:"os"                                       description:"operating system of the stemcell to update (required when the Kilnfile lists more than one stemcell)"`
 		ReleasesDir     string `short:"rd"  long:"releases-directory"    default:"releases" description:"path to a directory to download releases into"`
 		UpdateReleases  bool   `short:"ur"  long:"update-releases"       default:"false"    description:"finds latest matching releases for new stemcell version"`
 		WithoutDownload bool   `short:"wd"  long:"without-download"      default:"false"    description:"updates stemcell releases without downloading releases"`
@@ -49,7 +51,19 @@ func (update UpdateStemcell) Execute(args []string) error {
 		return fmt.Errorf("invalid stemcell version (please enter a valid version): %w", err)
 	}
 
-	kilnStemcellVersion := kilnfile.Stemcell.Version
+	if update.Options.OS == "" && len(kilnfileLock.AllStemcells()) > 1 {
+		return fmt.Errorf("the Kilnfile.lock has more than one stemcell; use --os to choose one")
+	}
+	stemcellCriteria, err := kilnfile.StemcellForOS(update.Options.OS)
+	if err != nil {
+		return err
+	}
+	lockedStemcell, err := kilnfileLock.StemcellForOS(update.Options.OS)
+	if err != nil {
+		return err
+	}
+
+	kilnStemcellVersion := stemcellCriteria.Version
 	releaseVersionConstraint, err = semver.NewConstraint(kilnStemcellVersion)
 	if err != nil {
 		return fmt.Errorf("invalid stemcell constraint in kilnfile: %w", err)
@@ -60,7 +74,7 @@ func (update UpdateStemcell) Execute(args []string) error {
 		return nil
 	}
 
-	currentStemcellVersion, _ := semver.NewVersion(kilnfileLock.Stemcell.Version)
+	currentStemcellVersion, _ := semver.NewVersion(lockedStemcell.Version)
 
 	if currentStemcellVersion.Equal(latestStemcellVersion) {
 		update.Logger.Println("Stemcell is up-to-date. Nothing to update for product")
@@ -68,25 +82,34 @@ func (update UpdateStemcell) Execute(args []string) error {
 	}
 
 	releaseSource := update.MultiReleaseSourceProvider(kilnfile, false)
+	ctx := context.Background()
 
 	for i, rel := range kilnfileLock.Releases {
-		update.Logger.Printf("Updating release %q with stemcell %s %s...", rel.Name, kilnfileLock.Stemcell.OS, trimmedInputVersion)
-
 		spec, err := kilnfile.BOSHReleaseTarballSpecification(rel.Name)
 		if err != nil {
 			return err
 		}
 
-		spec.StemcellOS = kilnfileLock.Stemcell.OS
+		releaseStemcell, err := kilnfileLock.StemcellForRelease(spec)
+		if err != nil {
+			return fmt.Errorf("release %q: %w", rel.Name, err)
+		}
+		if releaseStemcell.OS != lockedStemcell.OS {
+			continue
+		}
+
+		update.Logger.Printf("Updating release %q with stemcell %s %s...", rel.Name, lockedStemcell.OS, trimmedInputVersion)
+
+		spec.StemcellOS = lockedStemcell.OS
 		spec.StemcellVersion = trimmedInputVersion
 
 		var remote cargo.BOSHReleaseTarballLock
 
 		if update.Options.UpdateReleases {
-			remote, err = releaseSource.FindReleaseVersion(spec, true)
+			remote, err = releaseSource.FindReleaseVersion(ctx, spec, true)
 		} else {
 			spec.Version = rel.Version
-			remote, err = releaseSource.GetMatchedRelease(spec)
+			remote, err = releaseSource.GetMatchedRelease(ctx, spec)
 		}
 
 		if err != nil {
@@ -108,6 +131,7 @@ func (update UpdateStemcell) Execute(args []string) error {
 		lock.RemotePath = remote.RemotePath
 		lock.RemoteSource = remote.RemoteSource
 		lock.SHA1 = remote.SHA1
+		lock.SHA256 = remote.SHA256
 
 		if update.Options.UpdateReleases {
 			lock.Version = remote.Version
@@ -115,18 +139,26 @@ func (update UpdateStemcell) Execute(args []string) error {
 
 		if !update.Options.WithoutDownload || lock.SHA1 == "" || lock.SHA1 == "not-calculated" {
 			// release source needs to download.
-			local, err := releaseSource.DownloadRelease(update.Options.ReleasesDir, remote)
+			local, err := releaseSource.DownloadRelease(ctx, update.Options.ReleasesDir, remote)
 			if err != nil {
 				return fmt.Errorf("while downloading release %s %s, encountered error: %w", lock.Name, lock.Version, err)
 			}
 
 			lock.SHA1 = local.Lock.SHA1
+			lock.SHA256 = local.Lock.SHA256
 		}
 	}
 
-	kilnfileLock.Stemcell.Version = trimmedInputVersion
+	lockedStemcell.Version = trimmedInputVersion
 
-	err = update.Options.SaveKilnfileLock(update.FS, kilnfileLock)
+	err = update.Options.EditKilnfileLock(update.FS, func(doc *cargo.KilnfileLockDocument) error {
+		for _, lock := range kilnfileLock.Releases {
+			if err := doc.SetRelease(lock); err != nil {
+				return err
+			}
+		}
+		return doc.SetStemcell(lockedStemcell)
+	})
 	if err != nil {
 		return err
 	}
@@ -137,7 +169,7 @@ func (update UpdateStemcell) Execute(args []string) error {
 
 func (update UpdateStemcell) Usage() jhanda.Usage {
 	return jhanda.Usage{
-		Description:      "Updates stemcell and release information in Kilnfile.lock",
+		Description:      "Updates stemcell and release information in Kilnfile.lock. When the Kilnfile lists more than one stemcell, --os chooses the stemcell and only the releases compiled against it are updated.",
 		ShortDescription: "updates stemcell and release information in Kilnfile.lock",
 		Flags:            update.Options,
 	}
@@ -1,6 +1,7 @@
 package commands_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -105,7 +106,7 @@ var _ = Describe("UpdateStemcell", func() {
 			}
 
 			releaseSource = new(fetcherFakes.MultiReleaseSource)
-			releaseSource.GetMatchedReleaseCalls(func(requirement cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error) {
+			releaseSource.GetMatchedReleaseCalls(func(_ context.Context, requirement cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error) {
 				switch requirement.Name {
 				case release1Name:
 					remote := cargo.BOSHReleaseTarballLock{
@@ -139,7 +140,7 @@ var _ = Describe("UpdateStemcell", func() {
 				}
 			})
 
-			releaseSource.FindReleaseVersionCalls(func(requirement cargo.BOSHReleaseTarballSpecification, download bool) (cargo.BOSHReleaseTarballLock, error) {
+			releaseSource.FindReleaseVersionCalls(func(_ context.Context, requirement cargo.BOSHReleaseTarballSpecification, download bool) (cargo.BOSHReleaseTarballLock, error) {
 				switch requirement.Name {
 				case release1Name:
 					remote := cargo.BOSHReleaseTarballLock{
@@ -173,7 +174,7 @@ var _ = Describe("UpdateStemcell", func() {
 				}
 			})
 
-			releaseSource.DownloadReleaseCalls(func(_ string, remote cargo.BOSHReleaseTarballLock) (component.Local, error) {
+			releaseSource.DownloadReleaseCalls(func(_ context.Context, _ string, remote cargo.BOSHReleaseTarballLock) (component.Local, error) {
 				switch remote.Name {
 				case release1Name:
 					local := component.Local{
@@ -286,7 +287,7 @@ var _ = Describe("UpdateStemcell", func() {
 			Expect(releaseSource.FindReleaseVersionCallCount()).To(Equal(0))
 			Expect(releaseSource.GetMatchedReleaseCallCount()).To(Equal(3))
 
-			req1 := releaseSource.GetMatchedReleaseArgsForCall(0)
+			_, req1 := releaseSource.GetMatchedReleaseArgsForCall(0)
 			Expect(req1).To(Equal(cargo.BOSHReleaseTarballSpecification{
 				Name:             release1Name,
 				Version:          release1Version,
@@ -295,7 +296,7 @@ var _ = Describe("UpdateStemcell", func() {
 				GitHubRepository: "https://example.com/lemon",
 			}))
 
-			req2 := releaseSource.GetMatchedReleaseArgsForCall(1)
+			_, req2 := releaseSource.GetMatchedReleaseArgsForCall(1)
 			Expect(req2).To(Equal(cargo.BOSHReleaseTarballSpecification{
 				Name:             release2Name,
 				Version:          release2Version,
@@ -304,7 +305,7 @@ var _ = Describe("UpdateStemcell", func() {
 				GitHubRepository: "https://example.com/orange",
 			}))
 
-			req3 := releaseSource.GetMatchedReleaseArgsForCall(2)
+			_, req3 := releaseSource.GetMatchedReleaseArgsForCall(2)
 			Expect(req3).To(Equal(cargo.BOSHReleaseTarballSpecification{
 				Name:             release3Name,
 				Version:          release3Version,
@@ -322,7 +323,7 @@ var _ = Describe("UpdateStemcell", func() {
 			Expect(releaseSource.FindReleaseVersionCallCount()).To(Equal(3))
 			Expect(releaseSource.GetMatchedReleaseCallCount()).To(Equal(0))
 
-			req1, noDownload1 := releaseSource.FindReleaseVersionArgsForCall(0)
+			_, req1, noDownload1 := releaseSource.FindReleaseVersionArgsForCall(0)
 			Expect(req1).To(Equal(cargo.BOSHReleaseTarballSpecification{
 				Name:             release1Name,
 				Version:          "*",
@@ -332,7 +333,7 @@ var _ = Describe("UpdateStemcell", func() {
 			}))
 			Expect(noDownload1).To(BeTrue())
 
-			req2, noDownload2 := releaseSource.FindReleaseVersionArgsForCall(1)
+			_, req2, noDownload2 := releaseSource.FindReleaseVersionArgsForCall(1)
 			Expect(req2).To(Equal(cargo.BOSHReleaseTarballSpecification{
 				Name:             release2Name,
 				Version:          "*",
@@ -353,7 +354,7 @@ var _ = Describe("UpdateStemcell", func() {
 
 			Expect(releaseSource.DownloadReleaseCallCount()).To(Equal(3))
 
-			actualDir, remote1 := releaseSource.DownloadReleaseArgsForCall(0)
+			_, actualDir, remote1 := releaseSource.DownloadReleaseArgsForCall(0)
 			Expect(actualDir).To(Equal(releasesDirPath))
 			Expect(remote1).To(Equal(
 				cargo.BOSHReleaseTarballLock{
@@ -365,7 +366,7 @@ var _ = Describe("UpdateStemcell", func() {
 				},
 			))
 
-			actualDir, remote2 := releaseSource.DownloadReleaseArgsForCall(1)
+			_, actualDir, remote2 := releaseSource.DownloadReleaseArgsForCall(1)
 			Expect(actualDir).To(Equal(releasesDirPath))
 			Expect(remote2).To(Equal(
 				cargo.BOSHReleaseTarballLock{
@@ -377,7 +378,7 @@ var _ = Describe("UpdateStemcell", func() {
 				},
 			))
 
-			actualDir, remote3 := releaseSource.DownloadReleaseArgsForCall(2)
+			_, actualDir, remote3 := releaseSource.DownloadReleaseArgsForCall(2)
 			Expect(actualDir).To(Equal(releasesDirPath))
 			Expect(remote3).To(Equal(
 				cargo.BOSHReleaseTarballLock{
@@ -405,13 +406,13 @@ var _ = Describe("UpdateStemcell", func() {
 
 				Expect(releaseSource.DownloadReleaseCallCount()).To(Equal(3))
 
-				_, remote := releaseSource.DownloadReleaseArgsForCall(0)
+				_, _, remote := releaseSource.DownloadReleaseArgsForCall(0)
 				Expect(remote.Name).To(Equal(release1Name))
 
-				_, remote = releaseSource.DownloadReleaseArgsForCall(1)
+				_, _, remote = releaseSource.DownloadReleaseArgsForCall(1)
 				Expect(remote.Name).To(Equal(release2Name))
 
-				_, remote = releaseSource.DownloadReleaseArgsForCall(2)
+				_, _, remote = releaseSource.DownloadReleaseArgsForCall(2)
 				Expect(remote.Name).To(Equal(release3Name))
 
 				Expect(string(outputBuffer.Contents())).To(ContainSubstring(fmt.Sprintf("No change for release %q", release2Name)))
@@ -430,7 +431,7 @@ var _ = Describe("UpdateStemcell", func() {
 
 				Expect(releaseSource.DownloadReleaseCallCount()).To(Equal(2))
 
-				actualDir, remote1 := releaseSource.DownloadReleaseArgsForCall(0)
+				_, actualDir, remote1 := releaseSource.DownloadReleaseArgsForCall(0)
 				Expect(actualDir).To(Equal(releasesDirPath))
 				Expect(remote1).To(Equal(
 					cargo.BOSHReleaseTarballLock{
@@ -442,7 +443,7 @@ var _ = Describe("UpdateStemcell", func() {
 					},
 				))
 
-				actualDir, remote2 := releaseSource.DownloadReleaseArgsForCall(1)
+				_, actualDir, remote2 := releaseSource.DownloadReleaseArgsForCall(1)
 				Expect(actualDir).To(Equal(releasesDirPath))
 				Expect(remote2).To(Equal(
 					cargo.BOSHReleaseTarballLock{
@@ -471,7 +472,7 @@ var _ = Describe("UpdateStemcell", func() {
 
 					Expect(releaseSource.DownloadReleaseCallCount()).To(Equal(1))
 
-					_, remote := releaseSource.DownloadReleaseArgsForCall(0)
+					_, _, remote := releaseSource.DownloadReleaseArgsForCall(0)
 					Expect(remote.Name).To(Equal(release1Name))
 
 					Expect(string(outputBuffer.Contents())).To(ContainSubstring(fmt.Sprintf("No change for release %q", release2Name)))
@@ -610,6 +611,37 @@ var _ = Describe("UpdateStemcell", func() {
 			})
 		})
 
+		When("the Kilnfile lists more than one stemcell", func() {
+			BeforeEach(func() {
+				kilnfile.Stemcells = []cargo.Stemcell{{OS: "windows2019", Version: "~2019"}}
+				kilnfile.Releases[2].StemcellOS = "windows2019"
+				kilnfileLock.Stemcells = []cargo.Stemcell{{OS: "windows2019", Version: "2019.50"}}
+			})
+
+			It("requires the stemcell OS", func() {
+				err := update.Execute([]string{"--kilnfile", kilnfilePath, "--version", "2019.51"})
+				Expect(err).To(MatchError(ContainSubstring("use --os")))
+			})
+
+			It("updates the stemcell and the releases compiled against it", func() {
+				err := update.Execute([]string{"--kilnfile", kilnfilePath, "--os", "windows2019", "--version", "2019.51"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(releaseSource.GetMatchedReleaseCallCount()).To(Equal(1))
+				_, req := releaseSource.GetMatchedReleaseArgsForCall(0)
+				Expect(req.Name).To(Equal(release3Name))
+				Expect(req.StemcellOS).To(Equal("windows2019"))
+				Expect(req.StemcellVersion).To(Equal("2019.51"))
+
+				var updatedLockfile cargo.KilnfileLock
+				Expect(fsReadYAML(fs, kilnfileLockPath, &updatedLockfile)).NotTo(HaveOccurred())
+				Expect(updatedLockfile.Stemcell).To(Equal(kilnfileLock.Stemcell))
+				Expect(updatedLockfile.Stemcells).To(Equal([]cargo.Stemcell{{OS: "windows2019", Version: "2019.51"}}))
+				Expect(updatedLockfile.Releases[0]).To(Equal(kilnfileLock.Releases[0]))
+				Expect(updatedLockfile.Releases[2].RemotePath).To(Equal(newRelease3RemotePath))
+			})
+		})
+
 		When("the release can't be found", func() {
 			BeforeEach(func() {
 				releaseSource.GetMatchedReleaseReturns(cargo.BOSHReleaseTarballLock{}, component.ErrNotFound)
@@ -0,0 +1,215 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/pivotal-cf/jhanda"
+
+	"github.com/pivotal-cf/kiln/internal/commands/flags"
+	"github.com/pivotal-cf/kiln/internal/component"
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+type UploadRelease struct {
+	Options struct {
+		flags.Standard
+		flags.Timeouts
+
+		UploadTargetID string `long:"upload-target-id" required:"true" description:"the ID of the release source where the release will be uploaded"`
+		LocalPath      string `long:"local-path"       required:"true" description:"path to the BOSH release tarball"`
+	}
+	fs                    billy.Filesystem
+	logger                *log.Logger
+	releaseUploaderFinder ReleaseUploaderFinder
+}
+
+func NewUploadRelease(fs billy.Filesystem, releaseUploaderFinder ReleaseUploaderFinder, logger *log.Logger) UploadRelease {
+	return UploadRelease{
+		fs:                    fs,
+		logger:                logger,
+		releaseUploaderFinder: releaseUploaderFinder,
+	}
+}
+
+//counterfeiter:generate -o ./fakes/release_uploader_finder.go --fake-name ReleaseUploaderFinder . ReleaseUploaderFinder
+type ReleaseUploaderFinder func(cargo.Kilnfile, string) (component.ReleaseUploader, error)
+
+func (command UploadRelease) Execute(args []string) error {
+	_, err := flags.LoadWithDefaultFilePaths(&command.Options, args, command.fs.Stat)
+	if err != nil {
+		return err
+	}
+
+	kilnfile, kilnfileLock, err := command.Options.LoadKilnfiles(command.fs, nil)
+	if err != nil {
+		return fmt.Errorf("error loading Kilnfiles: %w", err)
+	}
+
+	uploader, err := command.releaseUploaderFinder(kilnfile, command.Options.UploadTargetID)
+	if err != nil {
+		return fmt.Errorf("couldn't load the release source: %w", err)
+	}
+
+	tarball, err := readReleaseTarball(command.fs, command.Options.LocalPath)
+	if err != nil {
+		return fmt.Errorf("couldn't read the release tarball %q: %w", command.Options.LocalPath, err)
+	}
+
+	spec := cargo.BOSHReleaseTarballSpecification{
+		Name:    tarball.Manifest.Name,
+		Version: tarball.Manifest.Version,
+	}
+	if kilnfileSpec, err := kilnfile.BOSHReleaseTarballSpecification(spec.Name); err == nil {
+		spec.GitHubRepository = kilnfileSpec.GitHubRepository
+	}
+	if stemcellOS, stemcellVersion, ok := tarball.Manifest.Stemcell(); ok {
+		spec.StemcellOS, spec.StemcellVersion = stemcellOS, stemcellVersion
+	}
+
+	ctx, cancel := command.Options.Context()
+	defer cancel()
+
+	remotePath, err := releaseUpload{
+		uploader: uploader,
+		sourceID: command.Options.UploadTargetID,
+		timeouts: command.Options.Timeouts,
+		fs:       command.fs,
+		logger:   command.logger,
+	}.upload(ctx, spec, command.Options.LocalPath, tarball.SHA1)
+	if err != nil {
+		return err
+	}
+
+	releaseLock, err := kilnfileLock.FindBOSHReleaseWithName(spec.Name)
+	if err != nil {
+		command.logger.Printf("%s is not in the Kilnfile.lock; it was not updated", spec.Name)
+		return nil
+	}
+
+	releaseLock.Version = spec.Version
+	releaseLock.SHA1 = tarball.SHA1
+	releaseLock.SHA256 = tarball.SHA256
+	releaseLock.RemoteSource = command.Options.UploadTargetID
+	releaseLock.RemotePath = remotePath
+	err = command.Options.EditKilnfileLock(command.fs, func(doc *cargo.KilnfileLockDocument) error {
+		return doc.SetRelease(releaseLock)
+	})
+	if err != nil {
+		return err
+	}
+
+	command.logger.Printf("Updated %s to %s in the Kilnfile.lock\n", spec.Name, spec.Version)
+	return nil
+}
+
+func readReleaseTarball(fs billy.Filesystem, tarballPath string) (cargo.BOSHReleaseTarball, error) {
+	file, err := fs.Open(tarballPath)
+	if err != nil {
+		return cargo.BOSHReleaseTarball{}, err
+	}
+	defer closeAndIgnoreError(file)
+	return cargo.ReadBOSHReleaseTarball(tarballPath, file)
+}
+
+// releaseUpload uploads release tarballs to a release source without
+// replacing files that are already there.
+type releaseUpload struct {
+	uploader component.ReleaseUploader
+	sourceID string
+	timeouts flags.Timeouts
+	fs       billy.Filesystem
+	logger   *log.Logger
+}
+
+// upload writes the tarball at localPath to the path the release source
+// generates for spec and returns that path. When the same tarball is already
+// stored there it is not uploaded again; when a different one is, upload
+// returns an error.
+func (u releaseUpload) upload(ctx context.Context, spec cargo.BOSHReleaseTarballSpecification, localPath, sum string) (string, error) {
+	remotePath, err := u.uploader.RemotePath(spec)
+	if err != nil {
+		return "", fmt.Errorf("couldn't generate a remote path for release %q: %w", spec.Name, err)
+	}
+
+	uploaded, err := u.findUploadedRelease(ctx, spec, remotePath)
+	if err != nil {
+		return "", err
+	}
+	switch uploaded.SHA1 {
+	case sum:
+		u.logger.Printf("%s %s is already uploaded to %s", spec.Name, spec.Version, remotePath)
+		return remotePath, nil
+	case "":
+	default:
+		return "", fmt.Errorf("refusing to overwrite %s in release source %q: it has SHA1 %s but %s has SHA1 %s",
+			remotePath, u.sourceID, uploaded.SHA1, localPath, sum)
+	}
+
+	file, err := u.fs.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer closeAndIgnoreError(file)
+
+	requestCtx, cancelRequest := u.timeouts.RequestContext(ctx)
+	defer cancelRequest()
+
+	lock, err := u.uploader.UploadRelease(requestCtx, spec, file)
+	if err != nil {
+		return "", fmt.Errorf("couldn't upload %s %s to release source %q: %w", spec.Name, spec.Version, u.sourceID, err)
+	}
+	if lock.SHA1 != sum {
+		return "", fmt.Errorf("the uploaded release has SHA1 %s but %s has SHA1 %s; the file may have changed during the upload", lock.SHA1, localPath, sum)
+	}
+	u.logger.Printf("Uploaded %s %s to %s", spec.Name, spec.Version, remotePath)
+
+	return remotePath, nil
+}
+
+// findUploadedRelease returns the lock for a release already stored at
+// remotePath. The SHA1 is empty when nothing is stored there. When the release
+// source does not report the SHA1 of a stored file, the file is downloaded to
+// calculate it.
+func (u releaseUpload) findUploadedRelease(ctx context.Context, spec cargo.BOSHReleaseTarballSpecification, remotePath string) (cargo.BOSHReleaseTarballLock, error) {
+	requestCtx, cancelRequest := u.timeouts.RequestContext(ctx)
+	defer cancelRequest()
+
+	uploaded, err := u.uploader.GetMatchedRelease(requestCtx, spec)
+	if err != nil {
+		if component.IsErrNotFound(err) {
+			return cargo.BOSHReleaseTarballLock{}, nil
+		}
+		return cargo.BOSHReleaseTarballLock{}, fmt.Errorf("couldn't check for an existing release in release source %q: %w", u.sourceID, err)
+	}
+	if uploaded.RemotePath != remotePath {
+		return cargo.BOSHReleaseTarballLock{}, nil
+	}
+	if uploaded.SHA1 != "" && uploaded.SHA1 != "not-calculated" {
+		return uploaded, nil
+	}
+
+	dir, err := os.MkdirTemp("", "kiln-upload-release-")
+	if err != nil {
+		return cargo.BOSHReleaseTarballLock{}, err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	local, err := u.uploader.DownloadRelease(requestCtx, dir, uploaded)
+	if err != nil {
+		return cargo.BOSHReleaseTarballLock{}, fmt.Errorf("couldn't download the existing release at %s to compare it: %w", remotePath, err)
+	}
+	uploaded.SHA1 = local.Lock.SHA1
+	return uploaded, nil
+}
+
+func (command UploadRelease) Usage() jhanda.Usage {
+	return jhanda.Usage{
+		Description:      "Uploads a BOSH release tarball to a release source and updates the Kilnfile.lock",
+		ShortDescription: "uploads a BOSH release to an S3 or Artifactory release source",
+		Flags:            command.Options,
+	}
+}
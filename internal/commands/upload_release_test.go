@@ -0,0 +1,158 @@
+package commands_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/kiln/internal/commands"
+	commandsFakes "github.com/pivotal-cf/kiln/internal/commands/fakes"
+	"github.com/pivotal-cf/kiln/internal/component"
+	componentFakes "github.com/pivotal-cf/kiln/internal/component/fakes"
+	test_helpers "github.com/pivotal-cf/kiln/internal/test-helpers"
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+var _ = Describe("upload-release", func() {
+	const (
+		kilnfilePath     = "Kilnfile"
+		kilnfileLockPath = kilnfilePath + ".lock"
+		tarballPath      = "bpm-1.2.0.tgz"
+		remotePath       = "bpm/bpm-1.2.0.tgz"
+		sourceID         = "some-bucket"
+	)
+
+	var (
+		fs            billy.Filesystem
+		finder        *commandsFakes.ReleaseUploaderFinder
+		uploader      *componentFakes.ReleaseUploader
+		uploadRelease commands.UploadRelease
+		kilnfileLock  cargo.KilnfileLock
+		tarballSHA1   string
+		tarballSHA256 string
+		uploadedBytes []byte
+		executeErr    error
+		executeArgs   []string
+		updatedLock   cargo.KilnfileLock
+	)
+
+	BeforeEach(func() {
+		fs = memfs.New()
+		var err error
+		tarballSHA1, err = test_helpers.WriteReleaseTarball(tarballPath, "bpm", "1.2.0", fs)
+		Expect(err).NotTo(HaveOccurred())
+		tarball, err := fs.Open(tarballPath)
+		Expect(err).NotTo(HaveOccurred())
+		tarballContents, err := io.ReadAll(tarball)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tarball.Close()).To(Succeed())
+		tarballSHA256 = fmt.Sprintf("%x", sha256.Sum256(tarballContents))
+
+		kilnfileLock = cargo.KilnfileLock{
+			Releases: []cargo.BOSHReleaseTarballLock{
+				{Name: "bpm", Version: "1.1.0", SHA1: "old-sha", RemoteSource: "bosh.io", RemotePath: "old-path"},
+			},
+		}
+
+		uploader = new(componentFakes.ReleaseUploader)
+		uploader.RemotePathReturns(remotePath, nil)
+		uploader.GetMatchedReleaseReturns(cargo.BOSHReleaseTarballLock{}, component.ErrNotFound)
+		uploadedBytes = nil
+		uploader.UploadReleaseStub = func(_ context.Context, spec cargo.BOSHReleaseTarballSpecification, r io.Reader) (cargo.BOSHReleaseTarballLock, error) {
+			uploadedBytes, _ = io.ReadAll(r)
+			return cargo.BOSHReleaseTarballLock{Name: spec.Name, Version: spec.Version, SHA1: tarballSHA1, RemoteSource: sourceID, RemotePath: remotePath}, nil
+		}
+
+		finder = new(commandsFakes.ReleaseUploaderFinder)
+		finder.Returns(uploader, nil)
+
+		uploadRelease = commands.NewUploadRelease(fs, finder.Spy, log.New(GinkgoWriter, "", 0))
+		executeArgs = []string{"--kilnfile", kilnfilePath, "--upload-target-id", sourceID, "--local-path", tarballPath}
+	})
+
+	JustBeforeEach(func() {
+		Expect(fsWriteYAML(fs, kilnfilePath, cargo.Kilnfile{})).To(Succeed())
+		Expect(fsWriteYAML(fs, kilnfileLockPath, kilnfileLock)).To(Succeed())
+
+		executeErr = uploadRelease.Execute(executeArgs)
+
+		updatedLock = cargo.KilnfileLock{}
+		Expect(fsReadYAML(fs, kilnfileLockPath, &updatedLock)).To(Succeed())
+	})
+
+	It("uploads the tarball and updates the Kilnfile.lock", func() {
+		Expect(executeErr).NotTo(HaveOccurred())
+
+		_, id := finder.ArgsForCall(0)
+		Expect(id).To(Equal(sourceID))
+
+		Expect(uploader.UploadReleaseCallCount()).To(Equal(1))
+		_, spec, _ := uploader.UploadReleaseArgsForCall(0)
+		Expect(spec.Name).To(Equal("bpm"))
+		Expect(spec.Version).To(Equal("1.2.0"))
+		Expect(uploadedBytes).NotTo(BeEmpty())
+
+		Expect(updatedLock.Releases).To(Equal([]cargo.BOSHReleaseTarballLock{
+			{Name: "bpm", Version: "1.2.0", SHA1: tarballSHA1, SHA256: tarballSHA256, RemoteSource: sourceID, RemotePath: remotePath},
+		}))
+	})
+
+	When("a file with a different SHA1 is already at the remote path", func() {
+		BeforeEach(func() {
+			uploader.GetMatchedReleaseReturns(cargo.BOSHReleaseTarballLock{Name: "bpm", Version: "1.2.0", SHA1: "different", RemoteSource: sourceID, RemotePath: remotePath}, nil)
+		})
+
+		It("refuses to overwrite it", func() {
+			Expect(executeErr).To(MatchError(ContainSubstring("refusing to overwrite")))
+			Expect(uploader.UploadReleaseCallCount()).To(Equal(0))
+			Expect(updatedLock).To(Equal(kilnfileLock))
+		})
+	})
+
+	When("the same file is already at the remote path", func() {
+		BeforeEach(func() {
+			uploader.GetMatchedReleaseReturns(cargo.BOSHReleaseTarballLock{Name: "bpm", Version: "1.2.0", RemoteSource: sourceID, RemotePath: remotePath}, nil)
+			uploader.DownloadReleaseStub = func(_ context.Context, dir string, lock cargo.BOSHReleaseTarballLock) (component.Local, error) {
+				return component.Local{Lock: lock.WithSHA1(tarballSHA1), LocalPath: dir + "/bpm-1.2.0.tgz"}, nil
+			}
+		})
+
+		It("downloads it to compare the SHA1 and only updates the Kilnfile.lock", func() {
+			Expect(executeErr).NotTo(HaveOccurred())
+			Expect(uploader.DownloadReleaseCallCount()).To(Equal(1))
+			Expect(uploader.UploadReleaseCallCount()).To(Equal(0))
+			Expect(updatedLock.Releases[0].SHA1).To(Equal(tarballSHA1))
+			Expect(updatedLock.Releases[0].RemoteSource).To(Equal(sourceID))
+		})
+	})
+
+	When("the upload fails", func() {
+		BeforeEach(func() {
+			uploader.UploadReleaseStub = nil
+			uploader.UploadReleaseReturns(cargo.BOSHReleaseTarballLock{}, errors.New("banana"))
+		})
+
+		It("does not change the Kilnfile.lock", func() {
+			Expect(executeErr).To(MatchError(ContainSubstring("banana")))
+			Expect(updatedLock).To(Equal(kilnfileLock))
+		})
+	})
+
+	When("the release source can not upload", func() {
+		BeforeEach(func() {
+			finder.Returns(nil, errors.New("no upload-capable release sources"))
+		})
+
+		It("returns an error", func() {
+			Expect(executeErr).To(MatchError(ContainSubstring("no upload-capable release sources")))
+		})
+	})
+})
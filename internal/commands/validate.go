@@ -15,6 +15,7 @@ type Validate struct {
 	Options struct {
 		flags.Standard
 		ReleaseSourceTypeAllowList []string `long:"allow-release-source-type"`
+		RequireSHA256              bool     `long:"require-sha256" description:"fail when a release in the Kilnfile.lock does not have a sha256"`
 	}
 
 	FS billy.Filesystem
@@ -39,7 +40,7 @@ func (v Validate) Execute(args []string) error {
 		return fmt.Errorf("failed to load kilnfiles: %w", err)
 	}
 
-	errs := cargo.Validate(kf, lock, cargo.ValidateResourceTypeAllowList(v.Options.ReleaseSourceTypeAllowList...))
+	errs := cargo.Validate(kf, lock, cargo.ValidateResourceTypeAllowList(v.Options.ReleaseSourceTypeAllowList...).SetRequireSHA256(v.Options.RequireSHA256))
 	if len(errs) > 0 {
 		return errorList(errs)
 	}
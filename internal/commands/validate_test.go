@@ -68,4 +68,51 @@ release_sources:
 			})
 		})
 	})
+
+	When("a release in the Kilnfile.lock does not have a sha256", func() {
+		BeforeEach(func() {
+			f, err := directory.Create("Kilnfile")
+			Expect(err).NotTo(HaveOccurred())
+			// language=yaml
+			_, _ = io.WriteString(f, `---
+release_sources:
+  - type: "bosh.io"
+releases:
+  - name: "bpm"
+  - name: "uaa"
+`)
+			_ = f.Close()
+		})
+
+		BeforeEach(func() {
+			f, err := directory.Create("Kilnfile.lock")
+			Expect(err).NotTo(HaveOccurred())
+			// language=yaml
+			_, _ = io.WriteString(f, `---
+releases:
+  - name: "bpm"
+    version: "1.2.0"
+    sha1: "some-sha1"
+    sha256: "some-sha256"
+    remote_source: "bosh.io"
+  - name: "uaa"
+    version: "7.0.0"
+    sha1: "some-other-sha1"
+    remote_source: "bosh.io"
+`)
+			_ = f.Close()
+		})
+
+		It("it does not fail", func() {
+			err := validate.Execute([]string{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		When("sha256 is required", func() {
+			It("it does fail", func() {
+				err := validate.Execute([]string{"--require-sha256"})
+				Expect(err).To(MatchError(`release "uaa" missing sha256 in lock`))
+			})
+		})
+	})
 })
@@ -0,0 +1,202 @@
+package commands
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/pivotal-cf/jhanda"
+)
+
+type VerifyBake struct {
+	bake      jhanda.Command
+	outLogger *log.Logger
+
+	Options struct {
+		OutputFile string `short:"o" long:"output-file" description:"path to keep the re-baked tile at (by default it is removed)"`
+	}
+}
+
+func NewVerifyBake(bake jhanda.Command, outLogger *log.Logger) VerifyBake {
+	return VerifyBake{bake: bake, outLogger: outLogger}
+}
+
+func (cmd VerifyBake) Execute(args []string) error {
+	paths, err := jhanda.Parse(&cmd.Options, args)
+	if err != nil {
+		return err
+	}
+	if len(paths) != 2 {
+		return fmt.Errorf("expected two arguments: <bake-record> <tile>: %d arguments passed", len(paths))
+	}
+	recordPath, tilePath := paths[0], paths[1]
+
+	record, err := readBakeRecordFile(recordPath)
+	if err != nil {
+		return err
+	}
+	if record.SourceRevision == "" || record.IsDevBuild() {
+		return fmt.Errorf("bake record %s does not have a source_revision to re-bake from", recordPath)
+	}
+
+	tileSum, err := tileChecksum(tilePath)
+	if err != nil {
+		return fmt.Errorf("failed to calculate checksum: %w", err)
+	}
+
+	repositoryRoot, err := gitOutput(filepath.Dir(recordPath), "rev-parse", "--show-toplevel")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp("", "kiln-verify-bake-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	worktree := filepath.Join(tmp, "source")
+	if _, err := gitOutput(repositoryRoot, "worktree", "add", "--detach", worktree, record.SourceRevision); err != nil {
+		return err
+	}
+	defer func() { _, _ = gitOutput(repositoryRoot, "worktree", "remove", "--force", worktree) }()
+
+	outputFile := cmd.Options.OutputFile
+	if outputFile == "" {
+		outputFile = filepath.Join(tmp, "tile.pivotal")
+	}
+	tileDirectory := filepath.Join(worktree, filepath.FromSlash(record.TileDirectory))
+	sourcePathArgs, err := bakeSourcePathArgs(tileDirectory)
+	if err != nil {
+		return err
+	}
+	if err := cmd.bake.Execute(append(reBakeArgs(record, tileDirectory, outputFile), sourcePathArgs...)); err != nil {
+		return fmt.Errorf("failed to re-bake %s: %w", record.SourceRevision, err)
+	}
+
+	reBakedSum, err := tileChecksum(outputFile)
+	if err != nil {
+		return fmt.Errorf("failed to calculate checksum: %w", err)
+	}
+	cmd.outLogger.Printf("re-baked %s from %s: sha256 %s", record.Name(), record.SourceRevision, reBakedSum)
+
+	if reBakedSum != tileSum {
+		difference, err := firstDifferingZipEntry(outputFile, tilePath)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("the re-baked tile does not match %s (sha256 %s): %s", tilePath, tileSum, difference)
+	}
+
+	switch record.FileChecksum {
+	case "":
+		cmd.outLogger.Printf("warning: bake record %s does not have a file_checksum", recordPath)
+	case reBakedSum:
+	default:
+		return fmt.Errorf("the re-baked tile matches %s but not the bake record file_checksum %s", tilePath, record.FileChecksum)
+	}
+
+	cmd.outLogger.Printf("%s matches the bake record", tilePath)
+	return nil
+}
+
+// bakeSourcePathArgs roots the bake flags that default to a path (base.yml,
+// releases, forms, and so on) in tileDirectory so the worktree is baked rather
+// than the current checkout. The releases directory is created in the worktree
+// for bake to fetch the locked releases into.
+func bakeSourcePathArgs(tileDirectory string) ([]string, error) {
+	var args []string
+	t := reflect.TypeFor[BakeOptions]()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		long, hasLong := field.Tag.Lookup("long")
+		defaultValue, hasDefault := field.Tag.Lookup("default")
+		if field.Anonymous || !hasLong || !hasDefault {
+			continue
+		}
+		for _, value := range strings.Split(defaultValue, ",") {
+			p := filepath.Join(tileDirectory, strings.TrimSpace(value))
+			if long == "releases-directory" {
+				if err := os.MkdirAll(p, 0o755); err != nil {
+					return nil, err
+				}
+			} else if _, err := os.Stat(p); err != nil {
+				continue
+			}
+			args = append(args, "--"+long, p)
+		}
+	}
+	return args, nil
+}
+
+func (cmd VerifyBake) Usage() jhanda.Usage {
+	return jhanda.Usage{
+		Description:      "Verifies a tile against a bake record. The command re-bakes the tile from the bake record's source_revision in a temporary git worktree and compares the SHA256 checksum of the result with the record's file_checksum and the given tile. When the tiles differ it reports the first differing zip entry.\n\nUsage: kiln verify-bake [options] <bake-record> <tile>",
+		ShortDescription: "re-bakes a tile from a bake record and compares the checksums",
+		Flags:            cmd.Options,
+	}
+}
+
+// firstDifferingZipEntry describes the first entry that is not the same in the
+// two zip files.
+func firstDifferingZipEntry(expPath, gotPath string) (string, error) {
+	exp, err := zip.OpenReader(expPath)
+	if err != nil {
+		return "", err
+	}
+	defer closeAndIgnoreError(exp)
+	got, err := zip.OpenReader(gotPath)
+	if err != nil {
+		return "", err
+	}
+	defer closeAndIgnoreError(got)
+
+	for i := 0; i < max(len(exp.File), len(got.File)); i++ {
+		if i >= len(exp.File) {
+			return fmt.Sprintf("zip entry %d %q is not in the re-baked tile", i, got.File[i].Name), nil
+		}
+		if i >= len(got.File) {
+			return fmt.Sprintf("zip entry %d %q is missing", i, exp.File[i].Name), nil
+		}
+		if difference := zipEntryDifference(exp.File[i].FileHeader, got.File[i].FileHeader); difference != "" {
+			return fmt.Sprintf("first differing zip entry %d %q: %s", i, exp.File[i].Name, difference), nil
+		}
+	}
+	return "the zip entries are the same but the archives differ", nil
+}
+
+func zipEntryDifference(exp, got zip.FileHeader) string {
+	switch {
+	case exp.Name != got.Name:
+		return fmt.Sprintf("the tile has %q instead", got.Name)
+	case exp.CRC32 != got.CRC32 || exp.UncompressedSize64 != got.UncompressedSize64:
+		return "the contents differ"
+	case exp.Mode() != got.Mode():
+		return fmt.Sprintf("the mode is %s instead of %s", got.Mode(), exp.Mode())
+	case !exp.Modified.Equal(got.Modified):
+		return fmt.Sprintf("the modification time is %s instead of %s", got.Modified, exp.Modified)
+	case exp.Method != got.Method:
+		return fmt.Sprintf("the compression method is %d instead of %d", got.Method, exp.Method)
+	case !bytes.Equal(exp.Extra, got.Extra) || exp.Comment != got.Comment:
+		return "the zip headers differ"
+	}
+	return ""
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
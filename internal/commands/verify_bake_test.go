@@ -0,0 +1,206 @@
+package commands_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/jhanda"
+
+	"github.com/pivotal-cf/kiln/internal/commands"
+	"github.com/pivotal-cf/kiln/pkg/bake"
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+// fakeTileBake writes a tile with the metadata file, selected like bake does
+// from --metadata and the Kilnfile bake_configurations, as its metadata
+type fakeTileBake struct {
+	args         []string
+	metadataPath string
+}
+
+func (b *fakeTileBake) Execute(args []string) error {
+	b.args = args
+	var options commands.BakeOptions
+	if _, err := jhanda.Parse(&options, args); err != nil {
+		return err
+	}
+	if err := commands.BakeArgumentsFromKilnfileConfiguration(&options, cargo.ReadKilnfile); err != nil {
+		return err
+	}
+	b.metadataPath = options.Metadata
+	metadata, err := os.ReadFile(options.Metadata)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(options.OutputFile, zipWithMetadata(metadata), 0o644)
+}
+
+func (b *fakeTileBake) flag(name string) string {
+	for i := 0; i+1 < len(b.args); i++ {
+		if b.args[i] == name {
+			return b.args[i+1]
+		}
+	}
+	return ""
+}
+
+func (b *fakeTileBake) Usage() jhanda.Usage { return jhanda.Usage{} }
+
+func zipWithMetadata(metadata []byte) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"metadata/metadata.yml", "migrations/v1/"} {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: time.Unix(1e9, 0).UTC()})
+		Expect(err).NotTo(HaveOccurred())
+		if name == "metadata/metadata.yml" {
+			_, _ = f.Write(metadata)
+		}
+	}
+	Expect(zw.Close()).To(Succeed())
+	return buf.Bytes()
+}
+
+var _ = Describe("verify-bake", func() {
+	var (
+		repo       string
+		output     bytes.Buffer
+		fakeBake   *fakeTileBake
+		command    commands.VerifyBake
+		recordPath string
+		record     bake.Record
+
+		git       func(args ...string) string
+		writeFile func(name, content string)
+	)
+
+	BeforeEach(func() {
+		repo = GinkgoT().TempDir()
+		output.Reset()
+		fakeBake = new(fakeTileBake)
+		command = commands.NewVerifyBake(fakeBake, log.New(&output, "", 0))
+
+		git = func(args ...string) string {
+			cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+			cmd.Dir = repo
+			out, err := cmd.CombinedOutput()
+			ExpectWithOffset(1, err).NotTo(HaveOccurred(), "git %v: %s", args, out)
+			return string(bytes.TrimSpace(out))
+		}
+		writeFile = func(name, content string) {
+			name = filepath.Join(repo, name)
+			Expect(os.MkdirAll(filepath.Dir(name), 0o755)).To(Succeed())
+			Expect(os.WriteFile(name, []byte(content), 0o644)).To(Succeed())
+		}
+
+		git("init")
+		writeFile("tile/Kilnfile", "slug: my-tile\n")
+		writeFile("tile/base.yml", "slug: my-tile-v1\n")
+		writeFile("tile/migrations/v1/201603041539_custom_buildpacks.js", "migration\n")
+		git("add", ".")
+		git("commit", "-m", "v1")
+		sum := sha256.Sum256(zipWithMetadata([]byte("slug: my-tile-v1\n")))
+		record = bake.Record{
+			SourceRevision: git("rev-parse", "HEAD"),
+			Version:        "1.0.0",
+			TileDirectory:  "tile",
+			FileChecksum:   hex.EncodeToString(sum[:]),
+		}
+
+		writeFile("tile/base.yml", "slug: my-tile-v2\n")
+		git("commit", "-am", "v2")
+
+		recordPath = filepath.Join(repo, "tile", bake.RecordsDirectory, "1.0.0.json")
+		buf, err := json.Marshal(record)
+		Expect(err).NotTo(HaveOccurred())
+		writeFile(filepath.Join("tile", bake.RecordsDirectory, "1.0.0.json"), string(buf))
+	})
+
+	It("re-bakes the tile from the source revision", func() {
+		writeFile("tile-1.0.0.pivotal", string(zipWithMetadata([]byte("slug: my-tile-v1\n"))))
+
+		Expect(command.Execute([]string{recordPath, filepath.Join(repo, "tile-1.0.0.pivotal")})).To(Succeed())
+
+		Expect(fakeBake.args).To(ContainElements("--version", "1.0.0"))
+		Expect(output.String()).To(ContainSubstring("sha256 " + record.FileChecksum))
+		Expect(output.String()).To(ContainSubstring("tile-1.0.0.pivotal matches the bake record"))
+		Expect(git("worktree", "list")).NotTo(ContainSubstring("kiln-verify-bake"))
+	})
+
+	It("bakes the sources in the worktree", func() {
+		writeFile("tile-1.0.0.pivotal", string(zipWithMetadata([]byte("slug: my-tile-v1\n"))))
+
+		Expect(command.Execute([]string{recordPath, filepath.Join(repo, "tile-1.0.0.pivotal")})).To(Succeed())
+
+		worktreeTileDirectory := filepath.Dir(fakeBake.flag("--kilnfile"))
+		Expect(worktreeTileDirectory).To(ContainSubstring("kiln-verify-bake"))
+		Expect(fakeBake.flag("--metadata")).To(Equal(filepath.Join(worktreeTileDirectory, "base.yml")))
+		Expect(fakeBake.flag("--releases-directory")).To(Equal(filepath.Join(worktreeTileDirectory, "releases")))
+		Expect(fakeBake.flag("--migrations-directory")).To(Equal(filepath.Join(worktreeTileDirectory, "migrations")))
+		Expect(fakeBake.args).NotTo(ContainElement("--forms-directory"))
+	})
+
+	When("the Kilnfile has a bake configuration", func() {
+		BeforeEach(func() {
+			writeFile("tile/Kilnfile", "slug: my-tile\nbake_configurations:\n- tile_name: peach\n  metadata_filepath: peach.yml\n")
+			writeFile("tile/peach.yml", "slug: peach-v1\n")
+			git("add", ".")
+			git("commit", "-m", "peach v1")
+			sum := sha256.Sum256(zipWithMetadata([]byte("slug: peach-v1\n")))
+			record.SourceRevision = git("rev-parse", "HEAD")
+			record.FileChecksum = hex.EncodeToString(sum[:])
+
+			writeFile("tile/peach.yml", "slug: peach-v2\n")
+			git("commit", "-am", "peach v2")
+
+			buf, err := json.Marshal(record)
+			Expect(err).NotTo(HaveOccurred())
+			writeFile(filepath.Join("tile", bake.RecordsDirectory, "1.0.0.json"), string(buf))
+		})
+
+		It("bakes the configured metadata in the worktree", func() {
+			writeFile("tile-1.0.0.pivotal", string(zipWithMetadata([]byte("slug: peach-v1\n"))))
+
+			Expect(command.Execute([]string{recordPath, filepath.Join(repo, "tile-1.0.0.pivotal")})).To(Succeed())
+
+			worktreeTileDirectory := filepath.Dir(fakeBake.flag("--kilnfile"))
+			Expect(fakeBake.metadataPath).To(Equal(filepath.Join(worktreeTileDirectory, "peach.yml")))
+			Expect(output.String()).To(ContainSubstring("tile-1.0.0.pivotal matches the bake record"))
+		})
+	})
+
+	When("the tile differs from the re-baked tile", func() {
+		It("reports the first differing zip entry", func() {
+			writeFile("tile-1.0.0.pivotal", string(zipWithMetadata([]byte("slug: my-tile-v2\n"))))
+
+			err := command.Execute([]string{recordPath, filepath.Join(repo, "tile-1.0.0.pivotal")})
+			Expect(err).To(MatchError(ContainSubstring(`first differing zip entry 0 "metadata/metadata.yml": the contents differ`)))
+		})
+	})
+
+	When("the record file_checksum differs from the re-baked tile", func() {
+		It("returns an error", func() {
+			record.FileChecksum = "some-other-checksum"
+			buf, err := json.Marshal(record)
+			Expect(err).NotTo(HaveOccurred())
+			writeFile(filepath.Join("tile", bake.RecordsDirectory, "1.0.0.json"), string(buf))
+			writeFile("tile-1.0.0.pivotal", string(zipWithMetadata([]byte("slug: my-tile-v1\n"))))
+
+			err = command.Execute([]string{recordPath, filepath.Join(repo, "tile-1.0.0.pivotal")})
+			Expect(err).To(MatchError(ContainSubstring("not the bake record file_checksum some-other-checksum")))
+		})
+	})
+
+	It("requires a bake record and a tile", func() {
+		Expect(command.Execute([]string{recordPath})).To(MatchError(ContainSubstring("expected two arguments")))
+	})
+})
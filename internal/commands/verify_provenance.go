@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/pivotal-cf/jhanda"
+
+	"github.com/pivotal-cf/kiln/pkg/provenance"
+)
+
+type VerifyProvenance struct {
+	outLogger *log.Logger
+
+	Options struct {
+		Key          string `long:"key"           required:"true" description:"path to the PEM encoded public key (or the private key) the provenance was signed with"`
+		KilnfileLock string `long:"kilnfile-lock"                 description:"path to a Kilnfile.lock to check against the provenance"`
+	}
+}
+
+func NewVerifyProvenance(outLogger *log.Logger) VerifyProvenance {
+	return VerifyProvenance{outLogger: outLogger}
+}
+
+func (cmd VerifyProvenance) Execute(args []string) error {
+	paths, err := jhanda.Parse(&cmd.Options, args)
+	if err != nil {
+		return err
+	}
+	if len(paths) != 2 {
+		return fmt.Errorf("expected two arguments: <provenance> <tile>: %d arguments passed", len(paths))
+	}
+	provenancePath, tilePath := paths[0], paths[1]
+
+	key, err := provenance.ReadVerificationKey(cmd.Options.Key)
+	if err != nil {
+		return err
+	}
+	envelope, err := provenance.ReadFile(provenancePath)
+	if err != nil {
+		return err
+	}
+	statement, err := envelope.Verify(key)
+	if err != nil {
+		return fmt.Errorf("failed to verify %s: %w", provenancePath, err)
+	}
+	if err := provenance.VerifyTile(statement, tilePath, cmd.Options.KilnfileLock); err != nil {
+		return fmt.Errorf("%s does not match %s: %w", tilePath, provenancePath, err)
+	}
+
+	keyID, err := provenance.KeyID(key)
+	if err != nil {
+		return err
+	}
+	cmd.outLogger.Printf("%s matches the provenance signed by key %s: baked from %s with %d materials",
+		tilePath, keyID, statement.SourceRevision(), len(statement.Predicate.BuildDefinition.ResolvedDependencies))
+	return nil
+}
+
+func (cmd VerifyProvenance) Usage() jhanda.Usage {
+	return jhanda.Usage{
+		Description:      "Verifies a tile against the signed provenance \"kiln bake --final --provenance-key\" writes next to the bake record. It checks the signature, that the subject digest is the SHA256 checksum of the tile, and that the source revision and release tarball digests match the tile.\n\nUsage: kiln verify-provenance --key <public-key> [options] <provenance> <tile>",
+		ShortDescription: "verifies a tile against its signed provenance",
+		Flags:            cmd.Options,
+	}
+}
@@ -0,0 +1,81 @@
+package commands_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"log"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/pivotal-cf/kiln/internal/commands"
+	"github.com/pivotal-cf/kiln/pkg/provenance"
+)
+
+var _ = Describe("verify-provenance", func() {
+	var (
+		tmp            string
+		tilePath       string
+		provenancePath string
+		publicKeyPath  string
+		output         *gbytes.Buffer
+		command        commands.VerifyProvenance
+	)
+
+	BeforeEach(func() {
+		tmp = GinkgoT().TempDir()
+		tilePath = filepath.Join(tmp, "tile-1.0.0.pivotal")
+		Expect(os.WriteFile(tilePath, zipWithMetadata([]byte("kiln_metadata:\n  metadata_git_sha: some-revision\n")), 0o644)).To(Succeed())
+
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		der, err := x509.MarshalPKIXPublicKey(publicKey)
+		Expect(err).NotTo(HaveOccurred())
+		publicKeyPath = filepath.Join(tmp, "provenance.pub")
+		Expect(os.WriteFile(publicKeyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644)).To(Succeed())
+
+		statement, err := provenance.NewStatement(tilePath, provenance.Build{SourceRevision: "some-revision", Version: "1.0.0"})
+		Expect(err).NotTo(HaveOccurred())
+		envelope, err := provenance.Sign(statement, privateKey)
+		Expect(err).NotTo(HaveOccurred())
+		provenancePath = filepath.Join(tmp, "1.0.0"+provenance.FileExtension)
+		Expect(provenance.WriteFile(provenancePath, envelope)).To(Succeed())
+
+		output = gbytes.NewBuffer()
+		command = commands.NewVerifyProvenance(log.New(output, "", 0))
+	})
+
+	When("the tile matches the provenance", func() {
+		It("succeeds", func() {
+			Expect(command.Execute([]string{"--key", publicKeyPath, provenancePath, tilePath})).To(Succeed())
+			Expect(output).To(gbytes.Say("matches the provenance signed by key .* baked from some-revision"))
+		})
+	})
+
+	When("the tile was changed", func() {
+		It("returns an error", func() {
+			Expect(os.WriteFile(tilePath, zipWithMetadata([]byte("kiln_metadata:\n  metadata_git_sha: other-revision\n")), 0o644)).To(Succeed())
+
+			err := command.Execute([]string{"--key", publicKeyPath, provenancePath, tilePath})
+			Expect(err).To(MatchError(ContainSubstring("the provenance subject does not match the tile sha256")))
+		})
+	})
+
+	When("the provenance was signed with another key", func() {
+		It("returns an error", func() {
+			otherKey, _, err := ed25519.GenerateKey(rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+			der, err := x509.MarshalPKIXPublicKey(otherKey)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.WriteFile(publicKeyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644)).To(Succeed())
+
+			err = command.Execute([]string{"--key", publicKeyPath, provenancePath, tilePath})
+			Expect(err).To(MatchError(ContainSubstring("no signature on the provenance was made by the key")))
+		})
+	})
+})
@@ -2,8 +2,7 @@ package component
 
 import (
 	"bytes"
-	"crypto/sha1"
-	"encoding/hex"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -63,6 +62,7 @@ type ArtifactoryFile struct {
 	URI    string `json:"uri"`
 	Folder bool   `json:"folder"`
 	SHA1   string
+	SHA256 string
 }
 
 // NewArtifactoryReleaseSource will provision a new ArtifactoryReleaseSource Project
@@ -72,8 +72,6 @@ func NewArtifactoryReleaseSource(c cargo.ReleaseSourceConfig, logger *log.Logger
 		panic(panicMessageWrongReleaseSourceType)
 	}
 
-	// ctx := context.TODO()
-
 	if logger == nil {
 		logger = log.New(os.Stderr, "[Artifactory release source] ", log.Default().Flags())
 	}
@@ -86,19 +84,14 @@ func NewArtifactoryReleaseSource(c cargo.ReleaseSourceConfig, logger *log.Logger
 	}
 }
 
-func (ars *ArtifactoryReleaseSource) DownloadRelease(releaseDir string, remoteRelease cargo.BOSHReleaseTarballLock) (Local, error) {
-	u, err := url.Parse(ars.ArtifactoryHost)
+func (ars *ArtifactoryReleaseSource) DownloadRelease(ctx context.Context, releaseDir string, remoteRelease cargo.BOSHReleaseTarballLock) (Local, error) {
+	downloadURL, err := ars.fileURL(remoteRelease.RemotePath)
 	if err != nil {
-		return Local{}, fmt.Errorf("error parsing artifactory host: %w", err)
-	}
-	downloadURL := ars.ArtifactoryHost
-	if path.Base(u.Path) != "artifactory" {
-		downloadURL += "/artifactory"
+		return Local{}, err
 	}
-	downloadURL += "/" + ars.Repo + "/" + strings.ReplaceAll(remoteRelease.RemotePath, "+", "%2B")
 
 	ars.logger.Printf(logLineDownload, remoteRelease.Name, remoteRelease.Version, ReleaseSourceTypeArtifactory, ars.ID)
-	resp, err := ars.getWithAuth(downloadURL)
+	resp, err := ars.getWithAuth(ctx, downloadURL)
 	if err != nil {
 		return Local{}, err
 	}
@@ -116,27 +109,109 @@ func (ars *ArtifactoryReleaseSource) DownloadRelease(releaseDir string, remoteRe
 	}
 	defer closeAndIgnoreError(out)
 
-	hash := sha1.New()
+	digest := newReleaseDigest()
 
-	mw := io.MultiWriter(out, hash)
+	mw := io.MultiWriter(out, digest)
 	_, err = io.Copy(mw, resp.Body)
 	if err != nil {
+		removePartialDownload(out)
 		return Local{}, err
 	}
 
-	remoteRelease.SHA1 = hex.EncodeToString(hash.Sum(nil))
+	remoteRelease = digest.setSums(remoteRelease)
 
 	return Local{Lock: remoteRelease, LocalPath: filePath}, nil
 }
 
+// UploadRelease deploys the release tarball to the path RemotePath returns for
+// spec in the configured Artifactory repository.
+func (ars *ArtifactoryReleaseSource) UploadRelease(ctx context.Context, spec cargo.BOSHReleaseTarballSpecification, file io.Reader) (cargo.BOSHReleaseTarballLock, error) {
+	remotePath, err := ars.RemotePath(spec)
+	if err != nil {
+		return cargo.BOSHReleaseTarballLock{}, err
+	}
+	uploadURL, err := ars.fileURL(remotePath)
+	if err != nil {
+		return cargo.BOSHReleaseTarballLock{}, err
+	}
+
+	ars.logger.Printf("uploading %s %s to %s release source %s", spec.Name, spec.Version, ReleaseSourceTypeArtifactory, ars.ID)
+
+	digest := newReleaseDigest()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPut, uploadURL, io.TeeReader(file, digest))
+	if err != nil {
+		return cargo.BOSHReleaseTarballLock{}, err
+	}
+	if ars.Username != "" {
+		request.SetBasicAuth(ars.Username, ars.Password)
+	}
+	response, err := ars.Client.Do(request)
+	if err != nil {
+		return cargo.BOSHReleaseTarballLock{}, wrapVPNError(err)
+	}
+	defer closeAndIgnoreError(response.Body)
+
+	if err := checkStatus(http.StatusCreated, response.StatusCode); err != nil {
+		return cargo.BOSHReleaseTarballLock{}, fmt.Errorf("failed to upload %s release to artifactory: %w", spec.Name, err)
+	}
+
+	return digest.setSums(cargo.BOSHReleaseTarballLock{
+		Name:            spec.Name,
+		Version:         spec.Version,
+		StemcellOS:      spec.StemcellOS,
+		StemcellVersion: spec.StemcellVersion,
+		RemoteSource:    ars.ID,
+		RemotePath:      remotePath,
+	}), nil
+}
+
+func (ars *ArtifactoryReleaseSource) fileURL(remotePath string) (string, error) {
+	baseURL, err := ars.baseURL()
+	if err != nil {
+		return "", err
+	}
+	return baseURL + "/" + ars.Repo + "/" + strings.ReplaceAll(remotePath, "+", "%2B"), nil
+}
+
+// baseURL returns the artifactory host with the "/artifactory" context path.
+func (ars *ArtifactoryReleaseSource) baseURL() (string, error) {
+	u, err := url.Parse(ars.ArtifactoryHost)
+	if err != nil {
+		return "", fmt.Errorf("error parsing artifactory host: %w", err)
+	}
+	baseURL := ars.ArtifactoryHost
+	if path.Base(u.Path) != "artifactory" {
+		baseURL += "/artifactory"
+	}
+	return baseURL, nil
+}
+
+// Probe requests the configured repository's details with the configured
+// credentials to check the repository exists and is readable.
+func (ars *ArtifactoryReleaseSource) Probe(ctx context.Context) error {
+	baseURL, err := ars.baseURL()
+	if err != nil {
+		return err
+	}
+	resp, err := ars.getWithAuth(ctx, baseURL+"/api/repositories/"+url.PathEscape(ars.Repo))
+	if err != nil {
+		return err
+	}
+	defer closeAndIgnoreError(resp.Body)
+	if err := checkStatus(http.StatusOK, resp.StatusCode); err != nil {
+		return fmt.Errorf("failed to get artifactory repository %s: %w", ars.Repo, err)
+	}
+	return nil
+}
+
 func (ars *ArtifactoryReleaseSource) Configuration() cargo.ReleaseSourceConfig {
 	return ars.ReleaseSourceConfig
 }
 
 // GetMatchedRelease uses the Name and Version and if supported StemcellOS and StemcellVersion
 // fields on Requirement to download a specific release.
-func (ars *ArtifactoryReleaseSource) GetMatchedRelease(spec cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error) {
-	matchedRelease, err := ars.findReleaseVersion(spec, spec)
+func (ars *ArtifactoryReleaseSource) GetMatchedRelease(ctx context.Context, spec cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error) {
+	matchedRelease, err := ars.findReleaseVersion(ctx, spec, spec)
 	if err != nil {
 		return cargo.BOSHReleaseTarballLock{}, wrapVPNError(err)
 	}
@@ -146,14 +221,14 @@ func (ars *ArtifactoryReleaseSource) GetMatchedRelease(spec cargo.BOSHReleaseTar
 
 // FindReleaseVersion may use any of the fields on Requirement to return the best matching
 // release.
-func (ars *ArtifactoryReleaseSource) FindReleaseVersion(spec cargo.BOSHReleaseTarballSpecification, _ bool) (cargo.BOSHReleaseTarballLock, error) {
+func (ars *ArtifactoryReleaseSource) FindReleaseVersion(ctx context.Context, spec cargo.BOSHReleaseTarballSpecification, _ bool) (cargo.BOSHReleaseTarballLock, error) {
 	searchSpec := spec
 	searchSpec.Version = "*" // we need to look at all available versions before deciding on the best match
-	foundRelease, err := ars.findReleaseVersion(spec, searchSpec)
+	foundRelease, err := ars.findReleaseVersion(ctx, spec, searchSpec)
 	return foundRelease, wrapVPNError(err)
 }
 
-func (ars *ArtifactoryReleaseSource) findReleaseVersion(spec, searchSpec cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error) {
+func (ars *ArtifactoryReleaseSource) findReleaseVersion(ctx context.Context, spec, searchSpec cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error) {
 	if spec.StemcellOS != "" {
 		if spec.StemcellVersion == "" {
 			return cargo.BOSHReleaseTarballLock{}, errors.New("stemcell version is required when stemcell os is set")
@@ -173,7 +248,7 @@ func (ars *ArtifactoryReleaseSource) findReleaseVersion(spec, searchSpec cargo.B
 	if err != nil {
 		return cargo.BOSHReleaseTarballLock{}, err
 	}
-	artifactoryFiles, err := ars.searchAql(remoteSearchPath)
+	artifactoryFiles, err := ars.searchAql(ctx, remoteSearchPath)
 	if err != nil {
 		return cargo.BOSHReleaseTarballLock{}, err
 	}
@@ -225,6 +300,7 @@ func (ars *ArtifactoryReleaseSource) findReleaseVersion(spec, searchSpec cargo.B
 					RemotePath:   artifactoryFile.URI,
 					RemoteSource: ars.ReleaseSourceConfig.ID,
 					SHA1:         artifactoryFile.SHA1,
+					SHA256:       artifactoryFile.SHA256,
 				}
 			} else {
 				foundVersion, _ := semver.NewVersion(foundRelease.Version) // foundRelease.Version was already validated
@@ -235,6 +311,7 @@ func (ars *ArtifactoryReleaseSource) findReleaseVersion(spec, searchSpec cargo.B
 						RemotePath:   artifactoryFile.URI,
 						RemoteSource: ars.ReleaseSourceConfig.ID,
 						SHA1:         artifactoryFile.SHA1,
+						SHA256:       artifactoryFile.SHA256,
 					}
 				}
 			}
@@ -292,8 +369,8 @@ func (ars *ArtifactoryReleaseSource) pathTemplate() *template.Template {
 			Parse(ars.PathTemplate))
 }
 
-func (ars *ArtifactoryReleaseSource) getWithAuth(url string) (*http.Response, error) {
-	request, err := http.NewRequest(http.MethodGet, url, nil)
+func (ars *ArtifactoryReleaseSource) getWithAuth(ctx context.Context, url string) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -315,8 +392,8 @@ func aqlQuery(repo, pathPattern string) string {
 		fileMatcher)
 }
 
-func (ars *ArtifactoryReleaseSource) searchAql(pathPattern string) ([]ArtifactoryFile, error) {
-	am, err := ars.buildArtifactoryServiceManager()
+func (ars *ArtifactoryReleaseSource) searchAql(ctx context.Context, pathPattern string) ([]ArtifactoryFile, error) {
+	am, err := ars.buildArtifactoryServiceManager(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -351,12 +428,13 @@ func (ars *ArtifactoryReleaseSource) searchAql(pathPattern string) ([]Artifactor
 			URI:    path.Join(result.Path, result.Name),
 			Folder: false,
 			SHA1:   result.SHA1,
+			SHA256: result.SHA256,
 		})
 	}
 	return arFiles, nil
 }
 
-func (ars *ArtifactoryReleaseSource) buildArtifactoryServiceManager() (artifactory.ArtifactoryServicesManager, error) {
+func (ars *ArtifactoryReleaseSource) buildArtifactoryServiceManager(ctx context.Context) (artifactory.ArtifactoryServicesManager, error) {
 	rtDetails := auth.NewArtifactoryDetails()
 	rtDetails.SetUser(ars.Username)
 	rtDetails.SetPassword(ars.Password)
@@ -370,7 +448,7 @@ func (ars *ArtifactoryReleaseSource) buildArtifactoryServiceManager() (artifacto
 	rtDetails.SetClient(jfHttpClient)
 
 	configBuilder := config.NewConfigBuilder()
-	configuration, err := configBuilder.SetServiceDetails(rtDetails).SetHttpRetries(3).SetHttpRetryWaitMilliSecs(100).Build()
+	configuration, err := configBuilder.SetServiceDetails(rtDetails).SetContext(ctx).SetHttpRetries(3).SetHttpRetryWaitMilliSecs(100).Build()
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package component_test
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,6 +11,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/julienschmidt/httprouter"
 	. "github.com/onsi/ginkgo/v2"
@@ -142,7 +144,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 					}), requireAuth))
 				})
 				It("resolves the lock from the spec", func() { // testing GetMatchedRelease
-					resultLock, resultErr := source.GetMatchedRelease(cargo.BOSHReleaseTarballSpecification{
+					resultLock, resultErr := source.GetMatchedRelease(context.Background(), cargo.BOSHReleaseTarballSpecification{
 						Name:            "mango",
 						Version:         "2.3.4",
 						StemcellOS:      "smoothie",
@@ -162,7 +164,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 				})
 
 				It("finds the bosh release", func() { // testing FindReleaseVersion
-					resultLock, resultErr := source.FindReleaseVersion(cargo.BOSHReleaseTarballSpecification{
+					resultLock, resultErr := source.FindReleaseVersion(context.Background(), cargo.BOSHReleaseTarballSpecification{
 						Name:            "mango",
 						Version:         "*",
 						StemcellOS:      "smoothie",
@@ -183,7 +185,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 
 				It("downloads the release", func() { // teesting DownloadRelease
 					By("calling FindReleaseVersion")
-					local, resultErr := source.DownloadRelease(releasesDirectory, cargo.BOSHReleaseTarballLock{
+					local, resultErr := source.DownloadRelease(context.Background(), releasesDirectory, cargo.BOSHReleaseTarballLock{
 						Name:            "mango",
 						Version:         "2.3.4",
 						StemcellOS:      "smoothie",
@@ -205,7 +207,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 
 					It("downloads the release", func() {
 						By("calling FindReleaseVersion")
-						local, resultErr := source.DownloadRelease(releasesDirectory, cargo.BOSHReleaseTarballLock{
+						local, resultErr := source.DownloadRelease(context.Background(), releasesDirectory, cargo.BOSHReleaseTarballLock{
 							Name:            "mango",
 							Version:         "2.3.4",
 							StemcellOS:      "smoothie",
@@ -226,7 +228,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 						source.Client = server.Client()
 					})
 					It("returns an error", func() {
-						local, resultErr := source.DownloadRelease(releasesDirectory, cargo.BOSHReleaseTarballLock{
+						local, resultErr := source.DownloadRelease(context.Background(), releasesDirectory, cargo.BOSHReleaseTarballLock{
 							Name:            "mango",
 							Version:         "2.3.4",
 							StemcellOS:      "smoothie",
@@ -297,7 +299,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 				})
 
 				It("resolves the lock from the spec", func() { // testing GetMatchedRelease
-					resultLock, resultErr := source.GetMatchedRelease(cargo.BOSHReleaseTarballSpecification{
+					resultLock, resultErr := source.GetMatchedRelease(context.Background(), cargo.BOSHReleaseTarballSpecification{
 						Name:    "mango",
 						Version: "2.3.4",
 					})
@@ -313,7 +315,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 				})
 
 				It("finds the bosh release", func() { // testing FindReleaseVersion
-					resultLock, resultErr := source.FindReleaseVersion(cargo.BOSHReleaseTarballSpecification{
+					resultLock, resultErr := source.FindReleaseVersion(context.Background(), cargo.BOSHReleaseTarballSpecification{
 						Name:    "mango",
 						Version: "2.3.4",
 						//StemcellOS:      "smoothie",
@@ -334,7 +336,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 
 				It("downloads the release", func() { // testing DownloadRelease
 					By("calling FindReleaseVersion")
-					local, resultErr := source.DownloadRelease(releasesDirectory, cargo.BOSHReleaseTarballLock{
+					local, resultErr := source.DownloadRelease(context.Background(), releasesDirectory, cargo.BOSHReleaseTarballLock{
 						Name:         "mango",
 						Version:      "2.3.4",
 						RemotePath:   "bosh-releases/mango-2.3.4.tgz",
@@ -346,7 +348,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 				})
 
 				It("still finds the release when the spec has stemcell info from the lock file", func() {
-					resultLock, resultErr := source.FindReleaseVersion(cargo.BOSHReleaseTarballSpecification{
+					resultLock, resultErr := source.FindReleaseVersion(context.Background(), cargo.BOSHReleaseTarballSpecification{
 						Name:            "mango",
 						Version:         "*",
 						StemcellOS:      "smoothie",
@@ -373,7 +375,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 
 					It("downloads the release", func() {
 						By("calling FindReleaseVersion")
-						local, resultErr := source.DownloadRelease(releasesDirectory, cargo.BOSHReleaseTarballLock{
+						local, resultErr := source.DownloadRelease(context.Background(), releasesDirectory, cargo.BOSHReleaseTarballLock{
 							Name:         "mango",
 							Version:      "2.3.4",
 							RemotePath:   "bosh-releases/mango-2.3.4.tgz",
@@ -392,7 +394,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 						source.Client = server.Client()
 					})
 					It("returns an error", func() {
-						local, resultErr := source.DownloadRelease(releasesDirectory, cargo.BOSHReleaseTarballLock{
+						local, resultErr := source.DownloadRelease(context.Background(), releasesDirectory, cargo.BOSHReleaseTarballLock{
 							Name:         "mango",
 							Version:      "2.3.4",
 							RemotePath:   "bosh-releases/smoothie/9.9/mango/mango-2.3.4.tgz",
@@ -458,7 +460,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 				})
 				When("we allow pre-releases", func() {
 					It("finds the latest version", func() {
-						resultLock, resultErr := source.FindReleaseVersion(cargo.BOSHReleaseTarballSpecification{
+						resultLock, resultErr := source.FindReleaseVersion(context.Background(), cargo.BOSHReleaseTarballSpecification{
 							Name:            "mango",
 							Version:         ">0-0",
 							StemcellOS:      "smoothie",
@@ -479,7 +481,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 				})
 				When("we disallow pre-releases", func() {
 					It("finds the latest bosh version", func() { // testing FindReleaseVersion
-						resultLock, resultErr := source.FindReleaseVersion(cargo.BOSHReleaseTarballSpecification{
+						resultLock, resultErr := source.FindReleaseVersion(context.Background(), cargo.BOSHReleaseTarballSpecification{
 							Name:            "mango",
 							Version:         "*",
 							StemcellOS:      "smoothie",
@@ -552,7 +554,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 				})
 				When("we allow pre-releases", func() {
 					It("finds the latest version", func() { // testing FindReleaseVersion
-						resultLock, resultErr := source.FindReleaseVersion(cargo.BOSHReleaseTarballSpecification{
+						resultLock, resultErr := source.FindReleaseVersion(context.Background(), cargo.BOSHReleaseTarballSpecification{
 							Name:            "mango",
 							Version:         ">=0.0.0-build.0",
 							StemcellOS:      "smoothie",
@@ -571,7 +573,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 				})
 				When("dont allow pre-releases", func() {
 					It("returns ErrNotFound", func() {
-						_, resultErr := source.FindReleaseVersion(cargo.BOSHReleaseTarballSpecification{
+						_, resultErr := source.FindReleaseVersion(context.Background(), cargo.BOSHReleaseTarballSpecification{
 							Name:            "mango",
 							Version:         "*",
 							StemcellOS:      "smoothie",
@@ -641,7 +643,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 					}), requireAuth))
 				})
 				It("resolves the lock from the spec", func() { // testing GetMatchedRelease
-					resultLock, resultErr := source.GetMatchedRelease(cargo.BOSHReleaseTarballSpecification{
+					resultLock, resultErr := source.GetMatchedRelease(context.Background(), cargo.BOSHReleaseTarballSpecification{
 						Name:            "mango",
 						Version:         "2.3.4",
 						StemcellOS:      "smoothie",
@@ -661,7 +663,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 				})
 
 				It("finds the bosh release", func() { // testing FindReleaseVersion
-					resultLock, resultErr := source.FindReleaseVersion(cargo.BOSHReleaseTarballSpecification{
+					resultLock, resultErr := source.FindReleaseVersion(context.Background(), cargo.BOSHReleaseTarballSpecification{
 						Name:            "mango",
 						Version:         "2.3.4",
 						StemcellOS:      "smoothie",
@@ -682,7 +684,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 
 				It("downloads the release", func() { // teesting DownloadRelease
 					By("calling FindReleaseVersion")
-					local, resultErr := source.DownloadRelease(releasesDirectory, cargo.BOSHReleaseTarballLock{
+					local, resultErr := source.DownloadRelease(context.Background(), releasesDirectory, cargo.BOSHReleaseTarballLock{
 						Name:         "mango",
 						Version:      "2.3.4",
 						RemotePath:   "bosh-releases/smoothie/9.9/mango/mango-2.3.4-smoothie-9.9.tgz",
@@ -702,7 +704,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 
 					It("downloads the release", func() {
 						By("calling FindReleaseVersion")
-						local, resultErr := source.DownloadRelease(releasesDirectory, cargo.BOSHReleaseTarballLock{
+						local, resultErr := source.DownloadRelease(context.Background(), releasesDirectory, cargo.BOSHReleaseTarballLock{
 							Name:         "mango",
 							Version:      "2.3.4",
 							RemotePath:   "bosh-releases/smoothie/9.9/mango/mango-2.3.4-smoothie-9.9.tgz",
@@ -721,7 +723,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 						source.Client = server.Client()
 					})
 					It("returns an error", func() {
-						local, resultErr := source.DownloadRelease(releasesDirectory, cargo.BOSHReleaseTarballLock{
+						local, resultErr := source.DownloadRelease(context.Background(), releasesDirectory, cargo.BOSHReleaseTarballLock{
 							Name:         "mango",
 							Version:      "2.3.4",
 							RemotePath:   "bosh-releases/smoothie/9.9/mango/mango-2.3.4-smoothie-9.9.tgz",
@@ -736,6 +738,88 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 		})
 	})
 
+	Describe("write operations", func() {
+		var (
+			uploadedBody []byte
+			uploadedPath string
+		)
+		BeforeEach(func() {
+			uploadedBody, uploadedPath = nil, ""
+			requireAuth := requireBasicAuthMiddleware(correctUsername, correctPassword)
+			artifactoryRouter.Handler(http.MethodPut, "/artifactory/basket/*path", applyMiddleware(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+				uploadedPath = req.URL.Path
+				uploadedBody = must(io.ReadAll(req.Body))
+				res.WriteHeader(http.StatusCreated)
+			}), requireAuth))
+		})
+
+		It("uploads the release to the path from the path template", func() {
+			var _ component.ReleaseUploader = source
+
+			lock, err := source.UploadRelease(context.Background(), cargo.BOSHReleaseTarballSpecification{
+				Name:            "mango",
+				Version:         "2.3.4",
+				StemcellOS:      "smoothie",
+				StemcellVersion: "9.9",
+			}, strings.NewReader("lemon"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(uploadedPath).To(Equal("/artifactory/basket/bosh-releases/smoothie/9.9/mango/mango-2.3.4-smoothie-9.9.tgz"))
+			Expect(string(uploadedBody)).To(Equal("lemon"))
+			Expect(lock).To(Equal(cargo.BOSHReleaseTarballLock{
+				Name:            "mango",
+				Version:         "2.3.4",
+				StemcellOS:      "smoothie",
+				StemcellVersion: "9.9",
+				SHA1:            "dfdd7bce2ad9f89d7204dd83161d66d1e521759c",
+				SHA256:          "f464d7d71c06e47a535ce441aa202aa717cddeab902a45b0c283aac7a9a090d7",
+				RemoteSource:    "some-mango-tree",
+				RemotePath:      "bosh-releases/smoothie/9.9/mango/mango-2.3.4-smoothie-9.9.tgz",
+			}))
+		})
+
+		When("the server does not accept the upload", func() {
+			BeforeEach(func() {
+				config.Password = "wrong"
+			})
+
+			It("returns an error", func() {
+				_, err := source.UploadRelease(context.Background(), cargo.BOSHReleaseTarballSpecification{
+					Name:            "mango",
+					Version:         "2.3.4",
+					StemcellOS:      "smoothie",
+					StemcellVersion: "9.9",
+				}, strings.NewReader("lemon"))
+				Expect(err).To(MatchError(ContainSubstring("failed to upload mango release to artifactory")))
+			})
+		})
+	})
+
+	Describe("Probe", func() {
+		BeforeEach(func() {
+			requireAuth := requireBasicAuthMiddleware(correctUsername, correctPassword)
+			artifactoryRouter.Handler(http.MethodGet, "/artifactory/api/repositories/basket", applyMiddleware(http.HandlerFunc(func(res http.ResponseWriter, _ *http.Request) {
+				res.WriteHeader(http.StatusOK)
+				_, _ = res.Write([]byte(`{"key": "basket"}`))
+			}), requireAuth))
+		})
+
+		It("gets the repository with the configured credentials", func() {
+			var prober component.ReleaseSourceProber = source
+			Expect(prober.Probe(context.Background())).To(Succeed())
+		})
+
+		When("the credentials are wrong", func() {
+			BeforeEach(func() {
+				config.Password = "wrong"
+			})
+
+			It("returns an error", func() {
+				Expect(source.Probe(context.Background())).To(MatchError(ContainSubstring("failed to get artifactory repository basket")))
+			})
+		})
+	})
+
 	When("not behind the corporate firewall", func() {
 		BeforeEach(func() {
 			requireAuth := requireBasicAuthMiddleware(correctUsername, correctPassword)
@@ -748,7 +832,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 		})
 		Describe("GetMatchedRelease", func() {
 			It("returns a helpful message", func() {
-				_, resultErr := source.GetMatchedRelease(cargo.BOSHReleaseTarballSpecification{
+				_, resultErr := source.GetMatchedRelease(context.Background(), cargo.BOSHReleaseTarballSpecification{
 					Name:            "mango",
 					Version:         "2.3.4",
 					StemcellOS:      "smoothie",
@@ -760,7 +844,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 		})
 		Describe("FindReleaseVersion", func() {
 			It("returns a helpful message", func() {
-				_, resultErr := source.FindReleaseVersion(cargo.BOSHReleaseTarballSpecification{
+				_, resultErr := source.FindReleaseVersion(context.Background(), cargo.BOSHReleaseTarballSpecification{
 					Name:            "mango",
 					Version:         "2.3.4",
 					StemcellOS:      "smoothie",
@@ -772,7 +856,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 		})
 		Describe("DownloadRelease", func() {
 			It("returns a helpful message", func() {
-				_, resultErr := source.DownloadRelease(releasesDirectory, cargo.BOSHReleaseTarballLock{
+				_, resultErr := source.DownloadRelease(context.Background(), releasesDirectory, cargo.BOSHReleaseTarballLock{
 					Name:         "mango",
 					Version:      "2.3.4",
 					RemotePath:   "bosh-releases/smoothie/9.9/mango/mango-2.3.4-smoothie-9.9.tgz",
@@ -807,7 +891,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 			})
 
 			It("does not match a file where the dot is replaced by another character", func() {
-				_, resultErr := source.GetMatchedRelease(cargo.BOSHReleaseTarballSpecification{
+				_, resultErr := source.GetMatchedRelease(context.Background(), cargo.BOSHReleaseTarballSpecification{
 					Name:    "my.release",
 					Version: "2.3.4",
 				})
@@ -839,7 +923,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 			})
 
 			It("does not match the file", func() {
-				_, resultErr := source.GetMatchedRelease(cargo.BOSHReleaseTarballSpecification{
+				_, resultErr := source.GetMatchedRelease(context.Background(), cargo.BOSHReleaseTarballSpecification{
 					Name:    "mango",
 					Version: "2.3.4",
 				})
@@ -870,7 +954,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 				}), requireAuth))
 			})
 			It("returns ErrNotFound", func() {
-				_, resultErr := source.FindReleaseVersion(cargo.BOSHReleaseTarballSpecification{
+				_, resultErr := source.FindReleaseVersion(context.Background(), cargo.BOSHReleaseTarballSpecification{
 					Name:            "missing-release",
 					Version:         "1.2.3",
 					StemcellOS:      "ubuntu-jammy",
@@ -878,7 +962,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 				}, false)
 				Expect(component.IsErrNotFound(resultErr)).To(BeTrue())
 
-				_, resultErr = source.GetMatchedRelease(cargo.BOSHReleaseTarballSpecification{
+				_, resultErr = source.GetMatchedRelease(context.Background(), cargo.BOSHReleaseTarballSpecification{
 					Name:            "missing-release",
 					Version:         "1.2.3",
 					StemcellOS:      "ubuntu-jammy",
@@ -941,7 +1025,7 @@ var _ = Describe("interacting with BOSH releases on Artifactory", func() {
 				}), requireAuth))
 			})
 			It("returns ErrNotFound", func() { // testing FindReleaseVersion
-				_, resultErr := source.FindReleaseVersion(cargo.BOSHReleaseTarballSpecification{
+				_, resultErr := source.FindReleaseVersion(context.Background(), cargo.BOSHReleaseTarballSpecification{
 					Name:            "mango",
 					Version:         "2.3.4",
 					StemcellOS:      "smoothie",
@@ -1,8 +1,7 @@
 package component
 
 import (
-	"crypto/sha1"
-	"encoding/hex"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -80,19 +79,36 @@ func (src BOSHIOReleaseSource) Configuration() cargo.ReleaseSourceConfig {
 	return src.ReleaseSourceConfig
 }
 
+// Probe requests the bosh.io release index page.
+func (src BOSHIOReleaseSource) Probe(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src.serverURI+"/releases", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("bosh.io is down with error: %w", err)
+	}
+	defer closeAndIgnoreError(resp.Body)
+	if err := checkStatus(http.StatusOK, resp.StatusCode); err != nil {
+		return fmt.Errorf("failed to get bosh.io release index: %w", err)
+	}
+	return nil
+}
+
 func unsetStemcell(spec cargo.BOSHReleaseTarballSpecification) cargo.BOSHReleaseTarballSpecification {
 	spec.StemcellOS = ""
 	spec.StemcellVersion = ""
 	return spec
 }
 
-func (src BOSHIOReleaseSource) GetMatchedRelease(requirement cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error) {
+func (src BOSHIOReleaseSource) GetMatchedRelease(ctx context.Context, requirement cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error) {
 	requirement = unsetStemcell(requirement)
 
 	for _, repo := range repos {
 		for _, suf := range suffixes {
 			fullName := repo + "/" + requirement.Name + suf
-			exists, remoteSha, err := src.releaseExistOnBoshio(fullName, requirement.Version)
+			exists, remoteSha, err := src.releaseExistOnBoshio(ctx, fullName, requirement.Version)
 			if err != nil {
 				return cargo.BOSHReleaseTarballLock{}, err
 			}
@@ -107,7 +123,7 @@ func (src BOSHIOReleaseSource) GetMatchedRelease(requirement cargo.BOSHReleaseTa
 	return cargo.BOSHReleaseTarballLock{}, ErrNotFound
 }
 
-func (src BOSHIOReleaseSource) FindReleaseVersion(spec cargo.BOSHReleaseTarballSpecification, _ bool) (cargo.BOSHReleaseTarballLock, error) {
+func (src BOSHIOReleaseSource) FindReleaseVersion(ctx context.Context, spec cargo.BOSHReleaseTarballSpecification, _ bool) (cargo.BOSHReleaseTarballLock, error) {
 	spec = unsetStemcell(spec)
 
 	constraint, err := spec.VersionConstraints()
@@ -120,7 +136,7 @@ func (src BOSHIOReleaseSource) FindReleaseVersion(spec cargo.BOSHReleaseTarballS
 	for _, repo := range repos {
 		for _, suf := range suffixes {
 			fullName := repo + "/" + spec.Name + suf
-			releaseResponses, err := src.getReleases(fullName)
+			releaseResponses, err := src.getReleases(ctx, fullName)
 			if err != nil {
 				return cargo.BOSHReleaseTarballLock{}, err
 			}
@@ -143,15 +159,20 @@ func (src BOSHIOReleaseSource) FindReleaseVersion(spec cargo.BOSHReleaseTarballS
 	return cargo.BOSHReleaseTarballLock{}, ErrNotFound
 }
 
-func (src BOSHIOReleaseSource) DownloadRelease(releaseDir string, remoteRelease cargo.BOSHReleaseTarballLock) (Local, error) {
+func (src BOSHIOReleaseSource) DownloadRelease(ctx context.Context, releaseDir string, remoteRelease cargo.BOSHReleaseTarballLock) (Local, error) {
 	src.logger.Printf(logLineDownload, remoteRelease.Name, remoteRelease.Version, ReleaseSourceTypeBOSHIO, src.ID())
 
 	downloadURL := remoteRelease.RemotePath
 
-	resp, err := http.Get(downloadURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
 	if err != nil {
 		return Local{}, err
 	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Local{}, err
+	}
+	defer closeAndIgnoreError(resp.Body)
 
 	filePath := filepath.Join(releaseDir, fmt.Sprintf("%s-%s.tgz", remoteRelease.Name, remoteRelease.Version))
 
@@ -161,16 +182,16 @@ func (src BOSHIOReleaseSource) DownloadRelease(releaseDir string, remoteRelease
 	}
 	defer closeAndIgnoreError(out)
 
-	hash := sha1.New()
+	digest := newReleaseDigest()
 
-	mw := io.MultiWriter(out,hash)
+	mw := io.MultiWriter(out, digest)
 	_, err = io.Copy(mw, resp.Body)
-	_ = resp.Body.Close()
 	if err != nil {
+		removePartialDownload(out)
 		return Local{}, err
 	}
 
-	remoteRelease.SHA1 = hex.EncodeToString(hash.Sum(nil))
+	remoteRelease = digest.setSums(remoteRelease)
 
 	return Local{Lock: remoteRelease, LocalPath: filePath}, nil
 }
@@ -189,8 +210,12 @@ func (src BOSHIOReleaseSource) createReleaseRemote(spec cargo.BOSHReleaseTarball
 	return releaseRemote
 }
 
-func (src BOSHIOReleaseSource) getReleases(name string) ([]releaseResponse, error) {
-	resp, err := http.Get(fmt.Sprintf("%s/api/v1/releases/github.com/%s", src.serverURI, name))
+func (src BOSHIOReleaseSource) getReleases(ctx context.Context, name string) ([]releaseResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/api/v1/releases/github.com/%s", src.serverURI, name), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("bosh.io API is down with error: %w", err)
 	}
@@ -228,8 +253,8 @@ type releaseResponse struct {
 	SHA1    string `json:"sha1"`
 }
 
-func (src BOSHIOReleaseSource) releaseExistOnBoshio(name, version string) (bool, string, error) {
-	releaseResponses, err := src.getReleases(name)
+func (src BOSHIOReleaseSource) releaseExistOnBoshio(ctx context.Context, name, version string) (bool, string, error) {
+	releaseResponses, err := src.getReleases(ctx, name)
 	if err != nil {
 		return false, "", err
 	}
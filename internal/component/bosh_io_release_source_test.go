@@ -1,7 +1,9 @@
 package component_test
 
 import (
+	"context"
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"io"
@@ -11,6 +13,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -61,7 +64,7 @@ var _ = Describe("BOSHIOReleaseSource", func() {
 				uaaRequirement := cargo.BOSHReleaseTarballSpecification{Name: "uaa", Version: "73.3.0", StemcellOS: os, StemcellVersion: version}
 				rabbitmqRequirement := cargo.BOSHReleaseTarballSpecification{Name: "cf-rabbitmq", Version: "268.0.0", StemcellOS: os, StemcellVersion: version}
 
-				foundRelease, err := releaseSource.GetMatchedRelease(uaaRequirement)
+				foundRelease, err := releaseSource.GetMatchedRelease(context.Background(), uaaRequirement)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(component.IsErrNotFound(err)).To(BeFalse())
 				uaaURL := fmt.Sprintf("%s/d/github.com/cloudfoundry/uaa-release?v=73.3.0", testServer.URL())
@@ -73,7 +76,7 @@ var _ = Describe("BOSHIOReleaseSource", func() {
 					SHA1:         "b6e8a9cbc8724edcecb8658fa9459ee6c8fc259e",
 				}))
 
-				foundRelease, err = releaseSource.GetMatchedRelease(rabbitmqRequirement)
+				foundRelease, err = releaseSource.GetMatchedRelease(context.Background(), rabbitmqRequirement)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(component.IsErrNotFound(err)).To(BeFalse())
 				cfRabbitURL := fmt.Sprintf("%s/d/github.com/pivotal-cf/cf-rabbitmq-release?v=268.0.0", testServer.URL())
@@ -108,7 +111,7 @@ var _ = Describe("BOSHIOReleaseSource", func() {
 
 			It("doesn't find releases which don't exist on bosh.io", func() {
 				zzzRequirement := cargo.BOSHReleaseTarballSpecification{Name: "zzz", Version: "999", StemcellOS: "ubuntu-xenial", StemcellVersion: "190.0.0"}
-				_, err := releaseSource.GetMatchedRelease(zzzRequirement)
+				_, err := releaseSource.GetMatchedRelease(context.Background(), zzzRequirement)
 				Expect(err).To(HaveOccurred())
 				Expect(component.IsErrNotFound(err)).To(BeTrue())
 			})
@@ -136,7 +139,7 @@ var _ = Describe("BOSHIOReleaseSource", func() {
 			})
 
 			It("does not match that release", func() {
-				_, err := releaseSource.GetMatchedRelease(cargo.BOSHReleaseTarballSpecification{
+				_, err := releaseSource.GetMatchedRelease(context.Background(), cargo.BOSHReleaseTarballSpecification{
 					Name:            releaseName,
 					Version:         releaseVersion,
 					StemcellOS:      "ignored",
@@ -182,7 +185,7 @@ var _ = Describe("BOSHIOReleaseSource", func() {
 						StemcellVersion: "4.5.6",
 					}
 
-					foundRelease, err := releaseSource.GetMatchedRelease(releaseRequirement)
+					foundRelease, err := releaseSource.GetMatchedRelease(context.Background(), releaseRequirement)
 
 					Expect(err).NotTo(HaveOccurred())
 
@@ -212,6 +215,32 @@ var _ = Describe("BOSHIOReleaseSource", func() {
 		})
 	})
 
+	Describe("Probe", func() {
+		var testServer *ghttp.Server
+
+		BeforeEach(func() {
+			testServer = ghttp.NewServer()
+		})
+
+		AfterEach(func() {
+			testServer.Close()
+		})
+
+		It("requests the release index", func() {
+			testServer.RouteToHandler("GET", "/releases", ghttp.RespondWith(http.StatusOK, "<html></html>"))
+			var prober component.ReleaseSourceProber = component.NewBOSHIOReleaseSource(cargo.ReleaseSourceConfig{ID: ID}, testServer.URL(), log.New(GinkgoWriter, "", 0))
+			Expect(prober.Probe(context.Background())).To(Succeed())
+		})
+
+		When("bosh.io responds with an error", func() {
+			It("returns an error", func() {
+				testServer.RouteToHandler("GET", "/releases", ghttp.RespondWith(http.StatusBadGateway, ""))
+				releaseSource := component.NewBOSHIOReleaseSource(cargo.ReleaseSourceConfig{ID: ID}, testServer.URL(), log.New(GinkgoWriter, "", 0))
+				Expect(releaseSource.Probe(context.Background())).To(MatchError(ContainSubstring("failed to get bosh.io release index")))
+			})
+		})
+	})
+
 	Describe("DownloadRelease", func() {
 		const (
 			release1Filename           = "some-1.2.3.tgz"
@@ -226,7 +255,7 @@ var _ = Describe("BOSHIOReleaseSource", func() {
 			release1ID cargo.BOSHReleaseTarballSpecification
 			release1   cargo.BOSHReleaseTarballLock
 
-			release1Sha1 string
+			release1Sha1, release1Sha256 string
 		)
 
 		BeforeEach(func() {
@@ -246,6 +275,7 @@ var _ = Describe("BOSHIOReleaseSource", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			release1Sha1 = hex.EncodeToString(hash.Sum(nil))
+			release1Sha256 = fmt.Sprintf("%x", sha256.Sum256([]byte(release1ServerFileContents)))
 
 			testServer.RouteToHandler("GET", release1ServerPath,
 				ghttp.RespondWith(http.StatusOK, release1ServerFileContents,
@@ -260,7 +290,7 @@ var _ = Describe("BOSHIOReleaseSource", func() {
 		})
 
 		It("downloads the given releases into the release dir", func() {
-			localRelease, err := releaseSource.DownloadRelease(releaseDir, release1)
+			localRelease, err := releaseSource.DownloadRelease(context.Background(), releaseDir, release1)
 
 			Expect(err).NotTo(HaveOccurred())
 
@@ -273,6 +303,7 @@ var _ = Describe("BOSHIOReleaseSource", func() {
 
 			lock := release1ID.Lock()
 			lock.SHA1 = release1Sha1
+			lock.SHA256 = release1Sha256
 			Expect(localRelease).To(Equal(
 				component.Local{
 					Lock: lock.WithRemote(
@@ -283,6 +314,26 @@ var _ = Describe("BOSHIOReleaseSource", func() {
 				},
 			))
 		})
+
+		When("the context is canceled during the download", func() {
+			BeforeEach(func() {
+				testServer.RouteToHandler("GET", "/slow-release", func(w http.ResponseWriter, r *http.Request) {
+					_, _ = w.Write([]byte("totes-a-"))
+					w.(http.Flusher).Flush()
+					<-r.Context().Done()
+				})
+				release1.RemotePath = testServer.URL() + "/slow-release"
+			})
+
+			It("removes the partially written file", func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+				defer cancel()
+
+				_, err := releaseSource.DownloadRelease(ctx, releaseDir, release1)
+				Expect(err).To(MatchError(context.DeadlineExceeded))
+				Expect(filepath.Join(releaseDir, release1Filename)).NotTo(BeAnExistingFile())
+			})
+		})
 	})
 
 	Describe("FindReleaseVersion from bosh.io", func() {
@@ -308,7 +359,7 @@ var _ = Describe("BOSHIOReleaseSource", func() {
 				It("gets the latest version from bosh.io", func() {
 					rabbitmqRequirement := cargo.BOSHReleaseTarballSpecification{Name: "cf-rabbitmq"}
 
-					foundRelease, err := releaseSource.FindReleaseVersion(rabbitmqRequirement, false)
+					foundRelease, err := releaseSource.FindReleaseVersion(context.Background(), rabbitmqRequirement, false)
 					Expect(err).NotTo(HaveOccurred())
 					cfRabbitURL := fmt.Sprintf("%s/d/github.com/cloudfoundry/cf-rabbitmq-release?v=309.0.5", testServer.URL())
 					Expect(foundRelease).To(Equal(cargo.BOSHReleaseTarballLock{
@@ -324,7 +375,7 @@ var _ = Describe("BOSHIOReleaseSource", func() {
 				It("gets the latest version from bosh.io", func() {
 					rabbitmqRequirement := cargo.BOSHReleaseTarballSpecification{Name: "cf-rabbitmq", Version: "~309"}
 
-					foundRelease, err := releaseSource.FindReleaseVersion(rabbitmqRequirement, false)
+					foundRelease, err := releaseSource.FindReleaseVersion(context.Background(), rabbitmqRequirement, false)
 					Expect(err).NotTo(HaveOccurred())
 					cfRabbitURL := fmt.Sprintf("%s/d/github.com/cloudfoundry/cf-rabbitmq-release?v=309.0.5", testServer.URL())
 					Expect(foundRelease).To(Equal(cargo.BOSHReleaseTarballLock{
@@ -355,7 +406,7 @@ var _ = Describe("BOSHIOReleaseSource", func() {
 			It("returns not found", func() {
 				rabbitmqRequirement := cargo.BOSHReleaseTarballSpecification{Name: "cf-rabbitmq"}
 
-				foundRelease, err := releaseSource.FindReleaseVersion(rabbitmqRequirement, false)
+				foundRelease, err := releaseSource.FindReleaseVersion(context.Background(), rabbitmqRequirement, false)
 				Expect(err).To(HaveOccurred())
 				Expect(component.IsErrNotFound(err)).To(BeTrue())
 				Expect(foundRelease).To(Equal(cargo.BOSHReleaseTarballLock{}))
@@ -1,7 +1,12 @@
 package component
 
 import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
 	"io"
+	"os"
 
 	"github.com/pivotal-cf/kiln/pkg/cargo"
 )
@@ -18,3 +23,47 @@ type Local struct {
 }
 
 func closeAndIgnoreError(c io.Closer) { _ = c.Close() }
+
+// removePartialDownload closes and deletes a release tarball that was not
+// completely written (for example because the context was canceled) so a
+// later fetch does not mistake it for a downloaded release.
+func removePartialDownload(f *os.File) {
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+}
+
+// releaseDigest calculates the checksums recorded in a BOSHReleaseTarballLock
+// from the bytes written to it.
+type releaseDigest struct {
+	sha1, sha256 hash.Hash
+}
+
+func newReleaseDigest() releaseDigest {
+	return releaseDigest{sha1: sha1.New(), sha256: sha256.New()}
+}
+
+func (d releaseDigest) Write(p []byte) (int, error) {
+	_, _ = d.sha1.Write(p)
+	return d.sha256.Write(p)
+}
+
+// setSums sets the SHA1 and SHA256 fields of lock to the digests of the bytes
+// written so far.
+func (d releaseDigest) setSums(lock cargo.BOSHReleaseTarballLock) cargo.BOSHReleaseTarballLock {
+	lock.SHA1 = hex.EncodeToString(d.sha1.Sum(nil))
+	lock.SHA256 = hex.EncodeToString(d.sha256.Sum(nil))
+	return lock
+}
+
+func calculateFileDigest(filePath string) (releaseDigest, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return releaseDigest{}, err
+	}
+	defer closeAndIgnoreError(f)
+	d := newReleaseDigest()
+	if _, err := io.Copy(d, f); err != nil {
+		return releaseDigest{}, err
+	}
+	return d, nil
+}
@@ -0,0 +1,251 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+// DirectoryReleaseSource serves BOSH release tarballs from a local (or network
+// mounted) directory. It is intended for air-gapped hosts with a shared mirror of
+// release tarballs.
+//
+// The directory is indexed the first time it is needed; each tarball's manifest is
+// read with cargo.OpenBOSHReleaseTarball. The remote_path in a lock is the path to
+// the tarball relative to the configured directory.
+type DirectoryReleaseSource struct {
+	cargo.ReleaseSourceConfig
+	logger *log.Logger
+
+	indexOnce sync.Once
+	index     []Local
+	indexErr  error
+}
+
+// NewDirectoryReleaseSource will provision a new DirectoryReleaseSource
+// from the Kilnfile (ReleaseSourceConfig). If type is incorrect it will PANIC
+func NewDirectoryReleaseSource(c cargo.ReleaseSourceConfig, logger *log.Logger) *DirectoryReleaseSource {
+	if c.Type != "" && c.Type != ReleaseSourceTypeDirectory {
+		panic(panicMessageWrongReleaseSourceType)
+	}
+
+	if logger == nil {
+		logger = log.New(os.Stderr, "[directory release source] ", log.Default().Flags())
+	}
+
+	return &DirectoryReleaseSource{
+		ReleaseSourceConfig: c,
+		logger:              logger,
+	}
+}
+
+func (src *DirectoryReleaseSource) Configuration() cargo.ReleaseSourceConfig {
+	return src.ReleaseSourceConfig
+}
+
+// Probe checks the configured path is a readable directory.
+func (src *DirectoryReleaseSource) Probe(context.Context) error {
+	if _, err := os.ReadDir(src.Directory); err != nil {
+		return fmt.Errorf("failed to read release directory: %w", err)
+	}
+	return nil
+}
+
+// GetMatchedRelease uses the Name and Version and if supported StemcellOS and StemcellVersion
+// fields on Requirement to download a specific release.
+func (src *DirectoryReleaseSource) GetMatchedRelease(_ context.Context, spec cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error) {
+	if _, err := semver.NewVersion(spec.Version); err != nil {
+		return cargo.BOSHReleaseTarballLock{}, fmt.Errorf("expected version to be an exact version")
+	}
+	return src.find(spec, func(v *semver.Version) bool {
+		return v.Original() == spec.Version
+	})
+}
+
+// FindReleaseVersion may use any of the fields on Requirement to return the best matching
+// release. The SHA1 is always known from the index so noDownload has no effect.
+func (src *DirectoryReleaseSource) FindReleaseVersion(_ context.Context, spec cargo.BOSHReleaseTarballSpecification, _ bool) (cargo.BOSHReleaseTarballLock, error) {
+	constraint, err := spec.VersionConstraints()
+	if err != nil {
+		return cargo.BOSHReleaseTarballLock{}, err
+	}
+	return src.find(spec, constraint.Check)
+}
+
+// DownloadRelease hard-links (or copies when linking is not possible) the tarball
+// into releasesDir.
+func (src *DirectoryReleaseSource) DownloadRelease(ctx context.Context, releaseDir string, remoteRelease cargo.BOSHReleaseTarballLock) (Local, error) {
+	src.logger.Printf(logLineDownload, remoteRelease.Name, remoteRelease.Version, ReleaseSourceTypeDirectory, src.ID)
+
+	remotePath := filepath.FromSlash(remoteRelease.RemotePath)
+	if filepath.IsAbs(remotePath) || strings.HasPrefix(filepath.Clean(remotePath), "..") {
+		return Local{}, fmt.Errorf("remote_path %q must be relative to the release source directory", remoteRelease.RemotePath)
+	}
+	sourcePath := filepath.Join(src.Directory, remotePath)
+	filePath := filepath.Join(releaseDir, filepath.Base(remotePath))
+
+	if err := linkOrCopyFile(ctx, sourcePath, filePath); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Local{}, errors.Join(ErrNotFound, err)
+		}
+		return Local{}, err
+	}
+
+	digest, err := calculateFileDigest(filePath)
+	if err != nil {
+		return Local{}, err
+	}
+	remoteRelease = digest.setSums(remoteRelease)
+
+	return Local{Lock: remoteRelease, LocalPath: filePath}, nil
+}
+
+// Releases returns the indexed tarballs in the directory.
+func (src *DirectoryReleaseSource) Releases() ([]Local, error) {
+	src.indexOnce.Do(func() {
+		src.index, src.indexErr = src.buildIndex()
+	})
+	return src.index, src.indexErr
+}
+
+func (src *DirectoryReleaseSource) buildIndex() ([]Local, error) {
+	var index []Local
+	err := filepath.WalkDir(src.Directory, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".tgz") {
+			return nil
+		}
+		tarball, err := cargo.OpenBOSHReleaseTarball(p)
+		if err != nil {
+			src.logger.Printf("skipping %s: %s", p, err)
+			return nil
+		}
+		rel, err := filepath.Rel(src.Directory, p)
+		if err != nil {
+			return err
+		}
+		lock := cargo.BOSHReleaseTarballLock{
+			Name:         tarball.Manifest.Name,
+			Version:      tarball.Manifest.Version,
+			SHA1:         tarball.SHA1,
+			SHA256:       tarball.SHA256,
+			RemoteSource: src.ID,
+			RemotePath:   filepath.ToSlash(rel),
+		}
+		if stemcellOS, stemcellVersion, ok := tarball.Manifest.Stemcell(); ok {
+			lock.StemcellOS = stemcellOS
+			lock.StemcellVersion = stemcellVersion
+		}
+		index = append(index, Local{Lock: lock, LocalPath: p})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to index release directory %s: %w", src.Directory, err)
+	}
+	return index, nil
+}
+
+// find returns the highest version in the index accepted by match. Tarballs
+// compiled for a stemcell only match a specification with the same stemcell;
+// when versions are equal, a compiled tarball is preferred.
+func (src *DirectoryReleaseSource) find(spec cargo.BOSHReleaseTarballSpecification, match func(*semver.Version) bool) (cargo.BOSHReleaseTarballLock, error) {
+	releases, err := src.Releases()
+	if err != nil {
+		return cargo.BOSHReleaseTarballLock{}, err
+	}
+
+	var (
+		found        cargo.BOSHReleaseTarballLock
+		foundVersion *semver.Version
+	)
+	for _, rel := range releases {
+		lock := rel.Lock
+		if lock.Name != spec.Name {
+			continue
+		}
+		if lock.StemcellOS != "" && (lock.StemcellOS != spec.StemcellOS || lock.StemcellVersion != spec.StemcellVersion) {
+			continue
+		}
+		v, err := semver.NewVersion(lock.Version)
+		if err != nil || !match(v) {
+			continue
+		}
+		if foundVersion != nil {
+			if v.LessThan(foundVersion) {
+				continue
+			}
+			if v.Equal(foundVersion) && (found.StemcellOS != "" || lock.StemcellOS == "") {
+				continue
+			}
+		}
+		found, foundVersion = lock, v
+	}
+	if foundVersion == nil {
+		return cargo.BOSHReleaseTarballLock{}, ErrNotFound
+	}
+
+	return found, nil
+}
+
+func linkOrCopyFile(ctx context.Context, source, destination string) error {
+	if sourceInfo, err := os.Stat(source); err != nil {
+		return err
+	} else if destinationInfo, err := os.Stat(destination); err == nil && os.SameFile(sourceInfo, destinationInfo) {
+		return nil
+	}
+
+	_ = os.Remove(destination)
+	if err := os.Link(source, destination); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer closeAndIgnoreError(in)
+
+	out, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, contextReader{ctx: ctx, r: in}); err != nil {
+		removePartialDownload(out)
+		return err
+	}
+	return out.Close()
+}
+
+func calculateFileSHA1(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	return calculateSHA1(f)
+}
+
+// contextReader stops a copy when its context is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
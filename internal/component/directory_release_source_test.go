@@ -0,0 +1,173 @@
+package component_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/kiln/internal/component"
+	test_helpers "github.com/pivotal-cf/kiln/internal/test-helpers"
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+var _ = Describe("DirectoryReleaseSource", func() {
+	var (
+		source *component.DirectoryReleaseSource
+
+		mirrorDirectory, releasesDirectory string
+
+		bpm110SHA1, bpm120SHA1, bpm120CompiledSHA1 string
+		bpm110SHA256                               string
+	)
+
+	BeforeEach(func() {
+		mirrorDirectory = must(os.MkdirTemp("", "mirror"))
+		releasesDirectory = must(os.MkdirTemp("", "releases"))
+		Expect(os.Mkdir(filepath.Join(mirrorDirectory, "compiled"), 0o755)).To(Succeed())
+
+		fs := osfs.New("")
+		bpm110SHA1 = must(test_helpers.WriteReleaseTarball(filepath.Join(mirrorDirectory, "bpm-1.1.0.tgz"), "bpm", "1.1.0", fs))
+		bpm110SHA256 = fmt.Sprintf("%x", sha256.Sum256(must(os.ReadFile(filepath.Join(mirrorDirectory, "bpm-1.1.0.tgz")))))
+		bpm120SHA1 = must(test_helpers.WriteReleaseTarball(filepath.Join(mirrorDirectory, "bpm-1.2.0.tgz"), "bpm", "1.2.0", fs))
+		bpm120CompiledSHA1 = must(test_helpers.WriteTarballWithFile(filepath.Join(mirrorDirectory, "compiled", "bpm-1.2.0-ubuntu-jammy-1.5.tgz"), "release.MF", `
+name: bpm
+version: 1.2.0
+compiled_packages:
+  - name: bpm
+    stemcell: ubuntu-jammy/1.5
+`, fs))
+		_ = must(test_helpers.WriteReleaseTarball(filepath.Join(mirrorDirectory, "uaa-7.0.0.tgz"), "uaa", "7.0.0", fs))
+		Expect(os.WriteFile(filepath.Join(mirrorDirectory, "not-a-release.tgz"), []byte("banana"), 0o644)).To(Succeed())
+
+		source = component.NewDirectoryReleaseSource(cargo.ReleaseSourceConfig{
+			Type:      component.ReleaseSourceTypeDirectory,
+			ID:        "mirror",
+			Directory: mirrorDirectory,
+		}, log.New(GinkgoWriter, "", 0))
+	})
+
+	AfterEach(func() {
+		_ = os.RemoveAll(mirrorDirectory)
+		_ = os.RemoveAll(releasesDirectory)
+	})
+
+	It("is a ReleaseSource", func() {
+		var rs component.ReleaseSource = source
+		Expect(rs.Configuration().ID).To(Equal("mirror"))
+	})
+
+	Describe("Releases", func() {
+		It("indexes the release tarballs and skips invalid files", func() {
+			releases, err := source.Releases()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(releases).To(HaveLen(4))
+		})
+	})
+
+	Describe("FindReleaseVersion", func() {
+		It("returns the highest version matching the constraint", func() {
+			lock, err := source.FindReleaseVersion(context.Background(), cargo.BOSHReleaseTarballSpecification{Name: "bpm", Version: "~1.1"}, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lock).To(Equal(cargo.BOSHReleaseTarballLock{
+				Name:         "bpm",
+				Version:      "1.1.0",
+				SHA1:         bpm110SHA1,
+				SHA256:       bpm110SHA256,
+				RemoteSource: "mirror",
+				RemotePath:   "bpm-1.1.0.tgz",
+			}))
+		})
+
+		It("does not return releases compiled for another stemcell", func() {
+			lock, err := source.FindReleaseVersion(context.Background(), cargo.BOSHReleaseTarballSpecification{Name: "bpm", StemcellOS: "ubuntu-jammy", StemcellVersion: "1.6"}, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lock.SHA1).To(Equal(bpm120SHA1))
+		})
+
+		It("prefers a release compiled for the stemcell", func() {
+			lock, err := source.FindReleaseVersion(context.Background(), cargo.BOSHReleaseTarballSpecification{Name: "bpm", StemcellOS: "ubuntu-jammy", StemcellVersion: "1.5"}, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lock.SHA1).To(Equal(bpm120CompiledSHA1))
+			Expect(lock.RemotePath).To(Equal("compiled/bpm-1.2.0-ubuntu-jammy-1.5.tgz"))
+		})
+
+		When("the release is not in the directory", func() {
+			It("returns ErrNotFound", func() {
+				_, err := source.FindReleaseVersion(context.Background(), cargo.BOSHReleaseTarballSpecification{Name: "banana"}, false)
+				Expect(component.IsErrNotFound(err)).To(BeTrue())
+			})
+		})
+
+		When("the directory does not exist", func() {
+			BeforeEach(func() {
+				source = component.NewDirectoryReleaseSource(cargo.ReleaseSourceConfig{
+					Directory: filepath.Join(mirrorDirectory, "missing"),
+				}, log.New(GinkgoWriter, "", 0))
+			})
+			It("returns an error", func() {
+				_, err := source.FindReleaseVersion(context.Background(), cargo.BOSHReleaseTarballSpecification{Name: "bpm"}, false)
+				Expect(err).To(MatchError(ContainSubstring("failed to index release directory")))
+				Expect(component.IsErrNotFound(err)).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("GetMatchedRelease", func() {
+		It("returns the release with the exact version", func() {
+			lock, err := source.GetMatchedRelease(context.Background(), cargo.BOSHReleaseTarballSpecification{Name: "uaa", Version: "7.0.0"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lock.RemotePath).To(Equal("uaa-7.0.0.tgz"))
+		})
+
+		When("the version is not in the directory", func() {
+			It("returns ErrNotFound", func() {
+				_, err := source.GetMatchedRelease(context.Background(), cargo.BOSHReleaseTarballSpecification{Name: "uaa", Version: "7.0.1"})
+				Expect(component.IsErrNotFound(err)).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("DownloadRelease", func() {
+		It("puts the tarball in the releases directory", func() {
+			local, err := source.DownloadRelease(context.Background(), releasesDirectory, cargo.BOSHReleaseTarballLock{
+				Name:       "bpm",
+				Version:    "1.2.0",
+				RemotePath: "compiled/bpm-1.2.0-ubuntu-jammy-1.5.tgz",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(local.LocalPath).To(Equal(filepath.Join(releasesDirectory, "bpm-1.2.0-ubuntu-jammy-1.5.tgz")))
+			Expect(local.Lock.SHA1).To(Equal(bpm120CompiledSHA1))
+			Expect(local.Lock.SHA256).To(Equal(fmt.Sprintf("%x", sha256.Sum256(must(os.ReadFile(local.LocalPath))))))
+			Expect(local.LocalPath).To(BeAnExistingFile())
+		})
+
+		When("the remote path is outside the directory", func() {
+			It("returns an error", func() {
+				_, err := source.DownloadRelease(context.Background(), releasesDirectory, cargo.BOSHReleaseTarballLock{
+					Name:       "bpm",
+					Version:    "1.2.0",
+					RemotePath: "../bpm-1.2.0.tgz",
+				})
+				Expect(err).To(MatchError(ContainSubstring("must be relative")))
+			})
+		})
+
+		When("the file does not exist", func() {
+			It("returns ErrNotFound", func() {
+				_, err := source.DownloadRelease(context.Background(), releasesDirectory, cargo.BOSHReleaseTarballLock{
+					Name:       "bpm",
+					Version:    "9.9.9",
+					RemotePath: "bpm-9.9.9.tgz",
+				})
+				Expect(component.IsErrNotFound(err)).To(BeTrue())
+			})
+		})
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 )
 
 const ErrNotFound stringError = "not found"
@@ -34,3 +35,48 @@ func (err ErrorUnexpectedStatus) Error() string {
 		http.StatusText(err.Got), err.Got,
 	)
 }
+
+// SourceError is the error one release source returned.
+type SourceError struct {
+	SourceID string
+	Err      error
+}
+
+func (err SourceError) Error() string { return scopedError(err.SourceID, err.Err).Error() }
+
+func (err SourceError) Unwrap() error { return err.Err }
+
+// ReleaseSourcesError is returned when a release could not be found after
+// asking several release sources. It separates the sources that did not have
+// the release from the ones that failed to answer. It matches ErrNotFound
+// only when no source failed.
+type ReleaseSourcesError struct {
+	NotFound []string
+	Failed   []SourceError
+}
+
+func (err *ReleaseSourcesError) Error() string {
+	if len(err.Failed) == 0 {
+		return fmt.Sprintf("%s in release sources %q", ErrNotFound, err.NotFound)
+	}
+	messages := make([]string, 0, len(err.Failed))
+	for _, failed := range err.Failed {
+		messages = append(messages, failed.Error())
+	}
+	msg := fmt.Sprintf("%d release sources failed: %s", len(err.Failed), strings.Join(messages, "; "))
+	if len(err.NotFound) > 0 {
+		msg += fmt.Sprintf(" (%s in %q)", ErrNotFound, err.NotFound)
+	}
+	return msg
+}
+
+func (err *ReleaseSourcesError) Unwrap() []error {
+	if len(err.Failed) == 0 {
+		return []error{ErrNotFound}
+	}
+	errs := make([]error, 0, len(err.Failed))
+	for _, failed := range err.Failed {
+		errs = append(errs, failed)
+	}
+	return errs
+}
@@ -0,0 +1,99 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+// FailoverMultiReleaseSource wraps a MultiReleaseSource so that when
+// DownloadRelease fails, the other release sources in the Kilnfile are asked for
+// the same release. A tarball from another source is only accepted when its
+// SHA1 matches the lock passed to DownloadRelease, so locks without a SHA1 are
+// never failed over. Sources a release is not pinned to (see
+// cargo.BOSHReleaseTarballSpecification.ReleaseSources) are not asked.
+type FailoverMultiReleaseSource struct {
+	MultiReleaseSource
+	Kilnfile cargo.Kilnfile
+	logger   *log.Logger
+}
+
+func NewFailoverMultiReleaseSource(source MultiReleaseSource, kilnfile cargo.Kilnfile, logger *log.Logger) FailoverMultiReleaseSource {
+	if logger == nil {
+		logger = log.New(os.Stderr, "[release failover] ", log.Default().Flags())
+	}
+	return FailoverMultiReleaseSource{
+		MultiReleaseSource: source,
+		Kilnfile:           kilnfile,
+		logger:             logger,
+	}
+}
+
+func (src FailoverMultiReleaseSource) DownloadRelease(ctx context.Context, releaseDir string, remoteRelease cargo.BOSHReleaseTarballLock) (Local, error) {
+	local, err := src.MultiReleaseSource.DownloadRelease(ctx, releaseDir, remoteRelease)
+	if err == nil || remoteRelease.SHA1 == "" || ctx.Err() != nil {
+		return local, err
+	}
+	src.logger.Printf("failed to download %s %s from release source %q, trying the other release sources: %s", remoteRelease.Name, remoteRelease.Version, remoteRelease.RemoteSource, err)
+
+	spec := cargo.BOSHReleaseTarballSpecification{
+		Name:            remoteRelease.Name,
+		Version:         remoteRelease.Version,
+		StemcellOS:      remoteRelease.StemcellOS,
+		StemcellVersion: remoteRelease.StemcellVersion,
+	}
+	if s, specErr := src.Kilnfile.BOSHReleaseTarballSpecification(remoteRelease.Name); specErr == nil {
+		spec.GitHubRepository = s.GitHubRepository
+		spec.ReleaseSources = s.ReleaseSources
+	}
+
+	errs := []error{err}
+	for _, config := range src.Kilnfile.ReleaseSources {
+		if ctx.Err() != nil {
+			break
+		}
+		id := cargo.BOSHReleaseTarballSourceID(config)
+		if id == remoteRelease.RemoteSource || !spec.AllowsReleaseSource(id) {
+			continue
+		}
+		alternate, findErr := src.FindByID(id)
+		if findErr != nil {
+			// the source was filtered out, for example because it is not publishable
+			continue
+		}
+
+		match, matchErr := alternate.GetMatchedRelease(ctx, spec)
+		if matchErr != nil {
+			if !IsErrNotFound(matchErr) {
+				errs = append(errs, scopedError(id, matchErr))
+			}
+			continue
+		}
+		if match.SHA1 != "" && match.SHA1 != "not-calculated" && match.SHA1 != remoteRelease.SHA1 {
+			src.logger.Printf("release source %q has %s %s with a different SHA1 (%s), skipping it", id, remoteRelease.Name, remoteRelease.Version, match.SHA1)
+			continue
+		}
+
+		match.SHA1 = remoteRelease.SHA1
+		match.SHA256 = remoteRelease.SHA256
+		local, err = src.MultiReleaseSource.DownloadRelease(ctx, releaseDir, match)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if local.Lock.SHA1 != remoteRelease.SHA1 {
+			_ = os.Remove(local.LocalPath)
+			errs = append(errs, scopedError(id, fmt.Errorf("downloaded %s %s had an incorrect SHA1 - expected %q, got %q", remoteRelease.Name, remoteRelease.Version, remoteRelease.SHA1, local.Lock.SHA1)))
+			continue
+		}
+
+		src.logger.Printf("downloaded %s %s from release source %q instead of %q", remoteRelease.Name, remoteRelease.Version, id, remoteRelease.RemoteSource)
+		return local, nil
+	}
+
+	return Local{}, errors.Join(errs...)
+}
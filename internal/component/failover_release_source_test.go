@@ -0,0 +1,137 @@
+package component_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/kiln/internal/component"
+	"github.com/pivotal-cf/kiln/internal/component/fakes"
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+var _ = Describe("FailoverMultiReleaseSource", func() {
+	const expectedSHA1 = "a2ec1fe2ad6f3a5df4b04a6f5a2d3e09d9b3e2a1"
+
+	var (
+		primary, mirror, other *fakes.ReleaseSource
+		source                 component.FailoverMultiReleaseSource
+		logOutput              *bytes.Buffer
+		lock                   cargo.BOSHReleaseTarballLock
+		releasesDirectory      string
+	)
+
+	BeforeEach(func() {
+		releasesDirectory = GinkgoT().TempDir()
+		primary = new(fakes.ReleaseSource)
+		primary.ConfigurationReturns(cargo.ReleaseSourceConfig{ID: "primary"})
+		primary.DownloadReleaseReturns(component.Local{}, errors.New("connection refused"))
+		other = new(fakes.ReleaseSource)
+		other.ConfigurationReturns(cargo.ReleaseSourceConfig{ID: "other"})
+		other.GetMatchedReleaseReturns(cargo.BOSHReleaseTarballLock{}, component.ErrNotFound)
+		mirror = new(fakes.ReleaseSource)
+		mirror.ConfigurationReturns(cargo.ReleaseSourceConfig{ID: "mirror"})
+		mirror.GetMatchedReleaseReturns(cargo.BOSHReleaseTarballLock{Name: "bpm", Version: "1.2.0", RemoteSource: "mirror", RemotePath: "bpm/bpm-1.2.0.tgz"}, nil)
+		mirror.DownloadReleaseStub = func(_ context.Context, dir string, l cargo.BOSHReleaseTarballLock) (component.Local, error) {
+			l.SHA1 = expectedSHA1
+			return component.Local{Lock: l, LocalPath: filepath.Join(dir, "bpm-1.2.0.tgz")}, nil
+		}
+
+		lock = cargo.BOSHReleaseTarballLock{Name: "bpm", Version: "1.2.0", SHA1: expectedSHA1, RemoteSource: "primary", RemotePath: "bpm-1.2.0.tgz", StemcellOS: "ubuntu-jammy", StemcellVersion: "1.44"}
+
+		logOutput = new(bytes.Buffer)
+		kilnfile := cargo.Kilnfile{
+			ReleaseSources: []cargo.ReleaseSourceConfig{{ID: "primary"}, {ID: "other"}, {ID: "mirror"}},
+			Releases:       []cargo.BOSHReleaseTarballSpecification{{Name: "bpm", GitHubRepository: "https://github.com/cloudfoundry/bpm-release"}},
+		}
+		source = component.NewFailoverMultiReleaseSource(component.NewMultiReleaseSource(primary, other, mirror), kilnfile, log.New(logOutput, "", 0))
+	})
+
+	It("downloads the release from another source with the same SHA1", func() {
+		local, err := source.DownloadRelease(context.Background(), releasesDirectory, lock)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(local.Lock.RemoteSource).To(Equal("mirror"))
+		Expect(local.Lock.SHA1).To(Equal(expectedSHA1))
+
+		_, spec := mirror.GetMatchedReleaseArgsForCall(0)
+		Expect(spec).To(Equal(cargo.BOSHReleaseTarballSpecification{
+			Name: "bpm", Version: "1.2.0", StemcellOS: "ubuntu-jammy", StemcellVersion: "1.44",
+			GitHubRepository: "https://github.com/cloudfoundry/bpm-release",
+		}))
+		Expect(logOutput.String()).To(ContainSubstring(`downloaded bpm 1.2.0 from release source "mirror" instead of "primary"`))
+	})
+
+	When("the other source has a different SHA1", func() {
+		BeforeEach(func() {
+			mirror.GetMatchedReleaseReturns(cargo.BOSHReleaseTarballLock{Name: "bpm", Version: "1.2.0", RemoteSource: "mirror", SHA1: "different"}, nil)
+		})
+
+		It("does not download it", func() {
+			_, err := source.DownloadRelease(context.Background(), releasesDirectory, lock)
+			Expect(err).To(MatchError(ContainSubstring("connection refused")))
+			Expect(mirror.DownloadReleaseCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the downloaded tarball does not match the lock", func() {
+		BeforeEach(func() {
+			mirror.DownloadReleaseStub = func(_ context.Context, dir string, l cargo.BOSHReleaseTarballLock) (component.Local, error) {
+				l.SHA1 = "different"
+				return component.Local{Lock: l, LocalPath: filepath.Join(dir, "bpm-1.2.0.tgz")}, nil
+			}
+		})
+
+		It("returns an error", func() {
+			_, err := source.DownloadRelease(context.Background(), releasesDirectory, lock)
+			Expect(err).To(MatchError(ContainSubstring("incorrect SHA1")))
+			Expect(err).To(MatchError(ContainSubstring("connection refused")))
+		})
+	})
+
+	When("the lock has no SHA1", func() {
+		BeforeEach(func() {
+			lock.SHA1 = ""
+		})
+
+		It("does not fail over", func() {
+			_, err := source.DownloadRelease(context.Background(), releasesDirectory, lock)
+			Expect(err).To(HaveOccurred())
+			Expect(mirror.GetMatchedReleaseCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the release is pinned to other release sources", func() {
+		BeforeEach(func() {
+			kilnfile := cargo.Kilnfile{
+				ReleaseSources: []cargo.ReleaseSourceConfig{{ID: "primary"}, {ID: "other"}, {ID: "mirror"}},
+				Releases:       []cargo.BOSHReleaseTarballSpecification{{Name: "bpm", ReleaseSources: []string{"primary", "other"}}},
+			}
+			source = component.NewFailoverMultiReleaseSource(component.NewMultiReleaseSource(primary, other, mirror), kilnfile, log.New(logOutput, "", 0))
+		})
+
+		It("does not ask the sources it is not pinned to", func() {
+			_, err := source.DownloadRelease(context.Background(), releasesDirectory, lock)
+			Expect(err).To(MatchError(ContainSubstring("connection refused")))
+			Expect(other.GetMatchedReleaseCallCount()).To(Equal(1))
+			Expect(mirror.GetMatchedReleaseCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the primary source succeeds", func() {
+		BeforeEach(func() {
+			primary.DownloadReleaseReturns(component.Local{Lock: lock}, nil)
+		})
+
+		It("does not ask the other sources", func() {
+			local, err := source.DownloadRelease(context.Background(), releasesDirectory, lock)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(local.Lock.RemoteSource).To(Equal("primary"))
+			Expect(mirror.GetMatchedReleaseCallCount()).To(Equal(0))
+		})
+	})
+})
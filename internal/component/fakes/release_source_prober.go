@@ -0,0 +1,415 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pivotal-cf/kiln/internal/component"
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+type ReleaseSourceProber struct {
+	ConfigurationStub        func() cargo.ReleaseSourceConfig
+	configurationMutex       sync.RWMutex
+	configurationArgsForCall []struct {
+	}
+	configurationReturns struct {
+		result1 cargo.ReleaseSourceConfig
+	}
+	configurationReturnsOnCall map[int]struct {
+		result1 cargo.ReleaseSourceConfig
+	}
+	DownloadReleaseStub        func(context.Context, string, cargo.BOSHReleaseTarballLock) (component.Local, error)
+	downloadReleaseMutex       sync.RWMutex
+	downloadReleaseArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 cargo.BOSHReleaseTarballLock
+	}
+	downloadReleaseReturns struct {
+		result1 component.Local
+		result2 error
+	}
+	downloadReleaseReturnsOnCall map[int]struct {
+		result1 component.Local
+		result2 error
+	}
+	FindReleaseVersionStub        func(context.Context, cargo.BOSHReleaseTarballSpecification, bool) (cargo.BOSHReleaseTarballLock, error)
+	findReleaseVersionMutex       sync.RWMutex
+	findReleaseVersionArgsForCall []struct {
+		arg1 context.Context
+		arg2 cargo.BOSHReleaseTarballSpecification
+		arg3 bool
+	}
+	findReleaseVersionReturns struct {
+		result1 cargo.BOSHReleaseTarballLock
+		result2 error
+	}
+	findReleaseVersionReturnsOnCall map[int]struct {
+		result1 cargo.BOSHReleaseTarballLock
+		result2 error
+	}
+	GetMatchedReleaseStub        func(context.Context, cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error)
+	getMatchedReleaseMutex       sync.RWMutex
+	getMatchedReleaseArgsForCall []struct {
+		arg1 context.Context
+		arg2 cargo.BOSHReleaseTarballSpecification
+	}
+	getMatchedReleaseReturns struct {
+		result1 cargo.BOSHReleaseTarballLock
+		result2 error
+	}
+	getMatchedReleaseReturnsOnCall map[int]struct {
+		result1 cargo.BOSHReleaseTarballLock
+		result2 error
+	}
+	ProbeStub        func(context.Context) error
+	probeMutex       sync.RWMutex
+	probeArgsForCall []struct {
+		arg1 context.Context
+	}
+	probeReturns struct {
+		result1 error
+	}
+	probeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *ReleaseSourceProber) Configuration() cargo.ReleaseSourceConfig {
+	fake.configurationMutex.Lock()
+	ret, specificReturn := fake.configurationReturnsOnCall[len(fake.configurationArgsForCall)]
+	fake.configurationArgsForCall = append(fake.configurationArgsForCall, struct {
+	}{})
+	stub := fake.ConfigurationStub
+	fakeReturns := fake.configurationReturns
+	fake.recordInvocation("Configuration", []interface{}{})
+	fake.configurationMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *ReleaseSourceProber) ConfigurationCallCount() int {
+	fake.configurationMutex.RLock()
+	defer fake.configurationMutex.RUnlock()
+	return len(fake.configurationArgsForCall)
+}
+
+func (fake *ReleaseSourceProber) ConfigurationCalls(stub func() cargo.ReleaseSourceConfig) {
+	fake.configurationMutex.Lock()
+	defer fake.configurationMutex.Unlock()
+	fake.ConfigurationStub = stub
+}
+
+func (fake *ReleaseSourceProber) ConfigurationReturns(result1 cargo.ReleaseSourceConfig) {
+	fake.configurationMutex.Lock()
+	defer fake.configurationMutex.Unlock()
+	fake.ConfigurationStub = nil
+	fake.configurationReturns = struct {
+		result1 cargo.ReleaseSourceConfig
+	}{result1}
+}
+
+func (fake *ReleaseSourceProber) ConfigurationReturnsOnCall(i int, result1 cargo.ReleaseSourceConfig) {
+	fake.configurationMutex.Lock()
+	defer fake.configurationMutex.Unlock()
+	fake.ConfigurationStub = nil
+	if fake.configurationReturnsOnCall == nil {
+		fake.configurationReturnsOnCall = make(map[int]struct {
+			result1 cargo.ReleaseSourceConfig
+		})
+	}
+	fake.configurationReturnsOnCall[i] = struct {
+		result1 cargo.ReleaseSourceConfig
+	}{result1}
+}
+
+func (fake *ReleaseSourceProber) DownloadRelease(arg1 context.Context, arg2 string, arg3 cargo.BOSHReleaseTarballLock) (component.Local, error) {
+	fake.downloadReleaseMutex.Lock()
+	ret, specificReturn := fake.downloadReleaseReturnsOnCall[len(fake.downloadReleaseArgsForCall)]
+	fake.downloadReleaseArgsForCall = append(fake.downloadReleaseArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 cargo.BOSHReleaseTarballLock
+	}{arg1, arg2, arg3})
+	stub := fake.DownloadReleaseStub
+	fakeReturns := fake.downloadReleaseReturns
+	fake.recordInvocation("DownloadRelease", []interface{}{arg1, arg2, arg3})
+	fake.downloadReleaseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *ReleaseSourceProber) DownloadReleaseCallCount() int {
+	fake.downloadReleaseMutex.RLock()
+	defer fake.downloadReleaseMutex.RUnlock()
+	return len(fake.downloadReleaseArgsForCall)
+}
+
+func (fake *ReleaseSourceProber) DownloadReleaseCalls(stub func(context.Context, string, cargo.BOSHReleaseTarballLock) (component.Local, error)) {
+	fake.downloadReleaseMutex.Lock()
+	defer fake.downloadReleaseMutex.Unlock()
+	fake.DownloadReleaseStub = stub
+}
+
+func (fake *ReleaseSourceProber) DownloadReleaseArgsForCall(i int) (context.Context, string, cargo.BOSHReleaseTarballLock) {
+	fake.downloadReleaseMutex.RLock()
+	defer fake.downloadReleaseMutex.RUnlock()
+	argsForCall := fake.downloadReleaseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *ReleaseSourceProber) DownloadReleaseReturns(result1 component.Local, result2 error) {
+	fake.downloadReleaseMutex.Lock()
+	defer fake.downloadReleaseMutex.Unlock()
+	fake.DownloadReleaseStub = nil
+	fake.downloadReleaseReturns = struct {
+		result1 component.Local
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReleaseSourceProber) DownloadReleaseReturnsOnCall(i int, result1 component.Local, result2 error) {
+	fake.downloadReleaseMutex.Lock()
+	defer fake.downloadReleaseMutex.Unlock()
+	fake.DownloadReleaseStub = nil
+	if fake.downloadReleaseReturnsOnCall == nil {
+		fake.downloadReleaseReturnsOnCall = make(map[int]struct {
+			result1 component.Local
+			result2 error
+		})
+	}
+	fake.downloadReleaseReturnsOnCall[i] = struct {
+		result1 component.Local
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReleaseSourceProber) FindReleaseVersion(arg1 context.Context, arg2 cargo.BOSHReleaseTarballSpecification, arg3 bool) (cargo.BOSHReleaseTarballLock, error) {
+	fake.findReleaseVersionMutex.Lock()
+	ret, specificReturn := fake.findReleaseVersionReturnsOnCall[len(fake.findReleaseVersionArgsForCall)]
+	fake.findReleaseVersionArgsForCall = append(fake.findReleaseVersionArgsForCall, struct {
+		arg1 context.Context
+		arg2 cargo.BOSHReleaseTarballSpecification
+		arg3 bool
+	}{arg1, arg2, arg3})
+	stub := fake.FindReleaseVersionStub
+	fakeReturns := fake.findReleaseVersionReturns
+	fake.recordInvocation("FindReleaseVersion", []interface{}{arg1, arg2, arg3})
+	fake.findReleaseVersionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *ReleaseSourceProber) FindReleaseVersionCallCount() int {
+	fake.findReleaseVersionMutex.RLock()
+	defer fake.findReleaseVersionMutex.RUnlock()
+	return len(fake.findReleaseVersionArgsForCall)
+}
+
+func (fake *ReleaseSourceProber) FindReleaseVersionCalls(stub func(context.Context, cargo.BOSHReleaseTarballSpecification, bool) (cargo.BOSHReleaseTarballLock, error)) {
+	fake.findReleaseVersionMutex.Lock()
+	defer fake.findReleaseVersionMutex.Unlock()
+	fake.FindReleaseVersionStub = stub
+}
+
+func (fake *ReleaseSourceProber) FindReleaseVersionArgsForCall(i int) (context.Context, cargo.BOSHReleaseTarballSpecification, bool) {
+	fake.findReleaseVersionMutex.RLock()
+	defer fake.findReleaseVersionMutex.RUnlock()
+	argsForCall := fake.findReleaseVersionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *ReleaseSourceProber) FindReleaseVersionReturns(result1 cargo.BOSHReleaseTarballLock, result2 error) {
+	fake.findReleaseVersionMutex.Lock()
+	defer fake.findReleaseVersionMutex.Unlock()
+	fake.FindReleaseVersionStub = nil
+	fake.findReleaseVersionReturns = struct {
+		result1 cargo.BOSHReleaseTarballLock
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReleaseSourceProber) FindReleaseVersionReturnsOnCall(i int, result1 cargo.BOSHReleaseTarballLock, result2 error) {
+	fake.findReleaseVersionMutex.Lock()
+	defer fake.findReleaseVersionMutex.Unlock()
+	fake.FindReleaseVersionStub = nil
+	if fake.findReleaseVersionReturnsOnCall == nil {
+		fake.findReleaseVersionReturnsOnCall = make(map[int]struct {
+			result1 cargo.BOSHReleaseTarballLock
+			result2 error
+		})
+	}
+	fake.findReleaseVersionReturnsOnCall[i] = struct {
+		result1 cargo.BOSHReleaseTarballLock
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReleaseSourceProber) GetMatchedRelease(arg1 context.Context, arg2 cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error) {
+	fake.getMatchedReleaseMutex.Lock()
+	ret, specificReturn := fake.getMatchedReleaseReturnsOnCall[len(fake.getMatchedReleaseArgsForCall)]
+	fake.getMatchedReleaseArgsForCall = append(fake.getMatchedReleaseArgsForCall, struct {
+		arg1 context.Context
+		arg2 cargo.BOSHReleaseTarballSpecification
+	}{arg1, arg2})
+	stub := fake.GetMatchedReleaseStub
+	fakeReturns := fake.getMatchedReleaseReturns
+	fake.recordInvocation("GetMatchedRelease", []interface{}{arg1, arg2})
+	fake.getMatchedReleaseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *ReleaseSourceProber) GetMatchedReleaseCallCount() int {
+	fake.getMatchedReleaseMutex.RLock()
+	defer fake.getMatchedReleaseMutex.RUnlock()
+	return len(fake.getMatchedReleaseArgsForCall)
+}
+
+func (fake *ReleaseSourceProber) GetMatchedReleaseCalls(stub func(context.Context, cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error)) {
+	fake.getMatchedReleaseMutex.Lock()
+	defer fake.getMatchedReleaseMutex.Unlock()
+	fake.GetMatchedReleaseStub = stub
+}
+
+func (fake *ReleaseSourceProber) GetMatchedReleaseArgsForCall(i int) (context.Context, cargo.BOSHReleaseTarballSpecification) {
+	fake.getMatchedReleaseMutex.RLock()
+	defer fake.getMatchedReleaseMutex.RUnlock()
+	argsForCall := fake.getMatchedReleaseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *ReleaseSourceProber) GetMatchedReleaseReturns(result1 cargo.BOSHReleaseTarballLock, result2 error) {
+	fake.getMatchedReleaseMutex.Lock()
+	defer fake.getMatchedReleaseMutex.Unlock()
+	fake.GetMatchedReleaseStub = nil
+	fake.getMatchedReleaseReturns = struct {
+		result1 cargo.BOSHReleaseTarballLock
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReleaseSourceProber) GetMatchedReleaseReturnsOnCall(i int, result1 cargo.BOSHReleaseTarballLock, result2 error) {
+	fake.getMatchedReleaseMutex.Lock()
+	defer fake.getMatchedReleaseMutex.Unlock()
+	fake.GetMatchedReleaseStub = nil
+	if fake.getMatchedReleaseReturnsOnCall == nil {
+		fake.getMatchedReleaseReturnsOnCall = make(map[int]struct {
+			result1 cargo.BOSHReleaseTarballLock
+			result2 error
+		})
+	}
+	fake.getMatchedReleaseReturnsOnCall[i] = struct {
+		result1 cargo.BOSHReleaseTarballLock
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReleaseSourceProber) Probe(arg1 context.Context) error {
+	fake.probeMutex.Lock()
+	ret, specificReturn := fake.probeReturnsOnCall[len(fake.probeArgsForCall)]
+	fake.probeArgsForCall = append(fake.probeArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.ProbeStub
+	fakeReturns := fake.probeReturns
+	fake.recordInvocation("Probe", []interface{}{arg1})
+	fake.probeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *ReleaseSourceProber) ProbeCallCount() int {
+	fake.probeMutex.RLock()
+	defer fake.probeMutex.RUnlock()
+	return len(fake.probeArgsForCall)
+}
+
+func (fake *ReleaseSourceProber) ProbeCalls(stub func(context.Context) error) {
+	fake.probeMutex.Lock()
+	defer fake.probeMutex.Unlock()
+	fake.ProbeStub = stub
+}
+
+func (fake *ReleaseSourceProber) ProbeArgsForCall(i int) context.Context {
+	fake.probeMutex.RLock()
+	defer fake.probeMutex.RUnlock()
+	argsForCall := fake.probeArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *ReleaseSourceProber) ProbeReturns(result1 error) {
+	fake.probeMutex.Lock()
+	defer fake.probeMutex.Unlock()
+	fake.ProbeStub = nil
+	fake.probeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *ReleaseSourceProber) ProbeReturnsOnCall(i int, result1 error) {
+	fake.probeMutex.Lock()
+	defer fake.probeMutex.Unlock()
+	fake.ProbeStub = nil
+	if fake.probeReturnsOnCall == nil {
+		fake.probeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.probeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *ReleaseSourceProber) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *ReleaseSourceProber) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ component.ReleaseSourceProber = new(ReleaseSourceProber)
@@ -0,0 +1,502 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/pivotal-cf/kiln/internal/component"
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+type ReleaseUploader struct {
+	ConfigurationStub        func() cargo.ReleaseSourceConfig
+	configurationMutex       sync.RWMutex
+	configurationArgsForCall []struct {
+	}
+	configurationReturns struct {
+		result1 cargo.ReleaseSourceConfig
+	}
+	configurationReturnsOnCall map[int]struct {
+		result1 cargo.ReleaseSourceConfig
+	}
+	DownloadReleaseStub        func(context.Context, string, cargo.BOSHReleaseTarballLock) (component.Local, error)
+	downloadReleaseMutex       sync.RWMutex
+	downloadReleaseArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 cargo.BOSHReleaseTarballLock
+	}
+	downloadReleaseReturns struct {
+		result1 component.Local
+		result2 error
+	}
+	downloadReleaseReturnsOnCall map[int]struct {
+		result1 component.Local
+		result2 error
+	}
+	FindReleaseVersionStub        func(context.Context, cargo.BOSHReleaseTarballSpecification, bool) (cargo.BOSHReleaseTarballLock, error)
+	findReleaseVersionMutex       sync.RWMutex
+	findReleaseVersionArgsForCall []struct {
+		arg1 context.Context
+		arg2 cargo.BOSHReleaseTarballSpecification
+		arg3 bool
+	}
+	findReleaseVersionReturns struct {
+		result1 cargo.BOSHReleaseTarballLock
+		result2 error
+	}
+	findReleaseVersionReturnsOnCall map[int]struct {
+		result1 cargo.BOSHReleaseTarballLock
+		result2 error
+	}
+	GetMatchedReleaseStub        func(context.Context, cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error)
+	getMatchedReleaseMutex       sync.RWMutex
+	getMatchedReleaseArgsForCall []struct {
+		arg1 context.Context
+		arg2 cargo.BOSHReleaseTarballSpecification
+	}
+	getMatchedReleaseReturns struct {
+		result1 cargo.BOSHReleaseTarballLock
+		result2 error
+	}
+	getMatchedReleaseReturnsOnCall map[int]struct {
+		result1 cargo.BOSHReleaseTarballLock
+		result2 error
+	}
+	RemotePathStub        func(cargo.BOSHReleaseTarballSpecification) (string, error)
+	remotePathMutex       sync.RWMutex
+	remotePathArgsForCall []struct {
+		arg1 cargo.BOSHReleaseTarballSpecification
+	}
+	remotePathReturns struct {
+		result1 string
+		result2 error
+	}
+	remotePathReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	UploadReleaseStub        func(context.Context, cargo.BOSHReleaseTarballSpecification, io.Reader) (cargo.BOSHReleaseTarballLock, error)
+	uploadReleaseMutex       sync.RWMutex
+	uploadReleaseArgsForCall []struct {
+		arg1 context.Context
+		arg2 cargo.BOSHReleaseTarballSpecification
+		arg3 io.Reader
+	}
+	uploadReleaseReturns struct {
+		result1 cargo.BOSHReleaseTarballLock
+		result2 error
+	}
+	uploadReleaseReturnsOnCall map[int]struct {
+		result1 cargo.BOSHReleaseTarballLock
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *ReleaseUploader) Configuration() cargo.ReleaseSourceConfig {
+	fake.configurationMutex.Lock()
+	ret, specificReturn := fake.configurationReturnsOnCall[len(fake.configurationArgsForCall)]
+	fake.configurationArgsForCall = append(fake.configurationArgsForCall, struct {
+	}{})
+	stub := fake.ConfigurationStub
+	fakeReturns := fake.configurationReturns
+	fake.recordInvocation("Configuration", []interface{}{})
+	fake.configurationMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *ReleaseUploader) ConfigurationCallCount() int {
+	fake.configurationMutex.RLock()
+	defer fake.configurationMutex.RUnlock()
+	return len(fake.configurationArgsForCall)
+}
+
+func (fake *ReleaseUploader) ConfigurationCalls(stub func() cargo.ReleaseSourceConfig) {
+	fake.configurationMutex.Lock()
+	defer fake.configurationMutex.Unlock()
+	fake.ConfigurationStub = stub
+}
+
+func (fake *ReleaseUploader) ConfigurationReturns(result1 cargo.ReleaseSourceConfig) {
+	fake.configurationMutex.Lock()
+	defer fake.configurationMutex.Unlock()
+	fake.ConfigurationStub = nil
+	fake.configurationReturns = struct {
+		result1 cargo.ReleaseSourceConfig
+	}{result1}
+}
+
+func (fake *ReleaseUploader) ConfigurationReturnsOnCall(i int, result1 cargo.ReleaseSourceConfig) {
+	fake.configurationMutex.Lock()
+	defer fake.configurationMutex.Unlock()
+	fake.ConfigurationStub = nil
+	if fake.configurationReturnsOnCall == nil {
+		fake.configurationReturnsOnCall = make(map[int]struct {
+			result1 cargo.ReleaseSourceConfig
+		})
+	}
+	fake.configurationReturnsOnCall[i] = struct {
+		result1 cargo.ReleaseSourceConfig
+	}{result1}
+}
+
+func (fake *ReleaseUploader) DownloadRelease(arg1 context.Context, arg2 string, arg3 cargo.BOSHReleaseTarballLock) (component.Local, error) {
+	fake.downloadReleaseMutex.Lock()
+	ret, specificReturn := fake.downloadReleaseReturnsOnCall[len(fake.downloadReleaseArgsForCall)]
+	fake.downloadReleaseArgsForCall = append(fake.downloadReleaseArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 cargo.BOSHReleaseTarballLock
+	}{arg1, arg2, arg3})
+	stub := fake.DownloadReleaseStub
+	fakeReturns := fake.downloadReleaseReturns
+	fake.recordInvocation("DownloadRelease", []interface{}{arg1, arg2, arg3})
+	fake.downloadReleaseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *ReleaseUploader) DownloadReleaseCallCount() int {
+	fake.downloadReleaseMutex.RLock()
+	defer fake.downloadReleaseMutex.RUnlock()
+	return len(fake.downloadReleaseArgsForCall)
+}
+
+func (fake *ReleaseUploader) DownloadReleaseCalls(stub func(context.Context, string, cargo.BOSHReleaseTarballLock) (component.Local, error)) {
+	fake.downloadReleaseMutex.Lock()
+	defer fake.downloadReleaseMutex.Unlock()
+	fake.DownloadReleaseStub = stub
+}
+
+func (fake *ReleaseUploader) DownloadReleaseArgsForCall(i int) (context.Context, string, cargo.BOSHReleaseTarballLock) {
+	fake.downloadReleaseMutex.RLock()
+	defer fake.downloadReleaseMutex.RUnlock()
+	argsForCall := fake.downloadReleaseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *ReleaseUploader) DownloadReleaseReturns(result1 component.Local, result2 error) {
+	fake.downloadReleaseMutex.Lock()
+	defer fake.downloadReleaseMutex.Unlock()
+	fake.DownloadReleaseStub = nil
+	fake.downloadReleaseReturns = struct {
+		result1 component.Local
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReleaseUploader) DownloadReleaseReturnsOnCall(i int, result1 component.Local, result2 error) {
+	fake.downloadReleaseMutex.Lock()
+	defer fake.downloadReleaseMutex.Unlock()
+	fake.DownloadReleaseStub = nil
+	if fake.downloadReleaseReturnsOnCall == nil {
+		fake.downloadReleaseReturnsOnCall = make(map[int]struct {
+			result1 component.Local
+			result2 error
+		})
+	}
+	fake.downloadReleaseReturnsOnCall[i] = struct {
+		result1 component.Local
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReleaseUploader) FindReleaseVersion(arg1 context.Context, arg2 cargo.BOSHReleaseTarballSpecification, arg3 bool) (cargo.BOSHReleaseTarballLock, error) {
+	fake.findReleaseVersionMutex.Lock()
+	ret, specificReturn := fake.findReleaseVersionReturnsOnCall[len(fake.findReleaseVersionArgsForCall)]
+	fake.findReleaseVersionArgsForCall = append(fake.findReleaseVersionArgsForCall, struct {
+		arg1 context.Context
+		arg2 cargo.BOSHReleaseTarballSpecification
+		arg3 bool
+	}{arg1, arg2, arg3})
+	stub := fake.FindReleaseVersionStub
+	fakeReturns := fake.findReleaseVersionReturns
+	fake.recordInvocation("FindReleaseVersion", []interface{}{arg1, arg2, arg3})
+	fake.findReleaseVersionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *ReleaseUploader) FindReleaseVersionCallCount() int {
+	fake.findReleaseVersionMutex.RLock()
+	defer fake.findReleaseVersionMutex.RUnlock()
+	return len(fake.findReleaseVersionArgsForCall)
+}
+
+func (fake *ReleaseUploader) FindReleaseVersionCalls(stub func(context.Context, cargo.BOSHReleaseTarballSpecification, bool) (cargo.BOSHReleaseTarballLock, error)) {
+	fake.findReleaseVersionMutex.Lock()
+	defer fake.findReleaseVersionMutex.Unlock()
+	fake.FindReleaseVersionStub = stub
+}
+
+func (fake *ReleaseUploader) FindReleaseVersionArgsForCall(i int) (context.Context, cargo.BOSHReleaseTarballSpecification, bool) {
+	fake.findReleaseVersionMutex.RLock()
+	defer fake.findReleaseVersionMutex.RUnlock()
+	argsForCall := fake.findReleaseVersionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *ReleaseUploader) FindReleaseVersionReturns(result1 cargo.BOSHReleaseTarballLock, result2 error) {
+	fake.findReleaseVersionMutex.Lock()
+	defer fake.findReleaseVersionMutex.Unlock()
+	fake.FindReleaseVersionStub = nil
+	fake.findReleaseVersionReturns = struct {
+		result1 cargo.BOSHReleaseTarballLock
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReleaseUploader) FindReleaseVersionReturnsOnCall(i int, result1 cargo.BOSHReleaseTarballLock, result2 error) {
+	fake.findReleaseVersionMutex.Lock()
+	defer fake.findReleaseVersionMutex.Unlock()
+	fake.FindReleaseVersionStub = nil
+	if fake.findReleaseVersionReturnsOnCall == nil {
+		fake.findReleaseVersionReturnsOnCall = make(map[int]struct {
+			result1 cargo.BOSHReleaseTarballLock
+			result2 error
+		})
+	}
+	fake.findReleaseVersionReturnsOnCall[i] = struct {
+		result1 cargo.BOSHReleaseTarballLock
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReleaseUploader) GetMatchedRelease(arg1 context.Context, arg2 cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error) {
+	fake.getMatchedReleaseMutex.Lock()
+	ret, specificReturn := fake.getMatchedReleaseReturnsOnCall[len(fake.getMatchedReleaseArgsForCall)]
+	fake.getMatchedReleaseArgsForCall = append(fake.getMatchedReleaseArgsForCall, struct {
+		arg1 context.Context
+		arg2 cargo.BOSHReleaseTarballSpecification
+	}{arg1, arg2})
+	stub := fake.GetMatchedReleaseStub
+	fakeReturns := fake.getMatchedReleaseReturns
+	fake.recordInvocation("GetMatchedRelease", []interface{}{arg1, arg2})
+	fake.getMatchedReleaseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *ReleaseUploader) GetMatchedReleaseCallCount() int {
+	fake.getMatchedReleaseMutex.RLock()
+	defer fake.getMatchedReleaseMutex.RUnlock()
+	return len(fake.getMatchedReleaseArgsForCall)
+}
+
+func (fake *ReleaseUploader) GetMatchedReleaseCalls(stub func(context.Context, cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error)) {
+	fake.getMatchedReleaseMutex.Lock()
+	defer fake.getMatchedReleaseMutex.Unlock()
+	fake.GetMatchedReleaseStub = stub
+}
+
+func (fake *ReleaseUploader) GetMatchedReleaseArgsForCall(i int) (context.Context, cargo.BOSHReleaseTarballSpecification) {
+	fake.getMatchedReleaseMutex.RLock()
+	defer fake.getMatchedReleaseMutex.RUnlock()
+	argsForCall := fake.getMatchedReleaseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *ReleaseUploader) GetMatchedReleaseReturns(result1 cargo.BOSHReleaseTarballLock, result2 error) {
+	fake.getMatchedReleaseMutex.Lock()
+	defer fake.getMatchedReleaseMutex.Unlock()
+	fake.GetMatchedReleaseStub = nil
+	fake.getMatchedReleaseReturns = struct {
+		result1 cargo.BOSHReleaseTarballLock
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReleaseUploader) GetMatchedReleaseReturnsOnCall(i int, result1 cargo.BOSHReleaseTarballLock, result2 error) {
+	fake.getMatchedReleaseMutex.Lock()
+	defer fake.getMatchedReleaseMutex.Unlock()
+	fake.GetMatchedReleaseStub = nil
+	if fake.getMatchedReleaseReturnsOnCall == nil {
+		fake.getMatchedReleaseReturnsOnCall = make(map[int]struct {
+			result1 cargo.BOSHReleaseTarballLock
+			result2 error
+		})
+	}
+	fake.getMatchedReleaseReturnsOnCall[i] = struct {
+		result1 cargo.BOSHReleaseTarballLock
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReleaseUploader) RemotePath(arg1 cargo.BOSHReleaseTarballSpecification) (string, error) {
+	fake.remotePathMutex.Lock()
+	ret, specificReturn := fake.remotePathReturnsOnCall[len(fake.remotePathArgsForCall)]
+	fake.remotePathArgsForCall = append(fake.remotePathArgsForCall, struct {
+		arg1 cargo.BOSHReleaseTarballSpecification
+	}{arg1})
+	stub := fake.RemotePathStub
+	fakeReturns := fake.remotePathReturns
+	fake.recordInvocation("RemotePath", []interface{}{arg1})
+	fake.remotePathMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *ReleaseUploader) RemotePathCallCount() int {
+	fake.remotePathMutex.RLock()
+	defer fake.remotePathMutex.RUnlock()
+	return len(fake.remotePathArgsForCall)
+}
+
+func (fake *ReleaseUploader) RemotePathCalls(stub func(cargo.BOSHReleaseTarballSpecification) (string, error)) {
+	fake.remotePathMutex.Lock()
+	defer fake.remotePathMutex.Unlock()
+	fake.RemotePathStub = stub
+}
+
+func (fake *ReleaseUploader) RemotePathArgsForCall(i int) cargo.BOSHReleaseTarballSpecification {
+	fake.remotePathMutex.RLock()
+	defer fake.remotePathMutex.RUnlock()
+	argsForCall := fake.remotePathArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *ReleaseUploader) RemotePathReturns(result1 string, result2 error) {
+	fake.remotePathMutex.Lock()
+	defer fake.remotePathMutex.Unlock()
+	fake.RemotePathStub = nil
+	fake.remotePathReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReleaseUploader) RemotePathReturnsOnCall(i int, result1 string, result2 error) {
+	fake.remotePathMutex.Lock()
+	defer fake.remotePathMutex.Unlock()
+	fake.RemotePathStub = nil
+	if fake.remotePathReturnsOnCall == nil {
+		fake.remotePathReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.remotePathReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReleaseUploader) UploadRelease(arg1 context.Context, arg2 cargo.BOSHReleaseTarballSpecification, arg3 io.Reader) (cargo.BOSHReleaseTarballLock, error) {
+	fake.uploadReleaseMutex.Lock()
+	ret, specificReturn := fake.uploadReleaseReturnsOnCall[len(fake.uploadReleaseArgsForCall)]
+	fake.uploadReleaseArgsForCall = append(fake.uploadReleaseArgsForCall, struct {
+		arg1 context.Context
+		arg2 cargo.BOSHReleaseTarballSpecification
+		arg3 io.Reader
+	}{arg1, arg2, arg3})
+	stub := fake.UploadReleaseStub
+	fakeReturns := fake.uploadReleaseReturns
+	fake.recordInvocation("UploadRelease", []interface{}{arg1, arg2, arg3})
+	fake.uploadReleaseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *ReleaseUploader) UploadReleaseCallCount() int {
+	fake.uploadReleaseMutex.RLock()
+	defer fake.uploadReleaseMutex.RUnlock()
+	return len(fake.uploadReleaseArgsForCall)
+}
+
+func (fake *ReleaseUploader) UploadReleaseCalls(stub func(context.Context, cargo.BOSHReleaseTarballSpecification, io.Reader) (cargo.BOSHReleaseTarballLock, error)) {
+	fake.uploadReleaseMutex.Lock()
+	defer fake.uploadReleaseMutex.Unlock()
+	fake.UploadReleaseStub = stub
+}
+
+func (fake *ReleaseUploader) UploadReleaseArgsForCall(i int) (context.Context, cargo.BOSHReleaseTarballSpecification, io.Reader) {
+	fake.uploadReleaseMutex.RLock()
+	defer fake.uploadReleaseMutex.RUnlock()
+	argsForCall := fake.uploadReleaseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *ReleaseUploader) UploadReleaseReturns(result1 cargo.BOSHReleaseTarballLock, result2 error) {
+	fake.uploadReleaseMutex.Lock()
+	defer fake.uploadReleaseMutex.Unlock()
+	fake.UploadReleaseStub = nil
+	fake.uploadReleaseReturns = struct {
+		result1 cargo.BOSHReleaseTarballLock
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReleaseUploader) UploadReleaseReturnsOnCall(i int, result1 cargo.BOSHReleaseTarballLock, result2 error) {
+	fake.uploadReleaseMutex.Lock()
+	defer fake.uploadReleaseMutex.Unlock()
+	fake.UploadReleaseStub = nil
+	if fake.uploadReleaseReturnsOnCall == nil {
+		fake.uploadReleaseReturnsOnCall = make(map[int]struct {
+			result1 cargo.BOSHReleaseTarballLock
+			result2 error
+		})
+	}
+	fake.uploadReleaseReturnsOnCall[i] = struct {
+		result1 cargo.BOSHReleaseTarballLock
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReleaseUploader) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *ReleaseUploader) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ component.ReleaseUploader = new(ReleaseUploader)
@@ -0,0 +1,120 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/feature/s3/transfermanager"
+	"github.com/pivotal-cf/kiln/internal/component"
+)
+
+type S3Uploader struct {
+	UploadObjectStub        func(context.Context, *transfermanager.UploadObjectInput, ...func(*transfermanager.Options)) (*transfermanager.UploadObjectOutput, error)
+	uploadObjectMutex       sync.RWMutex
+	uploadObjectArgsForCall []struct {
+		arg1 context.Context
+		arg2 *transfermanager.UploadObjectInput
+		arg3 []func(*transfermanager.Options)
+	}
+	uploadObjectReturns struct {
+		result1 *transfermanager.UploadObjectOutput
+		result2 error
+	}
+	uploadObjectReturnsOnCall map[int]struct {
+		result1 *transfermanager.UploadObjectOutput
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *S3Uploader) UploadObject(arg1 context.Context, arg2 *transfermanager.UploadObjectInput, arg3 ...func(*transfermanager.Options)) (*transfermanager.UploadObjectOutput, error) {
+	fake.uploadObjectMutex.Lock()
+	ret, specificReturn := fake.uploadObjectReturnsOnCall[len(fake.uploadObjectArgsForCall)]
+	fake.uploadObjectArgsForCall = append(fake.uploadObjectArgsForCall, struct {
+		arg1 context.Context
+		arg2 *transfermanager.UploadObjectInput
+		arg3 []func(*transfermanager.Options)
+	}{arg1, arg2, arg3})
+	stub := fake.UploadObjectStub
+	fakeReturns := fake.uploadObjectReturns
+	fake.recordInvocation("UploadObject", []interface{}{arg1, arg2, arg3})
+	fake.uploadObjectMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *S3Uploader) UploadObjectCallCount() int {
+	fake.uploadObjectMutex.RLock()
+	defer fake.uploadObjectMutex.RUnlock()
+	return len(fake.uploadObjectArgsForCall)
+}
+
+func (fake *S3Uploader) UploadObjectCalls(stub func(context.Context, *transfermanager.UploadObjectInput, ...func(*transfermanager.Options)) (*transfermanager.UploadObjectOutput, error)) {
+	fake.uploadObjectMutex.Lock()
+	defer fake.uploadObjectMutex.Unlock()
+	fake.UploadObjectStub = stub
+}
+
+func (fake *S3Uploader) UploadObjectArgsForCall(i int) (context.Context, *transfermanager.UploadObjectInput, []func(*transfermanager.Options)) {
+	fake.uploadObjectMutex.RLock()
+	defer fake.uploadObjectMutex.RUnlock()
+	argsForCall := fake.uploadObjectArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *S3Uploader) UploadObjectReturns(result1 *transfermanager.UploadObjectOutput, result2 error) {
+	fake.uploadObjectMutex.Lock()
+	defer fake.uploadObjectMutex.Unlock()
+	fake.UploadObjectStub = nil
+	fake.uploadObjectReturns = struct {
+		result1 *transfermanager.UploadObjectOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *S3Uploader) UploadObjectReturnsOnCall(i int, result1 *transfermanager.UploadObjectOutput, result2 error) {
+	fake.uploadObjectMutex.Lock()
+	defer fake.uploadObjectMutex.Unlock()
+	fake.UploadObjectStub = nil
+	if fake.uploadObjectReturnsOnCall == nil {
+		fake.uploadObjectReturnsOnCall = make(map[int]struct {
+			result1 *transfermanager.UploadObjectOutput
+			result2 error
+		})
+	}
+	fake.uploadObjectReturnsOnCall[i] = struct {
+		result1 *transfermanager.UploadObjectOutput
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *S3Uploader) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *S3Uploader) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ component.S3Uploader = new(S3Uploader)
@@ -3,7 +3,6 @@ package component
 import (
 	"context"
 	"crypto/sha1"
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -29,6 +28,8 @@ type GithubReleaseSource struct {
 	ReleaseAssetDownloader
 	ReleasesLister
 	ReleaseByTagGetter
+
+	Organizations OrganizationGetter
 }
 
 // NewGithubReleaseSource will provision a new GithubReleaseSource Project
@@ -71,6 +72,8 @@ func NewGithubReleaseSource(c cargo.ReleaseSourceConfig, logger *log.Logger) *Gi
 		ReleaseAssetDownloader: githubClient.Repositories,
 		ReleaseByTagGetter:     githubClient.Repositories,
 		ReleasesLister:         githubClient.Repositories,
+
+		Organizations: githubClient.Organizations,
 	}
 }
 
@@ -80,15 +83,29 @@ func (grs *GithubReleaseSource) Configuration() cargo.ReleaseSourceConfig {
 	return grs.ReleaseSourceConfig
 }
 
+//counterfeiter:generate -o ./fakes/organization_getter.go --fake-name OrganizationGetter . OrganizationGetter
+
+type OrganizationGetter interface {
+	Get(ctx context.Context, org string) (*github.Organization, *github.Response, error)
+}
+
+// Probe looks up the configured organization to check the token is accepted.
+func (grs *GithubReleaseSource) Probe(ctx context.Context) error {
+	_, _, err := grs.Organizations.Get(ctx, grs.Org)
+	if err != nil {
+		return fmt.Errorf("failed to get github organization %s: %w", grs.Org, err)
+	}
+	return nil
+}
+
 // GetMatchedRelease uses the Name and Version and if supported StemcellOS and StemcellVersion
 // fields on Requirement to download a specific release.
-func (grs *GithubReleaseSource) GetMatchedRelease(s cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error) {
+func (grs *GithubReleaseSource) GetMatchedRelease(ctx context.Context, s cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error) {
 	_, err := semver.NewVersion(s.Version)
 	if err != nil {
 		return cargo.BOSHReleaseTarballLock{}, fmt.Errorf("expected version to be an exact version")
 	}
 
-	ctx := context.TODO()
 	release, err := grs.GetGithubReleaseWithTag(ctx, s)
 	if err != nil {
 		return cargo.BOSHReleaseTarballLock{}, err
@@ -196,8 +213,7 @@ func (grs *GithubReleaseSource) GetLatestMatchingRelease(ctx context.Context, s
 
 // FindReleaseVersion may use any of the fields on Requirement to return the best matching
 // release.
-func (grs *GithubReleaseSource) FindReleaseVersion(s cargo.BOSHReleaseTarballSpecification, noDownload bool) (cargo.BOSHReleaseTarballLock, error) {
-	ctx := context.TODO()
+func (grs *GithubReleaseSource) FindReleaseVersion(ctx context.Context, s cargo.BOSHReleaseTarballSpecification, noDownload bool) (cargo.BOSHReleaseTarballLock, error) {
 	release, err := grs.GetLatestMatchingRelease(ctx, s)
 	if err != nil {
 		return cargo.BOSHReleaseTarballLock{}, err
@@ -261,9 +277,9 @@ type ReleasesLister interface {
 // DownloadRelease downloads the release and writes the resulting file to the releasesDir.
 // It should also calculate and set the SHA1 field on the Local result; it does not need
 // to ensure the sums match, the caller must verify this.
-func (grs *GithubReleaseSource) DownloadRelease(releaseDir string, remoteRelease cargo.BOSHReleaseTarballLock) (Local, error) {
+func (grs *GithubReleaseSource) DownloadRelease(ctx context.Context, releaseDir string, remoteRelease cargo.BOSHReleaseTarballLock) (Local, error) {
 	grs.Logger.Printf(logLineDownload, remoteRelease.Name, remoteRelease.Version, ReleaseSourceTypeGithub, grs.ID)
-	return downloadRelease(context.TODO(), releaseDir, remoteRelease, grs, grs.Logger)
+	return downloadRelease(ctx, releaseDir, remoteRelease, grs, grs.Logger)
 }
 
 //counterfeiter:generate -o ./fakes/release_by_tag_getter_asset_downloader.go --fake-name ReleaseByTagGetterAssetDownloader . ReleaseByTagGetterAssetDownloader
@@ -312,15 +328,16 @@ func downloadRelease(ctx context.Context, releaseDir string, remoteRelease cargo
 	}
 	defer closeAndIgnoreError(file)
 
-	hash := sha1.New()
+	digest := newReleaseDigest()
 
-	mw := io.MultiWriter(file, hash)
+	mw := io.MultiWriter(file, digest)
 	_, err = io.Copy(mw, rc)
 	if err != nil {
+		removePartialDownload(file)
 		return Local{}, fmt.Errorf("failed to calculate checksum for downloaded file: %w: ", err)
 	}
 
-	remoteRelease.SHA1 = hex.EncodeToString(hash.Sum(nil))
+	remoteRelease = digest.setSums(remoteRelease)
 
 	return Local{Lock: remoteRelease, LocalPath: filePath}, nil
 }
@@ -103,7 +103,7 @@ func TestGithubReleaseSource_ComponentLockFromGithubRelease(t *testing.T) {
 			},
 		}
 
-		lock, err := grsMock.GetMatchedRelease(cargo.BOSHReleaseTarballSpecification{
+		lock, err := grsMock.GetMatchedRelease(context.Background(), cargo.BOSHReleaseTarballSpecification{
 			Name:             "routing",
 			Version:          "0.226.0",
 			GitHubRepository: "https://github.com/cloudfoundry/routing-release",
@@ -192,7 +192,7 @@ func TestGithubReleaseSource_ComponentLockFromGithubRelease(t *testing.T) {
 		}
 
 		// When...
-		lock, err := grsMock.GetMatchedRelease(cargo.BOSHReleaseTarballSpecification{
+		lock, err := grsMock.GetMatchedRelease(context.Background(), cargo.BOSHReleaseTarballSpecification{
 			Name:             "routing",
 			Version:          "0.226.0",
 			GitHubRepository: "https://github.com/cloudfoundry/routing-release",
@@ -237,7 +237,7 @@ func TestGithubReleaseSource_FindReleaseVersion(t *testing.T) {
 		logger := log.New(GinkgoWriter, "[test] ", log.Default().Flags())
 
 		grs := component.NewGithubReleaseSource(cargo.ReleaseSourceConfig{Type: component.ReleaseSourceTypeGithub, GithubToken: "fake_token", Org: "cloudfoundry"}, logger)
-		_, err := grs.FindReleaseVersion(s, false)
+		_, err := grs.FindReleaseVersion(context.Background(), s, false)
 
 		t.Run("it returns an error about version not being specific", func(t *testing.T) {
 			damnIt := NewWithT(t)
@@ -247,7 +247,7 @@ func TestGithubReleaseSource_FindReleaseVersion(t *testing.T) {
 	})
 
 	t.Run("noDownload is true", func(t *testing.T) {
-		please := NewWithT(t)
+		damnIt := NewWithT(t)
 
 		downloader := new(fakes.ReleaseAssetDownloader)
 		downloader.DownloadReleaseAssetReturns(nil, "", fmt.Errorf("this is a mistake! I'm not supposed to be here!"))
@@ -291,11 +291,11 @@ func TestGithubReleaseSource_FindReleaseVersion(t *testing.T) {
 			},
 		}
 
-		lock, err := grsMock.FindReleaseVersion(s, true)
-		please.Expect(err).ToNot(HaveOccurred())
+		lock, err := grsMock.FindReleaseVersion(context.Background(), s, true)
+		damnIt.Expect(err).ToNot(HaveOccurred())
 
-		please.Expect(lock.SHA1).To(Equal("not-calculated"))
-		please.Expect(downloader.Invocations()).To(BeEmpty())
+		damnIt.Expect(lock.SHA1).To(Equal("not-calculated"))
+		damnIt.Expect(downloader.Invocations()).To(BeEmpty())
 	})
 }
 
@@ -308,7 +308,7 @@ func TestGithubReleaseSource_GetMatchedRelease(t *testing.T) {
 		logger := log.New(GinkgoWriter, "[test] ", log.Default().Flags())
 
 		grs := component.NewGithubReleaseSource(cargo.ReleaseSourceConfig{Type: component.ReleaseSourceTypeGithub, GithubToken: "fake_token", Org: "cloudfoundry"}, logger)
-		_, err := grs.GetMatchedRelease(s)
+		_, err := grs.GetMatchedRelease(context.Background(), s)
 
 		t.Run("it returns an error about version not being specific", func(t *testing.T) {
 			damnIt := NewWithT(t)
@@ -574,8 +574,40 @@ func TestGetLatestMatchingRelease(t *testing.T) {
 		_, err := grsMock.GetLatestMatchingRelease(ctx, spec)
 
 		// then
-		please := NewWithT(t)
-		please.Expect(component.IsErrNotFound(err)).To(BeTrue())
+		damnIt := NewWithT(t)
+		damnIt.Expect(component.IsErrNotFound(err)).To(BeTrue())
+	})
+}
+
+func TestGithubReleaseSource_Probe(t *testing.T) {
+	t.Run("when the organization is found", func(t *testing.T) {
+		damnIt := NewWithT(t)
+
+		orgGetter := new(fakes.OrganizationGetter)
+		orgGetter.GetReturns(&github.Organization{}, &github.Response{Response: &http.Response{StatusCode: http.StatusOK}}, nil)
+
+		var grs component.ReleaseSourceProber = &component.GithubReleaseSource{
+			ReleaseSourceConfig: cargo.ReleaseSourceConfig{Org: "cloudfoundry"},
+			Organizations:       orgGetter,
+		}
+
+		damnIt.Expect(grs.Probe(context.Background())).To(Succeed())
+		_, org := orgGetter.GetArgsForCall(0)
+		damnIt.Expect(org).To(Equal("cloudfoundry"))
+	})
+
+	t.Run("when the token is rejected", func(t *testing.T) {
+		damnIt := NewWithT(t)
+
+		orgGetter := new(fakes.OrganizationGetter)
+		orgGetter.GetReturns(nil, &github.Response{Response: &http.Response{StatusCode: http.StatusUnauthorized}}, errors.New("401 Bad credentials"))
+
+		grs := &component.GithubReleaseSource{
+			ReleaseSourceConfig: cargo.ReleaseSourceConfig{Org: "cloudfoundry"},
+			Organizations:       orgGetter,
+		}
+
+		damnIt.Expect(grs.Probe(context.Background())).To(MatchError(ContainSubstring("failed to get github organization cloudfoundry: 401 Bad credentials")))
 	})
 }
 
@@ -592,7 +624,7 @@ func TestDownloadReleaseAsset(t *testing.T) {
 		},
 		logger,
 	)
-	testLock, err := grs.GetMatchedRelease(cargo.BOSHReleaseTarballSpecification{Name: "routing", Version: "0.226.0", GitHubRepository: "https://github.com/cloudfoundry/routing-release"})
+	testLock, err := grs.GetMatchedRelease(context.Background(), cargo.BOSHReleaseTarballSpecification{Name: "routing", Version: "0.226.0", GitHubRepository: "https://github.com/cloudfoundry/routing-release"})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -604,7 +636,7 @@ func TestDownloadReleaseAsset(t *testing.T) {
 			_ = os.RemoveAll(tempDir)
 		})
 
-		local, err := grs.DownloadRelease(tempDir, testLock)
+		local, err := grs.DownloadRelease(context.Background(), tempDir, testLock)
 		damnIt.Expect(err).NotTo(HaveOccurred())
 
 		damnIt.Expect(local.LocalPath).NotTo(BeAnExistingFile(), "it creates the expected asset")
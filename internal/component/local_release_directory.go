@@ -44,6 +44,7 @@ func (l LocalReleaseDirectory) GetLocalReleases(releasesDir string) ([]Local, er
 			Name:    releaseTarball.Manifest.Name,
 			Version: releaseTarball.Manifest.Version,
 			SHA1:    releaseTarball.SHA1,
+			SHA256:  releaseTarball.SHA256,
 		}
 
 		stemcellOS, stemcellVersion, ok := releaseTarball.Manifest.Stemcell()
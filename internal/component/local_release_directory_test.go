@@ -61,6 +61,7 @@ var _ = Describe("LocalReleaseDirectory", func() {
 							Name:            "some-release",
 							Version:         "1.2.3",
 							SHA1:            "6d96f7c98610fa6d8e7f45271111221b5b8497a2",
+							SHA256:          "6ff4d9d50beaa2f73063a66c8cf0df769bf244cb2f78bd257f58275d0d6a266d",
 							StemcellOS:      "some-os",
 							StemcellVersion: "4.5.6",
 						},
@@ -0,0 +1,525 @@
+package component
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/Masterminds/semver/v3"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+const (
+	// OCIBOSHReleaseTarballMediaType is the layer media type kiln looks for in an
+	// OCI artifact manifest. When a manifest has a single layer, that layer is used
+	// regardless of its media type.
+	OCIBOSHReleaseTarballMediaType = "application/vnd.cloudfoundry.bosh.release.v1.tar+gzip"
+
+	ociDockerManifestMediaType = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// OCIReleaseSource fetches BOSH release tarballs stored as artifacts in an
+// OCI distribution registry. Each release version is a tag on the repository
+// computed from RepositoryTemplate; the tarball is the artifact's layer.
+//
+// The remote_path in a lock has the form "repository:tag".
+type OCIReleaseSource struct {
+	cargo.ReleaseSourceConfig
+	Client *http.Client
+	logger *log.Logger
+
+	tokenMutex sync.Mutex
+	tokens     map[string]string
+}
+
+// NewOCIReleaseSource will provision a new OCIReleaseSource
+// from the Kilnfile (ReleaseSourceConfig). If type is incorrect it will PANIC
+func NewOCIReleaseSource(c cargo.ReleaseSourceConfig, logger *log.Logger) *OCIReleaseSource {
+	if c.Type != "" && c.Type != ReleaseSourceTypeOCI {
+		panic(panicMessageWrongReleaseSourceType)
+	}
+
+	if logger == nil {
+		logger = log.New(os.Stderr, "[OCI release source] ", log.Default().Flags())
+	}
+
+	return &OCIReleaseSource{
+		ReleaseSourceConfig: c,
+		Client:              http.DefaultClient,
+		logger:              logger,
+		tokens:              make(map[string]string),
+	}
+}
+
+func (src *OCIReleaseSource) Configuration() cargo.ReleaseSourceConfig {
+	return src.ReleaseSourceConfig
+}
+
+// GetMatchedRelease uses the Name and Version and if supported StemcellOS and StemcellVersion
+// fields on Requirement to download a specific release.
+func (src *OCIReleaseSource) GetMatchedRelease(ctx context.Context, spec cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error) {
+	if _, err := semver.NewVersion(spec.Version); err != nil {
+		return cargo.BOSHReleaseTarballLock{}, fmt.Errorf("expected version to be an exact version")
+	}
+
+	repository, err := src.Repository(spec)
+	if err != nil {
+		return cargo.BOSHReleaseTarballLock{}, err
+	}
+	tag := ociTagFromVersion(spec.Version)
+
+	res, err := src.request(ctx, http.MethodHead, repository, src.registryURL("/v2/"+repository+"/manifests/"+tag), ociManifestAccept)
+	if err != nil {
+		return cargo.BOSHReleaseTarballLock{}, err
+	}
+	closeAndIgnoreError(res.Body)
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return cargo.BOSHReleaseTarballLock{}, ErrNotFound
+	default:
+		return cargo.BOSHReleaseTarballLock{}, checkStatus(http.StatusOK, res.StatusCode)
+	}
+
+	return cargo.BOSHReleaseTarballLock{
+		Name:         spec.Name,
+		Version:      spec.Version,
+		RemotePath:   repository + ":" + tag,
+		RemoteSource: src.ID,
+	}, nil
+}
+
+// FindReleaseVersion lists the tags on the release repository and returns the
+// highest version matching the version constraint on the specification.
+func (src *OCIReleaseSource) FindReleaseVersion(ctx context.Context, spec cargo.BOSHReleaseTarballSpecification, noDownload bool) (cargo.BOSHReleaseTarballLock, error) {
+	constraint, err := spec.VersionConstraints()
+	if err != nil {
+		return cargo.BOSHReleaseTarballLock{}, err
+	}
+
+	repository, err := src.Repository(spec)
+	if err != nil {
+		return cargo.BOSHReleaseTarballLock{}, err
+	}
+
+	tags, err := src.listTags(ctx, repository)
+	if err != nil {
+		return cargo.BOSHReleaseTarballLock{}, err
+	}
+
+	var (
+		highestVersion *semver.Version
+		highestTag     string
+	)
+	for _, tag := range tags {
+		v, err := semver.NewVersion(ociVersionFromTag(tag))
+		if err != nil {
+			continue
+		}
+		if !constraint.Check(v) {
+			continue
+		}
+		if highestVersion != nil && !v.GreaterThan(highestVersion) {
+			continue
+		}
+		highestVersion, highestTag = v, tag
+	}
+	if highestVersion == nil {
+		return cargo.BOSHReleaseTarballLock{}, ErrNotFound
+	}
+
+	lock := cargo.BOSHReleaseTarballLock{
+		Name:         spec.Name,
+		Version:      ociVersionFromTag(highestTag),
+		RemotePath:   repository + ":" + highestTag,
+		RemoteSource: src.ID,
+	}
+
+	if noDownload {
+		lock.SHA1 = "not-calculated"
+		return lock, nil
+	}
+
+	tmp, err := os.MkdirTemp("", "kiln-oci-release-*")
+	if err != nil {
+		return cargo.BOSHReleaseTarballLock{}, err
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	local, err := src.DownloadRelease(ctx, tmp, lock)
+	if err != nil {
+		return cargo.BOSHReleaseTarballLock{}, err
+	}
+	lock.SHA1 = local.Lock.SHA1
+	lock.SHA256 = local.Lock.SHA256
+	return lock, nil
+}
+
+// DownloadRelease downloads the release and writes the resulting file to the releasesDir.
+// It should also calculate and set the SHA1 field on the Local result; it does not need
+// to ensure the sums match, the caller must verify this.
+func (src *OCIReleaseSource) DownloadRelease(ctx context.Context, releaseDir string, remoteRelease cargo.BOSHReleaseTarballLock) (Local, error) {
+	src.logger.Printf(logLineDownload, remoteRelease.Name, remoteRelease.Version, ReleaseSourceTypeOCI, src.ID)
+
+	repository, tag, ok := parseOCIRemotePath(remoteRelease.RemotePath)
+	if !ok {
+		return Local{}, fmt.Errorf("failed to parse remote_path %q: expected the form repository:tag", remoteRelease.RemotePath)
+	}
+
+	layer, err := src.releaseLayer(ctx, repository, tag)
+	if err != nil {
+		return Local{}, err
+	}
+
+	res, err := src.request(ctx, http.MethodGet, repository, src.registryURL("/v2/"+repository+"/blobs/"+layer.Digest.String()), "")
+	if err != nil {
+		return Local{}, err
+	}
+	defer closeAndIgnoreError(res.Body)
+	if err := checkStatus(http.StatusOK, res.StatusCode); err != nil {
+		return Local{}, fmt.Errorf("failed to download %s release blob %s: %w", remoteRelease.Name, layer.Digest, err)
+	}
+
+	filePath := filepath.Join(releaseDir, fmt.Sprintf("%s-%s.tgz", remoteRelease.Name, remoteRelease.Version))
+
+	out, err := os.Create(filePath)
+	if err != nil {
+		return Local{}, err
+	}
+	defer closeAndIgnoreError(out)
+
+	digest := newReleaseDigest()
+	verifier := layer.Digest.Verifier()
+
+	_, err = io.Copy(io.MultiWriter(out, digest, verifier), res.Body)
+	if err != nil {
+		removePartialDownload(out)
+		return Local{}, err
+	}
+
+	if !verifier.Verified() {
+		removePartialDownload(out)
+		return Local{}, fmt.Errorf("downloaded blob for %s %s does not match digest %s", remoteRelease.Name, remoteRelease.Version, layer.Digest)
+	}
+
+	remoteRelease = digest.setSums(remoteRelease)
+
+	return Local{Lock: remoteRelease, LocalPath: filePath}, nil
+}
+
+// Repository evaluates the repository_template with the specification.
+func (src *OCIReleaseSource) Repository(spec cargo.BOSHReleaseTarballSpecification) (string, error) {
+	tmp, err := template.New("repository").
+		Funcs(template.FuncMap{"trimSuffix": strings.TrimSuffix}).
+		Parse(src.RepositoryTemplate)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse repository_template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmp.Execute(&buf, spec); err != nil {
+		return "", fmt.Errorf("unable to evaluate repository_template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+var ociManifestAccept = strings.Join([]string{ocispec.MediaTypeImageManifest, ociDockerManifestMediaType}, ", ")
+
+func (src *OCIReleaseSource) releaseLayer(ctx context.Context, repository, tag string) (ocispec.Descriptor, error) {
+	res, err := src.request(ctx, http.MethodGet, repository, src.registryURL("/v2/"+repository+"/manifests/"+tag), ociManifestAccept)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	defer closeAndIgnoreError(res.Body)
+	if res.StatusCode == http.StatusNotFound {
+		return ocispec.Descriptor{}, ErrNotFound
+	}
+	if err := checkStatus(http.StatusOK, res.StatusCode); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to get manifest for %s:%s: %w", repository, tag, err)
+	}
+
+	var manifest ocispec.Manifest
+	if err := json.NewDecoder(res.Body).Decode(&manifest); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to parse manifest for %s:%s: %w", repository, tag, err)
+	}
+
+	for _, layer := range manifest.Layers {
+		if layer.MediaType == OCIBOSHReleaseTarballMediaType {
+			return layer, layer.Digest.Validate()
+		}
+	}
+	if len(manifest.Layers) == 1 {
+		return manifest.Layers[0], manifest.Layers[0].Digest.Validate()
+	}
+	return ocispec.Descriptor{}, fmt.Errorf("manifest for %s:%s does not have a layer with media type %q", repository, tag, OCIBOSHReleaseTarballMediaType)
+}
+
+func (src *OCIReleaseSource) listTags(ctx context.Context, repository string) ([]string, error) {
+	var tags []string
+	next := src.registryURL("/v2/" + repository + "/tags/list")
+	for next != "" {
+		res, err := src.request(ctx, http.MethodGet, repository, next, "application/json")
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode == http.StatusNotFound {
+			closeAndIgnoreError(res.Body)
+			return nil, ErrNotFound
+		}
+		if err := checkStatus(http.StatusOK, res.StatusCode); err != nil {
+			closeAndIgnoreError(res.Body)
+			return nil, fmt.Errorf("failed to list tags for %s: %w", repository, err)
+		}
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&page)
+		closeAndIgnoreError(res.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse tag list for %s: %w", repository, err)
+		}
+		tags = append(tags, page.Tags...)
+
+		next, err = nextLink(res.Request.URL, res.Header.Get("Link"))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tags, nil
+}
+
+func (src *OCIReleaseSource) registryURL(p string) string {
+	host := strings.TrimSuffix(src.Registry, "/")
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	return host + p
+}
+
+// Probe requests the registry API version check endpoint. When the registry
+// responds with a bearer token challenge, it checks a token can be fetched with
+// the configured credentials.
+func (src *OCIReleaseSource) Probe(ctx context.Context) error {
+	get := func(token string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, src.registryURL("/v2/"), nil)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case token != "":
+			req.Header.Set("Authorization", "Bearer "+token)
+		case src.Username != "":
+			req.SetBasicAuth(src.Username, src.Password)
+		}
+		res, err := src.Client.Do(req)
+		return res, wrapVPNError(err)
+	}
+
+	res, err := get("")
+	if err != nil {
+		return err
+	}
+	closeAndIgnoreError(res.Body)
+	if challenge := res.Header.Get("WWW-Authenticate"); res.StatusCode == http.StatusUnauthorized && strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		token, err := src.fetchToken(ctx, challenge, "")
+		if err != nil {
+			return err
+		}
+		res, err = get(token)
+		if err != nil {
+			return err
+		}
+		closeAndIgnoreError(res.Body)
+	}
+	if err := checkStatus(http.StatusOK, res.StatusCode); err != nil {
+		return fmt.Errorf("failed to check registry %s: %w", src.Registry, err)
+	}
+	return nil
+}
+
+// request does an HTTP request against the registry. When the registry responds
+// with a bearer token challenge, a token is requested (using basic auth if
+// credentials are configured) and the request is retried.
+func (src *OCIReleaseSource) request(ctx context.Context, method, repository, u, accept string) (*http.Response, error) {
+	scope := "repository:" + repository + ":pull"
+
+	do := func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		src.tokenMutex.Lock()
+		token, hasToken := src.tokens[scope]
+		src.tokenMutex.Unlock()
+		switch {
+		case hasToken:
+			req.Header.Set("Authorization", "Bearer "+token)
+		case src.Username != "":
+			req.SetBasicAuth(src.Username, src.Password)
+		}
+		return src.Client.Do(req)
+	}
+
+	res, err := do()
+	if err != nil {
+		return nil, wrapVPNError(err)
+	}
+	if res.StatusCode != http.StatusUnauthorized {
+		return res, nil
+	}
+	challenge := res.Header.Get("WWW-Authenticate")
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return res, nil
+	}
+	closeAndIgnoreError(res.Body)
+
+	token, err := src.fetchToken(ctx, challenge, scope)
+	if err != nil {
+		return nil, err
+	}
+	src.tokenMutex.Lock()
+	src.tokens[scope] = token
+	src.tokenMutex.Unlock()
+
+	res, err = do()
+	return res, wrapVPNError(err)
+}
+
+func (src *OCIReleaseSource) fetchToken(ctx context.Context, challenge, scope string) (string, error) {
+	params := parseAuthChallenge(challenge[len("bearer "):])
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("registry responded with an invalid bearer challenge: %q", challenge)
+	}
+	q := realm.Query()
+	if service := params["service"]; service != "" {
+		q.Set("service", service)
+	}
+	if s := params["scope"]; s != "" {
+		scope = s
+	}
+	if scope != "" {
+		q.Set("scope", scope)
+	}
+	realm.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if src.Username != "" {
+		req.SetBasicAuth(src.Username, src.Password)
+	}
+	res, err := src.Client.Do(req)
+	if err != nil {
+		return "", wrapVPNError(err)
+	}
+	defer closeAndIgnoreError(res.Body)
+	if err := checkStatus(http.StatusOK, res.StatusCode); err != nil {
+		return "", fmt.Errorf("failed to get registry token: %w", err)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to parse registry token response: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", errors.New("registry token response did not contain a token")
+}
+
+// parseAuthChallenge parses the comma separated key="value" pairs in a
+// WWW-Authenticate header value (without the scheme).
+func parseAuthChallenge(s string) map[string]string {
+	params := make(map[string]string)
+	for s != "" {
+		s = strings.TrimLeft(s, ", ")
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, s = rest[1:], ""
+			} else {
+				value, s = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			value, s, _ = strings.Cut(rest, ",")
+		}
+		params[key] = value
+	}
+	return params
+}
+
+// nextLink parses an RFC 5988 Link header as returned by the tags list
+// endpoint and returns the absolute URL of the next page.
+func nextLink(base *url.URL, header string) (string, error) {
+	if header == "" {
+		return "", nil
+	}
+	for _, link := range strings.Split(header, ",") {
+		target, params, _ := strings.Cut(strings.TrimSpace(link), ";")
+		if !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		ref, err := url.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+		if err != nil {
+			return "", fmt.Errorf("failed to parse Link header: %w", err)
+		}
+		return base.ResolveReference(ref).String(), nil
+	}
+	return "", nil
+}
+
+// parseOCIRemotePath splits a remote path of the form "repository:tag".
+// Repository names may not contain colons so the last colon separates the tag.
+func parseOCIRemotePath(remotePath string) (repository, tag string, ok bool) {
+	i := strings.LastIndex(remotePath, ":")
+	if i <= 0 || i == len(remotePath)-1 {
+		return "", "", false
+	}
+	repository, tag = remotePath[:i], remotePath[i+1:]
+	if strings.Contains(tag, "/") {
+		return "", "", false
+	}
+	return repository, tag, true
+}
+
+// OCI tags may not contain "+" so semver build metadata is stored with "_".
+// This is the same convention used by helm.
+func ociTagFromVersion(version string) string { return strings.Replace(version, "+", "_", 1) }
+
+// ociVersionFromTag reverses ociTagFromVersion. A version has at most one "+"
+// so tags with more than one "_" were not created from a version and are
+// returned unchanged.
+func ociVersionFromTag(tag string) string {
+	if strings.Count(tag, "_") != 1 {
+		return tag
+	}
+	return strings.Replace(tag, "_", "+", 1)
+}
@@ -0,0 +1,28 @@
+package component
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestOCIVersionTag(t *testing.T) {
+	for _, tt := range []struct {
+		Version, Tag string
+	}{
+		{Version: "1.2.0", Tag: "1.2.0"},
+		{Version: "1.2.1+build.1", Tag: "1.2.1_build.1"},
+		{Version: "1.2.1-rc.1+build.1", Tag: "1.2.1-rc.1_build.1"},
+	} {
+		t.Run(tt.Version, func(t *testing.T) {
+			please := NewWithT(t)
+			please.Expect(ociTagFromVersion(tt.Version)).To(Equal(tt.Tag))
+			please.Expect(ociVersionFromTag(tt.Tag)).To(Equal(tt.Version))
+		})
+	}
+
+	t.Run("tag not created from a version", func(t *testing.T) {
+		please := NewWithT(t)
+		please.Expect(ociVersionFromTag("1.2.1_build_1")).To(Equal("1.2.1_build_1"))
+	})
+}
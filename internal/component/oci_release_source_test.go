@@ -0,0 +1,375 @@
+package component_test
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/kiln/internal/component"
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+var _ = Describe("OCIReleaseSource", func() {
+	const (
+		repository = "bosh-releases/bpm"
+		token      = "some-token"
+	)
+
+	var (
+		source   *component.OCIReleaseSource
+		config   cargo.ReleaseSourceConfig
+		server   *httptest.Server
+		registry *fakeOCIRegistry
+
+		releasesDirectory string
+	)
+
+	BeforeEach(func() {
+		releasesDirectory = must(os.MkdirTemp("", "releases"))
+
+		registry = newFakeOCIRegistry()
+		registry.push(repository, "1.1.0", []byte("bpm-1.1.0"))
+		registry.push(repository, "1.2.0", []byte("bpm-1.2.0"))
+		registry.push(repository, "1.2.1_build.1", []byte("bpm-1.2.1+build.1"))
+		registry.push(repository, "2.0.0", []byte("bpm-2.0.0"))
+		registry.push(repository, "latest", []byte("bpm-2.0.0"))
+
+		config = cargo.ReleaseSourceConfig{
+			Type:               component.ReleaseSourceTypeOCI,
+			ID:                 "some-registry",
+			RepositoryTemplate: "bosh-releases/{{.Name}}",
+		}
+	})
+
+	JustBeforeEach(func() {
+		server = httptest.NewServer(registry)
+		config.Registry = server.URL
+		source = component.NewOCIReleaseSource(config, log.New(GinkgoWriter, "", 0))
+		source.Client = server.Client()
+	})
+
+	AfterEach(func() {
+		server.Close()
+		_ = os.RemoveAll(releasesDirectory)
+	})
+
+	It("is a ReleaseSource", func() {
+		var rs component.ReleaseSource = source
+		Expect(rs.Configuration().ID).To(Equal("some-registry"))
+	})
+
+	Describe("GetMatchedRelease", func() {
+		It("returns a lock for an existing tag", func() {
+			lock, err := source.GetMatchedRelease(context.Background(), cargo.BOSHReleaseTarballSpecification{Name: "bpm", Version: "1.2.0"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lock).To(Equal(cargo.BOSHReleaseTarballLock{
+				Name:         "bpm",
+				Version:      "1.2.0",
+				RemotePath:   repository + ":1.2.0",
+				RemoteSource: "some-registry",
+			}))
+		})
+
+		It("maps build metadata to a tag", func() {
+			lock, err := source.GetMatchedRelease(context.Background(), cargo.BOSHReleaseTarballSpecification{Name: "bpm", Version: "1.2.1+build.1"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lock.RemotePath).To(Equal(repository + ":1.2.1_build.1"))
+		})
+
+		When("the tag does not exist", func() {
+			It("returns ErrNotFound", func() {
+				_, err := source.GetMatchedRelease(context.Background(), cargo.BOSHReleaseTarballSpecification{Name: "bpm", Version: "9.9.9"})
+				Expect(component.IsErrNotFound(err)).To(BeTrue())
+			})
+		})
+
+		When("the version is a constraint", func() {
+			It("returns an error", func() {
+				_, err := source.GetMatchedRelease(context.Background(), cargo.BOSHReleaseTarballSpecification{Name: "bpm", Version: "~1"})
+				Expect(err).To(MatchError(ContainSubstring("exact version")))
+			})
+		})
+	})
+
+	Describe("FindReleaseVersion", func() {
+		It("returns the highest tag matching the constraint", func() {
+			lock, err := source.FindReleaseVersion(context.Background(), cargo.BOSHReleaseTarballSpecification{Name: "bpm", Version: "~1.2"}, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lock).To(Equal(cargo.BOSHReleaseTarballLock{
+				Name:         "bpm",
+				Version:      "1.2.1+build.1",
+				RemotePath:   repository + ":1.2.1_build.1",
+				RemoteSource: "some-registry",
+				SHA1:         "not-calculated",
+			}))
+		})
+
+		It("downloads the release to calculate the SHA1", func() {
+			lock, err := source.FindReleaseVersion(context.Background(), cargo.BOSHReleaseTarballSpecification{Name: "bpm"}, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lock.Version).To(Equal("2.0.0"))
+			Expect(lock.SHA1).To(Equal(sha1Hex([]byte("bpm-2.0.0"))))
+		})
+
+		When("the tags list is paginated", func() {
+			BeforeEach(func() {
+				registry.pageSize = 2
+			})
+			It("reads every page", func() {
+				lock, err := source.FindReleaseVersion(context.Background(), cargo.BOSHReleaseTarballSpecification{Name: "bpm"}, true)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lock.Version).To(Equal("2.0.0"))
+			})
+		})
+
+		When("no tag matches the constraint", func() {
+			It("returns ErrNotFound", func() {
+				_, err := source.FindReleaseVersion(context.Background(), cargo.BOSHReleaseTarballSpecification{Name: "bpm", Version: "3.x"}, true)
+				Expect(component.IsErrNotFound(err)).To(BeTrue())
+			})
+		})
+
+		When("the repository does not exist", func() {
+			It("returns ErrNotFound", func() {
+				_, err := source.FindReleaseVersion(context.Background(), cargo.BOSHReleaseTarballSpecification{Name: "banana"}, true)
+				Expect(component.IsErrNotFound(err)).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("DownloadRelease", func() {
+		It("writes the layer to the releases directory and calculates the SHA1", func() {
+			local, err := source.DownloadRelease(context.Background(), releasesDirectory, cargo.BOSHReleaseTarballLock{
+				Name:       "bpm",
+				Version:    "1.1.0",
+				RemotePath: repository + ":1.1.0",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(local.LocalPath).To(Equal(filepath.Join(releasesDirectory, "bpm-1.1.0.tgz")))
+			Expect(local.Lock.SHA1).To(Equal(sha1Hex([]byte("bpm-1.1.0"))))
+			Expect(os.ReadFile(local.LocalPath)).To(Equal([]byte("bpm-1.1.0")))
+		})
+
+		When("the blob does not match the digest in the manifest", func() {
+			BeforeEach(func() {
+				registry.corrupt = true
+			})
+			It("returns an error and removes the file", func() {
+				_, err := source.DownloadRelease(context.Background(), releasesDirectory, cargo.BOSHReleaseTarballLock{
+					Name:       "bpm",
+					Version:    "1.1.0",
+					RemotePath: repository + ":1.1.0",
+				})
+				Expect(err).To(MatchError(ContainSubstring("does not match digest")))
+				Expect(filepath.Join(releasesDirectory, "bpm-1.1.0.tgz")).NotTo(BeAnExistingFile())
+			})
+		})
+
+		When("the connection is closed during the download", func() {
+			BeforeEach(func() {
+				registry.truncated = true
+			})
+			It("returns an error and removes the file", func() {
+				_, err := source.DownloadRelease(context.Background(), releasesDirectory, cargo.BOSHReleaseTarballLock{
+					Name:       "bpm",
+					Version:    "1.1.0",
+					RemotePath: repository + ":1.1.0",
+				})
+				Expect(err).To(HaveOccurred())
+				Expect(filepath.Join(releasesDirectory, "bpm-1.1.0.tgz")).NotTo(BeAnExistingFile())
+			})
+		})
+
+		When("the remote path is malformed", func() {
+			It("returns an error", func() {
+				_, err := source.DownloadRelease(context.Background(), releasesDirectory, cargo.BOSHReleaseTarballLock{
+					Name:       "bpm",
+					Version:    "1.1.0",
+					RemotePath: repository,
+				})
+				Expect(err).To(MatchError(ContainSubstring("repository:tag")))
+			})
+		})
+	})
+
+	When("the registry requires a bearer token", func() {
+		BeforeEach(func() {
+			registry.token = token
+			config.Username = "some-user"
+			config.Password = "some-password"
+		})
+
+		It("requests a token with the configured credentials", func() {
+			lock, err := source.FindReleaseVersion(context.Background(), cargo.BOSHReleaseTarballSpecification{Name: "bpm", Version: "1.1.0"}, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lock.SHA1).To(Equal(sha1Hex([]byte("bpm-1.1.0"))))
+			Expect(registry.tokenRequests).To(Equal(1))
+			Expect(registry.tokenScope).To(Equal("repository:" + repository + ":pull"))
+		})
+
+		It("probes the registry with a token", func() {
+			Expect(source.Probe(context.Background())).To(Succeed())
+			Expect(registry.tokenRequests).To(Equal(1))
+			Expect(registry.tokenScope).To(BeEmpty())
+		})
+
+		When("the credentials are wrong", func() {
+			BeforeEach(func() {
+				config.Password = "wrong"
+			})
+
+			It("fails the probe", func() {
+				Expect(source.Probe(context.Background())).To(MatchError(ContainSubstring("failed to get registry token")))
+			})
+		})
+	})
+
+	Describe("Probe", func() {
+		It("checks the registry API is available", func() {
+			var prober component.ReleaseSourceProber = source
+			Expect(prober.Probe(context.Background())).To(Succeed())
+		})
+	})
+
+	Describe("ReleaseSourceFactory", func() {
+		It("constructs an OCI release source", func() {
+			rs := component.ReleaseSourceFactory(config)
+			Expect(rs).To(BeAssignableToTypeOf(new(component.OCIReleaseSource)))
+		})
+	})
+})
+
+// fakeOCIRegistry is a minimal in-memory implementation of the parts of the
+// OCI distribution API used by OCIReleaseSource.
+type fakeOCIRegistry struct {
+	repositories map[string]map[string]string // repository -> tag -> blob digest
+	blobs        map[string][]byte
+
+	pageSize  int
+	corrupt   bool
+	truncated bool
+
+	token         string
+	tokenRequests int
+	tokenScope    string
+}
+
+func newFakeOCIRegistry() *fakeOCIRegistry {
+	return &fakeOCIRegistry{
+		repositories: make(map[string]map[string]string),
+		blobs:        make(map[string][]byte),
+	}
+}
+
+func (reg *fakeOCIRegistry) push(repository, tag string, content []byte) {
+	sum := sha256.Sum256(content)
+	d := "sha256:" + hex.EncodeToString(sum[:])
+	reg.blobs[d] = content
+	if reg.repositories[repository] == nil {
+		reg.repositories[repository] = make(map[string]string)
+	}
+	reg.repositories[repository][tag] = d
+}
+
+func (reg *fakeOCIRegistry) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/token" {
+		reg.tokenRequests++
+		reg.tokenScope = req.URL.Query().Get("scope")
+		if u, p, ok := req.BasicAuth(); !ok || u != "some-user" || p != "some-password" {
+			res.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(res).Encode(map[string]string{"token": reg.token})
+		return
+	}
+
+	if reg.token != "" && req.Header.Get("Authorization") != "Bearer "+reg.token {
+		res.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="http://%s/token",service="fake"`, req.Host))
+		res.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	p := strings.TrimPrefix(req.URL.Path, "/v2/")
+	switch {
+	case p == "":
+		res.WriteHeader(http.StatusOK)
+	case strings.HasSuffix(p, "/tags/list"):
+		tags, ok := reg.repositories[strings.TrimSuffix(p, "/tags/list")]
+		if !ok {
+			res.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var list []string
+		for tag := range tags {
+			list = append(list, tag)
+		}
+		slices.Sort(list)
+		last := req.URL.Query().Get("last")
+		start := 0
+		for i, tag := range list {
+			if tag == last {
+				start = i + 1
+			}
+		}
+		list = list[start:]
+		if reg.pageSize > 0 && len(list) > reg.pageSize {
+			list = list[:reg.pageSize]
+			res.Header().Set("Link", fmt.Sprintf(`<%s?n=%d&last=%s>; rel="next"`, req.URL.Path, reg.pageSize, list[len(list)-1]))
+		}
+		_ = json.NewEncoder(res).Encode(map[string]any{"name": p, "tags": list})
+	case strings.Contains(p, "/manifests/"):
+		repository, tag, _ := strings.Cut(p, "/manifests/")
+		d, ok := reg.repositories[repository][tag]
+		if !ok {
+			res.WriteHeader(http.StatusNotFound)
+			return
+		}
+		res.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+		if req.Method == http.MethodHead {
+			return
+		}
+		_ = json.NewEncoder(res).Encode(map[string]any{
+			"schemaVersion": 2,
+			"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+			"config":        map[string]any{"mediaType": "application/vnd.oci.empty.v1+json", "digest": "sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a", "size": 2},
+			"layers": []map[string]any{
+				{"mediaType": component.OCIBOSHReleaseTarballMediaType, "digest": d, "size": len(reg.blobs[d])},
+			},
+		})
+	case strings.Contains(p, "/blobs/"):
+		_, d, _ := strings.Cut(p, "/blobs/")
+		content, ok := reg.blobs[d]
+		if !ok {
+			res.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if reg.corrupt {
+			content = append([]byte("corrupt"), content...)
+		}
+		if reg.truncated {
+			res.Header().Set("Content-Length", fmt.Sprint(len(content)))
+			content = content[:len(content)/2]
+		}
+		_, _ = res.Write(content)
+	default:
+		res.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func sha1Hex(b []byte) string {
+	sum := sha1.Sum(b)
+	return hex.EncodeToString(sum[:])
+}
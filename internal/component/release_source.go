@@ -1,16 +1,19 @@
 package component
 
 import (
+	"context"
 	"fmt"
+	"io"
+
 	"github.com/pivotal-cf/kiln/pkg/cargo"
 )
 
 // MultiReleaseSource wraps a set of release sources. It is mostly used to generate fakes
 // for testing commands. See ReleaseSourceList for the concrete implementation.
 type MultiReleaseSource interface {
-	GetMatchedRelease(cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error)
-	FindReleaseVersion(spec cargo.BOSHReleaseTarballSpecification, noDownload bool) (cargo.BOSHReleaseTarballLock, error)
-	DownloadRelease(releasesDir string, remoteRelease cargo.BOSHReleaseTarballLock) (Local, error)
+	GetMatchedRelease(context.Context, cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error)
+	FindReleaseVersion(ctx context.Context, spec cargo.BOSHReleaseTarballSpecification, noDownload bool) (cargo.BOSHReleaseTarballLock, error)
+	DownloadRelease(ctx context.Context, releasesDir string, remoteRelease cargo.BOSHReleaseTarballLock) (Local, error)
 
 	FindByID(string) (ReleaseSource, error)
 
@@ -30,8 +33,25 @@ type RemotePather interface {
 
 //counterfeiter:generate -o ./fakes/remote_pather.go --fake-name RemotePather . RemotePather
 
+// ReleaseUploader is a release source that can store BOSH release tarballs.
+// The tarball is written to the path RemotePath returns for the specification.
+type ReleaseUploader interface {
+	ReleaseSource
+	RemotePather
+
+	// UploadRelease writes the tarball read from file to the release source and
+	// returns a lock with the SHA1 of the uploaded bytes. It overwrites an
+	// existing file at the same path; callers must check for one first.
+	UploadRelease(ctx context.Context, spec cargo.BOSHReleaseTarballSpecification, file io.Reader) (cargo.BOSHReleaseTarballLock, error)
+}
+
+//counterfeiter:generate -o ./fakes/release_uploader.go --fake-name ReleaseUploader . ReleaseUploader
+
 // ReleaseSource represents a source where a tile component BOSH releases may come from.
 // The releases may be compiled or just built bosh releases.
+//
+// Implementations must stop making requests when the context is done. DownloadRelease
+// must not leave a partially written file in releasesDir when it returns an error.
 type ReleaseSource interface {
 	// Configuration returns the configuration of the ReleaseSource that came from the kilnfile.
 	// It should not be modified.
@@ -39,20 +59,32 @@ type ReleaseSource interface {
 
 	// GetMatchedRelease uses the Name and Version and if supported StemcellOS and StemcellVersion
 	// fields on Requirement to download a specific release.
-	GetMatchedRelease(cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error)
+	GetMatchedRelease(context.Context, cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error)
 
 	// FindReleaseVersion may use any of the fields on Requirement to return the best matching
 	// release.
-	FindReleaseVersion(spec cargo.BOSHReleaseTarballSpecification, noDownload bool) (cargo.BOSHReleaseTarballLock, error)
+	FindReleaseVersion(ctx context.Context, spec cargo.BOSHReleaseTarballSpecification, noDownload bool) (cargo.BOSHReleaseTarballLock, error)
 
 	// DownloadRelease downloads the release and writes the resulting file to the releasesDir.
 	// It should also calculate and set the SHA1 field on the Local result; it does not need
 	// to ensure the sums match, the caller must verify this.
-	DownloadRelease(releasesDir string, remoteRelease cargo.BOSHReleaseTarballLock) (Local, error)
+	DownloadRelease(ctx context.Context, releasesDir string, remoteRelease cargo.BOSHReleaseTarballLock) (Local, error)
 }
 
 //counterfeiter:generate -o ./fakes/release_source.go --fake-name ReleaseSource . ReleaseSource
 
+// ReleaseSourceProber is a release source that can check it is reachable and
+// that its credentials are accepted without downloading a release.
+type ReleaseSourceProber interface {
+	ReleaseSource
+
+	// Probe makes a cheap authenticated request to the release source. It returns
+	// an error describing why the request failed.
+	Probe(ctx context.Context) error
+}
+
+//counterfeiter:generate -o ./fakes/release_source_prober.go --fake-name ReleaseSourceProber . ReleaseSourceProber
+
 const (
 	panicMessageWrongReleaseSourceType = "wrong constructor for release source configuration"
 	logLineDownload                    = "downloading %s %s from %s release source %s"
@@ -64,6 +96,8 @@ const (
 	ReleaseSourceTypeS3          = cargo.BOSHReleaseTarballSourceTypeS3
 	ReleaseSourceTypeGithub      = cargo.BOSHReleaseTarballSourceTypeGithub
 	ReleaseSourceTypeArtifactory = cargo.BOSHReleaseTarballSourceTypeArtifactory
+	ReleaseSourceTypeOCI         = cargo.BOSHReleaseTarballSourceTypeOCI
+	ReleaseSourceTypeDirectory   = cargo.BOSHReleaseTarballSourceTypeDirectory
 )
 
 // ReleaseSourceFactory returns a configured ReleaseSource based on the Type field on the
@@ -79,6 +113,10 @@ func ReleaseSourceFactory(releaseConfig cargo.ReleaseSourceConfig) ReleaseSource
 		return NewGithubReleaseSource(releaseConfig, nil)
 	case ReleaseSourceTypeArtifactory:
 		return NewArtifactoryReleaseSource(releaseConfig, nil)
+	case ReleaseSourceTypeOCI:
+		return NewOCIReleaseSource(releaseConfig, nil)
+	case ReleaseSourceTypeDirectory:
+		return NewDirectoryReleaseSource(releaseConfig, nil)
 	default:
 		panic(fmt.Sprintf("unknown release config: %v", releaseConfig))
 	}
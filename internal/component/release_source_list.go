@@ -1,10 +1,10 @@
 package component
 
 import (
+	"context"
 	"errors"
 	"fmt"
-
-	"github.com/Masterminds/semver/v3"
+	"sync"
 
 	"github.com/pivotal-cf/kiln/pkg/cargo"
 )
@@ -67,6 +67,39 @@ func (list ReleaseSourceList) FindRemotePather(sourceID string) (RemotePather, e
 	return pather, nil
 }
 
+func (list ReleaseSourceList) FindReleaseUploader(sourceID string) (ReleaseUploader, error) {
+	var (
+		uploader     ReleaseUploader
+		availableIDs []string
+	)
+
+	for _, src := range list {
+		u, ok := src.(ReleaseUploader)
+		if !ok {
+			continue
+		}
+		id := src.Configuration().ID
+		availableIDs = append(availableIDs, id)
+		if id == sourceID {
+			uploader = u
+			break
+		}
+	}
+
+	if len(availableIDs) == 0 {
+		return nil, errors.New("no upload-capable release sources were found in the Kilnfile")
+	}
+
+	if uploader == nil {
+		return nil, fmt.Errorf(
+			"could not find a valid matching release source in the Kilnfile, available upload-compatible sources are: %q",
+			availableIDs,
+		)
+	}
+
+	return uploader, nil
+}
+
 func panicIfDuplicateIDs(releaseSources []ReleaseSource) {
 	indexOfID := make(map[string]int)
 	for index, rs := range releaseSources {
@@ -83,18 +116,62 @@ func NewMultiReleaseSource(sources ...ReleaseSource) ReleaseSourceList {
 	return sources
 }
 
-func (list ReleaseSourceList) GetMatchedRelease(requirement cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error) {
-	for _, src := range list {
-		rel, err := src.GetMatchedRelease(requirement)
+// GetMatchedRelease returns the release matching the requirement, skipping
+// sources the requirement is not pinned to or that its source_preference
+// publishable_only excludes. Without a SourcePreference the first release
+// source that has it wins; with one every source is asked and the preference
+// chooses between them as it does in FindReleaseVersion.
+func (list ReleaseSourceList) GetMatchedRelease(ctx context.Context, requirement cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error) {
+	sources, skipped := list.sourcesFor(requirement)
+	if len(sources) == 0 {
+		return cargo.BOSHReleaseTarballLock{}, noReleaseSourcesError(requirement, skipped)
+	}
+	var candidates []ReleaseCandidate
+	for _, src := range sources {
+		config := src.Configuration()
+		rel, err := src.GetMatchedRelease(ctx, requirement)
 		if err != nil {
 			if IsErrNotFound(err) {
 				continue
 			}
-			return cargo.BOSHReleaseTarballLock{}, scopedError(src.Configuration().ID, err)
+			return cargo.BOSHReleaseTarballLock{}, scopedError(config.ID, err)
+		}
+		if requirement.SourcePreference == nil {
+			return rel, nil
+		}
+		candidates = append(candidates, ReleaseCandidate{SourceID: config.ID, Publishable: config.Publishable, Lock: rel})
+	}
+	if len(candidates) == 0 {
+		return cargo.BOSHReleaseTarballLock{}, ErrNotFound
+	}
+	selection, err := selectRelease(requirement, candidates)
+	if err != nil {
+		return cargo.BOSHReleaseTarballLock{}, err
+	}
+	return selection.Chosen.Lock, nil
+}
+
+// sourcesFor returns the release sources that may be asked for the
+// requirement and the IDs of the ones that may not.
+func (list ReleaseSourceList) sourcesFor(requirement cargo.BOSHReleaseTarballSpecification) (ReleaseSourceList, []string) {
+	var (
+		sources ReleaseSourceList
+		skipped []string
+	)
+	for _, src := range list {
+		config := src.Configuration()
+		if !requirement.AllowsReleaseSource(config.ID) ||
+			(requirement.SourcePreference != nil && requirement.SourcePreference.PublishableOnly && !config.Publishable) {
+			skipped = append(skipped, config.ID)
+			continue
 		}
-		return rel, nil
+		sources = append(sources, src)
 	}
-	return cargo.BOSHReleaseTarballLock{}, ErrNotFound
+	return sources, skipped
+}
+
+func noReleaseSourcesError(requirement cargo.BOSHReleaseTarballSpecification, skipped []string) error {
+	return fmt.Errorf("no release sources to search for %s; release_source or source_preference publishable_only excluded %q: %w", requirement.Name, skipped, ErrNotFound)
 }
 
 func (list ReleaseSourceList) SetDownloadThreads(n int) {
@@ -107,13 +184,13 @@ func (list ReleaseSourceList) SetDownloadThreads(n int) {
 	}
 }
 
-func (list ReleaseSourceList) DownloadRelease(releaseDir string, remoteRelease cargo.BOSHReleaseTarballLock) (Local, error) {
+func (list ReleaseSourceList) DownloadRelease(ctx context.Context, releaseDir string, remoteRelease cargo.BOSHReleaseTarballLock) (Local, error) {
 	src, err := list.FindByID(remoteRelease.RemoteSource)
 	if err != nil {
 		return Local{}, err
 	}
 
-	localRelease, err := src.DownloadRelease(releaseDir, remoteRelease)
+	localRelease, err := src.DownloadRelease(ctx, releaseDir, remoteRelease)
 	if err != nil {
 		return Local{}, scopedError(src.Configuration().ID, err)
 	}
@@ -121,37 +198,74 @@ func (list ReleaseSourceList) DownloadRelease(releaseDir string, remoteRelease c
 	return localRelease, nil
 }
 
-func (list ReleaseSourceList) FindReleaseVersion(requirement cargo.BOSHReleaseTarballSpecification, noDownload bool) (cargo.BOSHReleaseTarballLock, error) {
-	var foundReleaseLock []cargo.BOSHReleaseTarballLock
-	for _, src := range list {
-		rel, err := src.FindReleaseVersion(requirement, noDownload)
-		if err != nil {
-			if !IsErrNotFound(err) {
-				return cargo.BOSHReleaseTarballLock{}, scopedError(src.Configuration().ID, err)
-			}
-			continue
-		}
-		foundReleaseLock = append(foundReleaseLock, rel)
+// FindReleaseVersion asks every release source for the latest release matching
+// the requirement at the same time and returns the one with the highest
+// version. Sources the requirement is not pinned to (see
+// cargo.BOSHReleaseTarballSpecification.ReleaseSources) are not asked.
+// When several sources have the same version, the requirement's
+// SourcePreference decides; otherwise the source listed first in the Kilnfile
+// wins. See ExplainReleaseVersion.
+//
+// If a source fails, the result may not be the highest available version, so
+// FindReleaseVersion returns a *ReleaseSourcesError naming the sources that
+// failed and the ones that did not have the release.
+func (list ReleaseSourceList) FindReleaseVersion(ctx context.Context, requirement cargo.BOSHReleaseTarballSpecification, noDownload bool) (cargo.BOSHReleaseTarballLock, error) {
+	selection, err := list.ExplainReleaseVersion(ctx, requirement, noDownload)
+	if err != nil {
+		return cargo.BOSHReleaseTarballLock{}, err
 	}
-	if len(foundReleaseLock) == 0 {
-		return cargo.BOSHReleaseTarballLock{}, ErrNotFound
+	return selection.Chosen.Lock, nil
+}
+
+// ExplainReleaseVersion does the same search as FindReleaseVersion and returns
+// every release found along with the reason one was chosen.
+func (list ReleaseSourceList) ExplainReleaseVersion(ctx context.Context, requirement cargo.BOSHReleaseTarballSpecification, noDownload bool) (ReleaseSelection, error) {
+	sources, skipped := list.sourcesFor(requirement)
+	if len(sources) == 0 {
+		return ReleaseSelection{}, noReleaseSourcesError(requirement, skipped)
 	}
-	highestLock := foundReleaseLock[0]
-	highestVersion, err := highestLock.ParseVersion()
-	if err != nil {
-		return cargo.BOSHReleaseTarballLock{}, fmt.Errorf("failed to parse version from release source: %w", err)
+
+	type result struct {
+		lock cargo.BOSHReleaseTarballLock
+		err  error
 	}
-	for _, rel := range foundReleaseLock[1:] {
-		newVersion, err := semver.NewVersion(rel.Version)
-		if err != nil {
-			return cargo.BOSHReleaseTarballLock{}, fmt.Errorf("failed to parse version from release source: %w", err)
-		}
-		if highestVersion.LessThan(newVersion) {
-			highestVersion = newVersion
-			highestLock = rel
+	results := make([]result, len(sources))
+	var wg sync.WaitGroup
+	for i, src := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rel, err := src.FindReleaseVersion(ctx, requirement, noDownload)
+			results[i] = result{lock: rel, err: err}
+		}()
+	}
+	wg.Wait()
+
+	var (
+		sourcesErr ReleaseSourcesError
+		candidates []ReleaseCandidate
+	)
+	for i, res := range results {
+		config := sources[i].Configuration()
+		switch {
+		case res.err == nil:
+			candidates = append(candidates, ReleaseCandidate{SourceID: config.ID, Publishable: config.Publishable, Lock: res.lock})
+		case IsErrNotFound(res.err):
+			sourcesErr.NotFound = append(sourcesErr.NotFound, config.ID)
+		default:
+			sourcesErr.Failed = append(sourcesErr.Failed, SourceError{SourceID: config.ID, Err: res.err})
 		}
 	}
-	return highestLock, nil
+	if len(sourcesErr.Failed) > 0 || len(candidates) == 0 {
+		return ReleaseSelection{}, &sourcesErr
+	}
+
+	selection, err := selectRelease(requirement, candidates)
+	if err != nil {
+		return ReleaseSelection{}, err
+	}
+	selection.Skipped = skipped
+	return selection, nil
 }
 
 func (list ReleaseSourceList) FindByID(id string) (ReleaseSource, error) {
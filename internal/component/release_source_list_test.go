@@ -1,7 +1,9 @@
 package component_test
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -58,7 +60,7 @@ var _ = Describe("multiReleaseSource", func() {
 			})
 
 			It("returns that match", func() {
-				rel, err := multiSrc.GetMatchedRelease(requirement)
+				rel, err := multiSrc.GetMatchedRelease(context.Background(), requirement)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(rel).To(Equal(matchedRelease))
 			})
@@ -71,12 +73,50 @@ var _ = Describe("multiReleaseSource", func() {
 				src2.GetMatchedReleaseReturns(cargo.BOSHReleaseTarballLock{}, component.ErrNotFound)
 			})
 			It("returns no match", func() {
-				_, err := multiSrc.GetMatchedRelease(requirement)
+				_, err := multiSrc.GetMatchedRelease(context.Background(), requirement)
 				Expect(err).To(HaveOccurred())
 				Expect(component.IsErrNotFound(err)).To(BeTrue())
 			})
 		})
 
+		When("the requirement has a source preference", func() {
+			BeforeEach(func() {
+				src1.ConfigurationReturns(cargo.ReleaseSourceConfig{ID: "src-1"})
+				src2.ConfigurationReturns(cargo.ReleaseSourceConfig{ID: "src-2", Publishable: true})
+				src3.ConfigurationReturns(cargo.ReleaseSourceConfig{ID: "src-3", Publishable: true})
+				for _, src := range []*fakes.ReleaseSource{src1, src2, src3} {
+					src.GetMatchedReleaseReturns(cargo.BOSHReleaseTarballLock{Name: releaseName, Version: releaseVersion, RemoteSource: src.Configuration().ID}, nil)
+				}
+			})
+
+			It("does not ask sources publishable_only excludes", func() {
+				requirement.SourcePreference = &cargo.SourcePreference{PublishableOnly: true}
+
+				rel, err := multiSrc.GetMatchedRelease(context.Background(), requirement)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rel.RemoteSource).To(Equal("src-2"))
+				Expect(src1.GetMatchedReleaseCallCount()).To(Equal(0))
+			})
+
+			It("chooses the release the preference prefers", func() {
+				requirement.SourcePreference = &cargo.SourcePreference{Sources: []string{"src-3"}}
+
+				rel, err := multiSrc.GetMatchedRelease(context.Background(), requirement)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rel.RemoteSource).To(Equal("src-3"))
+			})
+
+			When("publishable_only excludes every source", func() {
+				It("returns no match", func() {
+					requirement.SourcePreference = &cargo.SourcePreference{PublishableOnly: true}
+					requirement.ReleaseSources = []string{"src-1"}
+
+					_, err := multiSrc.GetMatchedRelease(context.Background(), requirement)
+					Expect(component.IsErrNotFound(err)).To(BeTrue())
+				})
+			})
+		})
+
 		When("one of the release sources errors", func() {
 			var expectedErr error
 
@@ -86,7 +126,7 @@ var _ = Describe("multiReleaseSource", func() {
 			})
 
 			It("returns that error", func() {
-				_, err := multiSrc.GetMatchedRelease(requirement)
+				_, err := multiSrc.GetMatchedRelease(context.Background(), requirement)
 				Expect(err).To(MatchError(ContainSubstring(src1.Configuration().ID)))
 				Expect(err).To(MatchError(ContainSubstring(expectedErr.Error())))
 			})
@@ -115,12 +155,12 @@ var _ = Describe("multiReleaseSource", func() {
 			})
 
 			It("returns the local release", func() {
-				l, err := multiSrc.DownloadRelease("somewhere", remote)
+				l, err := multiSrc.DownloadRelease(context.Background(), "somewhere", remote)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(l).To(Equal(local))
 
 				Expect(src2.DownloadReleaseCallCount()).To(Equal(1))
-				dir, r := src2.DownloadReleaseArgsForCall(0)
+				_, dir, r := src2.DownloadReleaseArgsForCall(0)
 				Expect(dir).To(Equal("somewhere"))
 				Expect(r).To(Equal(remote))
 			})
@@ -134,7 +174,7 @@ var _ = Describe("multiReleaseSource", func() {
 			})
 
 			It("returns the error", func() {
-				_, err := multiSrc.DownloadRelease("somewhere", remote)
+				_, err := multiSrc.DownloadRelease(context.Background(), "somewhere", remote)
 				Expect(err).To(MatchError(ContainSubstring(src2.Configuration().ID)))
 				Expect(err).To(MatchError(ContainSubstring(expectedErr.Error())))
 			})
@@ -146,7 +186,7 @@ var _ = Describe("multiReleaseSource", func() {
 			})
 
 			It("errors", func() {
-				_, err := multiSrc.DownloadRelease("somewhere", remote)
+				_, err := multiSrc.DownloadRelease(context.Background(), "somewhere", remote)
 				Expect(err).To(MatchError(ContainSubstring("couldn't find a release source")))
 				Expect(err).To(MatchError(ContainSubstring("no-such-source")))
 				Expect(err).To(MatchError(ContainSubstring(src1.Configuration().ID)))
@@ -186,6 +226,22 @@ var _ = Describe("multiReleaseSource", func() {
 		})
 	})
 
+	Describe("GetMatchedRelease with a pinned release", func() {
+		BeforeEach(func() {
+			requirement.ReleaseSources = []string{"src-3"}
+			src1.GetMatchedReleaseReturns(cargo.BOSHReleaseTarballLock{Name: releaseName, Version: releaseVersion, RemoteSource: "src-1"}, nil)
+			src3.GetMatchedReleaseReturns(cargo.BOSHReleaseTarballLock{Name: releaseName, Version: releaseVersion, RemoteSource: "src-3"}, nil)
+		})
+
+		It("only asks the pinned sources", func() {
+			rel, err := multiSrc.GetMatchedRelease(context.Background(), requirement)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rel.RemoteSource).To(Equal("src-3"))
+			Expect(src1.GetMatchedReleaseCallCount()).To(Equal(0))
+			Expect(src2.GetMatchedReleaseCallCount()).To(Equal(0))
+		})
+	})
+
 	Describe("FindReleaseVersion", func() {
 		When("one of the release sources has a match", func() {
 			var matchedRelease cargo.BOSHReleaseTarballLock
@@ -203,7 +259,7 @@ var _ = Describe("multiReleaseSource", func() {
 			})
 
 			It("returns that match", func() {
-				rel, err := multiSrc.FindReleaseVersion(requirement, false)
+				rel, err := multiSrc.FindReleaseVersion(context.Background(), requirement, false)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(rel).To(Equal(matchedRelease))
 			})
@@ -230,7 +286,7 @@ var _ = Describe("multiReleaseSource", func() {
 			})
 
 			It("returns that match", func() {
-				rel, err := multiSrc.FindReleaseVersion(requirement, false)
+				rel, err := multiSrc.FindReleaseVersion(context.Background(), requirement, false)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(rel).To(Equal(matchedRelease))
 			})
@@ -257,10 +313,205 @@ var _ = Describe("multiReleaseSource", func() {
 			})
 
 			It("returns the match from the first source", func() {
-				rel, err := multiSrc.FindReleaseVersion(requirement, false)
+				rel, err := multiSrc.FindReleaseVersion(context.Background(), requirement, false)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(rel).To(Equal(matchedRelease))
 			})
 		})
+		When("the first source is the slowest to answer", func() {
+			BeforeEach(func() {
+				matchedRelease := cargo.BOSHReleaseTarballLock{Name: releaseName, Version: releaseVersion, RemoteSource: src1.Configuration().ID}
+				src1.FindReleaseVersionStub = func(context.Context, cargo.BOSHReleaseTarballSpecification, bool) (cargo.BOSHReleaseTarballLock, error) {
+					time.Sleep(50 * time.Millisecond)
+					return matchedRelease, nil
+				}
+				src2.FindReleaseVersionReturns(cargo.BOSHReleaseTarballLock{Name: releaseName, Version: releaseVersion, RemoteSource: src2.Configuration().ID}, nil)
+				src3.FindReleaseVersionReturns(cargo.BOSHReleaseTarballLock{}, component.ErrNotFound)
+			})
+
+			It("still prefers the source listed first", func() {
+				rel, err := multiSrc.FindReleaseVersion(context.Background(), requirement, false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rel.RemoteSource).To(Equal(src1.Configuration().ID))
+			})
+		})
+		When("no source has the release", func() {
+			BeforeEach(func() {
+				src1.FindReleaseVersionReturns(cargo.BOSHReleaseTarballLock{}, component.ErrNotFound)
+				src2.FindReleaseVersionReturns(cargo.BOSHReleaseTarballLock{}, component.ErrNotFound)
+				src3.FindReleaseVersionReturns(cargo.BOSHReleaseTarballLock{}, component.ErrNotFound)
+			})
+
+			It("returns a not found error listing the sources", func() {
+				_, err := multiSrc.FindReleaseVersion(context.Background(), requirement, false)
+				Expect(component.IsErrNotFound(err)).To(BeTrue())
+				var sourcesErr *component.ReleaseSourcesError
+				Expect(errors.As(err, &sourcesErr)).To(BeTrue())
+				Expect(sourcesErr.NotFound).To(Equal([]string{src1.Configuration().ID, src2.Configuration().ID, src3.Configuration().ID}))
+				Expect(sourcesErr.Failed).To(BeEmpty())
+			})
+		})
+		When("some of the release sources fail", func() {
+			var failure error
+
+			BeforeEach(func() {
+				failure = errors.New("lemon")
+				src1.FindReleaseVersionReturns(cargo.BOSHReleaseTarballLock{}, failure)
+				src2.FindReleaseVersionReturns(cargo.BOSHReleaseTarballLock{Name: releaseName, Version: releaseVersion, RemoteSource: src2.Configuration().ID}, nil)
+				src3.FindReleaseVersionReturns(cargo.BOSHReleaseTarballLock{}, component.ErrNotFound)
+			})
+
+			It("asks every source and reports which ones failed", func() {
+				_, err := multiSrc.FindReleaseVersion(context.Background(), requirement, false)
+				Expect(err).To(MatchError(failure))
+				Expect(component.IsErrNotFound(err)).To(BeFalse())
+				Expect(src2.FindReleaseVersionCallCount()).To(Equal(1))
+				Expect(src3.FindReleaseVersionCallCount()).To(Equal(1))
+
+				var sourcesErr *component.ReleaseSourcesError
+				Expect(errors.As(err, &sourcesErr)).To(BeTrue())
+				Expect(sourcesErr.Failed).To(HaveLen(1))
+				Expect(sourcesErr.Failed[0].SourceID).To(Equal(src1.Configuration().ID))
+				Expect(sourcesErr.NotFound).To(Equal([]string{src3.Configuration().ID}))
+				Expect(err.Error()).To(ContainSubstring(src1.Configuration().ID))
+			})
+		})
+	})
+
+	Describe("ExplainReleaseVersion", func() {
+		var sourceRelease, compiledRelease, publishableRelease cargo.BOSHReleaseTarballLock
+
+		BeforeEach(func() {
+			requirement.StemcellOS = "ubuntu-jammy"
+			requirement.StemcellVersion = "1.100"
+
+			src2.ConfigurationReturns(cargo.ReleaseSourceConfig{ID: "src-2", Publishable: true})
+			src3.ConfigurationReturns(cargo.ReleaseSourceConfig{ID: "src-3", Publishable: true})
+
+			sourceRelease = cargo.BOSHReleaseTarballLock{Name: releaseName, Version: releaseVersion, RemoteSource: "src-1"}
+			compiledRelease = cargo.BOSHReleaseTarballLock{Name: releaseName, Version: releaseVersion, StemcellOS: "ubuntu-jammy", StemcellVersion: "1.100", RemoteSource: "src-2"}
+			publishableRelease = cargo.BOSHReleaseTarballLock{Name: releaseName, Version: releaseVersion, RemoteSource: "src-3"}
+			src1.FindReleaseVersionReturns(sourceRelease, nil)
+			src2.FindReleaseVersionReturns(compiledRelease, nil)
+			src3.FindReleaseVersionReturns(publishableRelease, nil)
+		})
+
+		explain := func() component.ReleaseSelection {
+			selection, err := multiSrc.(component.ReleaseVersionExplainer).ExplainReleaseVersion(context.Background(), requirement, false)
+			Expect(err).NotTo(HaveOccurred())
+			return selection
+		}
+
+		When("there is no source preference", func() {
+			It("chooses the source listed first in the Kilnfile", func() {
+				selection := explain()
+				Expect(selection.Chosen.Lock).To(Equal(sourceRelease))
+				Expect(selection.Candidates).To(HaveLen(3))
+				Expect(selection.Reason).To(Equal(`chose stuff-and-things 42.42 from "src-1" because its release source is listed before "src-2" in the Kilnfile release_sources`))
+			})
+		})
+
+		When("a source has a higher version", func() {
+			BeforeEach(func() {
+				publishableRelease.Version = releaseVersionNewer
+				src3.FindReleaseVersionReturns(publishableRelease, nil)
+				requirement.SourcePreference = &cargo.SourcePreference{PreferCompiled: true}
+			})
+
+			It("chooses the highest version regardless of the preference", func() {
+				selection := explain()
+				Expect(selection.Chosen.Lock).To(Equal(publishableRelease))
+				Expect(selection.Reason).To(ContainSubstring(`because it has a higher version than 42.42 from "src-2"`))
+			})
+		})
+
+		When("compiled releases are preferred", func() {
+			BeforeEach(func() {
+				requirement.SourcePreference = &cargo.SourcePreference{PreferCompiled: true, Sources: []string{"src-3"}}
+			})
+
+			It("chooses the release compiled for the stemcell", func() {
+				selection := explain()
+				Expect(selection.Chosen.Lock).To(Equal(compiledRelease))
+				Expect(selection.Reason).To(ContainSubstring("it is compiled for stemcell ubuntu-jammy 1.100"))
+			})
+		})
+
+		When("publishable sources are preferred", func() {
+			BeforeEach(func() {
+				src3.ConfigurationReturns(cargo.ReleaseSourceConfig{ID: "src-3"})
+				requirement.SourcePreference = &cargo.SourcePreference{PreferPublishable: true}
+			})
+
+			It("chooses the publishable source", func() {
+				selection := explain()
+				Expect(selection.Chosen.Lock).To(Equal(compiledRelease))
+				Expect(selection.Candidates[1].Lock).To(Equal(sourceRelease))
+				Expect(selection.Reason).To(ContainSubstring(`its release source is publishable and "src-1" is not`))
+			})
+		})
+
+		When("the sources are ordered", func() {
+			BeforeEach(func() {
+				requirement.SourcePreference = &cargo.SourcePreference{Sources: []string{"src-3", "src-1"}}
+			})
+
+			It("chooses the first listed source", func() {
+				selection := explain()
+				Expect(selection.Chosen.Lock).To(Equal(publishableRelease))
+				Expect(selection.Candidates[1].Lock).To(Equal(sourceRelease))
+				Expect(selection.Reason).To(ContainSubstring(`listed before "src-1" in source_preference sources`))
+			})
+		})
+
+		When("only publishable sources are allowed", func() {
+			BeforeEach(func() {
+				requirement.SourcePreference = &cargo.SourcePreference{PublishableOnly: true}
+			})
+
+			It("does not ask the other sources", func() {
+				selection := explain()
+				Expect(selection.Chosen.Lock).To(Equal(compiledRelease))
+				Expect(selection.Skipped).To(Equal([]string{"src-1"}))
+				Expect(src1.FindReleaseVersionCallCount()).To(Equal(0))
+			})
+		})
+
+		When("the release is pinned to release sources", func() {
+			BeforeEach(func() {
+				requirement.ReleaseSources = []string{"src-1", "src-3"}
+			})
+
+			It("only asks the pinned sources", func() {
+				selection := explain()
+				Expect(selection.Chosen.Lock).To(Equal(sourceRelease))
+				Expect(selection.Candidates).To(HaveLen(2))
+				Expect(selection.Skipped).To(Equal([]string{"src-2"}))
+				Expect(src2.FindReleaseVersionCallCount()).To(Equal(0))
+			})
+		})
+
+		When("the release is pinned to a source that is not in the list", func() {
+			BeforeEach(func() {
+				requirement.ReleaseSources = []string{"src-4"}
+			})
+
+			It("returns a not found error", func() {
+				_, err := multiSrc.FindReleaseVersion(context.Background(), requirement, false)
+				Expect(component.IsErrNotFound(err)).To(BeTrue())
+				Expect(err).To(MatchError(ContainSubstring(`no release sources to search for stuff-and-things`)))
+			})
+		})
+
+		When("only one source has the release", func() {
+			BeforeEach(func() {
+				src1.FindReleaseVersionReturns(cargo.BOSHReleaseTarballLock{}, component.ErrNotFound)
+				src2.FindReleaseVersionReturns(cargo.BOSHReleaseTarballLock{}, component.ErrNotFound)
+			})
+
+			It("says so", func() {
+				Expect(explain().Reason).To(Equal(`chose stuff-and-things 42.42 from "src-3" because it is the only release source with a matching release`))
+			})
+		})
 	})
 })
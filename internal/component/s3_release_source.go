@@ -3,8 +3,6 @@ package component
 import (
 	"bytes"
 	"context"
-	"crypto/sha1"
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -33,6 +31,11 @@ type S3Downloader interface {
 	DownloadObject(ctx context.Context, input *transfermanager.DownloadObjectInput, opts ...func(*transfermanager.Options)) (*transfermanager.DownloadObjectOutput, error)
 }
 
+//counterfeiter:generate -o ./fakes/s3_uploader.go --fake-name S3Uploader . S3Uploader
+type S3Uploader interface {
+	UploadObject(ctx context.Context, input *transfermanager.UploadObjectInput, opts ...func(*transfermanager.Options)) (*transfermanager.UploadObjectOutput, error)
+}
+
 //counterfeiter:generate -o ./fakes/s3_client.go --fake-name S3Client . S3Client
 type S3Client interface {
 	HeadObject(ctx context.Context, input *s3.HeadObjectInput, options ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
@@ -44,13 +47,14 @@ type S3ReleaseSource struct {
 
 	s3Client     S3Client
 	s3Downloader S3Downloader
+	s3Uploader   S3Uploader
 
 	DownloadThreads int
 
 	logger *log.Logger
 }
 
-func NewS3ReleaseSource(rsConfig cargo.ReleaseSourceConfig, client S3Client, downloader S3Downloader, logger *log.Logger) S3ReleaseSource {
+func NewS3ReleaseSource(rsConfig cargo.ReleaseSourceConfig, client S3Client, downloader S3Downloader, uploader S3Uploader, logger *log.Logger) S3ReleaseSource {
 	if rsConfig.Type != "" && rsConfig.Type != ReleaseSourceTypeS3 {
 		panic(panicMessageWrongReleaseSourceType)
 	}
@@ -63,6 +67,7 @@ func NewS3ReleaseSource(rsConfig cargo.ReleaseSourceConfig, client S3Client, dow
 		ReleaseSourceConfig: rsConfig,
 		s3Client:            client,
 		s3Downloader:        downloader,
+		s3Uploader:          uploader,
 		logger:              logger,
 	}
 }
@@ -96,11 +101,13 @@ func NewS3ReleaseSourceFromConfig(rsConfig cargo.ReleaseSourceConfig, logger *lo
 	}
 
 	client := s3.NewFromConfig(awsConfig)
+	transfer := transfermanager.New(client)
 
 	return NewS3ReleaseSource(
 		rsConfig,
 		client,
-		transfermanager.New(client),
+		transfer,
+		transfer,
 		logger,
 	)
 }
@@ -118,13 +125,13 @@ func (src S3ReleaseSource) ID() string                               { return sr
 func (src S3ReleaseSource) Publishable() bool                        { return src.ReleaseSourceConfig.Publishable }
 func (src S3ReleaseSource) Configuration() cargo.ReleaseSourceConfig { return src.ReleaseSourceConfig }
 
-func (src S3ReleaseSource) GetMatchedRelease(spec cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error) {
+func (src S3ReleaseSource) GetMatchedRelease(ctx context.Context, spec cargo.BOSHReleaseTarballSpecification) (cargo.BOSHReleaseTarballLock, error) {
 	remotePath, err := src.RemotePath(spec)
 	if err != nil {
 		return cargo.BOSHReleaseTarballLock{}, err
 	}
 
-	_, err = src.s3Client.HeadObject(context.Background(),
+	_, err = src.s3Client.HeadObject(ctx,
 		&s3.HeadObjectInput{
 			Bucket: aws.String(src.Bucket),
 			Key:    aws.String(remotePath),
@@ -146,7 +153,7 @@ func (src S3ReleaseSource) GetMatchedRelease(spec cargo.BOSHReleaseTarballSpecif
 	}, nil
 }
 
-func (src S3ReleaseSource) FindReleaseVersion(spec cargo.BOSHReleaseTarballSpecification, noDownload bool) (cargo.BOSHReleaseTarballLock, error) {
+func (src S3ReleaseSource) FindReleaseVersion(ctx context.Context, spec cargo.BOSHReleaseTarballSpecification, noDownload bool) (cargo.BOSHReleaseTarballLock, error) {
 	pathTemplatePattern, _ := regexp.Compile(`^\d+\.\d+`)
 	tasVersion := pathTemplatePattern.FindString(src.PathTemplate)
 	var prefix string
@@ -155,7 +162,7 @@ func (src S3ReleaseSource) FindReleaseVersion(spec cargo.BOSHReleaseTarballSpeci
 	}
 	prefix += spec.Name + "/"
 
-	releaseResults, err := src.s3Client.ListObjectsV2(context.Background(),
+	releaseResults, err := src.s3Client.ListObjectsV2(ctx,
 		&s3.ListObjectsV2Input{
 			Bucket: &src.Bucket,
 			Prefix: &prefix,
@@ -219,16 +226,17 @@ func (src S3ReleaseSource) FindReleaseVersion(spec cargo.BOSHReleaseTarballSpeci
 		foundRelease.SHA1 = "not-calculated"
 	} else {
 		var releaseLocal Local
-		releaseLocal, err = src.DownloadRelease("/tmp", foundRelease)
+		releaseLocal, err = src.DownloadRelease(ctx, "/tmp", foundRelease)
 		if err != nil {
 			return cargo.BOSHReleaseTarballLock{}, err
 		}
 		foundRelease.SHA1 = releaseLocal.Lock.SHA1
+		foundRelease.SHA256 = releaseLocal.Lock.SHA256
 	}
 	return foundRelease, nil
 }
 
-func (src S3ReleaseSource) DownloadRelease(releaseDir string, lock cargo.BOSHReleaseTarballLock) (Local, error) {
+func (src S3ReleaseSource) DownloadRelease(ctx context.Context, releaseDir string, lock cargo.BOSHReleaseTarballLock) (Local, error) {
 	setConcurrency := func(opts *transfermanager.Options) {
 		if src.DownloadThreads > 0 {
 			opts.Concurrency = src.DownloadThreads
@@ -245,13 +253,14 @@ func (src S3ReleaseSource) DownloadRelease(releaseDir string, lock cargo.BOSHRel
 	}
 	defer closeAndIgnoreError(file)
 
-	_, err = src.s3Downloader.DownloadObject(context.Background(),
+	_, err = src.s3Downloader.DownloadObject(ctx,
 		&transfermanager.DownloadObjectInput{
 			Bucket:   aws.String(src.Bucket),
 			Key:      aws.String(lock.RemotePath),
 			WriterAt: file,
 		}, setConcurrency)
 	if err != nil {
+		removePartialDownload(file)
 		return Local{}, fmt.Errorf("failed to download file: %w", err)
 	}
 
@@ -260,17 +269,58 @@ func (src S3ReleaseSource) DownloadRelease(releaseDir string, lock cargo.BOSHRel
 		return Local{}, fmt.Errorf("error reseting file cursor: %w", err) // untested
 	}
 
-	hash := sha1.New()
-	_, err = io.Copy(hash, file)
+	digest := newReleaseDigest()
+	_, err = io.Copy(digest, file)
 	if err != nil {
 		return Local{}, fmt.Errorf("error hashing file contents: %w", err) // untested
 	}
 
-	lock.SHA1 = hex.EncodeToString(hash.Sum(nil))
+	lock = digest.setSums(lock)
 
 	return Local{Lock: lock, LocalPath: outputFile}, nil
 }
 
+func (src S3ReleaseSource) UploadRelease(ctx context.Context, spec cargo.BOSHReleaseTarballSpecification, file io.Reader) (cargo.BOSHReleaseTarballLock, error) {
+	remotePath, err := src.RemotePath(spec)
+	if err != nil {
+		return cargo.BOSHReleaseTarballLock{}, err
+	}
+
+	src.logger.Printf("uploading %s %s to %s release source %s", spec.Name, spec.Version, ReleaseSourceTypeS3, src.ID())
+
+	digest := newReleaseDigest()
+	_, err = src.s3Uploader.UploadObject(ctx, &transfermanager.UploadObjectInput{
+		Bucket: aws.String(src.Bucket),
+		Key:    aws.String(remotePath),
+		Body:   io.TeeReader(file, digest),
+	})
+	if err != nil {
+		return cargo.BOSHReleaseTarballLock{}, fmt.Errorf("failed to upload file: %w", err)
+	}
+
+	return digest.setSums(cargo.BOSHReleaseTarballLock{
+		Name:            spec.Name,
+		Version:         spec.Version,
+		StemcellOS:      spec.StemcellOS,
+		StemcellVersion: spec.StemcellVersion,
+		RemoteSource:    src.ID(),
+		RemotePath:      remotePath,
+	}), nil
+}
+
+// Probe lists at most one object in the bucket to check the credentials
+// (and role, when one is configured) grant access to it.
+func (src S3ReleaseSource) Probe(ctx context.Context) error {
+	_, err := src.s3Client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket:  aws.String(src.Bucket),
+		MaxKeys: aws.Int32(1),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list objects in bucket %s: %w", src.Bucket, err)
+	}
+	return nil
+}
+
 func (src S3ReleaseSource) RemotePath(spec cargo.BOSHReleaseTarballSpecification) (string, error) {
 	pathBuf := new(bytes.Buffer)
 
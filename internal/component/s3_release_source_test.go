@@ -2,11 +2,14 @@ package component_test
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/feature/s3/transfermanager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
@@ -117,7 +120,7 @@ var _ = Describe("S3ReleaseSource", func() {
 				Bucket:       bucket,
 				PathTemplate: "",
 				Publishable:  false,
-			}, nil, fakeS3Downloader, logger)
+			}, nil, fakeS3Downloader, nil, logger)
 		})
 
 		AfterEach(func() {
@@ -126,7 +129,7 @@ var _ = Describe("S3ReleaseSource", func() {
 
 		It("downloads the appropriate versions of built releases listed in remoteReleases", func() {
 			releaseSource.DownloadThreads = 7
-			localRelease, err := releaseSource.DownloadRelease(releaseDir, remoteRelease)
+			localRelease, err := releaseSource.DownloadRelease(context.Background(), releaseDir, remoteRelease)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(fakeS3Downloader.DownloadObjectCallCount()).To(Equal(1))
 
@@ -142,7 +145,7 @@ var _ = Describe("S3ReleaseSource", func() {
 			verifySetsConcurrency(opts, 7)
 
 			Expect(localRelease).To(Equal(component.Local{
-				Lock:      remoteRelease.WithSHA1(sha1),
+				Lock:      remoteRelease.WithSHA1(sha1).WithSHA256(fmt.Sprintf("%x", sha256.Sum256(releaseContents))),
 				LocalPath: releasePath,
 			}))
 		})
@@ -150,7 +153,7 @@ var _ = Describe("S3ReleaseSource", func() {
 		Context("when number of threads is not specified", func() {
 			It("uses the s3manager package's default download concurrency", func() {
 				releaseSource.DownloadThreads = 0
-				_, err := releaseSource.DownloadRelease(releaseDir, remoteRelease)
+				_, err := releaseSource.DownloadRelease(context.Background(), releaseDir, remoteRelease)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(fakeS3Downloader.DownloadObjectCallCount()).To(Equal(1))
 
@@ -162,7 +165,7 @@ var _ = Describe("S3ReleaseSource", func() {
 		Context("failure cases", func() {
 			Context("when a file can't be created", func() {
 				It("returns an error", func() {
-					_, err := releaseSource.DownloadRelease("/non-existent-folder", remoteRelease)
+					_, err := releaseSource.DownloadRelease(context.Background(), "/non-existent-folder", remoteRelease)
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring("/non-existent-folder"))
 				})
@@ -176,7 +179,7 @@ var _ = Describe("S3ReleaseSource", func() {
 				})
 
 				It("returns an error", func() {
-					_, err := releaseSource.DownloadRelease(releaseDir, remoteRelease)
+					_, err := releaseSource.DownloadRelease(context.Background(), releaseDir, remoteRelease)
 					Expect(err).To(HaveOccurred())
 					Expect(err).To(MatchError("failed to download file: 503 Service Unavailable"))
 				})
@@ -218,13 +221,14 @@ var _ = Describe("S3ReleaseSource", func() {
 				},
 				fakeS3Client,
 				nil,
+				nil,
 				logger,
 			)
 			bpmKey = "2.5/bpm/bpm-release-1.2.3-ubuntu-xenial-190.0.0.tgz"
 		})
 
 		It("searches for the requested release", func() {
-			remoteRelease, err := releaseSource.GetMatchedRelease(desiredRelease)
+			remoteRelease, err := releaseSource.GetMatchedRelease(context.Background(), desiredRelease)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeS3Client.HeadObjectCallCount()).To(Equal(1))
@@ -246,7 +250,7 @@ var _ = Describe("S3ReleaseSource", func() {
 			})
 
 			It("returns not found", func() {
-				_, err := releaseSource.GetMatchedRelease(desiredRelease)
+				_, err := releaseSource.GetMatchedRelease(context.Background(), desiredRelease)
 				Expect(err).To(HaveOccurred())
 				Expect(component.IsErrNotFound(err)).To(BeTrue())
 			})
@@ -263,12 +267,13 @@ var _ = Describe("S3ReleaseSource", func() {
 					},
 					fakeS3Client,
 					nil,
+					nil,
 					logger,
 				)
 			})
 
 			It("returns a descriptive error", func() {
-				_, err := releaseSource.GetMatchedRelease(desiredRelease)
+				_, err := releaseSource.GetMatchedRelease(context.Background(), desiredRelease)
 
 				Expect(err).To(MatchError(ContainSubstring(`unable to evaluate path_template`)))
 			})
@@ -326,13 +331,14 @@ var _ = Describe("S3ReleaseSource", func() {
 					},
 					fakeS3Client,
 					fakeS3Downloader,
+					nil,
 					logger,
 				)
 				uaaKey = "uaa/uaa-1.1.1.tgz"
 			})
 
 			It("gets the version that satisfies the constraint", func() {
-				remoteRelease, err := releaseSource.FindReleaseVersion(desiredRelease, false)
+				remoteRelease, err := releaseSource.FindReleaseVersion(context.Background(), desiredRelease, false)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(fakeS3Client.ListObjectsV2CallCount()).To(Equal(1))
@@ -342,7 +348,8 @@ var _ = Describe("S3ReleaseSource", func() {
 				Expect(remoteRelease).To(Equal(
 					releaseID.Lock().
 						WithRemote(sourceID, uaaKey).
-						WithSHA1("1a77ff749f0f2f49493eb8a517fb7eaa04df9b62"),
+						WithSHA1("1a77ff749f0f2f49493eb8a517fb7eaa04df9b62").
+						WithSHA256("572b6164ed25b6e690d5d7b2ec29f8ab4d836ee10354d36449057f6392e88448"),
 				),
 				)
 			})
@@ -387,13 +394,14 @@ var _ = Describe("S3ReleaseSource", func() {
 					},
 					fakeS3Client,
 					fakeS3Downloader,
+					nil,
 					logger,
 				)
 				uaaKey = "uaa/uaa-123.tgz"
 			})
 
 			It("gets the latest version of a release", func() {
-				remoteRelease, err := releaseSource.FindReleaseVersion(desiredRelease, false)
+				remoteRelease, err := releaseSource.FindReleaseVersion(context.Background(), desiredRelease, false)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(fakeS3Client.ListObjectsV2CallCount()).To(Equal(1))
@@ -406,6 +414,7 @@ var _ = Describe("S3ReleaseSource", func() {
 					RemotePath:   uaaKey,
 					RemoteSource: sourceID,
 					SHA1:         "bc7cb372ee4b9a9d6f4e8a993d46405d2c114e9c",
+					SHA256:       "031ec6d6041055c25659017b0ccbe925131c1c65e7c371e0b8790524fea5405e",
 				}))
 			})
 		})
@@ -448,13 +457,14 @@ var _ = Describe("S3ReleaseSource", func() {
 					},
 					fakeS3Client,
 					fakeS3Downloader,
+					nil,
 					logger,
 				)
 				uaaKey = "uaa/uaa-123.tgz"
 			})
 
 			It("gets the latest version of a release", func() {
-				remoteRelease, err := releaseSource.FindReleaseVersion(desiredRelease, true)
+				remoteRelease, err := releaseSource.FindReleaseVersion(context.Background(), desiredRelease, true)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(fakeS3Client.ListObjectsV2CallCount()).To(Equal(1))
@@ -522,20 +532,116 @@ var _ = Describe("S3ReleaseSource", func() {
 					},
 					fakeS3Client,
 					fakeS3Downloader,
+					nil,
 					logger,
 				)
 				uaaKey = "2.11/uaa/uaa-1.2.3-ubuntu-xenial-621.71.tgz"
 			})
 
 			It("gets the latest version of a release", func() {
-				remoteRelease, err := releaseSource.FindReleaseVersion(desiredRelease, false)
+				remoteRelease, err := releaseSource.FindReleaseVersion(context.Background(), desiredRelease, false)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(fakeS3Client.ListObjectsV2CallCount()).To(Equal(1))
 				_, input, _ := fakeS3Client.ListObjectsV2ArgsForCall(0)
 				Expect(*input.Prefix).To(Equal("2.11/uaa/"))
 
-				Expect(remoteRelease).To(Equal(releaseID.Lock().WithRemote(sourceID, uaaKey).WithSHA1("78facf87f730395fb263fb5e89157c438fc1d8a9")))
+				Expect(remoteRelease).To(Equal(releaseID.Lock().WithRemote(sourceID, uaaKey).WithSHA1("78facf87f730395fb263fb5e89157c438fc1d8a9").WithSHA256("11b31a3c1b2e28c9dccaf717bf73d0fd6f9c3f09548f9f400bd1ca3d80909a79")))
+			})
+		})
+	})
+
+	Describe("UploadRelease", func() {
+		var (
+			releaseSource  component.S3ReleaseSource
+			fakeS3Uploader *fetcherFakes.S3Uploader
+			uploadedBody   []byte
+		)
+
+		BeforeEach(func() {
+			uploadedBody = nil
+			fakeS3Uploader = new(fetcherFakes.S3Uploader)
+			fakeS3Uploader.UploadObjectStub = func(_ context.Context, input *transfermanager.UploadObjectInput, _ ...func(*transfermanager.Options)) (*transfermanager.UploadObjectOutput, error) {
+				uploadedBody = must(io.ReadAll(input.Body))
+				return new(transfermanager.UploadObjectOutput), nil
+			}
+
+			releaseSource = component.NewS3ReleaseSource(
+				cargo.ReleaseSourceConfig{
+					ID:           sourceID,
+					Bucket:       "orange-bucket",
+					PathTemplate: `{{.Name}}/{{.Name}}-{{.Version}}-{{.StemcellOS}}-{{.StemcellVersion}}.tgz`,
+				},
+				nil,
+				nil,
+				fakeS3Uploader,
+				log.New(GinkgoWriter, "", 0),
+			)
+		})
+
+		It("uploads the release to the path from the path template", func() {
+			var _ component.ReleaseUploader = releaseSource
+
+			lock, err := releaseSource.UploadRelease(context.Background(), cargo.BOSHReleaseTarballSpecification{
+				Name:            "bob",
+				Version:         "2.0",
+				StemcellOS:      "plan9",
+				StemcellVersion: "42",
+			}, strings.NewReader("lemon"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeS3Uploader.UploadObjectCallCount()).To(Equal(1))
+			_, input, _ := fakeS3Uploader.UploadObjectArgsForCall(0)
+			Expect(*input.Bucket).To(Equal("orange-bucket"))
+			Expect(*input.Key).To(Equal("bob/bob-2.0-plan9-42.tgz"))
+			Expect(string(uploadedBody)).To(Equal("lemon"))
+
+			Expect(lock.SHA1).To(Equal("dfdd7bce2ad9f89d7204dd83161d66d1e521759c"))
+			Expect(lock.SHA256).To(Equal("f464d7d71c06e47a535ce441aa202aa717cddeab902a45b0c283aac7a9a090d7"))
+			Expect(lock.RemotePath).To(Equal("bob/bob-2.0-plan9-42.tgz"))
+			Expect(lock.RemoteSource).To(Equal(sourceID))
+		})
+
+		When("the upload fails", func() {
+			BeforeEach(func() {
+				fakeS3Uploader.UploadObjectStub = nil
+				fakeS3Uploader.UploadObjectReturns(nil, errors.New("boom"))
+			})
+
+			It("returns an error", func() {
+				_, err := releaseSource.UploadRelease(context.Background(), cargo.BOSHReleaseTarballSpecification{Name: "bob", Version: "2.0"}, strings.NewReader("lemon"))
+				Expect(err).To(MatchError(ContainSubstring("boom")))
+			})
+		})
+	})
+
+	Describe("Probe", func() {
+		It("lists one object in the bucket", func() {
+			fakeS3Client := new(fetcherFakes.S3Client)
+			fakeS3Client.ListObjectsV2Returns(new(s3.ListObjectsV2Output), nil)
+			var releaseSource component.ReleaseSourceProber = component.NewS3ReleaseSource(
+				cargo.ReleaseSourceConfig{ID: sourceID, Bucket: "orange-bucket"},
+				fakeS3Client, nil, nil,
+				log.New(GinkgoWriter, "", 0),
+			)
+
+			Expect(releaseSource.Probe(context.Background())).To(Succeed())
+			_, input, _ := fakeS3Client.ListObjectsV2ArgsForCall(0)
+			Expect(*input.Bucket).To(Equal("orange-bucket"))
+			Expect(*input.MaxKeys).To(Equal(int32(1)))
+		})
+
+		When("access is denied", func() {
+			It("returns an error", func() {
+				fakeS3Client := new(fetcherFakes.S3Client)
+				fakeS3Client.ListObjectsV2Returns(nil, errors.New("AccessDenied"))
+				releaseSource := component.NewS3ReleaseSource(
+					cargo.ReleaseSourceConfig{ID: sourceID, Bucket: "orange-bucket"},
+					fakeS3Client, nil, nil,
+					log.New(GinkgoWriter, "", 0),
+				)
+
+				Expect(releaseSource.Probe(context.Background())).To(MatchError("failed to list objects in bucket orange-bucket: AccessDenied"))
 			})
 		})
 	})
@@ -556,6 +662,7 @@ var _ = Describe("S3ReleaseSource", func() {
 				},
 				nil,
 				nil,
+				nil,
 				log.New(GinkgoWriter, "", 0),
 			)
 			requirement = cargo.BOSHReleaseTarballSpecification{
@@ -583,6 +690,7 @@ var _ = Describe("S3ReleaseSource", func() {
 					},
 					nil,
 					nil,
+					nil,
 					log.New(GinkgoWriter, "", 0),
 				)
 			})
@@ -0,0 +1,110 @@
+package component
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+// ReleaseVersionExplainer is a MultiReleaseSource that can say why
+// FindReleaseVersion chose a release.
+type ReleaseVersionExplainer interface {
+	ExplainReleaseVersion(ctx context.Context, spec cargo.BOSHReleaseTarballSpecification, noDownload bool) (ReleaseSelection, error)
+}
+
+// ReleaseCandidate is a release one release source found while choosing a version.
+type ReleaseCandidate struct {
+	SourceID    string
+	Publishable bool
+	Lock        cargo.BOSHReleaseTarballLock
+}
+
+// ReleaseSelection is the result of choosing between the releases found in
+// several release sources.
+type ReleaseSelection struct {
+	Chosen ReleaseCandidate
+
+	// Candidates are the releases found, from most to least preferred.
+	Candidates []ReleaseCandidate
+
+	// Skipped are the release sources that were not asked because the release
+	// is pinned to other sources or because of the source preference (for
+	// example because they are not publishable).
+	Skipped []string
+
+	// Reason is a sentence explaining why Chosen was preferred.
+	Reason string
+}
+
+// selectRelease orders candidates by version and then by the spec's
+// SourcePreference. Candidates must be in Kilnfile order; it is the last tie-breaker.
+func selectRelease(spec cargo.BOSHReleaseTarballSpecification, candidates []ReleaseCandidate) (ReleaseSelection, error) {
+	var preference cargo.SourcePreference
+	if spec.SourcePreference != nil {
+		preference = *spec.SourcePreference
+	}
+
+	versions := make(map[string]*semver.Version, len(candidates))
+	for _, c := range candidates {
+		v, err := c.Lock.ParseVersion()
+		if err != nil {
+			return ReleaseSelection{}, fmt.Errorf("failed to parse version from release source: %w", err)
+		}
+		versions[c.SourceID] = v
+	}
+
+	sourceRank := func(c ReleaseCandidate) int {
+		if i := slices.Index(preference.Sources, c.SourceID); i >= 0 {
+			return i
+		}
+		return len(preference.Sources)
+	}
+	isCompiled := func(c ReleaseCandidate) bool {
+		return spec.StemcellOS != "" && c.Lock.StemcellOS == spec.StemcellOS && c.Lock.StemcellVersion == spec.StemcellVersion
+	}
+
+	// compare returns a negative number when a is preferred over b, along with
+	// the rule that decided it.
+	compare := func(a, b ReleaseCandidate) (int, string) {
+		if c := versions[b.SourceID].Compare(versions[a.SourceID]); c != 0 {
+			return c, fmt.Sprintf("it has a higher version than %s from %q", b.Lock.Version, b.SourceID)
+		}
+		if preference.PreferCompiled && isCompiled(a) != isCompiled(b) {
+			if isCompiled(a) {
+				return -1, fmt.Sprintf("it is compiled for stemcell %s %s and the release from %q is not (prefer_compiled)", spec.StemcellOS, spec.StemcellVersion, b.SourceID)
+			}
+			return 1, ""
+		}
+		if preference.PreferPublishable && a.Publishable != b.Publishable {
+			if a.Publishable {
+				return -1, fmt.Sprintf("its release source is publishable and %q is not (prefer_publishable)", b.SourceID)
+			}
+			return 1, ""
+		}
+		if c := sourceRank(a) - sourceRank(b); c != 0 {
+			return c, fmt.Sprintf("its release source is listed before %q in source_preference sources", b.SourceID)
+		}
+		return 0, fmt.Sprintf("its release source is listed before %q in the Kilnfile release_sources", b.SourceID)
+	}
+
+	ordered := slices.Clone(candidates)
+	slices.SortStableFunc(ordered, func(a, b ReleaseCandidate) int {
+		c, _ := compare(a, b)
+		return c
+	})
+
+	selection := ReleaseSelection{
+		Chosen:     ordered[0],
+		Candidates: ordered,
+	}
+	because := "it is the only release source with a matching release"
+	if len(ordered) > 1 {
+		_, because = compare(ordered[0], ordered[1])
+	}
+	selection.Reason = fmt.Sprintf("chose %s %s from %q because %s", selection.Chosen.Lock.Name, selection.Chosen.Lock.Version, selection.Chosen.SourceID, because)
+	return selection, nil
+}
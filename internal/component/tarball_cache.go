@@ -0,0 +1,272 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+// TarballCache is a content-addressed store of BOSH release tarballs keyed by SHA1.
+// It is shared between tile repositories so a tarball only needs to be downloaded once
+// per host.
+//
+// Each entry is a directory named with the tarball SHA1 containing the tarball with the
+// file name it was downloaded with. The modification time of the tarball is updated
+// when it is used so entries can be pruned by age.
+type TarballCache struct {
+	Directory string
+}
+
+// CachedTarball describes an entry in a TarballCache.
+type CachedTarball struct {
+	SHA1     string    `json:"sha1"`
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// DefaultTarballCacheDirectory returns the default location of the shared
+// release tarball cache "~/.kiln/cache/releases".
+func DefaultTarballCacheDirectory() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".kiln", "cache", "releases"), nil
+}
+
+// Lookup returns the path of the cached tarball with the SHA1.
+func (cache TarballCache) Lookup(sum string) (string, bool) {
+	if !isSHA1(sum) {
+		return "", false
+	}
+	matches, err := filepath.Glob(filepath.Join(cache.Directory, sum, "*.tgz"))
+	if err != nil || len(matches) != 1 {
+		return "", false
+	}
+	return matches[0], true
+}
+
+// Link hard-links (or copies) the cached tarball with the lock's SHA1 into
+// releaseDir. Because the link shares its content with the cache entry, the
+// tarball is hashed again: when it does not match the lock's SHA1 (or the
+// SHA256 when the lock has one) the link and the cache entry are removed. It
+// returns ErrNotFound when the tarball is not in the cache.
+func (cache TarballCache) Link(ctx context.Context, lock cargo.BOSHReleaseTarballLock, releaseDir string) (Local, error) {
+	cachedPath, ok := cache.Lookup(lock.SHA1)
+	if !ok {
+		return Local{}, ErrNotFound
+	}
+	filePath := filepath.Join(releaseDir, filepath.Base(cachedPath))
+	if err := linkOrCopyFile(ctx, cachedPath, filePath); err != nil {
+		return Local{}, err
+	}
+	digest, err := calculateFileDigest(filePath)
+	if err != nil {
+		return Local{}, err
+	}
+	sums := digest.setSums(lock)
+	if sums.SHA1 != lock.SHA1 || (lock.SHA256 != "" && sums.SHA256 != lock.SHA256) {
+		_ = os.Remove(filePath)
+		if err := cache.Remove(lock.SHA1); err != nil {
+			return Local{}, err
+		}
+		return Local{}, fmt.Errorf("removed corrupt cache entry %s: the cached tarball has SHA1 %s and SHA256 %s", lock.SHA1, sums.SHA1, sums.SHA256)
+	}
+	now := time.Now()
+	_ = os.Chtimes(cachedPath, now, now)
+	return Local{Lock: sums, LocalPath: filePath}, nil
+}
+
+// Put adds a downloaded tarball to the cache. The caller must have verified
+// local.Lock.SHA1 is the SHA1 of the file at local.LocalPath.
+func (cache TarballCache) Put(ctx context.Context, local Local) error {
+	if !isSHA1(local.Lock.SHA1) {
+		return fmt.Errorf("can not cache %s: %q is not a SHA1", local.LocalPath, local.Lock.SHA1)
+	}
+	if _, ok := cache.Lookup(local.Lock.SHA1); ok {
+		return nil
+	}
+	if _, err := os.Stat(local.LocalPath); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(cache.Directory, 0o755); err != nil {
+		return err
+	}
+	// write to a temporary directory and rename so concurrent kiln processes
+	// never see a partially written entry
+	tmp, err := os.MkdirTemp(cache.Directory, ".tmp-"+local.Lock.SHA1)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+	if err := linkOrCopyFile(ctx, local.LocalPath, filepath.Join(tmp, filepath.Base(local.LocalPath))); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(cache.Directory, local.Lock.SHA1)); err != nil {
+		if _, ok := cache.Lookup(local.Lock.SHA1); ok {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// List returns the cached tarballs sorted by SHA1.
+func (cache TarballCache) List() ([]CachedTarball, error) {
+	entries, err := os.ReadDir(cache.Directory)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var result []CachedTarball
+	for _, entry := range entries {
+		if !entry.IsDir() || !isSHA1(entry.Name()) {
+			continue
+		}
+		p, ok := cache.Lookup(entry.Name())
+		if !ok {
+			continue
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, CachedTarball{
+			SHA1:     entry.Name(),
+			Path:     p,
+			Size:     info.Size(),
+			LastUsed: info.ModTime(),
+		})
+	}
+	return result, nil
+}
+
+// Verify recalculates the SHA1 of every cached tarball and returns the entries
+// whose content does not match their key.
+func (cache TarballCache) Verify() ([]CachedTarball, error) {
+	list, err := cache.List()
+	if err != nil {
+		return nil, err
+	}
+	var invalid []CachedTarball
+	for _, entry := range list {
+		sum, err := calculateFileSHA1(entry.Path)
+		if err != nil {
+			return nil, err
+		}
+		if sum != entry.SHA1 {
+			invalid = append(invalid, entry)
+		}
+	}
+	return invalid, nil
+}
+
+// Remove deletes the cached tarball with the SHA1.
+func (cache TarballCache) Remove(sum string) error {
+	if !isSHA1(sum) {
+		return fmt.Errorf("%q is not a SHA1", sum)
+	}
+	return os.RemoveAll(filepath.Join(cache.Directory, sum))
+}
+
+// Prune removes entries not used since now minus maxAge and then removes the
+// least recently used entries until the cache is no larger than maxSize bytes.
+// A zero maxAge or maxSize disables the respective check.
+func (cache TarballCache) Prune(maxSize int64, maxAge time.Duration, now time.Time) ([]CachedTarball, error) {
+	list, err := cache.List()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].LastUsed.Before(list[j].LastUsed)
+	})
+
+	var total int64
+	for _, entry := range list {
+		total += entry.Size
+	}
+
+	var removed []CachedTarball
+	for _, entry := range list {
+		tooOld := maxAge > 0 && now.Sub(entry.LastUsed) > maxAge
+		tooBig := maxSize > 0 && total > maxSize
+		if !tooOld && !tooBig {
+			continue
+		}
+		if err := cache.Remove(entry.SHA1); err != nil {
+			return removed, err
+		}
+		total -= entry.Size
+		removed = append(removed, entry)
+	}
+	return removed, nil
+}
+
+func isSHA1(sum string) bool {
+	if len(sum) != 40 {
+		return false
+	}
+	for _, c := range sum {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// CachedMultiReleaseSource wraps a MultiReleaseSource so DownloadRelease
+// consults a TarballCache before going to the network. When the lock passed to
+// DownloadRelease has a SHA1 and the downloaded tarball matches it, the tarball
+// is added to the cache.
+type CachedMultiReleaseSource struct {
+	MultiReleaseSource
+	Cache  TarballCache
+	logger *log.Logger
+}
+
+func NewCachedMultiReleaseSource(source MultiReleaseSource, cache TarballCache, logger *log.Logger) CachedMultiReleaseSource {
+	if logger == nil {
+		logger = log.New(os.Stderr, "[release cache] ", log.Default().Flags())
+	}
+	return CachedMultiReleaseSource{
+		MultiReleaseSource: source,
+		Cache:              cache,
+		logger:             logger,
+	}
+}
+
+func (src CachedMultiReleaseSource) DownloadRelease(ctx context.Context, releaseDir string, remoteRelease cargo.BOSHReleaseTarballLock) (Local, error) {
+	expectedSHA1 := remoteRelease.SHA1
+	if local, err := src.Cache.Link(ctx, remoteRelease, releaseDir); err == nil {
+		src.logger.Printf("using cached %s %s from %s", remoteRelease.Name, remoteRelease.Version, src.Cache.Directory)
+		return local, nil
+	} else if !IsErrNotFound(err) {
+		src.logger.Printf("warning: failed to use cached %s %s: %s", remoteRelease.Name, remoteRelease.Version, err)
+	}
+
+	remoteRelease.SHA1 = ""
+	remoteRelease.SHA256 = ""
+	local, err := src.MultiReleaseSource.DownloadRelease(ctx, releaseDir, remoteRelease)
+	if err != nil {
+		return Local{}, err
+	}
+
+	if expectedSHA1 != "" && local.Lock.SHA1 == expectedSHA1 {
+		if err := src.Cache.Put(ctx, local); err != nil {
+			src.logger.Printf("warning: failed to add %s %s to the release cache: %s", remoteRelease.Name, remoteRelease.Version, err)
+		}
+	}
+
+	return local, nil
+}
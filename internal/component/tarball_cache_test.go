@@ -0,0 +1,259 @@
+package component_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/kiln/internal/component"
+	"github.com/pivotal-cf/kiln/internal/component/fakes"
+	test_helpers "github.com/pivotal-cf/kiln/internal/test-helpers"
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+var _ = Describe("TarballCache", func() {
+	var (
+		cache component.TarballCache
+
+		downloadDirectory, releasesDirectory string
+
+		bpmPath, bpmSHA1 string
+	)
+
+	BeforeEach(func() {
+		cache = component.TarballCache{Directory: filepath.Join(GinkgoT().TempDir(), "cache")}
+		downloadDirectory = GinkgoT().TempDir()
+		releasesDirectory = GinkgoT().TempDir()
+
+		bpmPath = filepath.Join(downloadDirectory, "bpm-1.2.0.tgz")
+		bpmSHA1 = must(test_helpers.WriteReleaseTarball(bpmPath, "bpm", "1.2.0", osfs.New("")))
+	})
+
+	put := func(name, version string) component.CachedTarball {
+		p := filepath.Join(downloadDirectory, name+"-"+version+".tgz")
+		sum := must(test_helpers.WriteReleaseTarball(p, name, version, osfs.New("")))
+		Expect(cache.Put(context.Background(), component.Local{Lock: cargo.BOSHReleaseTarballLock{SHA1: sum}, LocalPath: p})).To(Succeed())
+		cached, ok := cache.Lookup(sum)
+		Expect(ok).To(BeTrue())
+		info := must(os.Stat(cached))
+		return component.CachedTarball{SHA1: sum, Path: cached, Size: info.Size()}
+	}
+
+	Describe("Put and Link", func() {
+		It("stores the tarball by SHA1 and links it into a releases directory", func() {
+			Expect(cache.Put(context.Background(), component.Local{Lock: cargo.BOSHReleaseTarballLock{SHA1: bpmSHA1}, LocalPath: bpmPath})).To(Succeed())
+
+			cached, ok := cache.Lookup(bpmSHA1)
+			Expect(ok).To(BeTrue())
+			Expect(cached).To(Equal(filepath.Join(cache.Directory, bpmSHA1, "bpm-1.2.0.tgz")))
+
+			linked, err := cache.Link(context.Background(), cargo.BOSHReleaseTarballLock{SHA1: bpmSHA1}, releasesDirectory)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(linked.LocalPath).To(Equal(filepath.Join(releasesDirectory, "bpm-1.2.0.tgz")))
+			Expect(linked.LocalPath).To(BeAnExistingFile())
+			Expect(linked.Lock.SHA1).To(Equal(bpmSHA1))
+			Expect(linked.Lock.SHA256).To(Equal(fmt.Sprintf("%x", sha256.Sum256(must(os.ReadFile(bpmPath))))))
+		})
+
+		When("the cached tarball was changed", func() {
+			It("removes the link and evicts the entry", func() {
+				Expect(cache.Put(context.Background(), component.Local{Lock: cargo.BOSHReleaseTarballLock{SHA1: bpmSHA1}, LocalPath: bpmPath})).To(Succeed())
+				cached, _ := cache.Lookup(bpmSHA1)
+				Expect(os.WriteFile(cached, []byte("changed in place"), 0o644)).To(Succeed())
+
+				_, err := cache.Link(context.Background(), cargo.BOSHReleaseTarballLock{SHA1: bpmSHA1}, releasesDirectory)
+				Expect(err).To(MatchError(ContainSubstring("removed corrupt cache entry " + bpmSHA1)))
+				Expect(filepath.Join(releasesDirectory, "bpm-1.2.0.tgz")).NotTo(BeAnExistingFile())
+				_, ok := cache.Lookup(bpmSHA1)
+				Expect(ok).To(BeFalse())
+			})
+		})
+
+		When("the cached tarball does not match the locked SHA256", func() {
+			It("evicts the entry", func() {
+				Expect(cache.Put(context.Background(), component.Local{Lock: cargo.BOSHReleaseTarballLock{SHA1: bpmSHA1}, LocalPath: bpmPath})).To(Succeed())
+
+				_, err := cache.Link(context.Background(), cargo.BOSHReleaseTarballLock{SHA1: bpmSHA1, SHA256: "not-the-sha256"}, releasesDirectory)
+				Expect(err).To(MatchError(ContainSubstring("removed corrupt cache entry")))
+				_, ok := cache.Lookup(bpmSHA1)
+				Expect(ok).To(BeFalse())
+			})
+		})
+
+		It("does not accept a lock without a SHA1", func() {
+			Expect(cache.Put(context.Background(), component.Local{LocalPath: bpmPath})).NotTo(Succeed())
+		})
+
+		When("the tarball is not cached", func() {
+			It("returns ErrNotFound", func() {
+				_, err := cache.Link(context.Background(), cargo.BOSHReleaseTarballLock{SHA1: bpmSHA1}, releasesDirectory)
+				Expect(component.IsErrNotFound(err)).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("List", func() {
+		When("the cache directory does not exist", func() {
+			It("returns an empty list", func() {
+				Expect(cache.List()).To(BeEmpty())
+			})
+		})
+
+		It("returns the cached tarballs", func() {
+			entry := put("bpm", "1.2.0")
+			list, err := cache.List()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(list).To(HaveLen(1))
+			Expect(list[0].SHA1).To(Equal(entry.SHA1))
+			Expect(list[0].Size).To(Equal(entry.Size))
+		})
+	})
+
+	Describe("Verify", func() {
+		It("returns tarballs whose content does not match their SHA1", func() {
+			good := put("bpm", "1.2.0")
+			bad := put("uaa", "7.0.0")
+			Expect(os.Remove(bad.Path)).To(Succeed())
+			Expect(os.WriteFile(bad.Path, []byte("banana"), 0o644)).To(Succeed())
+
+			invalid, err := cache.Verify()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(invalid).To(HaveLen(1))
+			Expect(invalid[0].SHA1).To(Equal(bad.SHA1))
+			Expect(invalid[0].SHA1).NotTo(Equal(good.SHA1))
+		})
+	})
+
+	Describe("Prune", func() {
+		var (
+			now                time.Time
+			oldest, old, fresh component.CachedTarball
+		)
+		BeforeEach(func() {
+			now = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+			oldest = put("bpm", "1.0.0")
+			old = put("bpm", "1.1.0")
+			fresh = put("bpm", "1.2.0")
+			Expect(os.Chtimes(oldest.Path, now, now.Add(-90*24*time.Hour))).To(Succeed())
+			Expect(os.Chtimes(old.Path, now, now.Add(-10*24*time.Hour))).To(Succeed())
+			Expect(os.Chtimes(fresh.Path, now, now.Add(-time.Hour))).To(Succeed())
+		})
+
+		It("removes tarballs older than the maximum age", func() {
+			removed, err := cache.Prune(0, 30*24*time.Hour, now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(removed).To(HaveLen(1))
+			Expect(removed[0].SHA1).To(Equal(oldest.SHA1))
+			Expect(cache.List()).To(HaveLen(2))
+		})
+
+		It("removes the least recently used tarballs until the cache fits the maximum size", func() {
+			removed, err := cache.Prune(fresh.Size, 0, now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(removed).To(HaveLen(2))
+			Expect(removed[0].SHA1).To(Equal(oldest.SHA1))
+			Expect(removed[1].SHA1).To(Equal(old.SHA1))
+			_, ok := cache.Lookup(fresh.SHA1)
+			Expect(ok).To(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("CachedMultiReleaseSource", func() {
+	var (
+		cache             component.TarballCache
+		wrapped           *fakes.MultiReleaseSource
+		source            component.CachedMultiReleaseSource
+		releasesDirectory string
+		bpmPath, bpmSHA1  string
+		lock              cargo.BOSHReleaseTarballLock
+	)
+
+	BeforeEach(func() {
+		cache = component.TarballCache{Directory: GinkgoT().TempDir()}
+		releasesDirectory = GinkgoT().TempDir()
+		bpmPath = filepath.Join(releasesDirectory, "bpm-1.2.0.tgz")
+		bpmSHA1 = must(test_helpers.WriteReleaseTarball(bpmPath, "bpm", "1.2.0", osfs.New("")))
+		Expect(os.Remove(bpmPath)).To(Succeed())
+
+		lock = cargo.BOSHReleaseTarballLock{Name: "bpm", Version: "1.2.0", SHA1: bpmSHA1, RemoteSource: "mirror", RemotePath: "bpm-1.2.0.tgz"}
+
+		wrapped = new(fakes.MultiReleaseSource)
+		wrapped.DownloadReleaseStub = func(_ context.Context, dir string, l cargo.BOSHReleaseTarballLock) (component.Local, error) {
+			p := filepath.Join(dir, "bpm-1.2.0.tgz")
+			sum, err := test_helpers.WriteReleaseTarball(p, "bpm", "1.2.0", osfs.New(""))
+			l.SHA1 = sum
+			return component.Local{Lock: l, LocalPath: p}, err
+		}
+		source = component.NewCachedMultiReleaseSource(wrapped, cache, log.New(GinkgoWriter, "", 0))
+	})
+
+	It("downloads a release once and then links it from the cache", func() {
+		local, err := source.DownloadRelease(context.Background(), releasesDirectory, lock)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(local.Lock.SHA1).To(Equal(bpmSHA1))
+		Expect(wrapped.DownloadReleaseCallCount()).To(Equal(1))
+		_, _, passedLock := wrapped.DownloadReleaseArgsForCall(0)
+		Expect(passedLock.SHA1).To(BeEmpty())
+
+		Expect(os.Remove(local.LocalPath)).To(Succeed())
+
+		local, err = source.DownloadRelease(context.Background(), releasesDirectory, lock)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(wrapped.DownloadReleaseCallCount()).To(Equal(1))
+		Expect(local.LocalPath).To(BeAnExistingFile())
+		Expect(local.Lock.SHA1).To(Equal(lock.SHA1))
+		Expect(local.Lock.RemoteSource).To(Equal(lock.RemoteSource))
+	})
+
+	When("the lock has a SHA256", func() {
+		It("checks it for a cached tarball", func() {
+			local, err := source.DownloadRelease(context.Background(), releasesDirectory, lock)
+			Expect(err).NotTo(HaveOccurred())
+			_, _, passedLock := wrapped.DownloadReleaseArgsForCall(0)
+			Expect(passedLock.SHA256).To(BeEmpty())
+			lock.SHA256 = fmt.Sprintf("%x", sha256.Sum256(must(os.ReadFile(local.LocalPath))))
+			Expect(os.Remove(local.LocalPath)).To(Succeed())
+
+			local, err = source.DownloadRelease(context.Background(), releasesDirectory, lock)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(wrapped.DownloadReleaseCallCount()).To(Equal(1))
+			Expect(local.Lock.SHA256).To(Equal(lock.SHA256))
+		})
+	})
+
+	When("the cached tarball was changed in a releases directory", func() {
+		It("evicts the entry and downloads the release again", func() {
+			local, err := source.DownloadRelease(context.Background(), releasesDirectory, lock)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.Remove(local.LocalPath)).To(Succeed())
+			cached, ok := cache.Lookup(bpmSHA1)
+			Expect(ok).To(BeTrue())
+			Expect(os.WriteFile(cached, []byte("changed in place"), 0o644)).To(Succeed())
+
+			local, err = source.DownloadRelease(context.Background(), releasesDirectory, lock)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(wrapped.DownloadReleaseCallCount()).To(Equal(2))
+			Expect(local.Lock.SHA1).To(Equal(bpmSHA1))
+		})
+	})
+
+	When("the downloaded tarball does not match the expected SHA1", func() {
+		BeforeEach(func() {
+			lock.SHA1 = "0000000000000000000000000000000000000000"
+		})
+		It("does not cache it", func() {
+			_, err := source.DownloadRelease(context.Background(), releasesDirectory, lock)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cache.List()).To(BeEmpty())
+		})
+	})
+})
@@ -46,7 +46,7 @@ func main() {
 	}
 
 	if global.Help {
-		if command == "carvel" && len(args) > 0 {
+		if (command == "carvel" || command == "cache" || command == "release-sources" || command == "kilnfile" || command == "bake-records") && len(args) > 0 {
 			args = append(args, "--help")
 		} else {
 			command = "help"
@@ -71,6 +71,10 @@ func main() {
 		repo := component.NewReleaseSourceRepo(kilnfile)
 		return repo.FindRemotePather(sourceID)
 	})
+	ruFinder := commands.ReleaseUploaderFinder(func(kilnfile cargo.Kilnfile, sourceID string) (component.ReleaseUploader, error) {
+		repo := component.NewReleaseSourceRepo(kilnfile)
+		return repo.FindReleaseUploader(sourceID)
+	})
 
 	commandSet := jhanda.CommandSet{}
 	fetch := commands.NewFetch(outLogger, mrsProvider, localReleaseDirectory)
@@ -81,12 +85,20 @@ func main() {
 	commandSet["bake"] = bakeCommand
 	commandSet["re-bake"] = commands.NewReBake(bakeCommand)
 	commandSet["rebake"] = commandSet["re-bake"]
+	commandSet["verify-bake"] = commands.NewVerifyBake(bakeCommand, outLogger)
+	commandSet["bake-records"] = commands.NewBakeRecords(outLogger)
+	commandSet["verify-provenance"] = commands.NewVerifyProvenance(outLogger)
+	commandSet["inspect"] = commands.NewInspect(outLogger)
 
 	commandSet["test"] = commands.NewTileTest()
 	commandSet["help"] = commands.NewHelp(os.Stdout, globalFlagsUsage, commandSet)
 	commandSet["version"] = commands.NewVersion(outLogger, version)
 	commandSet["update-release"] = commands.NewUpdateRelease(outLogger, fs, mrsProvider)
+	commandSet["update-releases"] = commands.NewUpdateReleases(outLogger, fs, mrsProvider)
 	commandSet["sync-with-local"] = commands.NewSyncWithLocal(fs, localReleaseDirectory, rpFinder, outLogger)
+	commandSet["upload-release"] = commands.NewUploadRelease(fs, ruFinder, outLogger)
+	commandSet["mirror"] = commands.NewMirror(fs, mrsProvider, ruFinder, outLogger)
+	commandSet["merge-lock"] = commands.NewMergeLock(fs, outLogger)
 
 	commandSet["update-stemcell"] = commands.UpdateStemcell{
 		Logger:                     outLogger,
@@ -100,6 +112,8 @@ func main() {
 
 	commandSet["find-stemcell-version"] = commands.NewFindStemcellVersion(outLogger, pivnetService)
 
+	commandSet["outdated"] = commands.NewOutdated(outLogger, fs, mrsProvider, pivnetService)
+
 	commandSet["validate"] = commands.NewValidate(osfs.New(""))
 	commandSet["release-notes"], err = commands.NewReleaseNotesCommand()
 	if err != nil {
@@ -109,8 +123,13 @@ func main() {
 	carvelCommand := commands.NewCarvel(outLogger, errLogger, version)
 	commandSet["carvel"] = carvelCommand
 
-	if command == "carvel" {
-		err = carvelCommand.Execute(args)
+	commandSet["cache"] = commands.NewCache(outLogger)
+	commandSet["release-sources"] = commands.NewReleaseSources(fs, component.ReleaseSourceFactory, outLogger)
+	commandSet["kilnfile"] = commands.NewKilnfile(fs, outLogger)
+
+	// command groups handle their own help flags for subcommands
+	if command == "carvel" || command == "cache" || command == "release-sources" || command == "kilnfile" || command == "bake-records" {
+		err = commandSet[command].Execute(args)
 	} else {
 		err = commandSet.Execute(command, args)
 	}
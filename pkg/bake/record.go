@@ -21,6 +21,7 @@ import (
 	"github.com/Masterminds/semver/v3"
 
 	"github.com/pivotal-cf/kiln/internal/builder"
+	"github.com/pivotal-cf/kiln/pkg/cargo"
 	"github.com/pivotal-cf/kiln/pkg/tile"
 )
 
@@ -47,6 +48,30 @@ type Record struct {
 
 	// TileDirectory may be the directory containing tile source.
 	TileDirectory string `yaml:"tile_directory,omitempty" json:"tile_directory,omitempty"`
+
+	// Stemcells are the stemcell criteria from the product template.
+	Stemcells []Stemcell `yaml:"stemcells,omitempty" json:"stemcells,omitempty"`
+
+	// Releases are the BOSH releases baked into the tile.
+	Releases []Release `yaml:"releases,omitempty" json:"releases,omitempty"`
+
+	// KilnfileLockChecksum may be the SHA256 checksum of the Kilnfile.lock used to bake the tile.
+	KilnfileLockChecksum string `yaml:"kilnfile_lock_checksum,omitempty" json:"kilnfile_lock_checksum,omitempty"`
+}
+
+// Release is a BOSH release baked into a tile. Records written by older
+// versions of Kiln do not have releases.
+type Release struct {
+	Name         string `yaml:"name"                    json:"name"`
+	Version      string `yaml:"version"                 json:"version"`
+	SHA1         string `yaml:"sha1,omitempty"          json:"sha1,omitempty"`
+	RemoteSource string `yaml:"remote_source,omitempty" json:"remote_source,omitempty"`
+}
+
+// Stemcell is a stemcell a tile was baked for.
+type Stemcell struct {
+	OS      string `yaml:"os"      json:"os"`
+	Version string `yaml:"version" json:"version"`
 }
 
 // NewRecord parses build information from an OpsManger Product Template (aka metadata/metadata.yml)
@@ -54,6 +79,10 @@ func NewRecord(fileChecksum string, productTemplateBytes []byte) (Record, error)
 	var productTemplate struct {
 		ProductVersion string               `yaml:"product_version"`
 		KilnMetadata   builder.KilnMetadata `yaml:"kiln_metadata"`
+
+		Releases                    []Release  `yaml:"releases"`
+		StemcellCriteria            Stemcell   `yaml:"stemcell_criteria"`
+		AdditionalStemcellsCriteria []Stemcell `yaml:"additional_stemcells_criteria"`
 	}
 
 	err := yaml.Unmarshal(productTemplateBytes, &productTemplate)
@@ -65,11 +94,28 @@ func NewRecord(fileChecksum string, productTemplateBytes []byte) (Record, error)
 		return Record{}, fmt.Errorf("failed to parse build information from product template: kiln_metadata.metadata_git_sha not found")
 	}
 
+	var stemcells []Stemcell
+	if productTemplate.StemcellCriteria != (Stemcell{}) {
+		stemcells = append(stemcells, productTemplate.StemcellCriteria)
+	}
+	stemcells = append(stemcells, productTemplate.AdditionalStemcellsCriteria...)
+
+	var releases []Release
+	for _, release := range productTemplate.Releases {
+		releases = append(releases, Release{
+			Name:    release.Name,
+			Version: release.Version,
+			SHA1:    release.SHA1,
+		})
+	}
+
 	return Record{
 		SourceRevision: productTemplate.KilnMetadata.MetadataGitSHA,
 		Version:        productTemplate.ProductVersion,
 		TileName:       productTemplate.KilnMetadata.TileName,
 		FileChecksum:   fileChecksum,
+		Stemcells:      stemcells,
+		Releases:       releases,
 	}, nil
 }
 
@@ -162,6 +208,15 @@ func (record Record) IsEquivalent(other Record, logger *log.Logger) bool {
 	record.TileName = ""
 	other.TileName = ""
 
+	// records written by older versions of Kiln do not have a bill of materials
+	if !record.HasBillOfMaterials() || !other.HasBillOfMaterials() {
+		record.Stemcells, other.Stemcells = nil, nil
+		record.Releases, other.Releases = nil, nil
+	}
+	if record.KilnfileLockChecksum == "" || other.KilnfileLockChecksum == "" {
+		record.KilnfileLockChecksum, other.KilnfileLockChecksum = "", ""
+	}
+
 	if exp, got := record.Version, other.Version; exp != got {
 		logger.Printf("tile versions are not the same: expected %q but got %q", exp, got)
 	}
@@ -174,7 +229,65 @@ func (record Record) IsEquivalent(other Record, logger *log.Logger) bool {
 		logger.Printf("tile file checksums are not the same: expected %q but got %q", exp, got)
 	}
 
-	return record == other
+	if exp, got := record.Releases, other.Releases; !slices.Equal(exp, got) {
+		logger.Printf("tile releases are not the same: expected %v but got %v", exp, got)
+	}
+
+	if exp, got := record.Stemcells, other.Stemcells; !slices.Equal(exp, got) {
+		logger.Printf("tile stemcells are not the same: expected %v but got %v", exp, got)
+	}
+
+	return slices.Equal(record.Releases, other.Releases) &&
+		slices.Equal(record.Stemcells, other.Stemcells) &&
+		record.comparableFields() == other.comparableFields()
+}
+
+// HasBillOfMaterials returns true when the record lists the releases baked into
+// the tile. Records written by older versions of Kiln do not.
+func (record Record) HasBillOfMaterials() bool {
+	return len(record.Releases) > 0
+}
+
+// recordFields are the comparable fields of Record.
+type recordFields struct {
+	SourceRevision, Version, KilnVersion, TileName, FileChecksum, TileDirectory, KilnfileLockChecksum string
+}
+
+func (record Record) comparableFields() recordFields {
+	return recordFields{
+		SourceRevision:       record.SourceRevision,
+		Version:              record.Version,
+		KilnVersion:          record.KilnVersion,
+		TileName:             record.TileName,
+		FileChecksum:         record.FileChecksum,
+		TileDirectory:        record.TileDirectory,
+		KilnfileLockChecksum: record.KilnfileLockChecksum,
+	}
+}
+
+// SetKilnfileLock records the checksum of the Kilnfile.lock used to bake the
+// tile and the remote sources of the releases locked in it.
+func (record Record) SetKilnfileLock(kilnfileLockPath string) (Record, error) {
+	buf, err := os.ReadFile(kilnfileLockPath)
+	if err != nil {
+		return record, err
+	}
+	var lock cargo.KilnfileLock
+	if err := yaml.Unmarshal(buf, &lock); err != nil {
+		return record, fmt.Errorf("failed to parse %s: %w", kilnfileLockPath, err)
+	}
+	sum := sha256.Sum256(buf)
+	record.KilnfileLockChecksum = hex.EncodeToString(sum[:])
+
+	record.Releases = slices.Clone(record.Releases)
+	for i, release := range record.Releases {
+		locked, err := lock.FindBOSHReleaseWithName(release.Name)
+		if err != nil {
+			continue
+		}
+		record.Releases[i].RemoteSource = locked.RemoteSource
+	}
+	return record, nil
 }
 
 func (record Record) WriteFile(tileSourceDirectory string) error {
@@ -191,17 +304,22 @@ func (record Record) WriteFile(tileSourceDirectory string) error {
 	if err != nil {
 		return err
 	}
-	fileName := record.Version + ".json"
-	if record.TileName != "" {
-		fileName = record.TileName + "-" + fileName
-	}
-	outputFilepath := filepath.Join(tileSourceDirectory, RecordsDirectory, fileName)
+	outputFilepath := record.Filepath(tileSourceDirectory)
 	if _, err := os.Stat(outputFilepath); err == nil {
 		return fmt.Errorf("tile bake record already exists for %s", record.Name())
 	}
 	return os.WriteFile(outputFilepath, buf, 0o644)
 }
 
+// Filepath returns the path WriteFile writes the record to.
+func (record Record) Filepath(tileSourceDirectory string) string {
+	fileName := record.Version + ".json"
+	if record.TileName != "" {
+		fileName = record.TileName + "-" + fileName
+	}
+	return filepath.Join(tileSourceDirectory, RecordsDirectory, fileName)
+}
+
 func (record Record) SetTileDirectory(tileSourceDirectory string) (Record, error) {
 	absoluteTileSourceDirectory, err := filepath.Abs(tileSourceDirectory)
 	if err != nil {
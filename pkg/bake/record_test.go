@@ -21,6 +21,11 @@ func TestNewRecordFromFile(t *testing.T) {
 		Version:        "0.2.0-dev",
 		SourceRevision: "5874e0f81d0af47922716a7c69a08bcdead13348",
 		FileChecksum:   "7490ba0b736c262ee7dc433c423c4f95ad838b014769d8465c50e445967d2735",
+		Stemcells:      []bake.Stemcell{{OS: "ubuntu-jammy", Version: "1.329"}},
+		Releases: []bake.Release{
+			{Name: "hello-release", Version: "0.2.3", SHA1: "a0f2747fd22796d5fbbe036d0d8786e76a2ac651"},
+			{Name: "bpm", Version: "1.2.12", SHA1: "aff9f4397c931c7b9cdb992c62d3f3f629756198"},
+		},
 	}, record)
 }
 
@@ -44,6 +49,29 @@ kiln_metadata:
 		}, b)
 	})
 
+	t.Run("when the product template has releases and stemcells", func(t *testing.T) {
+		// language=yaml
+		b, err := bake.NewRecord("some-peach-jam", []byte(`
+product_version: some-product-version
+kiln_metadata:
+  metadata_git_sha: some-tile-source-revision
+releases:
+  - name: bpm
+    file: bpm-1.2.12.tgz
+    version: 1.2.12
+    sha1: some-bpm-sha1
+stemcell_criteria:
+  os: ubuntu-jammy
+  version: "1.329"
+additional_stemcells_criteria:
+  - os: windows2019
+    version: "2019.70"
+`))
+		require.NoError(t, err)
+		require.Equal(t, []bake.Release{{Name: "bpm", Version: "1.2.12", SHA1: "some-bpm-sha1"}}, b.Releases)
+		require.Equal(t, []bake.Stemcell{{OS: "ubuntu-jammy", Version: "1.329"}, {OS: "windows2019", Version: "2019.70"}}, b.Stemcells)
+	})
+
 	t.Run("when the product template is missing kiln_metadata", func(t *testing.T) {
 		// language=yaml
 		_, err := bake.NewRecord("some-peach-jam", []byte(`
@@ -303,3 +331,44 @@ func createGitRepository(t *testing.T, dir string) {
 	gitInit.Dir = dir
 	require.NoError(t, gitInit.Run())
 }
+
+func TestBakeRecord_SetKilnfileLock(t *testing.T) {
+	kilnfileLockPath := filepath.Join(t.TempDir(), "Kilnfile.lock")
+	// language=yaml
+	require.NoError(t, os.WriteFile(kilnfileLockPath, []byte(`releases:
+  - name: bpm
+    version: 1.2.12
+    sha1: some-bpm-sha1
+    remote_source: bosh.io
+    remote_path: https://bosh.io/d/github.com/cloudfoundry/bpm-release?v=1.2.12
+`), 0o644))
+
+	record, err := bake.Record{
+		Releases: []bake.Release{{Name: "bpm", Version: "1.2.12"}, {Name: "not-locked", Version: "1.0.0"}},
+	}.SetKilnfileLock(kilnfileLockPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, []bake.Release{{Name: "bpm", Version: "1.2.12", RemoteSource: "bosh.io"}, {Name: "not-locked", Version: "1.0.0"}}, record.Releases)
+	assert.Len(t, record.KilnfileLockChecksum, 64)
+}
+
+func TestBakeRecord_IsEquivalent(t *testing.T) {
+	record := bake.Record{
+		SourceRevision: "some-revision",
+		Version:        "1.0.0",
+		FileChecksum:   "some-checksum",
+	}
+	withBillOfMaterials := record
+	withBillOfMaterials.Releases = []bake.Release{{Name: "bpm", Version: "1.2.12"}}
+	withBillOfMaterials.KilnfileLockChecksum = "some-lock-checksum"
+
+	t.Run("when a record was written before releases were recorded", func(t *testing.T) {
+		assert.True(t, record.IsEquivalent(withBillOfMaterials, nil))
+	})
+
+	t.Run("when the releases differ", func(t *testing.T) {
+		other := withBillOfMaterials
+		other.Releases = []bake.Release{{Name: "bpm", Version: "1.2.13"}}
+		assert.False(t, withBillOfMaterials.IsEquivalent(other, nil))
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"archive/zip"
 	"compress/gzip"
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"io"
@@ -120,6 +121,7 @@ type BOSHReleaseTarball struct {
 	Manifest BOSHReleaseManifest
 
 	SHA1     string
+	SHA256   string
 	FilePath string
 }
 
@@ -152,8 +154,8 @@ func OpenBOSHReleaseTarball(tarballPath string) (BOSHReleaseTarball, error) {
 }
 
 func ReadBOSHReleaseTarball(tarballPath string, r io.Reader) (BOSHReleaseTarball, error) {
-	sum := sha1.New()
-	r = io.TeeReader(r, sum)
+	sum, sum256 := sha1.New(), sha256.New()
+	r = io.TeeReader(r, io.MultiWriter(sum, sum256))
 	compressor := fileutil.NewTarballCompressor(nil, system.NewOsFileSystem(logger.NewLogger(logger.LevelNone)))
 	isNonCompressed := compressor.IsNonCompressedTarball(tarballPath)
 	m, err := ReadProductTemplatePartFromBOSHReleaseTarball(r, isNonCompressed)
@@ -164,6 +166,7 @@ func ReadBOSHReleaseTarball(tarballPath string, r io.Reader) (BOSHReleaseTarball
 	return BOSHReleaseTarball{
 		Manifest: m,
 		SHA1:     hex.EncodeToString(sum.Sum(nil)),
+		SHA256:   hex.EncodeToString(sum256.Sum(nil)),
 		FilePath: tarballPath,
 	}, err
 }
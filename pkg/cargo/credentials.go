@@ -0,0 +1,245 @@
+package cargo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CredentialsProfilesPath is the path, relative to the user's home directory,
+// of the file CredentialReference.Profile values are read from. The file is a
+// YAML map from profile names to maps of credential names to secrets.
+//
+//	artifactory:
+//	  username: some-user
+//	  password: some-password
+const CredentialsProfilesPath = ".kiln/credentials"
+
+// CredentialReference points at a secret stored outside the Kilnfile. Exactly
+// one field must be set.
+//
+// Helper is a command (and its arguments) that reads a JSON
+// CredentialHelperRequest from standard input and writes a JSON
+// CredentialHelperResponse to standard output.
+type CredentialReference struct {
+	Env     string   `yaml:"env,omitempty"`
+	File    string   `yaml:"file,omitempty"`
+	Helper  []string `yaml:"helper,omitempty"`
+	Profile string   `yaml:"profile,omitempty"`
+}
+
+// CredentialHelperRequest is written to the standard input of a credential helper.
+type CredentialHelperRequest struct {
+	ReleaseSource string `json:"release_source"`
+	Type          string `json:"type"`
+	Credential    string `json:"credential"`
+}
+
+// CredentialHelperResponse is read from the standard output of a credential helper.
+type CredentialHelperResponse struct {
+	Secret string `json:"secret"`
+}
+
+// CredentialResolver sets release source secrets from the credential
+// references in a Kilnfile. Nil fields default to the operating system
+// implementations.
+type CredentialResolver struct {
+	LookupEnv func(key string) (string, bool)
+	ReadFile  func(name string) ([]byte, error)
+	HomeDir   func() (string, error)
+	RunHelper func(command []string, request []byte) ([]byte, error)
+
+	profiles map[string]map[string]string
+}
+
+// ResolveCredentials returns a copy of kilnfile where each release source
+// field named in ReleaseSourceConfig.Credentials is set to the referenced secret.
+func (r CredentialResolver) ResolveCredentials(kilnfile Kilnfile) (Kilnfile, error) {
+	if !slices.ContainsFunc(kilnfile.ReleaseSources, func(c ReleaseSourceConfig) bool { return len(c.Credentials) > 0 }) {
+		return kilnfile, nil
+	}
+	r.setDefaults()
+
+	kilnfile.ReleaseSources = slices.Clone(kilnfile.ReleaseSources)
+	for i := range kilnfile.ReleaseSources {
+		source := &kilnfile.ReleaseSources[i]
+		id := BOSHReleaseTarballSourceID(*source)
+
+		names := make([]string, 0, len(source.Credentials))
+		for name := range source.Credentials {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		for _, name := range names {
+			field, err := source.credentialField(name)
+			if err != nil {
+				return Kilnfile{}, fmt.Errorf("release source %q: %w", id, err)
+			}
+			if *field != "" {
+				return Kilnfile{}, fmt.Errorf("release source %q sets %s and a credential reference for it", id, name)
+			}
+			secret, err := r.resolve(source.Credentials[name], CredentialHelperRequest{
+				ReleaseSource: id,
+				Type:          source.Type,
+				Credential:    name,
+			})
+			if err != nil {
+				return Kilnfile{}, fmt.Errorf("failed to resolve %s for release source %q: %w", name, id, err)
+			}
+			*field = secret
+		}
+	}
+	return kilnfile, nil
+}
+
+func (c *ReleaseSourceConfig) credentialField(name string) (*string, error) {
+	switch name {
+	case "access_key_id":
+		return &c.AccessKeyId, nil
+	case "secret_access_key":
+		return &c.SecretAccessKey, nil
+	case "github_token":
+		return &c.GithubToken, nil
+	case "username":
+		return &c.Username, nil
+	case "password":
+		return &c.Password, nil
+	default:
+		return nil, fmt.Errorf("unknown credential %q (expected one of access_key_id, secret_access_key, github_token, username, password)", name)
+	}
+}
+
+func (r *CredentialResolver) setDefaults() {
+	if r.LookupEnv == nil {
+		r.LookupEnv = os.LookupEnv
+	}
+	if r.ReadFile == nil {
+		r.ReadFile = os.ReadFile
+	}
+	if r.HomeDir == nil {
+		r.HomeDir = os.UserHomeDir
+	}
+	if r.RunHelper == nil {
+		r.RunHelper = runCredentialHelper
+	}
+}
+
+func (r *CredentialResolver) resolve(ref CredentialReference, request CredentialHelperRequest) (string, error) {
+	set := 0
+	for _, isSet := range []bool{ref.Env != "", ref.File != "", len(ref.Helper) > 0, ref.Profile != ""} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		return "", errors.New("exactly one of env, file, helper, or profile must be set")
+	}
+
+	switch {
+	case ref.Env != "":
+		value, ok := r.LookupEnv(ref.Env)
+		if !ok || value == "" {
+			return "", fmt.Errorf("environment variable %s is not set", ref.Env)
+		}
+		return value, nil
+	case ref.File != "":
+		p, err := r.expandHome(ref.File)
+		if err != nil {
+			return "", err
+		}
+		buf, err := r.ReadFile(p)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(buf), "\r\n"), nil
+	case len(ref.Helper) > 0:
+		requestJSON, err := json.Marshal(request)
+		if err != nil {
+			return "", err
+		}
+		out, err := r.RunHelper(ref.Helper, requestJSON)
+		if err != nil {
+			return "", fmt.Errorf("credential helper %s failed: %w", ref.Helper[0], err)
+		}
+		var response CredentialHelperResponse
+		if err := json.Unmarshal(out, &response); err != nil {
+			return "", fmt.Errorf("credential helper %s wrote an invalid response: %w", ref.Helper[0], err)
+		}
+		if response.Secret == "" {
+			return "", fmt.Errorf("credential helper %s did not return a secret", ref.Helper[0])
+		}
+		return response.Secret, nil
+	default:
+		profiles, err := r.loadProfiles()
+		if err != nil {
+			return "", err
+		}
+		profile, ok := profiles[ref.Profile]
+		if !ok {
+			return "", fmt.Errorf("profile %q not found in ~/%s", ref.Profile, CredentialsProfilesPath)
+		}
+		value, ok := profile[request.Credential]
+		if !ok || value == "" {
+			return "", fmt.Errorf("profile %q in ~/%s does not set %s", ref.Profile, CredentialsProfilesPath, request.Credential)
+		}
+		return value, nil
+	}
+}
+
+func (r *CredentialResolver) expandHome(p string) (string, error) {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p, nil
+	}
+	home, err := r.HomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, strings.TrimPrefix(p, "~")), nil
+}
+
+func (r *CredentialResolver) loadProfiles() (map[string]map[string]string, error) {
+	if r.profiles != nil {
+		return r.profiles, nil
+	}
+	p, err := r.expandHome("~/" + CredentialsProfilesPath)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := r.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credential profiles: %w", err)
+	}
+	var profiles map[string]map[string]string
+	if err := yaml.Unmarshal(buf, &profiles); err != nil {
+		return nil, fmt.Errorf("failed to parse credential profiles %s: %w", p, err)
+	}
+	if profiles == nil {
+		profiles = make(map[string]map[string]string)
+	}
+	r.profiles = profiles
+	return profiles, nil
+}
+
+func runCredentialHelper(command []string, request []byte) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
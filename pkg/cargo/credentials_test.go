@@ -0,0 +1,149 @@
+package cargo_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+func TestCredentialResolver_ResolveCredentials(t *testing.T) {
+	files := map[string]string{
+		"/home/releng/.kiln/credentials": "artifactory:\n  username: some-user\n  password: some-password\n",
+		"/home/releng/secret-key":        "some-secret-key\n",
+	}
+	resolver := cargo.CredentialResolver{
+		LookupEnv: func(key string) (string, bool) {
+			if key == "AWS_ACCESS_KEY_ID" {
+				return "some-access-key-id", true
+			}
+			return "", false
+		},
+		ReadFile: func(name string) ([]byte, error) {
+			content, ok := files[name]
+			if !ok {
+				return nil, fs.ErrNotExist
+			}
+			return []byte(content), nil
+		},
+		HomeDir: func() (string, error) { return "/home/releng", nil },
+		RunHelper: func(command []string, request []byte) ([]byte, error) {
+			var req cargo.CredentialHelperRequest
+			if err := json.Unmarshal(request, &req); err != nil {
+				return nil, err
+			}
+			if command[0] != "token-helper" || req.ReleaseSource != "cloudfoundry" || req.Credential != "github_token" {
+				return nil, errors.New("unexpected request")
+			}
+			return []byte(`{"secret": "some-github-token"}`), nil
+		},
+	}
+
+	t.Run("every kind of reference", func(t *testing.T) {
+		var kilnfile cargo.Kilnfile
+		require.NoError(t, yaml.Unmarshal([]byte(`---
+release_sources:
+  - type: s3
+    bucket: some-bucket
+    credentials:
+      access_key_id: {env: AWS_ACCESS_KEY_ID}
+      secret_access_key: {file: ~/secret-key}
+  - type: github
+    org: cloudfoundry
+    credentials:
+      github_token: {helper: [token-helper, --org, cloudfoundry]}
+  - type: artifactory
+    credentials:
+      username: {profile: artifactory}
+      password: {profile: artifactory}
+  - type: bosh.io
+`), &kilnfile))
+
+		resolved, err := resolver.ResolveCredentials(kilnfile)
+		require.NoError(t, err)
+
+		assert.Equal(t, "some-access-key-id", resolved.ReleaseSources[0].AccessKeyId)
+		assert.Equal(t, "some-secret-key", resolved.ReleaseSources[0].SecretAccessKey)
+		assert.Equal(t, "some-github-token", resolved.ReleaseSources[1].GithubToken)
+		assert.Equal(t, "some-user", resolved.ReleaseSources[2].Username)
+		assert.Equal(t, "some-password", resolved.ReleaseSources[2].Password)
+		assert.Empty(t, kilnfile.ReleaseSources[0].AccessKeyId, "it does not modify the argument")
+	})
+
+	for _, tt := range []struct {
+		Name      string
+		Source    cargo.ReleaseSourceConfig
+		ErrorText string
+	}{
+		{
+			Name:      "environment variable is not set",
+			Source:    cargo.ReleaseSourceConfig{Type: "s3", Bucket: "b", Credentials: map[string]cargo.CredentialReference{"secret_access_key": {Env: "MISSING"}}},
+			ErrorText: "environment variable MISSING is not set",
+		},
+		{
+			Name:      "file does not exist",
+			Source:    cargo.ReleaseSourceConfig{Type: "s3", Bucket: "b", Credentials: map[string]cargo.CredentialReference{"secret_access_key": {File: "/missing"}}},
+			ErrorText: "file does not exist",
+		},
+		{
+			Name:      "profile does not set the credential",
+			Source:    cargo.ReleaseSourceConfig{Type: "s3", Bucket: "b", Credentials: map[string]cargo.CredentialReference{"secret_access_key": {Profile: "artifactory"}}},
+			ErrorText: `profile "artifactory" in ~/.kiln/credentials does not set secret_access_key`,
+		},
+		{
+			Name:      "profile does not exist",
+			Source:    cargo.ReleaseSourceConfig{Type: "s3", Bucket: "b", Credentials: map[string]cargo.CredentialReference{"secret_access_key": {Profile: "missing"}}},
+			ErrorText: `profile "missing" not found`,
+		},
+		{
+			Name:      "unknown credential",
+			Source:    cargo.ReleaseSourceConfig{Type: "s3", Bucket: "b", Credentials: map[string]cargo.CredentialReference{"bucket": {Env: "AWS_ACCESS_KEY_ID"}}},
+			ErrorText: `unknown credential "bucket"`,
+		},
+		{
+			Name:      "more than one location",
+			Source:    cargo.ReleaseSourceConfig{Type: "s3", Bucket: "b", Credentials: map[string]cargo.CredentialReference{"access_key_id": {Env: "AWS_ACCESS_KEY_ID", File: "/home/releng/secret-key"}}},
+			ErrorText: "exactly one of env, file, helper, or profile must be set",
+		},
+		{
+			Name:      "the value is also set directly",
+			Source:    cargo.ReleaseSourceConfig{Type: "s3", Bucket: "b", AccessKeyId: "literal", Credentials: map[string]cargo.CredentialReference{"access_key_id": {Env: "AWS_ACCESS_KEY_ID"}}},
+			ErrorText: `release source "b" sets access_key_id and a credential reference for it`,
+		},
+		{
+			Name:      "helper fails",
+			Source:    cargo.ReleaseSourceConfig{Type: "github", Org: "other", Credentials: map[string]cargo.CredentialReference{"github_token": {Helper: []string{"token-helper"}}}},
+			ErrorText: "credential helper token-helper failed: unexpected request",
+		},
+	} {
+		t.Run(tt.Name, func(t *testing.T) {
+			_, err := resolver.ResolveCredentials(cargo.Kilnfile{ReleaseSources: []cargo.ReleaseSourceConfig{tt.Source}})
+			assert.ErrorContains(t, err, tt.ErrorText)
+		})
+	}
+}
+
+func TestCredentialResolver_helper_executable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the helper is a shell script")
+	}
+	helper := filepath.Join(t.TempDir(), "kiln-credential-helper")
+	require.NoError(t, os.WriteFile(helper, []byte("#!/bin/sh\ncat > /dev/null\necho '{\"secret\": \"from-helper\"}'\n"), 0o755))
+
+	resolved, err := cargo.CredentialResolver{}.ResolveCredentials(cargo.Kilnfile{
+		ReleaseSources: []cargo.ReleaseSourceConfig{
+			{Type: "github", Org: "cloudfoundry", Credentials: map[string]cargo.CredentialReference{"github_token": {Helper: []string{helper}}}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "from-helper", resolved.ReleaseSources[0].GithubToken)
+}
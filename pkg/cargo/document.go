@@ -0,0 +1,459 @@
+package cargo
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KilnfileLockDocument is a parsed Kilnfile.lock that can be edited without
+// losing the comments, key order, or formatting of the entries that did not
+// change. Use it instead of marshaling a KilnfileLock when writing the file.
+type KilnfileLockDocument struct {
+	doc yamlDocument
+}
+
+// ParseKilnfileLockDocument parses a Kilnfile.lock. An empty buf is an empty
+// Kilnfile.lock.
+func ParseKilnfileLockDocument(buf []byte) (*KilnfileLockDocument, error) {
+	doc, err := parseYAMLDocument(buf, 4)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Kilnfile.lock: %w", err)
+	}
+	return &KilnfileLockDocument{doc: doc}, nil
+}
+
+// KilnfileLock decodes the document.
+func (d *KilnfileLockDocument) KilnfileLock() (KilnfileLock, error) {
+	var lock KilnfileLock
+	return lock, d.doc.root.Decode(&lock)
+}
+
+// SetKilnfileLock updates the document to match lock. Releases are matched by
+// name, so only the changed entries are touched; releases not in lock are
+// removed and new releases are appended.
+func (d *KilnfileLockDocument) SetKilnfileLock(lock KilnfileLock) error {
+	return setDocument(d.doc.mapping(), lock)
+}
+
+// SetRelease updates the release with the same name or appends it.
+func (d *KilnfileLockDocument) SetRelease(lock BOSHReleaseTarballLock) error {
+	if lock.Name == "" {
+		return errors.New("name must not be empty")
+	}
+	return setNamedItem(d.doc.mapping(), "releases", "name", lock.Name, lock)
+}
+
+// RemoveRelease removes the release with the name and returns false if there
+// was none.
+func (d *KilnfileLockDocument) RemoveRelease(name string) bool {
+	return removeNamedItem(d.doc.mapping(), "releases", "name", name)
+}
+
+// SetStemcell updates the stemcell with the same OS in stemcell_criteria or
+// stemcells. A stemcell with a new OS is set as the stemcell_criteria when it
+// is empty and appended to stemcells otherwise.
+func (d *KilnfileLockDocument) SetStemcell(stemcell Stemcell) error {
+	var lock KilnfileLock
+	if err := d.doc.root.Decode(&lock); err != nil {
+		return err
+	}
+	return setStemcell(d.doc.mapping(), lock.Stemcell, stemcell)
+}
+
+// RemoveStemcell removes the stemcell with the OS from stemcells and returns
+// false if there was none. It does not remove the stemcell_criteria.
+func (d *KilnfileLockDocument) RemoveStemcell(os string) bool {
+	return removeNamedItem(d.doc.mapping(), "stemcells", "os", os)
+}
+
+// Bytes encodes the document using the indentation of the parsed file.
+func (d *KilnfileLockDocument) Bytes() ([]byte, error) {
+	return d.doc.bytes()
+}
+
+// KilnfileDocument is a parsed Kilnfile that can be edited without losing the
+// comments, key order, or formatting of the entries that did not change.
+//
+// It edits the Kilnfile before template interpolation, so the values passed to
+// its methods should not come from an interpolated Kilnfile unless they changed.
+type KilnfileDocument struct {
+	doc yamlDocument
+}
+
+// ParseKilnfileDocument parses a Kilnfile. An empty buf is an empty Kilnfile.
+func ParseKilnfileDocument(buf []byte) (*KilnfileDocument, error) {
+	doc, err := parseYAMLDocument(buf, 2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Kilnfile: %w", err)
+	}
+	return &KilnfileDocument{doc: doc}, nil
+}
+
+// Kilnfile decodes the document without interpolating it.
+func (d *KilnfileDocument) Kilnfile() (Kilnfile, error) {
+	var kilnfile Kilnfile
+	return kilnfile, d.doc.root.Decode(&kilnfile)
+}
+
+// SetKilnfile updates the document to match kilnfile. Releases are matched by
+// name, so only the changed entries are touched.
+func (d *KilnfileDocument) SetKilnfile(kilnfile Kilnfile) error {
+	return setDocument(d.doc.mapping(), kilnfile)
+}
+
+// SetRelease updates the release specification with the same name or appends it.
+func (d *KilnfileDocument) SetRelease(spec BOSHReleaseTarballSpecification) error {
+	if spec.Name == "" {
+		return errors.New("name must not be empty")
+	}
+	return setNamedItem(d.doc.mapping(), "releases", "name", spec.Name, spec)
+}
+
+// RemoveRelease removes the release specification with the name and returns
+// false if there was none.
+func (d *KilnfileDocument) RemoveRelease(name string) bool {
+	return removeNamedItem(d.doc.mapping(), "releases", "name", name)
+}
+
+// SetStemcell updates the stemcell criteria with the same OS in
+// stemcell_criteria or stemcells. Criteria with a new OS are set as the
+// stemcell_criteria when it is empty and appended to stemcells otherwise.
+func (d *KilnfileDocument) SetStemcell(stemcell Stemcell) error {
+	var kilnfile Kilnfile
+	if err := d.doc.root.Decode(&kilnfile); err != nil {
+		return err
+	}
+	return setStemcell(d.doc.mapping(), kilnfile.Stemcell, stemcell)
+}
+
+// RemoveStemcell removes the stemcell criteria with the OS from stemcells and
+// returns false if there were none. It does not remove the stemcell_criteria.
+func (d *KilnfileDocument) RemoveStemcell(os string) bool {
+	return removeNamedItem(d.doc.mapping(), "stemcells", "os", os)
+}
+
+// Bytes encodes the document using the indentation of the parsed file.
+func (d *KilnfileDocument) Bytes() ([]byte, error) {
+	return d.doc.bytes()
+}
+
+type yamlDocument struct {
+	root          yaml.Node
+	indent        int
+	explicitStart bool
+}
+
+func parseYAMLDocument(buf []byte, defaultIndent int) (yamlDocument, error) {
+	var doc yamlDocument
+	if err := yaml.Unmarshal(buf, &doc.root); err != nil {
+		return yamlDocument{}, err
+	}
+	if doc.root.Kind == 0 {
+		doc.root = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if len(doc.root.Content) != 1 || doc.root.Content[0].Kind != yaml.MappingNode {
+		return yamlDocument{}, errors.New("expected a mapping at the top level")
+	}
+	if len(doc.mapping().Content) == 0 {
+		// an empty file is often written as "{}"; keep added entries in block style
+		doc.mapping().Style = 0
+	}
+	doc.indent = detectIndent(buf, defaultIndent)
+	doc.explicitStart = bytes.HasPrefix(buf, []byte("---\n")) || bytes.HasPrefix(buf, []byte("---\r\n"))
+	return doc, nil
+}
+
+func (doc *yamlDocument) mapping() *yaml.Node {
+	return doc.root.Content[0]
+}
+
+func (doc *yamlDocument) bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if doc.explicitStart {
+		buf.WriteString("---\n")
+	}
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(doc.indent)
+	if err := enc.Encode(&doc.root); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// detectIndent returns the smallest indentation in the file so re-encoding it
+// does not re-indent every line.
+func detectIndent(buf []byte, defaultIndent int) int {
+	indent := 0
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if n := len(line) - len(trimmed); n > 0 && (indent == 0 || n < indent) {
+			indent = n
+		}
+	}
+	if indent < 2 {
+		return defaultIndent
+	}
+	return indent
+}
+
+func setDocument[T any](mapping *yaml.Node, value T) error {
+	src, err := encodeNode(value)
+	if err != nil {
+		return err
+	}
+	mergeNode(mapping, src, knownNode[T](mapping))
+	return nil
+}
+
+func setValue[T any](mapping *yaml.Node, key string, value T) error {
+	src, err := encodeNode(value)
+	if err != nil {
+		return err
+	}
+	existing := mappingValue(mapping, key)
+	if existing == nil {
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, src)
+		return nil
+	}
+	mergeNode(existing, src, knownNode[T](existing))
+	return nil
+}
+
+func setStemcell(mapping *yaml.Node, criteria, stemcell Stemcell) error {
+	if criteria.OS == stemcell.OS || criteria == (Stemcell{}) {
+		return setValue(mapping, "stemcell_criteria", stemcell)
+	}
+	return setNamedItem(mapping, "stemcells", "os", stemcell.OS, stemcell)
+}
+
+func setNamedItem[T any](mapping *yaml.Node, key, itemKey, name string, value T) error {
+	src, err := encodeNode(value)
+	if err != nil {
+		return err
+	}
+	seq := mappingValue(mapping, key)
+	switch {
+	case seq == nil:
+		mapping.Content = append(mapping.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+			&yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{src}},
+		)
+		return nil
+	case isNullNode(seq):
+		seq.Kind, seq.Tag, seq.Value, seq.Style = yaml.SequenceNode, "!!seq", "", 0
+	case seq.Kind != yaml.SequenceNode:
+		return fmt.Errorf("%s is not a list", key)
+	}
+	if existing := namedItem(seq, itemKey, name); existing != nil {
+		mergeNode(existing, src, knownNode[T](existing))
+		return nil
+	}
+	if seq.Style == yaml.FlowStyle && len(seq.Content) == 0 {
+		seq.Style = 0
+	}
+	seq.Content = append(seq.Content, src)
+	return nil
+}
+
+func removeNamedItem(mapping *yaml.Node, key, itemKey, name string) bool {
+	seq := mappingValue(mapping, key)
+	if seq == nil || seq.Kind != yaml.SequenceNode {
+		return false
+	}
+	for i, item := range seq.Content {
+		if nodeName(item, itemKey) == name {
+			seq.Content = append(seq.Content[:i], seq.Content[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func encodeNode(value any) (*yaml.Node, error) {
+	var n yaml.Node
+	if err := n.Encode(value); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// knownNode re-encodes n as a T. Keys that are in n but not in the result are
+// not fields of T (or are zero values the file spells out), so mergeNode keeps
+// them rather than treating them as removed.
+func knownNode[T any](n *yaml.Node) *yaml.Node {
+	var value T
+	if err := n.Decode(&value); err != nil {
+		return nil
+	}
+	known, err := encodeNode(value)
+	if err != nil {
+		return nil
+	}
+	return known
+}
+
+// mergeNode updates dst in place to represent src. Unchanged nodes keep their
+// comments and style. Mapping keys and named sequence items missing from src
+// are only removed when they are present in known.
+func mergeNode(dst, src, known *yaml.Node) {
+	switch {
+	case dst.Kind == yaml.MappingNode && src.Kind == yaml.MappingNode:
+		mergeMapping(dst, src, known)
+	case dst.Kind == yaml.SequenceNode && src.Kind == yaml.SequenceNode && sequenceItemKey(dst) != "" && sequenceItemKey(dst) == sequenceItemKey(src):
+		mergeNamedSequence(dst, src, known, sequenceItemKey(dst))
+	case equalNodes(dst, src):
+	case dst.Kind == yaml.ScalarNode && src.Kind == yaml.ScalarNode:
+		dst.Value, dst.Tag = src.Value, src.Tag
+		if src.Style != 0 {
+			dst.Style = src.Style
+		}
+	default:
+		head, line, foot := dst.HeadComment, dst.LineComment, dst.FootComment
+		*dst = *src
+		dst.HeadComment, dst.LineComment, dst.FootComment = head, line, foot
+	}
+}
+
+func mergeMapping(dst, src, known *yaml.Node) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		if existing := mappingValue(dst, key.Value); existing != nil {
+			mergeNode(existing, value, mappingValue(known, key.Value))
+		} else if !isEmptyNode(value) {
+			dst.Content = append(dst.Content, key, value)
+		}
+	}
+	if known == nil {
+		return
+	}
+	content := make([]*yaml.Node, 0, len(dst.Content))
+	for i := 0; i+1 < len(dst.Content); i += 2 {
+		key := dst.Content[i].Value
+		if mappingValue(src, key) == nil && mappingValue(known, key) != nil {
+			continue
+		}
+		content = append(content, dst.Content[i], dst.Content[i+1])
+	}
+	dst.Content = content
+}
+
+func mergeNamedSequence(dst, src, known *yaml.Node, itemKey string) {
+	for _, item := range src.Content {
+		name := nodeName(item, itemKey)
+		if existing := namedItem(dst, itemKey, name); existing != nil {
+			mergeNode(existing, item, namedItem(known, itemKey, name))
+		} else {
+			dst.Content = append(dst.Content, item)
+		}
+	}
+	if known == nil {
+		return
+	}
+	content := make([]*yaml.Node, 0, len(dst.Content))
+	for _, item := range dst.Content {
+		name := nodeName(item, itemKey)
+		if namedItem(src, itemKey, name) == nil && namedItem(known, itemKey, name) != nil {
+			continue
+		}
+		content = append(content, item)
+	}
+	dst.Content = content
+}
+
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func nodeName(n *yaml.Node, itemKey string) string {
+	name := mappingValue(n, itemKey)
+	if name == nil || name.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return name.Value
+}
+
+func namedItem(seq *yaml.Node, itemKey, name string) *yaml.Node {
+	if seq == nil || seq.Kind != yaml.SequenceNode {
+		return nil
+	}
+	for _, item := range seq.Content {
+		if nodeName(item, itemKey) == name {
+			return item
+		}
+	}
+	return nil
+}
+
+// sequenceItemKey returns the key naming every item of a non-empty list of
+// mappings: "name" for releases and "os" for stemcells. It returns "" for
+// other lists.
+func sequenceItemKey(seq *yaml.Node) string {
+	if len(seq.Content) == 0 {
+		return ""
+	}
+	for _, itemKey := range []string{"name", "os"} {
+		if !slices.ContainsFunc(seq.Content, func(item *yaml.Node) bool { return nodeName(item, itemKey) == "" }) {
+			return itemKey
+		}
+	}
+	return ""
+}
+
+// isEmptyNode returns true for null, empty strings, and empty lists and
+// mappings, which are not added to a mapping that does not have the key.
+func isEmptyNode(n *yaml.Node) bool {
+	if n.Kind == yaml.SequenceNode || n.Kind == yaml.MappingNode {
+		return len(n.Content) == 0
+	}
+	return isNullNode(n)
+}
+
+func isNullNode(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && (n.ShortTag() == "!!null" || (n.ShortTag() == "!!str" && n.Value == ""))
+}
+
+// equalNodes compares the values of two nodes, ignoring comments and style.
+// An empty string and null are equal so `remote_source:` stays as it is, and a
+// string equals any scalar with the same text so `version: 1.100` is not quoted.
+func equalNodes(a, b *yaml.Node) bool {
+	if a.Kind == yaml.ScalarNode && b.Kind == yaml.ScalarNode {
+		if isNullNode(a) || isNullNode(b) {
+			return isNullNode(a) && isNullNode(b)
+		}
+		return a.Value == b.Value && (a.ShortTag() == b.ShortTag() || a.ShortTag() == "!!str" || b.ShortTag() == "!!str")
+	}
+	if a.Kind != b.Kind || len(a.Content) != len(b.Content) {
+		return false
+	}
+	if a.Kind == yaml.AliasNode {
+		return a.Alias == b.Alias
+	}
+	for i := range a.Content {
+		if !equalNodes(a.Content[i], b.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,191 @@
+package cargo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+const commentedKilnfileLock = `---
+# managed by kiln
+releases:
+    - name: bpm
+      sha1: bpm-sha # from bosh.io
+      version: 1.2.0
+      remote_source: bosh.io
+      remote_path: bpm-1.2.0
+      owner: runtime # not a Kilnfile.lock field
+    - name: uaa
+      sha1: uaa-sha
+      version: "75.10"
+      remote_source:
+      remote_path: uaa-75.10
+stemcell_criteria:
+    version: "1.100"
+    os: ubuntu-jammy # keep in sync with the Kilnfile
+`
+
+func TestKilnfileLockDocument(t *testing.T) {
+	t.Run("unchanged documents are written as they were", func(t *testing.T) {
+		doc, err := cargo.ParseKilnfileLockDocument([]byte(commentedKilnfileLock))
+		require.NoError(t, err)
+		lock, err := doc.KilnfileLock()
+		require.NoError(t, err)
+
+		require.NoError(t, doc.SetKilnfileLock(lock))
+
+		buf, err := doc.Bytes()
+		require.NoError(t, err)
+		assert.Equal(t, commentedKilnfileLock, string(buf))
+	})
+
+	t.Run("SetRelease only touches the changed fields", func(t *testing.T) {
+		doc, err := cargo.ParseKilnfileLockDocument([]byte(commentedKilnfileLock))
+		require.NoError(t, err)
+
+		require.NoError(t, doc.SetRelease(cargo.BOSHReleaseTarballLock{
+			Name: "bpm", Version: "1.3.0", SHA1: "new-sha", SHA256: "new-sha256", RemoteSource: "bosh.io", RemotePath: "bpm-1.3.0",
+		}))
+
+		buf, err := doc.Bytes()
+		require.NoError(t, err)
+		assert.Equal(t, `---
+# managed by kiln
+releases:
+    - name: bpm
+      sha1: new-sha # from bosh.io
+      version: 1.3.0
+      remote_source: bosh.io
+      remote_path: bpm-1.3.0
+      owner: runtime # not a Kilnfile.lock field
+      sha256: new-sha256
+    - name: uaa
+      sha1: uaa-sha
+      version: "75.10"
+      remote_source:
+      remote_path: uaa-75.10
+stemcell_criteria:
+    version: "1.100"
+    os: ubuntu-jammy # keep in sync with the Kilnfile
+`, string(buf))
+	})
+
+	t.Run("releases can be added and removed", func(t *testing.T) {
+		doc, err := cargo.ParseKilnfileLockDocument([]byte(commentedKilnfileLock))
+		require.NoError(t, err)
+
+		assert.True(t, doc.RemoveRelease("uaa"))
+		assert.False(t, doc.RemoveRelease("uaa"))
+		require.NoError(t, doc.SetRelease(cargo.BOSHReleaseTarballLock{Name: "diego", Version: "2.90.0", SHA1: "diego-sha", RemoteSource: "bosh.io", RemotePath: "diego-2.90.0"}))
+		require.NoError(t, doc.SetStemcell(cargo.Stemcell{OS: "ubuntu-jammy", Version: "1.105"}))
+
+		lock, err := doc.KilnfileLock()
+		require.NoError(t, err)
+		assert.Equal(t, cargo.KilnfileLock{
+			Releases: []cargo.BOSHReleaseTarballLock{
+				{Name: "bpm", Version: "1.2.0", SHA1: "bpm-sha", RemoteSource: "bosh.io", RemotePath: "bpm-1.2.0"},
+				{Name: "diego", Version: "2.90.0", SHA1: "diego-sha", RemoteSource: "bosh.io", RemotePath: "diego-2.90.0"},
+			},
+			Stemcell: cargo.Stemcell{OS: "ubuntu-jammy", Version: "1.105"},
+		}, lock)
+
+		buf, err := doc.Bytes()
+		require.NoError(t, err)
+		assert.Contains(t, string(buf), "os: ubuntu-jammy # keep in sync with the Kilnfile\n")
+		assert.Contains(t, string(buf), "# managed by kiln\n")
+	})
+
+	t.Run("SetKilnfileLock removes releases that are gone", func(t *testing.T) {
+		doc, err := cargo.ParseKilnfileLockDocument([]byte(commentedKilnfileLock))
+		require.NoError(t, err)
+		lock, err := doc.KilnfileLock()
+		require.NoError(t, err)
+		lock.Releases = lock.Releases[:1]
+
+		require.NoError(t, doc.SetKilnfileLock(lock))
+
+		buf, err := doc.Bytes()
+		require.NoError(t, err)
+		assert.NotContains(t, string(buf), "uaa")
+		assert.Contains(t, string(buf), "owner: runtime # not a Kilnfile.lock field\n")
+	})
+
+	t.Run("an empty document", func(t *testing.T) {
+		doc, err := cargo.ParseKilnfileLockDocument(nil)
+		require.NoError(t, err)
+		require.NoError(t, doc.SetRelease(cargo.BOSHReleaseTarballLock{Name: "bpm", Version: "1.2.0"}))
+
+		buf, err := doc.Bytes()
+		require.NoError(t, err)
+		assert.Equal(t, "releases:\n    - name: bpm\n      sha1: \"\"\n      version: 1.2.0\n      remote_source: \"\"\n      remote_path: \"\"\n", string(buf))
+	})
+
+	t.Run("stemcells with another OS are added to the stemcells list", func(t *testing.T) {
+		doc, err := cargo.ParseKilnfileLockDocument([]byte(commentedKilnfileLock))
+		require.NoError(t, err)
+
+		require.NoError(t, doc.SetStemcell(cargo.Stemcell{OS: "windows2019", Version: "2019.50"}))
+		require.NoError(t, doc.SetStemcell(cargo.Stemcell{OS: "windows2019", Version: "2019.51"}))
+		require.NoError(t, doc.SetStemcell(cargo.Stemcell{OS: "ubuntu-jammy", Version: "1.105"}))
+
+		buf, err := doc.Bytes()
+		require.NoError(t, err)
+		assert.Contains(t, string(buf), `stemcell_criteria:
+    version: "1.105"
+    os: ubuntu-jammy # keep in sync with the Kilnfile
+stemcells:
+    - os: windows2019
+      version: "2019.51"
+`)
+
+		assert.True(t, doc.RemoveStemcell("windows2019"))
+		assert.False(t, doc.RemoveStemcell("ubuntu-jammy"), "it does not remove the stemcell_criteria")
+	})
+
+	t.Run("a document that is not a mapping", func(t *testing.T) {
+		_, err := cargo.ParseKilnfileLockDocument([]byte("- bpm\n"))
+		assert.Error(t, err)
+	})
+}
+
+func TestKilnfileDocument(t *testing.T) {
+	const kilnfile = `# the product
+slug: banana
+release_sources:
+  - type: github
+    org: cloudfoundry
+    github_token: $( variable "github_token" )
+releases:
+  - name: bpm
+    version: ~1.2 # pinned until the next major
+  - name: uaa
+stemcell_criteria:
+  os: ubuntu-jammy
+  version: ~1
+`
+	doc, err := cargo.ParseKilnfileDocument([]byte(kilnfile))
+	require.NoError(t, err)
+
+	require.NoError(t, doc.SetRelease(cargo.BOSHReleaseTarballSpecification{Name: "bpm", Version: "~1.3"}))
+	require.NoError(t, doc.SetStemcell(cargo.Stemcell{OS: "ubuntu-jammy", Version: "~2"}))
+
+	buf, err := doc.Bytes()
+	require.NoError(t, err)
+	assert.Equal(t, `# the product
+slug: banana
+release_sources:
+  - type: github
+    org: cloudfoundry
+    github_token: $( variable "github_token" )
+releases:
+  - name: bpm
+    version: ~1.3 # pinned until the next major
+  - name: uaa
+stemcell_criteria:
+  os: ubuntu-jammy
+  version: ~2
+`, string(buf))
+}
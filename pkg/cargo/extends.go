@@ -0,0 +1,159 @@
+package cargo
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ResolveKilnfileExtends returns the effective Kilnfile: kilnfile merged with
+// the Kilnfiles listed in its Extends field. The paths are relative to the
+// directory of kilnfilePath. load reads (and interpolates) a Kilnfile; the
+// Kilnfiles it returns may extend others.
+//
+// The bases are merged in order. Release sources are matched by ID, releases
+// by name, and stemcells by OS. Two bases may only define the same entry when
+// they define it the same way. The entries in kilnfile replace the base entries
+// with the same key; its other entries are added after the base entries. The
+// stemcell_criteria and source_preference in kilnfile, when set, replace the
+// ones in the bases. Other fields, like slug and bake_configurations, are not
+// inherited.
+//
+// The result does not have Extends set.
+func ResolveKilnfileExtends(kilnfilePath string, kilnfile Kilnfile, load func(path string) (Kilnfile, error)) (Kilnfile, error) {
+	return resolveKilnfileExtends(kilnfilePath, kilnfile, load, []string{filepath.Clean(kilnfilePath)})
+}
+
+func resolveKilnfileExtends(kilnfilePath string, kilnfile Kilnfile, load func(path string) (Kilnfile, error), chain []string) (Kilnfile, error) {
+	if len(kilnfile.Extends) == 0 {
+		return kilnfile, nil
+	}
+
+	base := kilnfileBases{definedIn: make(map[string]string)}
+	for _, name := range kilnfile.Extends {
+		basePath := name
+		if !filepath.IsAbs(basePath) {
+			basePath = filepath.Join(filepath.Dir(kilnfilePath), basePath)
+		}
+		basePath = filepath.Clean(basePath)
+		if slices.Contains(chain, basePath) {
+			return Kilnfile{}, fmt.Errorf("Kilnfile extends itself: %s", strings.Join(append(chain, basePath), " -> "))
+		}
+
+		baseKilnfile, err := load(basePath)
+		if err != nil {
+			return Kilnfile{}, fmt.Errorf("failed to load %s extended by %s: %w", name, kilnfilePath, err)
+		}
+		baseKilnfile, err = resolveKilnfileExtends(basePath, baseKilnfile, load, append(chain[:len(chain):len(chain)], basePath))
+		if err != nil {
+			return Kilnfile{}, err
+		}
+		if err := base.add(basePath, baseKilnfile); err != nil {
+			return Kilnfile{}, err
+		}
+	}
+
+	return base.extend(kilnfile), nil
+}
+
+// kilnfileBases merges the inherited parts of the extended Kilnfiles.
+type kilnfileBases struct {
+	kilnfile Kilnfile
+
+	// definedIn is the path of the Kilnfile that first defined an entry
+	definedIn map[string]string
+}
+
+func (b *kilnfileBases) add(path string, kilnfile Kilnfile) error {
+	var err error
+	b.kilnfile.ReleaseSources, err = mergeBaseEntries(b, b.kilnfile.ReleaseSources, kilnfile.ReleaseSources, path, "release source", BOSHReleaseTarballSourceID)
+	if err != nil {
+		return err
+	}
+	b.kilnfile.Releases, err = mergeBaseEntries(b, b.kilnfile.Releases, kilnfile.Releases, path, "release", releaseSpecName)
+	if err != nil {
+		return err
+	}
+	b.kilnfile.Stemcells, err = mergeBaseEntries(b, b.kilnfile.Stemcells, kilnfile.Stemcells, path, "stemcell", stemcellOS)
+	if err != nil {
+		return err
+	}
+
+	if kilnfile.Stemcell != (Stemcell{}) {
+		b.kilnfile.Stemcell, err = mergeBaseValue(b, b.kilnfile.Stemcell, kilnfile.Stemcell, path, "stemcell_criteria")
+		if err != nil {
+			return err
+		}
+	}
+	if kilnfile.SourcePreference != nil {
+		b.kilnfile.SourcePreference, err = mergeBaseValue(b, b.kilnfile.SourcePreference, kilnfile.SourcePreference, path, "source_preference")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *kilnfileBases) extend(kilnfile Kilnfile) Kilnfile {
+	kilnfile.Extends = nil
+	kilnfile.ReleaseSources = overrideBaseEntries(b.kilnfile.ReleaseSources, kilnfile.ReleaseSources, BOSHReleaseTarballSourceID)
+	kilnfile.Releases = overrideBaseEntries(b.kilnfile.Releases, kilnfile.Releases, releaseSpecName)
+	kilnfile.Stemcells = overrideBaseEntries(b.kilnfile.Stemcells, kilnfile.Stemcells, stemcellOS)
+	if kilnfile.Stemcell == (Stemcell{}) {
+		kilnfile.Stemcell = b.kilnfile.Stemcell
+	}
+	if kilnfile.SourcePreference == nil {
+		kilnfile.SourcePreference = b.kilnfile.SourcePreference
+	}
+	return kilnfile
+}
+
+// mergeBaseEntries appends the entries from the Kilnfile at path to merged. An
+// entry with the same key as one from an earlier base must be equal to it.
+func mergeBaseEntries[T any](b *kilnfileBases, merged, entries []T, path, kind string, key func(T) string) ([]T, error) {
+	for _, entry := range entries {
+		index := slices.IndexFunc(merged, func(e T) bool { return key(e) == key(entry) })
+		if index < 0 {
+			b.definedIn[kind+" "+key(entry)] = path
+			merged = append(merged, entry)
+			continue
+		}
+		if !reflect.DeepEqual(merged[index], entry) {
+			return nil, fmt.Errorf("%s %q is defined differently in %s and %s", kind, key(entry), b.definedIn[kind+" "+key(entry)], path)
+		}
+	}
+	return merged, nil
+}
+
+// mergeBaseValue is mergeBaseEntries for fields with a single value.
+func mergeBaseValue[T comparable](b *kilnfileBases, merged, value T, path, field string) (T, error) {
+	var zero T
+	if merged == zero {
+		b.definedIn[field] = path
+		return value, nil
+	}
+	if !reflect.DeepEqual(merged, value) {
+		return zero, fmt.Errorf("%s is defined differently in %s and %s", field, b.definedIn[field], path)
+	}
+	return merged, nil
+}
+
+// overrideBaseEntries replaces the base entries with the entries with the same
+// key and appends the others.
+func overrideBaseEntries[T any](base, entries []T, key func(T) string) []T {
+	result := slices.Clone(base)
+	for _, entry := range entries {
+		if index := slices.IndexFunc(result, func(e T) bool { return key(e) == key(entry) }); index >= 0 {
+			result[index] = entry
+		} else {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+func releaseSpecName(spec BOSHReleaseTarballSpecification) string { return spec.Name }
+
+func stemcellOS(stemcell Stemcell) string { return stemcell.OS }
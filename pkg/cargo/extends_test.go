@@ -0,0 +1,100 @@
+package cargo_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+func TestResolveKilnfileExtends(t *testing.T) {
+	boshIO := cargo.ReleaseSourceConfig{Type: cargo.BOSHReleaseTarballSourceTypeBOSHIO}
+	compiled := cargo.ReleaseSourceConfig{Type: cargo.BOSHReleaseTarballSourceTypeS3, Bucket: "compiled-releases"}
+	jammy := cargo.Stemcell{OS: "ubuntu-jammy", Version: "~1"}
+
+	files := map[string]cargo.Kilnfile{
+		"shared/Kilnfile.base": {
+			Slug:           "not-inherited",
+			ReleaseSources: []cargo.ReleaseSourceConfig{boshIO},
+			Releases:       []cargo.BOSHReleaseTarballSpecification{{Name: "bpm", Version: "~1"}, {Name: "uaa", Version: "~75"}},
+			Stemcell:       jammy,
+		},
+		"shared/Kilnfile.compiled": {
+			Extends:          []string{"Kilnfile.base"},
+			ReleaseSources:   []cargo.ReleaseSourceConfig{compiled},
+			SourcePreference: &cargo.SourcePreference{PreferCompiled: true},
+		},
+	}
+	load := func(path string) (cargo.Kilnfile, error) {
+		kilnfile, ok := files[path]
+		if !ok {
+			return cargo.Kilnfile{}, fmt.Errorf("%s not found", path)
+		}
+		return kilnfile, nil
+	}
+
+	t.Run("merges the bases and overrides entries", func(t *testing.T) {
+		kilnfile, err := cargo.ResolveKilnfileExtends("tile/Kilnfile", cargo.Kilnfile{
+			Extends:  []string{"../shared/Kilnfile.base", "../shared/Kilnfile.compiled"},
+			Slug:     "tile",
+			Releases: []cargo.BOSHReleaseTarballSpecification{{Name: "uaa", Version: "~76"}, {Name: "diego"}},
+		}, load)
+		require.NoError(t, err)
+
+		assert.Equal(t, cargo.Kilnfile{
+			Slug:             "tile",
+			ReleaseSources:   []cargo.ReleaseSourceConfig{boshIO, compiled},
+			Releases:         []cargo.BOSHReleaseTarballSpecification{{Name: "bpm", Version: "~1"}, {Name: "uaa", Version: "~76"}, {Name: "diego"}},
+			Stemcell:         jammy,
+			SourcePreference: &cargo.SourcePreference{PreferCompiled: true},
+		}, kilnfile)
+	})
+
+	t.Run("without extends", func(t *testing.T) {
+		kilnfile := cargo.Kilnfile{Slug: "tile"}
+		result, err := cargo.ResolveKilnfileExtends("Kilnfile", kilnfile, load)
+		require.NoError(t, err)
+		assert.Equal(t, kilnfile, result)
+	})
+
+	t.Run("bases define a release differently", func(t *testing.T) {
+		files["shared/Kilnfile.other"] = cargo.Kilnfile{
+			Releases: []cargo.BOSHReleaseTarballSpecification{{Name: "uaa", Version: "~77"}},
+		}
+		defer delete(files, "shared/Kilnfile.other")
+
+		_, err := cargo.ResolveKilnfileExtends("shared/Kilnfile", cargo.Kilnfile{
+			Extends: []string{"Kilnfile.base", "Kilnfile.other"},
+		}, load)
+		assert.EqualError(t, err, `release "uaa" is defined differently in shared/Kilnfile.base and shared/Kilnfile.other`)
+	})
+
+	t.Run("bases define the stemcell criteria differently", func(t *testing.T) {
+		files["shared/Kilnfile.other"] = cargo.Kilnfile{Stemcell: cargo.Stemcell{OS: "ubuntu-noble", Version: "~1"}}
+		defer delete(files, "shared/Kilnfile.other")
+
+		_, err := cargo.ResolveKilnfileExtends("shared/Kilnfile", cargo.Kilnfile{
+			Extends:  []string{"Kilnfile.base", "Kilnfile.other"},
+			Stemcell: jammy,
+		}, load)
+		assert.EqualError(t, err, "stemcell_criteria is defined differently in shared/Kilnfile.base and shared/Kilnfile.other")
+	})
+
+	t.Run("a cycle", func(t *testing.T) {
+		files["shared/Kilnfile.a"] = cargo.Kilnfile{Extends: []string{"Kilnfile.b"}}
+		files["shared/Kilnfile.b"] = cargo.Kilnfile{Extends: []string{"Kilnfile.a"}}
+		defer delete(files, "shared/Kilnfile.a")
+		defer delete(files, "shared/Kilnfile.b")
+
+		_, err := cargo.ResolveKilnfileExtends("shared/Kilnfile", cargo.Kilnfile{Extends: []string{"Kilnfile.a"}}, load)
+		assert.EqualError(t, err, "Kilnfile extends itself: shared/Kilnfile -> shared/Kilnfile.a -> shared/Kilnfile.b -> shared/Kilnfile.a")
+	})
+
+	t.Run("a missing base", func(t *testing.T) {
+		_, err := cargo.ResolveKilnfileExtends("Kilnfile", cargo.Kilnfile{Extends: []string{"Kilnfile.missing"}}, load)
+		assert.ErrorContains(t, err, "failed to load Kilnfile.missing extended by Kilnfile")
+	})
+}
@@ -90,7 +90,25 @@ func ReadKilnfileAndKilnfileLock(path string) (Kilnfile, KilnfileLock, error) {
 	return kilnfile, kilnfileLock, nil
 }
 
+// ReadKilnfile reads the Kilnfile without interpolating it and merges the
+// Kilnfiles it extends (see ResolveKilnfileExtends). Use
+// ReadKilnfileWithoutExtends to read a Kilnfile that will be passed to
+// WriteKilnfile.
 func ReadKilnfile(path string) (Kilnfile, error) {
+	kilnfile, err := readKilnfile(path)
+	if err != nil {
+		return Kilnfile{}, err
+	}
+	return ResolveKilnfileExtends(path, kilnfile, readKilnfile)
+}
+
+// ReadKilnfileWithoutExtends reads the Kilnfile as written. The Kilnfiles it
+// extends are not merged into it.
+func ReadKilnfileWithoutExtends(path string) (Kilnfile, error) {
+	return readKilnfile(path)
+}
+
+func readKilnfile(path string) (Kilnfile, error) {
 	kf, err := os.ReadFile(path)
 	if err != nil {
 		return Kilnfile{}, fmt.Errorf("failed to read Kilnfile: %w", err)
@@ -122,16 +140,47 @@ func ReadKilnfileLock(path string) (KilnfileLock, error) {
 
 // WriteKilnfile does not validate the Kilnfile nor does it validate the path.
 // Use ResolveKilnfilePath and maybe Validate before calling this.
+//
+// When the file exists, only the changed entries are rewritten (see
+// KilnfileDocument) so comments and key order are kept. Pass the Kilnfile as
+// written (see ReadKilnfileWithoutExtends). When the existing file extends
+// other Kilnfiles and kf does not, kf is assumed to be the result of
+// ResolveKilnfileExtends and an error is returned rather than copying the
+// inherited entries into the file.
 func WriteKilnfile(path string, kf Kilnfile) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if len(kf.Extends) == 0 && len(existing) > 0 {
+		var written struct {
+			Extends []string `yaml:"extends"`
+		}
+		if err := yaml.Unmarshal(existing, &written); err != nil {
+			return fmt.Errorf("failed to unmarshall Kilnfile: %w", err)
+		}
+		if len(written.Extends) > 0 {
+			return fmt.Errorf("%s extends other Kilnfiles but the Kilnfile to write does not: read it with ReadKilnfileWithoutExtends so the inherited entries are not copied into it", path)
+		}
+	}
+	doc, err := ParseKilnfileDocument(existing)
+	if err != nil {
+		return err
+	}
+	if err := doc.SetKilnfile(kf); err != nil {
+		return err
+	}
+	buf, err := doc.Bytes()
+	if err != nil {
+		return err
+	}
 	f, err := os.Create(path)
 	if err != nil {
 		return err
 	}
 	defer closeAndIgnoreError(f)
-	e := yaml.NewEncoder(f)
-	e.SetIndent(2)
-	defer closeAndIgnoreError(e)
-	return e.Encode(kf)
+	_, err = f.Write(buf)
+	return err
 }
 
 func closeAndIgnoreError(c io.Closer) {
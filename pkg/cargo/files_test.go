@@ -211,6 +211,46 @@ func TestWriteKilnfile(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Contains(t, string(kfYAML), "slug: banana")
 	})
+	t.Run("it keeps comments in an existing Kilnfile", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "Kilnfile")
+		require.NoError(t, os.WriteFile(dir, []byte("# the product\nslug: apple # renamed later\nreleases:\n  - name: bpm\n"), 0o666))
+		assert.NoError(t, cargo.WriteKilnfile(dir, cargo.Kilnfile{
+			Slug:     "banana",
+			Releases: []cargo.BOSHReleaseTarballSpecification{{Name: "bpm"}},
+		}))
+
+		kfYAML, err := os.ReadFile(dir)
+		assert.NoError(t, err)
+		assert.Equal(t, "# the product\nslug: banana # renamed later\nreleases:\n  - name: bpm\n", string(kfYAML))
+	})
+	t.Run("it does not write a Kilnfile merged with the Kilnfiles it extends", func(t *testing.T) {
+		dir := t.TempDir()
+		basePath := filepath.Join(dir, "Kilnfile.base")
+		require.NoError(t, os.WriteFile(basePath, []byte("releases:\n  - name: bpm\n"), 0o666))
+		kilnfilePath := filepath.Join(dir, "Kilnfile")
+		const kilnfileYAML = "extends: [Kilnfile.base]\nreleases:\n  - name: diego\n"
+		require.NoError(t, os.WriteFile(kilnfilePath, []byte(kilnfileYAML), 0o666))
+
+		merged, err := cargo.ReadKilnfile(kilnfilePath)
+		require.NoError(t, err)
+		require.Len(t, merged.Releases, 2)
+		assert.ErrorContains(t, cargo.WriteKilnfile(kilnfilePath, merged), "ReadKilnfileWithoutExtends")
+
+		kfYAML, err := os.ReadFile(kilnfilePath)
+		require.NoError(t, err)
+		assert.Equal(t, kilnfileYAML, string(kfYAML))
+
+		written, err := cargo.ReadKilnfileWithoutExtends(kilnfilePath)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Kilnfile.base"}, written.Extends)
+		written.Releases[0].Version = "~2"
+		require.NoError(t, cargo.WriteKilnfile(kilnfilePath, written))
+
+		kfYAML, err = os.ReadFile(kilnfilePath)
+		require.NoError(t, err)
+		assert.NotContains(t, string(kfYAML), "bpm")
+		assert.Contains(t, string(kfYAML), "~2")
+	})
 }
 
 func TestResolveKilnfilePath(t *testing.T) {
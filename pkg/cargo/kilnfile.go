@@ -3,6 +3,7 @@ package cargo
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
@@ -14,26 +15,123 @@ const (
 )
 
 type Kilnfile struct {
+	// Extends are paths, relative to the Kilnfile, of Kilnfiles whose release
+	// sources, releases, and stemcell criteria this Kilnfile inherits. See
+	// ResolveKilnfileExtends.
+	Extends []string `yaml:"extends,omitempty"`
+
 	ReleaseSources     []ReleaseSourceConfig             `yaml:"release_sources,omitempty"`
 	Slug               string                            `yaml:"slug,omitempty"`
 	Releases           []BOSHReleaseTarballSpecification `yaml:"releases,omitempty"`
 	TileNames          []string                          `yaml:"tile_names,omitempty"`
 	Stemcell           Stemcell                          `yaml:"stemcell_criteria,omitempty"`
 	BakeConfigurations []BakeConfiguration               `yaml:"bake_configurations"`
+
+	// Stemcells are the criteria for tiles built on more than one stemcell
+	// operating system. They are used along with Stemcell and each must have a
+	// different OS. Releases set the OS they are compiled against with "os".
+	Stemcells []Stemcell `yaml:"stemcells,omitempty"`
+
+	// SourcePreference is the policy used to choose between releases with the
+	// same version found in several release sources. A release may override it.
+	SourcePreference *SourcePreference `yaml:"source_preference,omitempty"`
 }
 
+// BOSHReleaseTarballSpecification returns the release with the name. When the
+// release does not set a SourcePreference, the Kilnfile's policy is set on the
+// result.
 func (kf *Kilnfile) BOSHReleaseTarballSpecification(name string) (BOSHReleaseTarballSpecification, error) {
 	for _, s := range kf.Releases {
 		if s.Name == name {
+			if s.SourcePreference == nil {
+				s.SourcePreference = kf.SourcePreference
+			}
 			return s, nil
 		}
 	}
 	return BOSHReleaseTarballSpecification{}, fmt.Errorf("failed to find component specification with name %q in Kilnfile", name)
 }
 
+// AllStemcells returns the stemcell criteria, when set, followed by Stemcells.
+func (kf Kilnfile) AllStemcells() []Stemcell {
+	return allStemcells(kf.Stemcell, kf.Stemcells)
+}
+
+// StemcellForOS returns the stemcell criteria for the operating system. An
+// empty os returns the first stemcell (or an empty Stemcell when there is none).
+func (kf Kilnfile) StemcellForOS(os string) (Stemcell, error) {
+	return findStemcell(kf.AllStemcells(), os, "Kilnfile")
+}
+
 type KilnfileLock struct {
 	Releases []BOSHReleaseTarballLock `yaml:"releases"`
 	Stemcell Stemcell                 `yaml:"stemcell_criteria"`
+
+	// Stemcells are the locked stemcells for tiles built on more than one
+	// stemcell operating system. See Kilnfile.Stemcells.
+	Stemcells []Stemcell `yaml:"stemcells,omitempty"`
+}
+
+// AllStemcells returns the stemcell criteria, when set, followed by Stemcells.
+func (k KilnfileLock) AllStemcells() []Stemcell {
+	return allStemcells(k.Stemcell, k.Stemcells)
+}
+
+// StemcellForOS returns the locked stemcell for the operating system. An
+// empty os returns the first stemcell (or an empty Stemcell when there is none).
+func (k KilnfileLock) StemcellForOS(os string) (Stemcell, error) {
+	return findStemcell(k.AllStemcells(), os, "Kilnfile.lock")
+}
+
+// StemcellForRelease returns the locked stemcell the release is compiled
+// against: the one with the release's os. When there is only one stemcell it
+// is returned regardless of the release's os, as it was before Kilnfiles could
+// list several.
+func (k KilnfileLock) StemcellForRelease(spec BOSHReleaseTarballSpecification) (Stemcell, error) {
+	if len(k.AllStemcells()) <= 1 {
+		return k.StemcellForOS("")
+	}
+	return k.StemcellForOS(spec.StemcellOS)
+}
+
+// SetStemcell updates the stemcell with the same OS. A stemcell with a new OS
+// becomes the stemcell criteria when it is not set and is appended to
+// Stemcells otherwise.
+func (k *KilnfileLock) SetStemcell(stemcell Stemcell) {
+	if k.Stemcell.OS == stemcell.OS || k.Stemcell == (Stemcell{}) {
+		k.Stemcell = stemcell
+		return
+	}
+	for i := range k.Stemcells {
+		if k.Stemcells[i].OS == stemcell.OS {
+			k.Stemcells[i] = stemcell
+			return
+		}
+	}
+	k.Stemcells = append(k.Stemcells, stemcell)
+}
+
+func allStemcells(criteria Stemcell, list []Stemcell) []Stemcell {
+	var result []Stemcell
+	if criteria != (Stemcell{}) {
+		result = append(result, criteria)
+	}
+	return append(result, list...)
+}
+
+func findStemcell(stemcells []Stemcell, os, fileName string) (Stemcell, error) {
+	if os == "" {
+		if len(stemcells) == 0 {
+			return Stemcell{}, nil
+		}
+		return stemcells[0], nil
+	}
+	for _, stemcell := range stemcells {
+		if stemcell.OS == os {
+			return stemcell, nil
+		}
+	}
+	return Stemcell{}, fmt.Errorf("stemcell with os %q not found in %s", os, fileName)
 }
 
 func (k KilnfileLock) FindBOSHReleaseWithName(name string) (BOSHReleaseTarballLock, error) {
@@ -75,6 +173,9 @@ type BOSHReleaseTarballSpecification struct {
 	// StemcellOS may be set when a specifying a component
 	// compiled with a particular stemcell. Usually you should
 	// also set StemcellVersion when setting this field.
+	//
+	// When the Kilnfile lists more than one stemcell, it selects the
+	// stemcell the release is compiled against.
 	StemcellOS string `yaml:"os,omitempty"`
 
 	// StemcellVersion may be set when a specifying a component
@@ -84,6 +185,20 @@ type BOSHReleaseTarballSpecification struct {
 
 	// GitHubRepository are where the BOSH release source code is
 	GitHubRepository string `yaml:"github_repository,omitempty"`
+
+	// SourcePreference overrides the Kilnfile's source_preference for this release.
+	SourcePreference *SourcePreference `yaml:"source_preference,omitempty"`
+
+	// ReleaseSources, when set, are the IDs of the only release sources this
+	// release may be found in or locked to. The order does not matter; see
+	// SourcePreference for choosing between them.
+	ReleaseSources []string `yaml:"release_source,omitempty"`
+}
+
+// AllowsReleaseSource returns false when the specification pins the release to
+// other release sources.
+func (spec BOSHReleaseTarballSpecification) AllowsReleaseSource(id string) bool {
+	return len(spec.ReleaseSources) == 0 || slices.Contains(spec.ReleaseSources, id)
 }
 
 func (spec BOSHReleaseTarballSpecification) VersionConstraints() (*semver.Constraints, error) {
@@ -131,6 +246,35 @@ type ReleaseSourceConfig struct {
 	ArtifactoryHost string `yaml:"artifactory_host,omitempty"`
 	Username        string `yaml:"username,omitempty"`
 	Password        string `yaml:"password,omitempty"`
+
+	Registry           string `yaml:"registry,omitempty"`
+	RepositoryTemplate string `yaml:"repository_template,omitempty"`
+
+	Directory string `yaml:"directory,omitempty"`
+
+	// Credentials maps the names of secret fields (for example
+	// secret_access_key or github_token) to where their values are stored.
+	// See CredentialResolver.
+	Credentials map[string]CredentialReference `yaml:"credentials,omitempty"`
+}
+
+// SourcePreference chooses between releases found in several release sources.
+// The highest version always wins; the preferences below only break ties
+// between releases with the same version, in the order they are listed here.
+type SourcePreference struct {
+	// PublishableOnly excludes release sources that are not publishable.
+	PublishableOnly bool `yaml:"publishable_only,omitempty"`
+
+	// PreferCompiled prefers a release compiled for the locked stemcell over
+	// a source release.
+	PreferCompiled bool `yaml:"prefer_compiled,omitempty"`
+
+	// PreferPublishable prefers publishable release sources.
+	PreferPublishable bool `yaml:"prefer_publishable,omitempty"`
+
+	// Sources lists release source IDs from most to least preferred. Sources
+	// not listed come after those listed, in Kilnfile order.
+	Sources []string `yaml:"sources,omitempty"`
 }
 
 // BOSHReleaseTarballLock represents an exact build of a bosh release
@@ -142,6 +286,7 @@ type ReleaseSourceConfig struct {
 type BOSHReleaseTarballLock struct {
 	Name    string `yaml:"name"`
 	SHA1    string `yaml:"sha1"`
+	SHA256  string `yaml:"sha256,omitempty"`
 	Version string `yaml:"version,omitempty"`
 
 	StemcellOS      string `yaml:"-"`
@@ -197,6 +342,11 @@ func (lock BOSHReleaseTarballLock) WithSHA1(sum string) BOSHReleaseTarballLock {
 	return lock
 }
 
+func (lock BOSHReleaseTarballLock) WithSHA256(sum string) BOSHReleaseTarballLock {
+	lock.SHA256 = sum
+	return lock
+}
+
 func (lock BOSHReleaseTarballLock) WithRemote(source, path string) BOSHReleaseTarballLock {
 	lock.RemoteSource = source
 	lock.RemotePath = path
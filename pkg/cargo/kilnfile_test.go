@@ -172,3 +172,77 @@ func TestStemcell_ProductSlug(t *testing.T) {
 		})
 	}
 }
+
+func TestKilnfile_BOSHReleaseTarballSpecification_source_preference(t *testing.T) {
+	kilnfilePreference := &SourcePreference{PreferCompiled: true}
+	releasePreference := &SourcePreference{Sources: []string{"bosh.io"}}
+	kilnfile := Kilnfile{
+		SourcePreference: kilnfilePreference,
+		Releases: []BOSHReleaseTarballSpecification{
+			{Name: "bpm"},
+			{Name: "uaa", SourcePreference: releasePreference},
+		},
+	}
+
+	bpm, err := kilnfile.BOSHReleaseTarballSpecification("bpm")
+	require.NoError(t, err)
+	assert.Same(t, kilnfilePreference, bpm.SourcePreference, "it uses the Kilnfile's policy")
+
+	uaa, err := kilnfile.BOSHReleaseTarballSpecification("uaa")
+	require.NoError(t, err)
+	assert.Same(t, releasePreference, uaa.SourcePreference, "the release's policy overrides the Kilnfile's")
+
+	assert.Nil(t, kilnfile.Releases[0].SourcePreference, "it does not modify the Kilnfile")
+}
+
+func TestKilnfileLock_stemcells(t *testing.T) {
+	jammy := Stemcell{OS: "ubuntu-jammy", Version: "1.100"}
+	windows := Stemcell{OS: "windows2019", Version: "2019.50"}
+	lock := KilnfileLock{Stemcell: jammy, Stemcells: []Stemcell{windows}}
+
+	assert.Equal(t, []Stemcell{jammy, windows}, lock.AllStemcells())
+
+	t.Run("StemcellForOS", func(t *testing.T) {
+		stemcell, err := lock.StemcellForOS("windows2019")
+		require.NoError(t, err)
+		assert.Equal(t, windows, stemcell)
+
+		stemcell, err = lock.StemcellForOS("")
+		require.NoError(t, err)
+		assert.Equal(t, jammy, stemcell, "it returns the first stemcell")
+
+		_, err = lock.StemcellForOS("ubuntu-noble")
+		assert.ErrorContains(t, err, `stemcell with os "ubuntu-noble" not found in Kilnfile.lock`)
+
+		stemcell, err = KilnfileLock{}.StemcellForOS("")
+		require.NoError(t, err)
+		assert.Zero(t, stemcell)
+	})
+
+	t.Run("StemcellForRelease", func(t *testing.T) {
+		stemcell, err := lock.StemcellForRelease(BOSHReleaseTarballSpecification{Name: "winc", StemcellOS: "windows2019"})
+		require.NoError(t, err)
+		assert.Equal(t, windows, stemcell)
+
+		stemcell, err = lock.StemcellForRelease(BOSHReleaseTarballSpecification{Name: "bpm"})
+		require.NoError(t, err)
+		assert.Equal(t, jammy, stemcell)
+
+		stemcell, err = KilnfileLock{Stemcell: jammy}.StemcellForRelease(BOSHReleaseTarballSpecification{Name: "winc", StemcellOS: "windows2019"})
+		require.NoError(t, err)
+		assert.Equal(t, jammy, stemcell, "a single stemcell is used for every release")
+	})
+
+	t.Run("SetStemcell", func(t *testing.T) {
+		var lock KilnfileLock
+		lock.SetStemcell(jammy)
+		lock.SetStemcell(windows)
+		lock.SetStemcell(Stemcell{OS: "ubuntu-jammy", Version: "1.105"})
+		lock.SetStemcell(Stemcell{OS: "windows2019", Version: "2019.51"})
+
+		assert.Equal(t, KilnfileLock{
+			Stemcell:  Stemcell{OS: "ubuntu-jammy", Version: "1.105"},
+			Stemcells: []Stemcell{{OS: "windows2019", Version: "2019.51"}},
+		}, lock)
+	})
+}
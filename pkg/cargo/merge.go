@@ -0,0 +1,213 @@
+package cargo
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// LockMergeStrategy chooses between two different locks for a release both
+// sides of a merge changed.
+type LockMergeStrategy string
+
+const (
+	// LockMergeHigherVersion picks the lock with the higher version. Locks for
+	// the same version that differ otherwise (for example the SHA1) conflict.
+	LockMergeHigherVersion LockMergeStrategy = "higher"
+	LockMergeOurs          LockMergeStrategy = "ours"
+	LockMergeTheirs        LockMergeStrategy = "theirs"
+	// LockMergeConflict never picks a side.
+	LockMergeConflict LockMergeStrategy = "conflict"
+)
+
+// KilnfileLockConflict is a release (or a stemcell) that could not be merged.
+type KilnfileLockConflict struct {
+	// Name is the release name, "stemcell_criteria", or "stemcells <os>".
+	Name   string
+	Reason string
+}
+
+// KilnfileLockMerge is the result of MergeKilnfileLocks.
+type KilnfileLockMerge struct {
+	// Lock is the merged Kilnfile.lock. Conflicting entries are taken from ours.
+	Lock KilnfileLock
+
+	// TheirLock is Lock with the conflicting entries taken from theirs. It is
+	// the same as Lock when there are no conflicts.
+	TheirLock KilnfileLock
+
+	Conflicts []KilnfileLockConflict
+
+	// Resolutions explain the choices made for releases both sides changed.
+	Resolutions []string
+}
+
+// MergeKilnfileLocks three-way merges Kilnfile.lock values, matching releases
+// by name and stemcells by OS. A release or stemcell changed on only one side
+// takes that side's value. When both sides changed a release differently,
+// strategy decides; when both changed a stemcell differently or one side
+// removed a release or stemcell the other changed, it is a conflict.
+//
+// Merged releases are in the order of ours, followed by the releases only
+// theirs added.
+func MergeKilnfileLocks(base, ours, theirs KilnfileLock, strategy LockMergeStrategy) (KilnfileLockMerge, error) {
+	switch strategy {
+	case "":
+		strategy = LockMergeHigherVersion
+	case LockMergeHigherVersion, LockMergeOurs, LockMergeTheirs, LockMergeConflict:
+	default:
+		return KilnfileLockMerge{}, fmt.Errorf("unknown merge strategy %q (expected higher, ours, theirs, or conflict)", strategy)
+	}
+
+	var result KilnfileLockMerge
+
+	for _, name := range releaseNames(ours.Releases, theirs.Releases, base.Releases) {
+		b := findLock(base.Releases, name)
+		o := findLock(ours.Releases, name)
+		t := findLock(theirs.Releases, name)
+
+		var chosen, theirChoice *BOSHReleaseTarballLock
+		switch {
+		case equalLocks(o, t), equalLocks(t, b):
+			chosen = o
+		case equalLocks(o, b):
+			chosen = t
+		case o == nil || t == nil:
+			result.Conflicts = append(result.Conflicts, KilnfileLockConflict{Name: name, Reason: fmt.Sprintf("one side removed %s and the other changed it", name)})
+			chosen, theirChoice = o, t
+		default:
+			var reason string
+			chosen, reason = chooseLock(*o, *t, strategy)
+			if chosen == nil {
+				result.Conflicts = append(result.Conflicts, KilnfileLockConflict{Name: name, Reason: reason})
+				chosen, theirChoice = o, t
+			} else {
+				result.Resolutions = append(result.Resolutions, reason)
+			}
+		}
+
+		if theirChoice == nil && chosen != nil {
+			theirChoice = chosen
+		}
+		if chosen != nil {
+			result.Lock.Releases = append(result.Lock.Releases, *chosen)
+		}
+		if theirChoice != nil {
+			result.TheirLock.Releases = append(result.TheirLock.Releases, *theirChoice)
+		}
+	}
+
+	var reason string
+	result.Lock.Stemcell, result.TheirLock.Stemcell, reason = mergeStemcell(base.Stemcell, ours.Stemcell, theirs.Stemcell)
+	if reason != "" {
+		result.Conflicts = append(result.Conflicts, KilnfileLockConflict{Name: "stemcell_criteria", Reason: reason})
+	}
+
+	for _, os := range stemcellOperatingSystems(ours.Stemcells, theirs.Stemcells, base.Stemcells) {
+		chosen, theirChoice, reason := mergeStemcell(findStemcellLock(base.Stemcells, os), findStemcellLock(ours.Stemcells, os), findStemcellLock(theirs.Stemcells, os))
+		if reason != "" {
+			result.Conflicts = append(result.Conflicts, KilnfileLockConflict{Name: "stemcells " + os, Reason: reason})
+		}
+		if chosen != (Stemcell{}) {
+			result.Lock.Stemcells = append(result.Lock.Stemcells, chosen)
+		}
+		if theirChoice != (Stemcell{}) {
+			result.TheirLock.Stemcells = append(result.TheirLock.Stemcells, theirChoice)
+		}
+	}
+
+	return result, nil
+}
+
+// mergeStemcell merges a stemcell; an empty Stemcell is one that is not in the
+// lock. When both sides changed it differently, it returns each side's stemcell
+// and the reason for the conflict.
+func mergeStemcell(base, ours, theirs Stemcell) (Stemcell, Stemcell, string) {
+	switch {
+	case ours == theirs, theirs == base:
+		return ours, ours, ""
+	case ours == base:
+		return theirs, theirs, ""
+	case ours == (Stemcell{}), theirs == (Stemcell{}):
+		return ours, theirs, fmt.Sprintf("one side removed the %s stemcell and the other changed it", base.OS)
+	}
+	return ours, theirs, fmt.Sprintf("both sides changed the stemcell (ours %s %s, theirs %s %s)", ours.OS, ours.Version, theirs.OS, theirs.Version)
+}
+
+// chooseLock picks between two different locks for the same release. It
+// returns nil and the reason for the conflict when it can not.
+func chooseLock(o, t BOSHReleaseTarballLock, strategy LockMergeStrategy) (*BOSHReleaseTarballLock, string) {
+	switch strategy {
+	case LockMergeOurs:
+		return &o, fmt.Sprintf("%s: both sides changed it; chose ours (%s) over theirs (%s)", o.Name, o.Version, t.Version)
+	case LockMergeTheirs:
+		return &t, fmt.Sprintf("%s: both sides changed it; chose theirs (%s) over ours (%s)", o.Name, t.Version, o.Version)
+	case LockMergeConflict:
+		return nil, fmt.Sprintf("both sides changed %s (ours %s, theirs %s)", o.Name, o.Version, t.Version)
+	}
+
+	ov, oErr := semver.NewVersion(o.Version)
+	tv, tErr := semver.NewVersion(t.Version)
+	if oErr != nil || tErr != nil {
+		return nil, fmt.Sprintf("both sides changed %s and the versions can not be compared (ours %s, theirs %s)", o.Name, o.Version, t.Version)
+	}
+	switch ov.Compare(tv) {
+	case 1:
+		return &o, fmt.Sprintf("%s: both sides changed it; chose the higher version %s (ours) over %s", o.Name, o.Version, t.Version)
+	case -1:
+		return &t, fmt.Sprintf("%s: both sides changed it; chose the higher version %s (theirs) over %s", o.Name, t.Version, o.Version)
+	}
+	return nil, fmt.Sprintf("both sides changed %s %s to different locks", o.Name, o.Version)
+}
+
+func releaseNames(lists ...[]BOSHReleaseTarballLock) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, list := range lists {
+		for _, lock := range list {
+			if !seen[lock.Name] {
+				seen[lock.Name] = true
+				names = append(names, lock.Name)
+			}
+		}
+	}
+	return names
+}
+
+func stemcellOperatingSystems(lists ...[]Stemcell) []string {
+	var names []string
+	for _, list := range lists {
+		for _, stemcell := range list {
+			if !slices.Contains(names, stemcell.OS) {
+				names = append(names, stemcell.OS)
+			}
+		}
+	}
+	return names
+}
+
+func findStemcellLock(list []Stemcell, os string) Stemcell {
+	for _, stemcell := range list {
+		if stemcell.OS == os {
+			return stemcell
+		}
+	}
+	return Stemcell{}
+}
+
+func findLock(list []BOSHReleaseTarballLock, name string) *BOSHReleaseTarballLock {
+	for i := range list {
+		if list[i].Name == name {
+			return &list[i]
+		}
+	}
+	return nil
+}
+
+func equalLocks(a, b *BOSHReleaseTarballLock) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
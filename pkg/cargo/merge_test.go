@@ -0,0 +1,157 @@
+package cargo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pivotal-cf/kiln/pkg/cargo"
+)
+
+func TestMergeKilnfileLocks(t *testing.T) {
+	release := func(name, version string) cargo.BOSHReleaseTarballLock {
+		return cargo.BOSHReleaseTarballLock{Name: name, Version: version, SHA1: name + "-" + version, RemoteSource: "bosh.io", RemotePath: name + "-" + version}
+	}
+	jammy := func(version string) cargo.Stemcell {
+		return cargo.Stemcell{OS: "ubuntu-jammy", Version: version}
+	}
+	base := cargo.KilnfileLock{
+		Releases: []cargo.BOSHReleaseTarballLock{release("bpm", "1.2.0"), release("uaa", "75.0.0"), release("diego", "2.90.0")},
+		Stemcell: jammy("1.100"),
+	}
+
+	t.Run("independent changes", func(t *testing.T) {
+		ours := cargo.KilnfileLock{
+			Releases: []cargo.BOSHReleaseTarballLock{release("bpm", "1.3.0"), release("uaa", "75.0.0"), release("diego", "2.90.0")},
+			Stemcell: jammy("1.100"),
+		}
+		theirs := cargo.KilnfileLock{
+			Releases: []cargo.BOSHReleaseTarballLock{release("bpm", "1.2.0"), release("uaa", "76.0.0"), release("capi", "1.0.0")},
+			Stemcell: jammy("1.105"),
+		}
+
+		merge, err := cargo.MergeKilnfileLocks(base, ours, theirs, "")
+		require.NoError(t, err)
+		assert.Empty(t, merge.Conflicts)
+		assert.Equal(t, cargo.KilnfileLock{
+			Releases: []cargo.BOSHReleaseTarballLock{release("bpm", "1.3.0"), release("uaa", "76.0.0"), release("capi", "1.0.0")},
+			Stemcell: jammy("1.105"),
+		}, merge.Lock)
+		assert.Equal(t, merge.Lock, merge.TheirLock)
+	})
+
+	t.Run("both sides bumped the same release", func(t *testing.T) {
+		ours := cargo.KilnfileLock{Releases: []cargo.BOSHReleaseTarballLock{release("bpm", "1.3.0")}, Stemcell: jammy("1.100")}
+		theirs := cargo.KilnfileLock{Releases: []cargo.BOSHReleaseTarballLock{release("bpm", "1.2.5")}, Stemcell: jammy("1.100")}
+		base := cargo.KilnfileLock{Releases: []cargo.BOSHReleaseTarballLock{release("bpm", "1.2.0")}, Stemcell: jammy("1.100")}
+
+		for _, tt := range []struct {
+			strategy cargo.LockMergeStrategy
+			version  string
+		}{
+			{strategy: cargo.LockMergeHigherVersion, version: "1.3.0"},
+			{strategy: cargo.LockMergeOurs, version: "1.3.0"},
+			{strategy: cargo.LockMergeTheirs, version: "1.2.5"},
+		} {
+			t.Run(string(tt.strategy), func(t *testing.T) {
+				merge, err := cargo.MergeKilnfileLocks(base, ours, theirs, tt.strategy)
+				require.NoError(t, err)
+				assert.Empty(t, merge.Conflicts)
+				assert.Equal(t, tt.version, merge.Lock.Releases[0].Version)
+				assert.Len(t, merge.Resolutions, 1)
+			})
+		}
+
+		t.Run("higher when theirs is higher", func(t *testing.T) {
+			merge, err := cargo.MergeKilnfileLocks(base, theirs, ours, cargo.LockMergeHigherVersion)
+			require.NoError(t, err)
+			assert.Equal(t, "1.3.0", merge.Lock.Releases[0].Version)
+			assert.Equal(t, []string{"bpm: both sides changed it; chose the higher version 1.3.0 (theirs) over 1.2.5"}, merge.Resolutions)
+		})
+
+		t.Run("conflict", func(t *testing.T) {
+			merge, err := cargo.MergeKilnfileLocks(base, ours, theirs, cargo.LockMergeConflict)
+			require.NoError(t, err)
+			require.Len(t, merge.Conflicts, 1)
+			assert.Equal(t, "bpm", merge.Conflicts[0].Name)
+			assert.Equal(t, "1.3.0", merge.Lock.Releases[0].Version)
+			assert.Equal(t, "1.2.5", merge.TheirLock.Releases[0].Version)
+		})
+	})
+
+	t.Run("both sides changed a release to the same version with different locks", func(t *testing.T) {
+		compiled := release("uaa", "76.0.0")
+		compiled.RemoteSource = "compiled-releases"
+		ours := cargo.KilnfileLock{Releases: []cargo.BOSHReleaseTarballLock{release("bpm", "1.2.0"), release("uaa", "76.0.0"), release("diego", "2.90.0")}, Stemcell: jammy("1.100")}
+		theirs := cargo.KilnfileLock{Releases: []cargo.BOSHReleaseTarballLock{release("bpm", "1.2.0"), compiled, release("diego", "2.90.0")}, Stemcell: jammy("1.100")}
+
+		merge, err := cargo.MergeKilnfileLocks(base, ours, theirs, cargo.LockMergeHigherVersion)
+		require.NoError(t, err)
+		assert.Equal(t, []cargo.KilnfileLockConflict{{Name: "uaa", Reason: "both sides changed uaa 76.0.0 to different locks"}}, merge.Conflicts)
+	})
+
+	t.Run("one side removed a release the other changed", func(t *testing.T) {
+		ours := cargo.KilnfileLock{Releases: []cargo.BOSHReleaseTarballLock{release("bpm", "1.2.0"), release("uaa", "75.0.0")}, Stemcell: jammy("1.100")}
+		theirs := cargo.KilnfileLock{Releases: []cargo.BOSHReleaseTarballLock{release("bpm", "1.2.0"), release("uaa", "75.0.0"), release("diego", "2.91.0")}, Stemcell: jammy("1.100")}
+
+		merge, err := cargo.MergeKilnfileLocks(base, ours, theirs, "")
+		require.NoError(t, err)
+		assert.Equal(t, []cargo.KilnfileLockConflict{{Name: "diego", Reason: "one side removed diego and the other changed it"}}, merge.Conflicts)
+		assert.Len(t, merge.Lock.Releases, 2)
+		assert.Len(t, merge.TheirLock.Releases, 3)
+	})
+
+	t.Run("one side removed a release the other did not change", func(t *testing.T) {
+		ours := cargo.KilnfileLock{Releases: []cargo.BOSHReleaseTarballLock{release("bpm", "1.2.0"), release("uaa", "75.0.0")}, Stemcell: jammy("1.100")}
+
+		merge, err := cargo.MergeKilnfileLocks(base, ours, base, "")
+		require.NoError(t, err)
+		assert.Empty(t, merge.Conflicts)
+		assert.Equal(t, ours, merge.Lock)
+	})
+
+	t.Run("both sides changed the stemcell", func(t *testing.T) {
+		ours := base
+		ours.Stemcell = jammy("1.105")
+		theirs := base
+		theirs.Stemcell = jammy("1.106")
+
+		merge, err := cargo.MergeKilnfileLocks(base, ours, theirs, "")
+		require.NoError(t, err)
+		require.Len(t, merge.Conflicts, 1)
+		assert.Equal(t, "stemcell_criteria", merge.Conflicts[0].Name)
+		assert.Equal(t, jammy("1.105"), merge.Lock.Stemcell)
+		assert.Equal(t, jammy("1.106"), merge.TheirLock.Stemcell)
+	})
+
+	t.Run("stemcells are merged by os", func(t *testing.T) {
+		windows := func(version string) cargo.Stemcell {
+			return cargo.Stemcell{OS: "windows2019", Version: version}
+		}
+		base := base
+		base.Stemcells = []cargo.Stemcell{windows("2019.50")}
+		ours := base
+		ours.Stemcell = jammy("1.105")
+		theirs := base
+		theirs.Stemcells = []cargo.Stemcell{windows("2019.51")}
+
+		merge, err := cargo.MergeKilnfileLocks(base, ours, theirs, "")
+		require.NoError(t, err)
+		assert.Empty(t, merge.Conflicts)
+		assert.Equal(t, jammy("1.105"), merge.Lock.Stemcell)
+		assert.Equal(t, []cargo.Stemcell{windows("2019.51")}, merge.Lock.Stemcells)
+
+		ours.Stemcells = []cargo.Stemcell{windows("2019.52")}
+		merge, err = cargo.MergeKilnfileLocks(base, ours, theirs, "")
+		require.NoError(t, err)
+		assert.Equal(t, []cargo.KilnfileLockConflict{{Name: "stemcells windows2019", Reason: "both sides changed the stemcell (ours windows2019 2019.52, theirs windows2019 2019.51)"}}, merge.Conflicts)
+		assert.Equal(t, []cargo.Stemcell{windows("2019.52")}, merge.Lock.Stemcells)
+		assert.Equal(t, []cargo.Stemcell{windows("2019.51")}, merge.TheirLock.Stemcells)
+	})
+
+	t.Run("unknown strategy", func(t *testing.T) {
+		_, err := cargo.MergeKilnfileLocks(base, base, base, "newest")
+		assert.ErrorContains(t, err, "unknown merge strategy")
+	})
+}
@@ -16,6 +16,14 @@ const (
 	// BOSHReleaseTarballSourceTypeArtifactory is the value for the Type field on cargo.ReleaseSourceConfig
 	// for releases stored on Artifactory.
 	BOSHReleaseTarballSourceTypeArtifactory = "artifactory"
+
+	// BOSHReleaseTarballSourceTypeOCI is the value for the Type field on cargo.ReleaseSourceConfig
+	// for releases stored as artifacts in an OCI registry.
+	BOSHReleaseTarballSourceTypeOCI = "oci"
+
+	// BOSHReleaseTarballSourceTypeDirectory is the value for the Type field on cargo.ReleaseSourceConfig
+	// for releases stored in a local (or network mounted) directory.
+	BOSHReleaseTarballSourceTypeDirectory = "directory"
 )
 
 func BOSHReleaseTarballSourceID(releaseConfig ReleaseSourceConfig) string {
@@ -31,6 +39,10 @@ func BOSHReleaseTarballSourceID(releaseConfig ReleaseSourceConfig) string {
 		return releaseConfig.Org
 	case BOSHReleaseTarballSourceTypeArtifactory:
 		return BOSHReleaseTarballSourceTypeArtifactory
+	case BOSHReleaseTarballSourceTypeOCI:
+		return releaseConfig.Registry
+	case BOSHReleaseTarballSourceTypeDirectory:
+		return BOSHReleaseTarballSourceTypeDirectory
 	default:
 		return ""
 	}
@@ -19,11 +19,15 @@ func TestReleaseSourceID(t *testing.T) {
 		{Name: BOSHReleaseTarballSourceTypeBOSHIO + " with ID set", ExpectedID: "identifier", Configuration: ReleaseSourceConfig{ID: "identifier", Type: BOSHReleaseTarballSourceTypeBOSHIO}},
 		{Name: BOSHReleaseTarballSourceTypeGithub + " with ID set", ExpectedID: "identifier", Configuration: ReleaseSourceConfig{ID: "identifier", Type: BOSHReleaseTarballSourceTypeGithub}},
 		{Name: BOSHReleaseTarballSourceTypeS3 + " with ID set", ExpectedID: "identifier", Configuration: ReleaseSourceConfig{ID: "identifier", Type: BOSHReleaseTarballSourceTypeS3}},
+		{Name: BOSHReleaseTarballSourceTypeDirectory + " with ID set", ExpectedID: "identifier", Configuration: ReleaseSourceConfig{ID: "identifier", Type: BOSHReleaseTarballSourceTypeDirectory}},
+		{Name: BOSHReleaseTarballSourceTypeOCI + " with ID set", ExpectedID: "identifier", Configuration: ReleaseSourceConfig{ID: "identifier", Type: BOSHReleaseTarballSourceTypeOCI}},
 
 		{Name: BOSHReleaseTarballSourceTypeArtifactory + " default", ExpectedID: BOSHReleaseTarballSourceTypeArtifactory, Configuration: ReleaseSourceConfig{ID: "", Type: BOSHReleaseTarballSourceTypeArtifactory}},
 		{Name: BOSHReleaseTarballSourceTypeBOSHIO + " default", ExpectedID: BOSHReleaseTarballSourceTypeBOSHIO, Configuration: ReleaseSourceConfig{ID: "", Type: BOSHReleaseTarballSourceTypeBOSHIO}},
 		{Name: BOSHReleaseTarballSourceTypeGithub + " default", ExpectedID: "identifier", Configuration: ReleaseSourceConfig{ID: "", Type: BOSHReleaseTarballSourceTypeGithub, Org: "identifier"}},
 		{Name: BOSHReleaseTarballSourceTypeS3 + " default", ExpectedID: "identifier", Configuration: ReleaseSourceConfig{ID: "", Type: BOSHReleaseTarballSourceTypeS3, Bucket: "identifier"}},
+		{Name: BOSHReleaseTarballSourceTypeDirectory + " default", ExpectedID: BOSHReleaseTarballSourceTypeDirectory, Configuration: ReleaseSourceConfig{ID: "", Type: BOSHReleaseTarballSourceTypeDirectory, Directory: "/some/path"}},
+		{Name: BOSHReleaseTarballSourceTypeOCI + " default", ExpectedID: "identifier", Configuration: ReleaseSourceConfig{ID: "", Type: BOSHReleaseTarballSourceTypeOCI, Registry: "identifier"}},
 	} {
 		t.Run(tt.Name, func(t *testing.T) {
 			assert.Equal(t, tt.ExpectedID, BOSHReleaseTarballSourceID(tt.Configuration))
@@ -10,6 +10,7 @@ import (
 
 type ValidationOptions struct {
 	resourceTypeAllowList []string
+	requireSHA256         bool
 }
 
 func NewValidateOptions() ValidationOptions {
@@ -26,12 +27,26 @@ func (o ValidationOptions) SetValidateResourceTypeAllowList(allowList []string)
 	return o
 }
 
+// ValidateRequireSHA256 calls ValidationOptions.SetRequireSHA256 on the result of NewValidateOptions
+func ValidateRequireSHA256() ValidationOptions {
+	return NewValidateOptions().SetRequireSHA256(true)
+}
+
+// SetRequireSHA256 makes Validate return an error for each release in the lock without a sha256.
+func (o ValidationOptions) SetRequireSHA256(require bool) ValidationOptions {
+	o.requireSHA256 = require
+	return o
+}
+
 func mergeOptions(options []ValidationOptions) ValidationOptions {
 	var opt ValidationOptions
 	for _, o := range options {
 		if o.resourceTypeAllowList != nil {
 			opt.resourceTypeAllowList = o.resourceTypeAllowList
 		}
+		if o.requireSHA256 {
+			opt.requireSHA256 = true
+		}
 	}
 	return opt
 }
@@ -78,10 +93,18 @@ func Validate(spec Kilnfile, lock KilnfileLock, options ...ValidationOptions) []
 				fmt.Errorf("release %q not found in spec", componentLock.Name))
 			continue
 		}
+
+		if opt.requireSHA256 && componentLock.SHA256 == "" {
+			result = append(result,
+				fmt.Errorf("release %q missing sha256 in lock", componentLock.Name))
+		}
 	}
 
 	result = append(result, ensureRemoteSourceExistsForEachReleaseLock(spec, lock)...)
 	result = append(result, ensureReleaseSourceConfiguration(spec.ReleaseSources)...)
+	result = append(result, ensureSourcePreferenceSourcesExist(spec)...)
+	result = append(result, ensurePinnedReleaseSources(spec, lock)...)
+	result = append(result, ensureStemcells(spec, lock)...)
 
 	if len(result) > 0 {
 		return result
@@ -140,6 +163,23 @@ func ensureReleaseSourceConfiguration(sources []ReleaseSourceConfig) []error {
 			if source.GithubToken != "" {
 				errs = append(errs, fmt.Errorf("artifactory has unexpected field github_token"))
 			}
+		case BOSHReleaseTarballSourceTypeOCI:
+			if source.Registry == "" {
+				errs = append(errs, fmt.Errorf("missing required field registry"))
+			}
+			if source.RepositoryTemplate == "" {
+				errs = append(errs, fmt.Errorf("missing required field repository_template"))
+			} else {
+				p := parse.New("repository_template")
+				p.Mode |= parse.SkipFuncCheck
+				if _, err := p.Parse(source.RepositoryTemplate, "", "", make(map[string]*parse.Tree)); err != nil {
+					errs = append(errs, fmt.Errorf("failed to parse repository_template: %w", err))
+				}
+			}
+		case BOSHReleaseTarballSourceTypeDirectory:
+			if source.Directory == "" {
+				errs = append(errs, fmt.Errorf("missing required field directory"))
+			}
 		case BOSHReleaseTarballSourceTypeBOSHIO:
 		case BOSHReleaseTarballSourceTypeS3:
 		case BOSHReleaseTarballSourceTypeGithub:
@@ -161,6 +201,80 @@ func ensureRemoteSourceExistsForEachReleaseLock(spec Kilnfile, lock KilnfileLock
 	return result
 }
 
+func ensureSourcePreferenceSourcesExist(spec Kilnfile) []error {
+	var result []error
+	check := func(preference *SourcePreference, field string) {
+		if preference == nil {
+			return
+		}
+		for _, id := range preference.Sources {
+			if !slices.ContainsFunc(spec.ReleaseSources, func(config ReleaseSourceConfig) bool {
+				return BOSHReleaseTarballSourceID(config) == id
+			}) {
+				result = append(result, fmt.Errorf("release source %q in %s not found in Kilnfile", id, field))
+			}
+		}
+	}
+	check(spec.SourcePreference, "source_preference")
+	for _, release := range spec.Releases {
+		check(release.SourcePreference, fmt.Sprintf("source_preference for release %q", release.Name))
+	}
+	return result
+}
+
+func ensurePinnedReleaseSources(spec Kilnfile, lock KilnfileLock) []error {
+	var result []error
+	for _, release := range spec.Releases {
+		for _, id := range release.ReleaseSources {
+			if !slices.ContainsFunc(spec.ReleaseSources, func(config ReleaseSourceConfig) bool {
+				return BOSHReleaseTarballSourceID(config) == id
+			}) {
+				result = append(result, fmt.Errorf("release %q is pinned to release source %q which is not found in Kilnfile", release.Name, id))
+			}
+		}
+		releaseLock, err := lock.FindBOSHReleaseWithName(release.Name)
+		if err != nil {
+			continue
+		}
+		if !release.AllowsReleaseSource(releaseLock.RemoteSource) {
+			result = append(result, fmt.Errorf("release %q is locked to release source %q but is pinned to %q", release.Name, releaseLock.RemoteSource, release.ReleaseSources))
+		}
+	}
+	return result
+}
+
+func ensureStemcells(spec Kilnfile, lock KilnfileLock) []error {
+	var result []error
+	for _, file := range []struct {
+		name      string
+		stemcells []Stemcell
+	}{
+		{name: "Kilnfile", stemcells: spec.AllStemcells()},
+		{name: "Kilnfile.lock", stemcells: lock.AllStemcells()},
+	} {
+		var operatingSystems []string
+		for _, stemcell := range file.stemcells {
+			if slices.Contains(operatingSystems, stemcell.OS) {
+				result = append(result, fmt.Errorf("stemcell os %q is listed more than once in %s", stemcell.OS, file.name))
+				continue
+			}
+			operatingSystems = append(operatingSystems, stemcell.OS)
+		}
+	}
+	if len(lock.AllStemcells()) <= 1 {
+		return result
+	}
+	for _, release := range spec.Releases {
+		if release.StemcellOS == "" {
+			continue
+		}
+		if _, err := lock.StemcellForOS(release.StemcellOS); err != nil {
+			result = append(result, fmt.Errorf("release %q is compiled against a %w", release.Name, err))
+		}
+	}
+	return result
+}
+
 func checkComponentVersionsAndConstraint(spec BOSHReleaseTarballSpecification, lock BOSHReleaseTarballLock, index int) error {
 	v, err := semver.NewVersion(lock.Version)
 	if err != nil {
@@ -146,6 +146,35 @@ func TestValidate_PinnedRelease(t *testing.T) {
 	please.Expect(results).To(HaveLen(0))
 }
 
+func TestValidate_stemcells(t *testing.T) {
+	t.Parallel()
+	please := NewWithT(t)
+	results := Validate(Kilnfile{
+		ReleaseSources: []ReleaseSourceConfig{
+			{ID: someReleaseSourceID},
+		},
+		Releases: []BOSHReleaseTarballSpecification{
+			{Name: "bpm"},
+			{Name: "winc", StemcellOS: "windows2019"},
+			{Name: "garden", StemcellOS: "ubuntu-noble"},
+		},
+		Stemcell:  Stemcell{OS: "ubuntu-jammy", Version: "~1"},
+		Stemcells: []Stemcell{{OS: "windows2019", Version: "~2019"}, {OS: "ubuntu-jammy", Version: "~1"}},
+	}, KilnfileLock{
+		Releases: []BOSHReleaseTarballLock{
+			{Name: "bpm", Version: "1.2.3", RemoteSource: someReleaseSourceID},
+			{Name: "winc", Version: "1.2.3", RemoteSource: someReleaseSourceID},
+			{Name: "garden", Version: "1.2.3", RemoteSource: someReleaseSourceID},
+		},
+		Stemcell:  Stemcell{OS: "ubuntu-jammy", Version: "1.100"},
+		Stemcells: []Stemcell{{OS: "windows2019", Version: "2019.50"}},
+	})
+	please.Expect(results).To(ConsistOf(
+		MatchError(`stemcell os "ubuntu-jammy" is listed more than once in Kilnfile`),
+		MatchError(`release "garden" is compiled against a stemcell with os "ubuntu-noble" not found in Kilnfile.lock`),
+	))
+}
+
 func TestValidate_release_sources(t *testing.T) {
 	t.Run("release source is not found", func(t *testing.T) {
 		please := NewWithT(t)
@@ -233,6 +262,50 @@ func TestValidate_release_sources(t *testing.T) {
 		})
 		please.Expect(results).To(HaveLen(0))
 	})
+	t.Run("release is pinned to release sources", func(t *testing.T) {
+		please := NewWithT(t)
+		results := Validate(Kilnfile{
+			ReleaseSources: []ReleaseSourceConfig{
+				{ID: "SOME_TREE"},
+				{ID: "OTHER_TREE"},
+			},
+			Releases: []BOSHReleaseTarballSpecification{
+				{Name: "lemon", ReleaseSources: []string{"SOME_TREE"}},
+				{Name: "orange", ReleaseSources: []string{"SOME_TREE", "MISSING_TREE"}},
+				{Name: "lime", ReleaseSources: []string{"SOME_TREE", "OTHER_TREE"}},
+			},
+		}, KilnfileLock{
+			Releases: []BOSHReleaseTarballLock{
+				{Name: "lemon", Version: "1.2.3", RemoteSource: "OTHER_TREE"},
+				{Name: "orange", Version: "1.2.3", RemoteSource: "SOME_TREE"},
+				{Name: "lime", Version: "1.2.3", RemoteSource: "OTHER_TREE"},
+			},
+		})
+		please.Expect(results).To(ConsistOf(
+			MatchError(`release "lemon" is locked to release source "OTHER_TREE" but is pinned to ["SOME_TREE"]`),
+			MatchError(`release "orange" is pinned to release source "MISSING_TREE" which is not found in Kilnfile`),
+		))
+	})
+	t.Run("source preference names a release source that is not found", func(t *testing.T) {
+		please := NewWithT(t)
+		results := Validate(Kilnfile{
+			ReleaseSources: []ReleaseSourceConfig{
+				{ID: "SOME_TREE"},
+			},
+			SourcePreference: &SourcePreference{Sources: []string{"SOME_TREE", "MISSING_TREE"}},
+			Releases: []BOSHReleaseTarballSpecification{
+				{Name: "lemon", SourcePreference: &SourcePreference{Sources: []string{"OTHER_TREE"}}},
+			},
+		}, KilnfileLock{
+			Releases: []BOSHReleaseTarballLock{
+				{Name: "lemon", Version: "1.2.3", RemoteSource: "SOME_TREE"},
+			},
+		})
+		please.Expect(results).To(ConsistOf(
+			MatchError(`release source "MISSING_TREE" in source_preference not found in Kilnfile`),
+			MatchError(`release source "OTHER_TREE" in source_preference for release "lemon" not found in Kilnfile`),
+		))
+	})
 }
 
 func TestValidate_checkComponentVersionsAndConstraint(t *testing.T) {
@@ -329,6 +402,34 @@ func TestValidateWithOptions(t *testing.T) {
 		})
 	})
 
+	t.Run("require sha256", func(t *testing.T) {
+		kf := Kilnfile{
+			ReleaseSources: []ReleaseSourceConfig{
+				{Type: BOSHReleaseTarballSourceTypeBOSHIO},
+			},
+			Releases: []BOSHReleaseTarballSpecification{
+				{Name: "apple"},
+				{Name: "banana"},
+			},
+		}
+		kl := KilnfileLock{
+			Releases: []BOSHReleaseTarballLock{
+				{Name: "apple", Version: "1.2.3", SHA256: "some-sha256", RemoteSource: BOSHReleaseTarballSourceTypeBOSHIO},
+				{Name: "banana", Version: "2.3.4", RemoteSource: BOSHReleaseTarballSourceTypeBOSHIO},
+			},
+		}
+		t.Run("when it is not required", func(t *testing.T) {
+			errs := Validate(kf, kl)
+			assert.Zero(t, errs)
+		})
+		t.Run("when it is required", func(t *testing.T) {
+			errs := Validate(kf, kl, ValidateRequireSHA256())
+			if assert.Len(t, errs, 1) {
+				assert.ErrorContains(t, errs[0], `release "banana" missing sha256 in lock`)
+			}
+		})
+	})
+
 	t.Run("when a release_source is not configured properly", func(t *testing.T) {
 		for _, tt := range []struct {
 			Name    string
@@ -603,6 +704,45 @@ func TestValidateWithOptions(t *testing.T) {
 					assert.ErrorContains(t, errs[0], "unexpected field github_token")
 				},
 			},
+			{
+				Name: "oci registry is empty",
+				Sources: []ReleaseSourceConfig{
+					{
+						Type:               BOSHReleaseTarballSourceTypeOCI,
+						RepositoryTemplate: "bosh-releases/{{.Name}}",
+					},
+				},
+				Error: func(t *testing.T, errs []error) {
+					require.Len(t, errs, 1)
+					assert.ErrorContains(t, errs[0], "missing required field registry")
+				},
+			},
+			{
+				Name: "oci repository_template is malformed",
+				Sources: []ReleaseSourceConfig{
+					{
+						Type:               BOSHReleaseTarballSourceTypeOCI,
+						Registry:           "registry.example.com",
+						RepositoryTemplate: "bosh-releases/{{.Name}",
+					},
+				},
+				Error: func(t *testing.T, errs []error) {
+					require.Len(t, errs, 1)
+					assert.ErrorContains(t, errs[0], "failed to parse repository_template")
+				},
+			},
+			{
+				Name: "directory is empty",
+				Sources: []ReleaseSourceConfig{
+					{
+						Type: BOSHReleaseTarballSourceTypeDirectory,
+					},
+				},
+				Error: func(t *testing.T, errs []error) {
+					require.Len(t, errs, 1)
+					assert.ErrorContains(t, errs[0], "missing required field directory")
+				},
+			},
 		} {
 			t.Run(tt.Name, func(t *testing.T) {
 				k := Kilnfile{
@@ -52,7 +52,10 @@ type Data struct {
 	TrainstatNotes []string
 
 	Stemcell cargo.Stemcell
-	Window   string
+	// Stemcells are the stemcells locked after Stemcell in tiles built on
+	// more than one stemcell operating system.
+	Stemcells []cargo.Stemcell
+	Window    string
 }
 
 //func (notes Data) Strings() string {
@@ -192,9 +195,10 @@ func (r fetchNotesData) fetch(ctx context.Context) (Data, error) {
 	}
 
 	data := Data{
-		Version:  finalVersion,
-		Bumps:    cargo.CalculateBumps(finalKilnfileLock.Releases, initialKilnfileLock.Releases),
-		Stemcell: finalKilnfileLock.Stemcell,
+		Version:   finalVersion,
+		Bumps:     cargo.CalculateBumps(finalKilnfileLock.Releases, initialKilnfileLock.Releases),
+		Stemcell:  finalKilnfileLock.Stemcell,
+		Stemcells: finalKilnfileLock.Stemcells,
 	}
 
 	wtKilnfile, err := r.kilnfileFromWorktree(r.kilnfilePath)
@@ -251,24 +255,32 @@ func (r fetchNotesData) kilnfileFromWorktree(kilnfilePath string) (cargo.Kilnfil
 	if err != nil {
 		return cargo.Kilnfile{}, nil
 	}
-	worktreeKilnfile, err := wt.Filesystem.Open(kilnfilePath)
-	if err != nil {
-		return cargo.Kilnfile{}, nil
-	}
-	defer closeAndIgnoreError(worktreeKilnfile)
+	readKilnfile := func(name string) (cargo.Kilnfile, error) {
+		f, err := wt.Filesystem.Open(name)
+		if err != nil {
+			return cargo.Kilnfile{}, err
+		}
+		defer closeAndIgnoreError(f)
 
-	buf, err := io.ReadAll(worktreeKilnfile)
-	if err != nil {
-		return cargo.Kilnfile{}, err
+		buf, err := io.ReadAll(f)
+		if err != nil {
+			return cargo.Kilnfile{}, err
+		}
+
+		var kf cargo.Kilnfile
+		return kf, yaml.Unmarshal(buf, &kf)
 	}
 
-	var wtKf cargo.Kilnfile
-	err = yaml.Unmarshal(buf, &wtKf)
+	if _, err := wt.Filesystem.Stat(kilnfilePath); err != nil {
+		return cargo.Kilnfile{}, nil
+	}
+	wtKf, err := readKilnfile(kilnfilePath)
 	if err != nil {
 		return cargo.Kilnfile{}, err
 	}
 
-	return wtKf, nil
+	// releases inherited from other Kilnfiles may have the GitHub repositories
+	return cargo.ResolveKilnfileExtends(kilnfilePath, wtKf, readKilnfile)
 }
 
 //counterfeiter:generate -o ./internal/fakes/historic_version.go --fake-name HistoricVersion . historicVersion
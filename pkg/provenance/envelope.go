@@ -0,0 +1,194 @@
+package provenance
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// PayloadType is the DSSE payload type of in-toto statements.
+const PayloadType = "application/vnd.in-toto+json"
+
+// Envelope is a DSSE envelope (https://github.com/secure-systems-lab/dsse)
+// holding a signed statement.
+type Envelope struct {
+	PayloadType string      `json:"payloadType"`
+	Payload     string      `json:"payload"`
+	Signatures  []Signature `json:"signatures"`
+}
+
+type Signature struct {
+	KeyID string `json:"keyid,omitempty"`
+	Sig   string `json:"sig"`
+}
+
+// Sign returns an envelope with the statement signed by key. The key must be
+// an ed25519 or ECDSA private key.
+func Sign(statement Statement, key crypto.Signer) (Envelope, error) {
+	payload, err := json.Marshal(statement)
+	if err != nil {
+		return Envelope{}, err
+	}
+	message := preAuthenticationEncoding(PayloadType, payload)
+
+	var sig []byte
+	switch key.Public().(type) {
+	case ed25519.PublicKey:
+		sig, err = key.Sign(rand.Reader, message, crypto.Hash(0))
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(message)
+		sig, err = key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	default:
+		return Envelope{}, fmt.Errorf("unsupported signing key type %T: use an ed25519 or ECDSA key", key.Public())
+	}
+	if err != nil {
+		return Envelope{}, fmt.Errorf("failed to sign provenance: %w", err)
+	}
+
+	keyID, err := KeyID(key.Public())
+	if err != nil {
+		return Envelope{}, err
+	}
+	return Envelope{
+		PayloadType: PayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures:  []Signature{{KeyID: keyID, Sig: base64.StdEncoding.EncodeToString(sig)}},
+	}, nil
+}
+
+// Verify checks that one of the signatures was made by key and returns the
+// signed statement.
+func (envelope Envelope) Verify(key crypto.PublicKey) (Statement, error) {
+	if envelope.PayloadType != PayloadType {
+		return Statement{}, fmt.Errorf("unexpected payload type %q", envelope.PayloadType)
+	}
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return Statement{}, fmt.Errorf("failed to decode payload: %w", err)
+	}
+	message := preAuthenticationEncoding(envelope.PayloadType, payload)
+
+	verified := false
+	for _, signature := range envelope.Signatures {
+		sig, err := base64.StdEncoding.DecodeString(signature.Sig)
+		if err != nil {
+			continue
+		}
+		switch key := key.(type) {
+		case ed25519.PublicKey:
+			verified = ed25519.Verify(key, message, sig)
+		case *ecdsa.PublicKey:
+			digest := sha256.Sum256(message)
+			verified = ecdsa.VerifyASN1(key, digest[:], sig)
+		default:
+			return Statement{}, fmt.Errorf("unsupported verification key type %T: use an ed25519 or ECDSA key", key)
+		}
+		if verified {
+			break
+		}
+	}
+	if !verified {
+		return Statement{}, errors.New("no signature on the provenance was made by the key")
+	}
+
+	var statement Statement
+	if err := json.Unmarshal(payload, &statement); err != nil {
+		return Statement{}, fmt.Errorf("failed to parse statement: %w", err)
+	}
+	if statement.Type != StatementType {
+		return Statement{}, fmt.Errorf("unexpected statement type %q", statement.Type)
+	}
+	return statement, nil
+}
+
+// preAuthenticationEncoding is the DSSE PAE of the payload; it is the message
+// that is signed.
+func preAuthenticationEncoding(payloadType string, payload []byte) []byte {
+	return fmt.Appendf(nil, "DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload)
+}
+
+// KeyID is the hex encoded SHA256 checksum of the PKIX encoded public key.
+func KeyID(key crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ReadSigningKey reads a PEM encoded ed25519 or ECDSA private key (PKCS #8 or
+// SEC 1).
+func ReadSigningKey(keyPath string) (crypto.Signer, error) {
+	block, err := readPEM(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	var key any
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s does not contain a private key (found %q)", keyPath, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %s: %w", keyPath, err)
+	}
+	switch key := key.(type) {
+	case ed25519.PrivateKey:
+		return key, nil
+	case *ecdsa.PrivateKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T in %s: use an ed25519 or ECDSA key", key, keyPath)
+	}
+}
+
+// ReadVerificationKey reads a PEM encoded public key. A private key file may
+// also be passed; its public key is returned.
+func ReadVerificationKey(keyPath string) (crypto.PublicKey, error) {
+	block, err := readPEM(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(block.Type, "PRIVATE KEY") {
+		signer, err := ReadSigningKey(keyPath)
+		if err != nil {
+			return nil, err
+		}
+		return signer.Public(), nil
+	}
+	if block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("%s does not contain a public key (found %q)", keyPath, block.Type)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %s: %w", keyPath, err)
+	}
+	return key, nil
+}
+
+func readPEM(keyPath string) (*pem.Block, error) {
+	buf, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(buf)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not PEM encoded", keyPath)
+	}
+	return block, nil
+}
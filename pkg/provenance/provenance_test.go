@@ -0,0 +1,141 @@
+package provenance_test
+
+import (
+	"archive/zip"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pivotal-cf/kiln/pkg/provenance"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	tilePath := writeTile(t, dir, "tile-1.0.0.pivotal", map[string]string{
+		"metadata/metadata.yml": "kiln_metadata:\n  metadata_git_sha: some-revision\n",
+		"releases/bpm.tgz":      "bpm",
+	})
+	lockPath := filepath.Join(dir, "Kilnfile.lock")
+	require.NoError(t, os.WriteFile(lockPath, []byte("releases: []\n"), 0o644))
+
+	statement, err := provenance.NewStatement(tilePath, provenance.Build{
+		SourceRevision:   "some-revision",
+		SourceURI:        "https://github.com/pivotal-cf/some-tile",
+		KilnfileLockPath: lockPath,
+		KilnVersion:      "1.0.0",
+		Version:          "1.0.0",
+	})
+	require.NoError(t, err)
+
+	bpmSHA1 := sha1.Sum([]byte("bpm"))
+	bpm, ok := statement.Material("releases/bpm.tgz")
+	require.True(t, ok)
+	assert.Equal(t, hex.EncodeToString(bpmSHA1[:]), bpm.Digest["sha1"])
+	assert.Equal(t, "some-revision", statement.SourceRevision())
+	source, _ := statement.Material(provenance.SourceMaterialName)
+	assert.Equal(t, "git+https://github.com/pivotal-cf/some-tile@some-revision", source.URI)
+
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	for name, key := range map[string]crypto.Signer{"ed25519": ed25519Key, "ecdsa": ecdsaKey} {
+		t.Run(name, func(t *testing.T) {
+			signingKey, err := provenance.ReadSigningKey(writeKey(t, dir, name+".key", "PRIVATE KEY", must(x509.MarshalPKCS8PrivateKey(key))))
+			require.NoError(t, err)
+			envelope, err := provenance.Sign(statement, signingKey)
+			require.NoError(t, err)
+
+			provenancePath := provenance.Filepath(filepath.Join(dir, "bake_records", "1.0.0.json"))
+			assert.Equal(t, filepath.Join(dir, "bake_records", "1.0.0.intoto.jsonl"), provenancePath)
+			require.NoError(t, os.MkdirAll(filepath.Dir(provenancePath), 0o755))
+			require.NoError(t, provenance.WriteFile(provenancePath, envelope))
+			envelope, err = provenance.ReadFile(provenancePath)
+			require.NoError(t, err)
+
+			publicKey, err := provenance.ReadVerificationKey(writeKey(t, dir, name+".pub", "PUBLIC KEY", must(x509.MarshalPKIXPublicKey(key.Public()))))
+			require.NoError(t, err)
+			verified, err := envelope.Verify(publicKey)
+			require.NoError(t, err)
+			assert.Equal(t, statement, verified)
+			assert.NoError(t, provenance.VerifyTile(verified, tilePath, lockPath))
+		})
+	}
+
+	t.Run("signed with another key", func(t *testing.T) {
+		envelope, err := provenance.Sign(statement, ed25519Key)
+		require.NoError(t, err)
+		otherKey, _, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		_, err = envelope.Verify(otherKey)
+		assert.EqualError(t, err, "no signature on the provenance was made by the key")
+	})
+
+	t.Run("a different tile", func(t *testing.T) {
+		otherTilePath := writeTile(t, dir, "other.pivotal", map[string]string{
+			"metadata/metadata.yml": "kiln_metadata:\n  metadata_git_sha: some-revision\n",
+			"releases/bpm.tgz":      "not bpm",
+		})
+		err := provenance.VerifyTile(statement, otherTilePath, "")
+		assert.ErrorContains(t, err, "the provenance subject does not match the tile sha256")
+	})
+
+	t.Run("a release tarball that does not match", func(t *testing.T) {
+		otherTilePath := writeTile(t, dir, "other.pivotal", map[string]string{
+			"metadata/metadata.yml": "kiln_metadata:\n  metadata_git_sha: some-revision\n",
+			"releases/bpm.tgz":      "not bpm",
+		})
+		other, err := provenance.NewStatement(otherTilePath, provenance.Build{SourceRevision: "some-revision"})
+		require.NoError(t, err)
+		other.Predicate.BuildDefinition.ResolvedDependencies = statement.Predicate.BuildDefinition.ResolvedDependencies
+
+		err = provenance.VerifyTile(other, otherTilePath, "")
+		assert.ErrorContains(t, err, "releases/bpm.tgz sha")
+	})
+}
+
+func writeTile(t *testing.T, dir, name string, files map[string]string) string {
+	t.Helper()
+	tilePath := filepath.Join(dir, name)
+	f, err := os.Create(tilePath)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	zw := zip.NewWriter(f)
+	for _, fileName := range slices.Sorted(maps.Keys(files)) {
+		w, err := zw.Create(fileName)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(files[fileName]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return tilePath
+}
+
+func writeKey(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+	keyPath := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return keyPath
+}
+
+func must[T any](value T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
@@ -0,0 +1,211 @@
+// Package provenance creates, signs, and verifies in-toto statements with SLSA
+// provenance for baked tiles.
+package provenance
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	StatementType       = "https://in-toto.io/Statement/v1"
+	SLSAProvenanceType  = "https://slsa.dev/provenance/v1"
+	BakeBuildType       = "https://github.com/pivotal-cf/kiln/bake@v1"
+	BuilderID           = "https://github.com/pivotal-cf/kiln"
+	SourceMaterialName  = "source"
+	KilnfileLockName    = "Kilnfile.lock"
+	gitCommitDigestName = "gitCommit"
+)
+
+// Statement is an in-toto statement with a SLSA provenance predicate.
+type Statement struct {
+	Type          string    `json:"_type"`
+	Subject       []Subject `json:"subject"`
+	PredicateType string    `json:"predicateType"`
+	Predicate     Predicate `json:"predicate"`
+}
+
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+type Predicate struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+type BuildDefinition struct {
+	BuildType            string             `json:"buildType"`
+	ExternalParameters   ExternalParameters `json:"externalParameters"`
+	ResolvedDependencies []Material         `json:"resolvedDependencies"`
+}
+
+type ExternalParameters struct {
+	Version  string `json:"version"`
+	TileName string `json:"tileName,omitempty"`
+}
+
+// Material is a SLSA resource descriptor for an input of the bake.
+type Material struct {
+	Name   string            `json:"name"`
+	URI    string            `json:"uri,omitempty"`
+	Digest map[string]string `json:"digest"`
+}
+
+type RunDetails struct {
+	Builder Builder `json:"builder"`
+}
+
+type Builder struct {
+	ID      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+// Build describes how a tile was baked.
+type Build struct {
+	// SourceRevision is the commit the tile was baked from
+	SourceRevision string
+
+	// SourceURI may be the URL of the tile source repository
+	SourceURI string
+
+	// KilnfileLockPath may be the path to the Kilnfile.lock used to bake the tile
+	KilnfileLockPath string
+
+	KilnVersion string
+	Version     string
+	TileName    string
+}
+
+// NewStatement returns the provenance of the tile at tilePath. The materials
+// are the source revision, the Kilnfile.lock, and the release tarballs in the
+// tile.
+func NewStatement(tilePath string, build Build) (Statement, error) {
+	tileSum, err := fileSHA256(tilePath)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	materials := []Material{{
+		Name:   SourceMaterialName,
+		URI:    sourceURI(build.SourceURI, build.SourceRevision),
+		Digest: map[string]string{gitCommitDigestName: build.SourceRevision},
+	}}
+	if build.KilnfileLockPath != "" {
+		lockSum, err := fileSHA256(build.KilnfileLockPath)
+		if err != nil {
+			return Statement{}, err
+		}
+		materials = append(materials, Material{Name: KilnfileLockName, Digest: map[string]string{"sha256": lockSum}})
+	}
+	releases, err := releaseMaterials(tilePath)
+	if err != nil {
+		return Statement{}, err
+	}
+	materials = append(materials, releases...)
+
+	statement := Statement{
+		Type:          StatementType,
+		Subject:       []Subject{{Name: filepath.Base(tilePath), Digest: map[string]string{"sha256": tileSum}}},
+		PredicateType: SLSAProvenanceType,
+		Predicate: Predicate{
+			BuildDefinition: BuildDefinition{
+				BuildType:            BakeBuildType,
+				ExternalParameters:   ExternalParameters{Version: build.Version, TileName: build.TileName},
+				ResolvedDependencies: materials,
+			},
+			RunDetails: RunDetails{Builder: Builder{ID: BuilderID}},
+		},
+	}
+	if build.KilnVersion != "" {
+		statement.Predicate.RunDetails.Builder.Version = map[string]string{"kiln": build.KilnVersion}
+	}
+	return statement, nil
+}
+
+// Material returns the material with the name.
+func (statement Statement) Material(name string) (Material, bool) {
+	for _, material := range statement.Predicate.BuildDefinition.ResolvedDependencies {
+		if material.Name == name {
+			return material, true
+		}
+	}
+	return Material{}, false
+}
+
+// SourceRevision returns the commit the tile was baked from.
+func (statement Statement) SourceRevision() string {
+	source, _ := statement.Material(SourceMaterialName)
+	return source.Digest[gitCommitDigestName]
+}
+
+func sourceURI(repository, revision string) string {
+	if repository == "" {
+		return ""
+	}
+	return "git+" + repository + "@" + revision
+}
+
+// releaseMaterials hashes the release tarballs in the tile.
+func releaseMaterials(tilePath string) ([]Material, error) {
+	zr, err := zip.OpenReader(tilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tile: %w", err)
+	}
+	defer closeAndIgnoreError(zr)
+
+	var materials []Material
+	for _, f := range zr.File {
+		if path.Dir(f.Name) != "releases" || strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		digest, err := zipEntryDigest(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %s: %w", f.Name, err)
+		}
+		materials = append(materials, Material{Name: f.Name, Digest: digest})
+	}
+	return materials, nil
+}
+
+func zipEntryDigest(f *zip.File) (map[string]string, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer closeAndIgnoreError(r)
+	sum1, sum256 := sha1.New(), sha256.New()
+	if _, err := io.Copy(io.MultiWriter(sum1, sum256), r); err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"sha1":   hex.EncodeToString(sum1.Sum(nil)),
+		"sha256": hex.EncodeToString(sum256.Sum(nil)),
+	}, nil
+}
+
+func fileSHA256(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer closeAndIgnoreError(f)
+	sum := sha256.New()
+	if _, err := io.Copy(sum, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+func closeAndIgnoreError(c io.Closer) {
+	_ = c.Close()
+}
@@ -0,0 +1,124 @@
+package provenance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/pivotal-cf/kiln/internal/builder"
+	"github.com/pivotal-cf/kiln/pkg/tile"
+)
+
+// FileExtension is the extension of provenance files. It differs from the
+// ".json" extension of bake records so bake.ReadRecords skips them.
+const FileExtension = ".intoto.jsonl"
+
+// Filepath returns the path of the provenance file written next to the bake
+// record at recordPath.
+func Filepath(recordPath string) string {
+	return strings.TrimSuffix(recordPath, ".json") + FileExtension
+}
+
+// WriteFile writes the envelope as a single line of JSON.
+func WriteFile(provenancePath string, envelope Envelope) error {
+	buf, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(provenancePath, append(buf, '\n'), 0o644)
+}
+
+func ReadFile(provenancePath string) (Envelope, error) {
+	buf, err := os.ReadFile(provenancePath)
+	if err != nil {
+		return Envelope{}, err
+	}
+	var envelope Envelope
+	if err := json.Unmarshal(buf, &envelope); err != nil {
+		return Envelope{}, fmt.Errorf("failed to parse provenance: %w", err)
+	}
+	return envelope, nil
+}
+
+// VerifyTile checks the statement describes the tile: the subject digest must
+// be the tile checksum, the source material must be the revision in the tile
+// metadata, and the release materials must be the release tarballs in the
+// tile. When kilnfileLockPath is not empty the Kilnfile.lock material must
+// match the file.
+func VerifyTile(statement Statement, tilePath, kilnfileLockPath string) error {
+	tileSum, err := fileSHA256(tilePath)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(statement.Subject, func(s Subject) bool { return s.Digest["sha256"] == tileSum }) {
+		return fmt.Errorf("the provenance subject does not match the tile sha256 %s", tileSum)
+	}
+
+	if err := verifySourceRevision(statement, tilePath); err != nil {
+		return err
+	}
+
+	if kilnfileLockPath != "" {
+		lockSum, err := fileSHA256(kilnfileLockPath)
+		if err != nil {
+			return err
+		}
+		material, ok := statement.Material(KilnfileLockName)
+		if !ok {
+			return fmt.Errorf("the provenance does not list the %s", KilnfileLockName)
+		}
+		if material.Digest["sha256"] != lockSum {
+			return fmt.Errorf("%s sha256 %s does not match the provenance %s", kilnfileLockPath, lockSum, material.Digest["sha256"])
+		}
+	}
+
+	releases, err := releaseMaterials(tilePath)
+	if err != nil {
+		return err
+	}
+	for _, release := range releases {
+		material, ok := statement.Material(release.Name)
+		if !ok {
+			return fmt.Errorf("the provenance does not list %s", release.Name)
+		}
+		for algorithm, digest := range release.Digest {
+			if material.Digest[algorithm] != digest {
+				return fmt.Errorf("%s %s %s does not match the provenance %s", release.Name, algorithm, digest, material.Digest[algorithm])
+			}
+		}
+	}
+	for _, material := range statement.Predicate.BuildDefinition.ResolvedDependencies {
+		if !strings.HasPrefix(material.Name, "releases/") {
+			continue
+		}
+		if !slices.ContainsFunc(releases, func(m Material) bool { return m.Name == material.Name }) {
+			return fmt.Errorf("the provenance lists %s but the tile does not contain it", material.Name)
+		}
+	}
+	return nil
+}
+
+func verifySourceRevision(statement Statement, tilePath string) error {
+	material, ok := statement.Material(SourceMaterialName)
+	if !ok {
+		return fmt.Errorf("the provenance does not list the %s revision", SourceMaterialName)
+	}
+	metadataBuffer, err := tile.ReadMetadataFromFile(tilePath)
+	if err != nil {
+		return err
+	}
+	var metadata struct {
+		KilnMetadata builder.KilnMetadata `yaml:"kiln_metadata"`
+	}
+	if err := yaml.Unmarshal(metadataBuffer, &metadata); err != nil {
+		return fmt.Errorf("failed to parse tile metadata: %w", err)
+	}
+	if got, exp := material.Digest[gitCommitDigestName], metadata.KilnMetadata.MetadataGitSHA; got != exp {
+		return fmt.Errorf("the provenance source revision %s does not match the tile metadata revision %s", got, exp)
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package tile
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// rangeReaderBlockSize is 64kb; archive/zip reads the central directory
+	// in small chunks so reads are rounded up to blocks and cached
+	rangeReaderBlockSize = 1 << 16
+
+	rangeReaderCachedBlocks = 16
+)
+
+// RangeReaderAt implements io.ReaderAt for a file on an HTTP server using range
+// requests. It lets zip.NewReader read the central directory and individual
+// entries of a tile without downloading all of it.
+//
+//	ra, err := tile.NewRangeReaderAt(http.DefaultClient, req)
+//	if err != nil {
+//		return err
+//	}
+//	zr, err := zip.NewReader(ra, ra.Size())
+type RangeReaderAt struct {
+	client *http.Client
+	req    *http.Request
+	size   int64
+
+	mu     sync.Mutex
+	blocks map[int64][]byte
+	order  []int64
+}
+
+// NewRangeReaderAt requests the first byte of the file to find its size. The
+// request method must be GET; headers such as Authorization are sent with every
+// range request. It returns an error when the server does not support range
+// requests.
+func NewRangeReaderAt(client *http.Client, req *http.Request) (*RangeReaderAt, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	ra := &RangeReaderAt{
+		client: client,
+		req:    req,
+		blocks: make(map[int64][]byte),
+	}
+	res, err := ra.get(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer closeAndIgnoreError(res.Body)
+	_, _, size, err := parseContentRange(res.Header.Get("Content-Range"))
+	if err != nil {
+		return nil, err
+	}
+	ra.size = size
+	return ra, nil
+}
+
+// Size is the length of the file on the server.
+func (ra *RangeReaderAt) Size() int64 { return ra.size }
+
+func (ra *RangeReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("tile.RangeReaderAt.ReadAt: negative offset")
+	}
+	if off >= ra.size {
+		return 0, io.EOF
+	}
+
+	// large reads (for example hashing a release tarball) skip the cache
+	if len(p) > rangeReaderBlockSize {
+		end := min(off+int64(len(p)), ra.size) - 1
+		n, err := ra.readRange(p[:end-off+1], off, end)
+		if err == nil && n < len(p) {
+			err = io.EOF
+		}
+		return n, err
+	}
+
+	n := 0
+	for n < len(p) && off+int64(n) < ra.size {
+		pos := off + int64(n)
+		block, err := ra.block(pos / rangeReaderBlockSize)
+		if err != nil {
+			return n, err
+		}
+		n += copy(p[n:], block[pos%rangeReaderBlockSize:])
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// OpenFile returns a reader for the contents of a zip entry in the file. Unlike
+// (*zip.File).Open, which reads the entry through ReadAt in small chunks (one
+// range request per block), the whole entry is streamed with a single range
+// request. Closing the reader before the end stops the download.
+func (ra *RangeReaderAt) OpenFile(f *zip.File) (io.ReadCloser, error) {
+	offset, err := f.DataOffset()
+	if err != nil {
+		return nil, err
+	}
+	if f.CompressedSize64 == 0 {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+	start, end := offset, offset+int64(f.CompressedSize64)-1
+	res, err := ra.get(start, end)
+	if err != nil {
+		return nil, err
+	}
+	gotStart, _, _, err := parseContentRange(res.Header.Get("Content-Range"))
+	if err == nil && gotStart != start {
+		err = fmt.Errorf("server returned range starting at %d instead of %d", gotStart, start)
+	}
+	if err != nil {
+		closeAndIgnoreError(res.Body)
+		return nil, err
+	}
+
+	var r io.ReadCloser
+	switch f.Method {
+	case zip.Store:
+		r = res.Body
+	case zip.Deflate:
+		r = flate.NewReader(res.Body)
+	default:
+		closeAndIgnoreError(res.Body)
+		return nil, zip.ErrAlgorithm
+	}
+	return &zipEntryReader{
+		r:      io.LimitReader(r, int64(f.UncompressedSize64)),
+		hash:   crc32.NewIEEE(),
+		crc32:  f.CRC32,
+		closer: []io.Closer{r, res.Body},
+	}, nil
+}
+
+// zipEntryReader checks the CRC-32 of a zip entry like the reader returned by
+// (*zip.File).Open.
+type zipEntryReader struct {
+	r      io.Reader
+	hash   hash.Hash32
+	crc32  uint32
+	closer []io.Closer
+}
+
+func (z *zipEntryReader) Read(p []byte) (int, error) {
+	n, err := z.r.Read(p)
+	z.hash.Write(p[:n])
+	if err == io.EOF && z.crc32 != 0 && z.hash.Sum32() != z.crc32 {
+		err = zip.ErrChecksum
+	}
+	return n, err
+}
+
+func (z *zipEntryReader) Close() error {
+	for _, c := range z.closer {
+		closeAndIgnoreError(c)
+	}
+	return nil
+}
+
+func (ra *RangeReaderAt) block(index int64) ([]byte, error) {
+	ra.mu.Lock()
+	defer ra.mu.Unlock()
+	if block, ok := ra.blocks[index]; ok {
+		return block, nil
+	}
+
+	start := index * rangeReaderBlockSize
+	end := min(start+rangeReaderBlockSize, ra.size) - 1
+	block := make([]byte, end-start+1)
+	if _, err := ra.readRange(block, start, end); err != nil {
+		return nil, err
+	}
+
+	if len(ra.order) == rangeReaderCachedBlocks {
+		delete(ra.blocks, ra.order[0])
+		ra.order = ra.order[1:]
+	}
+	ra.blocks[index] = block
+	ra.order = append(ra.order, index)
+	return block, nil
+}
+
+// readRange reads the inclusive byte range [start, end] into p.
+func (ra *RangeReaderAt) readRange(p []byte, start, end int64) (int, error) {
+	res, err := ra.get(start, end)
+	if err != nil {
+		return 0, err
+	}
+	defer closeAndIgnoreError(res.Body)
+	gotStart, _, _, err := parseContentRange(res.Header.Get("Content-Range"))
+	if err != nil {
+		return 0, err
+	}
+	if gotStart != start {
+		return 0, fmt.Errorf("server returned range starting at %d instead of %d", gotStart, start)
+	}
+	return io.ReadFull(res.Body, p)
+}
+
+func (ra *RangeReaderAt) get(start, end int64) (*http.Response, error) {
+	req := ra.req.Clone(ra.req.Context())
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	res, err := ra.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusPartialContent {
+		closeAndIgnoreError(res.Body)
+		if res.StatusCode == http.StatusOK {
+			return nil, fmt.Errorf("%s does not support range requests", req.URL.Redacted())
+		}
+		return nil, fmt.Errorf("range request to %s failed: %s", req.URL.Redacted(), res.Status)
+	}
+	return res, nil
+}
+
+// parseContentRange parses a "bytes <start>-<end>/<size>" header value.
+func parseContentRange(value string) (start, end, size int64, err error) {
+	byteRange, found := strings.CutPrefix(value, "bytes ")
+	if !found {
+		return 0, 0, 0, fmt.Errorf("unexpected Content-Range %q", value)
+	}
+	byteRange, sizeValue, found := strings.Cut(byteRange, "/")
+	if !found {
+		return 0, 0, 0, fmt.Errorf("unexpected Content-Range %q", value)
+	}
+	startValue, endValue, found := strings.Cut(byteRange, "-")
+	if !found {
+		return 0, 0, 0, fmt.Errorf("unexpected Content-Range %q", value)
+	}
+	if start, err = strconv.ParseInt(startValue, 10, 64); err != nil {
+		return 0, 0, 0, fmt.Errorf("unexpected Content-Range %q: %w", value, err)
+	}
+	if end, err = strconv.ParseInt(endValue, 10, 64); err != nil {
+		return 0, 0, 0, fmt.Errorf("unexpected Content-Range %q: %w", value, err)
+	}
+	if size, err = strconv.ParseInt(sizeValue, 10, 64); err != nil {
+		return 0, 0, 0, fmt.Errorf("unexpected Content-Range %q: the size must be known: %w", value, err)
+	}
+	return start, end, size, nil
+}
@@ -0,0 +1,144 @@
+package tile_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/pivotal-cf/kiln/pkg/tile"
+)
+
+func TestRangeReaderAt(t *testing.T) {
+	tileBytes, err := os.ReadFile("testdata/tile-0.1.2.pivotal")
+	require.NoError(t, err)
+
+	var requests atomic.Int64
+	fileServer := http.FileServer(http.Dir("testdata"))
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		requests.Add(1)
+		fileServer.ServeHTTP(res, req)
+	}))
+	t.Cleanup(server.Close)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/tile-0.1.2.pivotal", nil)
+	require.NoError(t, err)
+	ra, err := tile.NewRangeReaderAt(server.Client(), req)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(tileBytes)), ra.Size())
+
+	t.Run("zip reader", func(t *testing.T) {
+		zr, err := zip.NewReader(ra, ra.Size())
+		require.NoError(t, err)
+		assert.Len(t, zr.File, 4)
+
+		metadataBytes, err := tile.ReadMetadataFromFS(zr)
+		require.NoError(t, err)
+		var metadata struct {
+			Name string `yaml:"name"`
+		}
+		require.NoError(t, yaml.Unmarshal(metadataBytes, &metadata))
+		assert.Equal(t, "hello", metadata.Name)
+	})
+
+	t.Run("cached reads", func(t *testing.T) {
+		before := requests.Load()
+		buf := make([]byte, 512)
+		for i := 0; i < 10; i++ {
+			n, err := ra.ReadAt(buf, 100)
+			require.NoError(t, err)
+			assert.Equal(t, tileBytes[100:100+n], buf)
+		}
+		assert.LessOrEqual(t, requests.Load()-before, int64(1))
+	})
+
+	t.Run("large reads", func(t *testing.T) {
+		buf := make([]byte, len(tileBytes)-10)
+		n, err := ra.ReadAt(buf, 10)
+		require.NoError(t, err)
+		assert.Equal(t, len(buf), n)
+		assert.True(t, bytes.Equal(tileBytes[10:], buf))
+	})
+
+	t.Run("reading past the end", func(t *testing.T) {
+		buf := make([]byte, 100)
+		n, err := ra.ReadAt(buf, ra.Size()-10)
+		assert.Equal(t, 10, n)
+		assert.ErrorIs(t, err, io.EOF)
+		assert.Equal(t, tileBytes[len(tileBytes)-10:], buf[:n])
+	})
+}
+
+func TestRangeReaderAt_rangeRequestsNotSupported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		_, _ = res.Write([]byte("the whole file"))
+	}))
+	t.Cleanup(server.Close)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/tile.pivotal", nil)
+	require.NoError(t, err)
+	_, err = tile.NewRangeReaderAt(server.Client(), req)
+	assert.ErrorContains(t, err, "does not support range requests")
+}
+
+func TestRangeReaderAt_OpenFile(t *testing.T) {
+	// the entries are larger than a cached block so reading them through
+	// (*zip.File).Open would take several range requests
+	release := make([]byte, 1<<18)
+	_, _ = rand.New(rand.NewSource(1)).Read(release)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, header := range []zip.FileHeader{
+		{Name: "releases/deflate.tgz", Method: zip.Deflate},
+		{Name: "releases/store.tgz", Method: zip.Store},
+	} {
+		w, err := zw.CreateHeader(&header)
+		require.NoError(t, err)
+		_, err = w.Write(release)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	tileBytes := buf.Bytes()
+
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		requests.Add(1)
+		http.ServeContent(res, req, "tile.pivotal", time.Time{}, bytes.NewReader(tileBytes))
+	}))
+	t.Cleanup(server.Close)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/tile.pivotal", nil)
+	require.NoError(t, err)
+	ra, err := tile.NewRangeReaderAt(server.Client(), req)
+	require.NoError(t, err)
+	zr, err := zip.NewReader(ra, ra.Size())
+	require.NoError(t, err)
+
+	for _, f := range zr.File {
+		t.Run(f.Name, func(t *testing.T) {
+			_, err := f.DataOffset() // reads the local file header
+			require.NoError(t, err)
+
+			before := requests.Load()
+			rc, err := ra.OpenFile(f)
+			require.NoError(t, err)
+			contents, err := io.ReadAll(rc)
+			require.NoError(t, rc.Close())
+			require.NoError(t, err)
+
+			assert.True(t, bytes.Equal(release, contents))
+			assert.Equal(t, int64(1), requests.Load()-before)
+		})
+	}
+}